@@ -0,0 +1,74 @@
+//go:build fuse
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/bootstrap"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/fuse"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/spf13/cobra"
+)
+
+var (
+	mountUser    string
+	mountPath    string
+	mountOptions []string
+)
+
+// MountCmd represents the mount command
+var MountCmd = &cobra.Command{
+	Use:   "mount [mountpoint]",
+	Short: "Mount the files of a user to a local directory with FUSE",
+	Long: `Mount the files of a user to a local directory with FUSE
+the storages are loaded from the database, the permissions of the user are applied`,
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		bootstrap.Init()
+		defer bootstrap.Release()
+		user, err := op.GetAdmin()
+		if mountUser != "" {
+			user, err = op.GetUserByName(mountUser)
+		}
+		if err != nil {
+			return fmt.Errorf("failed to get user: %+v", err)
+		}
+		if user.Disabled {
+			return fmt.Errorf("user [%s] is disabled", user.Username)
+		}
+		bootstrap.LoadStorages()
+		<-conf.StoragesLoadSignal()
+		opts := make([]string, 0, len(mountOptions)*2)
+		for _, o := range mountOptions {
+			opts = append(opts, "-o", o)
+		}
+		host, done := fuse.Mount(user, mountPath, args[0], opts)
+		utils.Log.Infof("mounting [%s] of user [%s] at [%s]", mountPath, user.Username, args[0])
+		quit := make(chan os.Signal, 1)
+		signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
+		select {
+		case <-quit:
+			host.Unmount()
+			<-done
+		case ok := <-done:
+			if !ok {
+				return fmt.Errorf("failed to mount at [%s]", args[0])
+			}
+		}
+		utils.Log.Infof("unmounted [%s]", args[0])
+		return nil
+	},
+}
+
+func init() {
+	RootCmd.AddCommand(MountCmd)
+	MountCmd.Flags().StringVarP(&mountUser, "user", "u", "", "the user whose files are mounted, admin by default")
+	MountCmd.Flags().StringVarP(&mountPath, "path", "p", "/", "the path to mount, relative to the base path of the user")
+	MountCmd.Flags().StringArrayVarP(&mountOptions, "option", "o", nil, "FUSE mount options, e.g. -o allow_other")
+}
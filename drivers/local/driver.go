@@ -356,6 +356,29 @@ func (d *Local) Copy(_ context.Context, srcObj, dstDir model.Obj) error {
 	return nil
 }
 
+func (d *Local) HardLink(_ context.Context, srcObj, dstDir model.Obj, name string) error {
+	if srcObj.IsDir() {
+		return errs.NotFile
+	}
+	dstPath := filepath.Join(dstDir.GetPath(), name)
+	if err := os.Link(srcObj.GetPath(), dstPath); err != nil {
+		if isCrossDeviceError(err) {
+			return errs.NotSupport
+		}
+		return err
+	}
+	if d.directoryMap.Has(dstDir.GetPath()) {
+		d.directoryMap.UpdateDirSize(dstDir.GetPath())
+		d.directoryMap.UpdateDirParents(dstDir.GetPath())
+	}
+	return nil
+}
+
+func (d *Local) SetModTime(_ context.Context, obj model.Obj, modTime time.Time) error {
+	// the zero access time is left unchanged
+	return os.Chtimes(obj.GetPath(), time.Time{}, modTime)
+}
+
 func (d *Local) Remove(ctx context.Context, obj model.Obj) error {
 	var err error
 	if utils.SliceContains([]string{"", "delete permanently"}, d.RecycleBinPath) {
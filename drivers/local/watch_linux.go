@@ -0,0 +1,139 @@
+package local
+
+import (
+	"context"
+	"encoding/binary"
+	"errors"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	log "github.com/sirupsen/logrus"
+	"golang.org/x/sys/unix"
+)
+
+const (
+	watchMask = unix.IN_CREATE | unix.IN_DELETE | unix.IN_MOVED_FROM | unix.IN_MOVED_TO |
+		unix.IN_CLOSE_WRITE | unix.IN_ONLYDIR
+	// the changes are reported in batches, writing a file generates many events
+	watchDelay = time.Second
+)
+
+// Watch watches the folders with inotify
+func (d *Local) Watch(ctx context.Context, changed func(dirs []string)) error {
+	fd, err := unix.InotifyInit1(unix.IN_CLOEXEC | unix.IN_NONBLOCK)
+	if err != nil {
+		return err
+	}
+	// the file is closed to stop reading once ctx is done
+	f := os.NewFile(uintptr(fd), "inotify")
+	defer f.Close()
+	w := &inotifyWatcher{
+		fd:    fd,
+		root:  d.GetRootPath(),
+		paths: make(map[int32]string),
+	}
+	w.addRecursive(w.root)
+
+	events := make(chan string)
+	readErr := make(chan error, 1)
+	go func() {
+		readErr <- w.read(ctx, f, events)
+	}()
+	dirs := make(map[string]struct{})
+	timer := time.NewTimer(watchDelay)
+	timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case err := <-readErr:
+			return err
+		case dir := <-events:
+			if len(dirs) == 0 {
+				timer.Reset(watchDelay)
+			}
+			dirs[dir] = struct{}{}
+		case <-timer.C:
+			report := make([]string, 0, len(dirs))
+			for dir := range dirs {
+				report = append(report, dir)
+			}
+			clear(dirs)
+			changed(report)
+		}
+	}
+}
+
+type inotifyWatcher struct {
+	fd   int
+	root string
+	// paths are the watched folders by watch descriptor
+	paths map[int32]string
+}
+
+// addRecursive watches the folder and its sub folders
+func (w *inotifyWatcher) addRecursive(dir string) {
+	_ = filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || !entry.IsDir() {
+			return nil
+		}
+		wd, err := unix.InotifyAddWatch(w.fd, path, watchMask)
+		if err != nil {
+			log.Warnf("failed to watch [%s]: %+v", path, err)
+			if errors.Is(err, unix.ENOSPC) {
+				// the limit of watches is reached
+				return filepath.SkipAll
+			}
+			return nil
+		}
+		w.paths[int32(wd)] = path
+		return nil
+	})
+}
+
+// read sends the actual paths of the changed folders to events
+func (w *inotifyWatcher) read(ctx context.Context, f *os.File, events chan<- string) error {
+	buf := make([]byte, 64*(unix.SizeofInotifyEvent+unix.NAME_MAX+1))
+	for {
+		n, err := f.Read(buf)
+		if err != nil {
+			return err
+		}
+		for offset := 0; offset+unix.SizeofInotifyEvent <= n; {
+			wd := int32(binary.NativeEndian.Uint32(buf[offset:]))
+			mask := binary.NativeEndian.Uint32(buf[offset+4:])
+			nameLen := int(binary.NativeEndian.Uint32(buf[offset+12:]))
+			nameStart := offset + unix.SizeofInotifyEvent
+			name := strings.TrimRight(string(buf[nameStart:nameStart+nameLen]), "\x00")
+			offset = nameStart + nameLen
+
+			dir, ok := w.paths[wd]
+			if !ok {
+				continue
+			}
+			if mask&unix.IN_IGNORED != 0 {
+				delete(w.paths, wd)
+				continue
+			}
+			if mask&unix.IN_ISDIR != 0 && mask&(unix.IN_CREATE|unix.IN_MOVED_TO) != 0 {
+				w.addRecursive(filepath.Join(dir, name))
+			}
+			select {
+			case events <- w.actualPath(dir):
+			case <-ctx.Done():
+				return nil
+			}
+		}
+	}
+}
+
+func (w *inotifyWatcher) actualPath(dir string) string {
+	rel, err := filepath.Rel(w.root, dir)
+	if err != nil || rel == "." {
+		return "/"
+	}
+	return "/" + filepath.ToSlash(rel)
+}
@@ -0,0 +1,219 @@
+// Package pack lays out zip and tar archives of listed objects, the layout only
+// depends on the listed names and sizes, so any range of an archive can be
+// generated again without generating the bytes before it.
+package pack
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"hash/crc32"
+	"io"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/pkg/http_range"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/go-cache"
+	"github.com/pkg/errors"
+)
+
+type Entry struct {
+	// Name is the slash separated path in the archive
+	Name     string
+	Size     int64
+	Modified time.Time
+	IsDir    bool
+	// Key identifies the content of the entry across requests, the checksums
+	// of the entries with a key are cached
+	Key string
+	// Open returns length bytes of the content from offset
+	Open func(ctx context.Context, offset, length int64) (io.ReadCloser, error)
+}
+
+// crcCache holds the checksums needed by the zip data descriptors and central directory,
+// so that resuming a download doesn't read the entries before the range again
+var crcCache = cache.NewMemCache(cache.WithShards[uint32](16))
+
+type file struct {
+	*Entry
+	// withCRC is set if the checksum is a part of the archive
+	withCRC  bool
+	crc      uint32
+	crcKnown bool
+}
+
+func (f *file) cacheKey() string {
+	if f.Key == "" {
+		return ""
+	}
+	return fmt.Sprintf("%s:%d:%d", f.Key, f.Size, f.Modified.UnixNano())
+}
+
+func (f *file) setCRC(crc uint32) {
+	f.crc, f.crcKnown = crc, true
+	if key := f.cacheKey(); key != "" {
+		crcCache.Set(key, crc, cache.WithEx[uint32](time.Hour*24))
+	}
+}
+
+type segment struct {
+	offset int64
+	size   int64
+	// data is the static content of the segment
+	data []byte
+	file *file
+	// build generates the content of a segment which depends on the checksum of file,
+	// the segment is the content of file if both data and build are nil
+	build func(crc uint32) []byte
+}
+
+type Archive struct {
+	segments []segment
+	size     int64
+	digest   []byte
+}
+
+type builder struct {
+	Archive
+	hash hash.Hash
+}
+
+func newBuilder(format string) *builder {
+	h := sha1.New()
+	_, _ = io.WriteString(h, format)
+	return &builder{hash: h}
+}
+
+func (b *builder) addEntry(e *Entry) {
+	_, _ = fmt.Fprintf(b.hash, "\x00%s\x00%d\x00%d\x00%t", e.Name, e.Size, e.Modified.UnixNano(), e.IsDir)
+}
+
+func (b *builder) add(s segment) {
+	if s.data != nil {
+		s.size = int64(len(s.data))
+	}
+	if s.size == 0 {
+		return
+	}
+	s.offset = b.size
+	b.size += s.size
+	b.segments = append(b.segments, s)
+}
+
+func (b *builder) archive() *Archive {
+	b.digest = b.hash.Sum(nil)
+	return &b.Archive
+}
+
+func (a *Archive) Size() int64 {
+	return a.size
+}
+
+// ETag identifies the layout of the archive, it changes once any entry changes
+func (a *Archive) ETag() string {
+	return `"` + hex.EncodeToString(a.digest) + `"`
+}
+
+// WriteRange writes length bytes of the archive from start to w
+func (a *Archive) WriteRange(ctx context.Context, w io.Writer, start, length int64) error {
+	end := start + length
+	for i := range a.segments {
+		s := &a.segments[i]
+		if s.offset+s.size <= start {
+			continue
+		}
+		if s.offset >= end {
+			break
+		}
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		from := max(start-s.offset, 0)
+		to := min(end-s.offset, s.size)
+		var err error
+		switch {
+		case s.data != nil:
+			_, err = w.Write(s.data[from:to])
+		case s.build != nil:
+			var crc uint32
+			if crc, err = a.crc(ctx, s.file); err == nil {
+				_, err = w.Write(s.build(crc)[from:to])
+			}
+		case s.file.withCRC && !s.file.crcKnown && from == 0 && to == s.size:
+			h := crc32.NewIEEE()
+			if err = copyContent(ctx, io.MultiWriter(w, h), s.file, 0, s.size); err == nil {
+				s.file.setCRC(h.Sum32())
+			}
+		default:
+			err = copyContent(ctx, w, s.file, from, to-from)
+		}
+		if err != nil {
+			if s.file != nil {
+				return errors.WithMessagef(err, "failed to write [%s]", s.file.Name)
+			}
+			return errors.WithStack(err)
+		}
+	}
+	return nil
+}
+
+// RangeRead implements model.RangeReaderIF
+func (a *Archive) RangeRead(ctx context.Context, httpRange http_range.Range) (io.ReadCloser, error) {
+	if httpRange.Start < 0 || httpRange.Start > a.size {
+		return nil, errors.Errorf("range start %d out of archive size %d", httpRange.Start, a.size)
+	}
+	length := httpRange.Length
+	if length < 0 || httpRange.Start+length > a.size {
+		length = a.size - httpRange.Start
+	}
+	pr, pw := io.Pipe()
+	go func() {
+		pw.CloseWithError(a.WriteRange(ctx, pw, httpRange.Start, length))
+	}()
+	return pr, nil
+}
+
+func (a *Archive) crc(ctx context.Context, f *file) (uint32, error) {
+	if f.crcKnown {
+		return f.crc, nil
+	}
+	if key := f.cacheKey(); key != "" {
+		if crc, ok := crcCache.Get(key); ok {
+			f.crc, f.crcKnown = crc, true
+			return crc, nil
+		}
+	}
+	h := crc32.NewIEEE()
+	if err := copyContent(ctx, h, f, 0, f.Size); err != nil {
+		return 0, err
+	}
+	f.setCRC(h.Sum32())
+	return f.crc, nil
+}
+
+// copyContent writes length bytes of the content of f from offset, the content is padded
+// with zeros if it is shorter than listed so that the layout is kept
+func copyContent(ctx context.Context, w io.Writer, f *file, offset, length int64) error {
+	rc, err := f.Open(ctx, offset, length)
+	if err != nil {
+		return err
+	}
+	defer rc.Close()
+	n, err := utils.CopyWithBufferN(w, rc, length)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+	if n < length {
+		_, err = utils.CopyWithBufferN(w, zeroReader{}, length-n)
+	}
+	return err
+}
+
+type zeroReader struct{}
+
+func (zeroReader) Read(p []byte) (int, error) {
+	clear(p)
+	return len(p), nil
+}
@@ -0,0 +1,117 @@
+package pack
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"context"
+	"io"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/pkg/http_range"
+)
+
+func testEntries(contents map[string]string) []Entry {
+	modified := time.Date(2024, 5, 6, 7, 8, 10, 0, time.UTC)
+	entries := []Entry{{Name: "dir", IsDir: true, Modified: modified}}
+	for _, name := range []string{"dir/a.txt", "dir/empty", "b.txt"} {
+		content := contents[name]
+		entries = append(entries, Entry{
+			Name:     name,
+			Size:     int64(len(content)),
+			Modified: modified,
+			Open: func(ctx context.Context, offset, length int64) (io.ReadCloser, error) {
+				return io.NopCloser(strings.NewReader(content[offset : offset+length])), nil
+			},
+		})
+	}
+	return entries
+}
+
+func readAll(t *testing.T, a *Archive, r http_range.Range) []byte {
+	t.Helper()
+	rc, err := a.RangeRead(context.Background(), r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rc.Close()
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("failed to read range %+v: %+v", r, err)
+	}
+	return data
+}
+
+func checkRanges(t *testing.T, a *Archive, data []byte) {
+	t.Helper()
+	if int64(len(data)) != a.Size() {
+		t.Fatalf("expected %d bytes, got %d", a.Size(), len(data))
+	}
+	for start := int64(0); start < a.Size(); start += 37 {
+		if got := readAll(t, a, http_range.Range{Start: start, Length: 50}); !bytes.Equal(got, data[start:min(start+50, a.Size())]) {
+			t.Fatalf("range from %d differs from the full archive", start)
+		}
+	}
+}
+
+func TestZip(t *testing.T) {
+	contents := map[string]string{"dir/a.txt": strings.Repeat("a", 1000), "b.txt": "bbb"}
+	a := Zip(testEntries(contents))
+	data := readAll(t, a, http_range.Range{Length: -1})
+	// a new archive doesn't know the checksums before the range
+	checkRanges(t, Zip(testEntries(contents)), data)
+
+	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		t.Fatalf("failed to open zip: %+v", err)
+	}
+	if len(r.File) != 4 || !r.File[0].FileInfo().IsDir() || r.File[0].Name != "dir/" {
+		t.Fatalf("unexpected files: %+v", r.File)
+	}
+	for _, f := range r.File[1:] {
+		if !f.Modified.Equal(time.Date(2024, 5, 6, 7, 8, 10, 0, time.UTC)) {
+			t.Errorf("unexpected modified time of %s: %s", f.Name, f.Modified)
+		}
+		rc, err := f.Open()
+		if err != nil {
+			t.Fatal(err)
+		}
+		content, err := io.ReadAll(rc)
+		rc.Close()
+		if err != nil || string(content) != contents[f.Name] {
+			t.Errorf("unexpected content of %s: %q, %+v", f.Name, content, err)
+		}
+	}
+}
+
+func TestTar(t *testing.T) {
+	contents := map[string]string{"dir/a.txt": strings.Repeat("a", 1000), "b.txt": "bbb"}
+	a, err := Tar(testEntries(contents))
+	if err != nil {
+		t.Fatal(err)
+	}
+	data := readAll(t, a, http_range.Range{Length: -1})
+	checkRanges(t, a, data)
+
+	r := tar.NewReader(bytes.NewReader(data))
+	var names []string
+	for {
+		hdr, err := r.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			t.Fatalf("failed to read tar: %+v", err)
+		}
+		names = append(names, hdr.Name)
+		content, _ := io.ReadAll(r)
+		if hdr.Typeflag == tar.TypeReg && string(content) != contents[hdr.Name] {
+			t.Errorf("unexpected content of %s: %q", hdr.Name, content)
+		}
+	}
+	if strings.Join(names, ",") != "dir/,dir/a.txt,dir/empty,b.txt" {
+		t.Errorf("unexpected names: %v", names)
+	}
+}
@@ -0,0 +1,46 @@
+package pack
+
+import (
+	"archive/tar"
+	"bytes"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+const tarBlockSize = 512
+
+// Tar lays out a tar archive, the headers fall back to PAX for long names and large sizes
+func Tar(entries []Entry) (*Archive, error) {
+	b := newBuilder("tar")
+	for i := range entries {
+		e := &entries[i]
+		b.addEntry(e)
+		hdr := &tar.Header{
+			Name:    strings.TrimPrefix(e.Name, "/"),
+			ModTime: e.Modified,
+		}
+		if e.IsDir {
+			hdr.Typeflag = tar.TypeDir
+			hdr.Name = strings.TrimSuffix(hdr.Name, "/") + "/"
+			hdr.Mode = 0o755
+		} else {
+			hdr.Typeflag = tar.TypeReg
+			hdr.Mode = 0o644
+			hdr.Size = e.Size
+		}
+		var buf bytes.Buffer
+		if err := tar.NewWriter(&buf).WriteHeader(hdr); err != nil {
+			return nil, errors.WithMessagef(err, "failed to write header of [%s]", e.Name)
+		}
+		b.add(segment{data: buf.Bytes()})
+		if hdr.Size > 0 {
+			b.add(segment{file: &file{Entry: e}, size: hdr.Size})
+			if pad := hdr.Size % tarBlockSize; pad > 0 {
+				b.add(segment{data: make([]byte, tarBlockSize-pad)})
+			}
+		}
+	}
+	b.add(segment{data: make([]byte, tarBlockSize*2)})
+	return b.archive(), nil
+}
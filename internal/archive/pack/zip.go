@@ -0,0 +1,250 @@
+package pack
+
+import (
+	"encoding/binary"
+	"math"
+	"strings"
+	"time"
+)
+
+const (
+	zipLocalHeaderSig    = 0x04034b50
+	zipDataDescriptorSig = 0x08074b50
+	zipCentralHeaderSig  = 0x02014b50
+	zipEndSig            = 0x06054b50
+	zip64EndSig          = 0x06064b50
+	zip64LocatorSig      = 0x07064b50
+
+	zipVersion20 = 20
+	zipVersion45 = 45
+	// the attributes are unix modes
+	zipCreatorUnix = 3 << 8
+
+	zipFlagDataDescriptor = 0x8
+	zipFlagUTF8           = 0x800
+
+	zip64ExtraID     = 0x0001
+	extTimeExtraID   = 0x5455
+	uint32max        = math.MaxUint32
+	uint16max        = math.MaxUint16
+	zipDirMode       = 0o40755
+	zipFileMode      = 0o100644
+	zipMSDOSDirAttr  = 0x10
+	zipDescriptorLen = 16
+)
+
+type writeBuf []byte
+
+func (b *writeBuf) uint8(v uint8) {
+	*b = append(*b, v)
+}
+
+func (b *writeBuf) uint16(v uint16) {
+	*b = binary.LittleEndian.AppendUint16(*b, v)
+}
+
+func (b *writeBuf) uint32(v uint32) {
+	*b = binary.LittleEndian.AppendUint32(*b, v)
+}
+
+func (b *writeBuf) uint64(v uint64) {
+	*b = binary.LittleEndian.AppendUint64(*b, v)
+}
+
+func (b *writeBuf) string(v string) {
+	*b = append(*b, v...)
+}
+
+// Zip lays out a zip archive which stores the entries without compression,
+// the sizes in the local headers are known so the entries can be read while streaming,
+// the checksums follow the content in data descriptors
+func Zip(entries []Entry) *Archive {
+	b := newBuilder("zip")
+	var central []segment
+	for i := range entries {
+		e := &entries[i]
+		b.addEntry(e)
+		f := &file{Entry: e, withCRC: true}
+		name := strings.TrimPrefix(e.Name, "/")
+		size := e.Size
+		if e.IsDir {
+			name = strings.TrimSuffix(name, "/") + "/"
+			size = 0
+		}
+		offset := b.size
+		zip64 := size >= uint32max
+		flags := uint16(zipFlagUTF8)
+		// the checksum of empty content is known, so it's not needed to follow the content
+		descriptor := size > 0
+		if descriptor {
+			flags |= zipFlagDataDescriptor
+		}
+		version := uint16(zipVersion20)
+		if zip64 || offset >= uint32max {
+			version = zipVersion45
+		}
+		dosDate, dosTime := msDosTime(e.Modified)
+		extTime := extTimeExtra(e.Modified)
+
+		var local writeBuf
+		local.uint32(zipLocalHeaderSig)
+		local.uint16(version)
+		local.uint16(flags)
+		local.uint16(0) // store
+		local.uint16(dosTime)
+		local.uint16(dosDate)
+		local.uint32(0) // crc32 is in the data descriptor
+		var localExtra writeBuf
+		if zip64 {
+			local.uint32(uint32max)
+			local.uint32(uint32max)
+			localExtra.uint16(zip64ExtraID)
+			localExtra.uint16(16)
+			localExtra.uint64(uint64(size))
+			localExtra.uint64(uint64(size))
+		} else {
+			local.uint32(uint32(size))
+			local.uint32(uint32(size))
+		}
+		localExtra = append(localExtra, extTime...)
+		local.uint16(uint16(len(name)))
+		local.uint16(uint16(len(localExtra)))
+		local.string(name)
+		local = append(local, localExtra...)
+		b.add(segment{data: local})
+
+		if size > 0 {
+			b.add(segment{file: f, size: size})
+			descriptorLen := int64(zipDescriptorLen)
+			if zip64 {
+				descriptorLen += 8
+			}
+			b.add(segment{file: f, size: descriptorLen, build: func(crc uint32) []byte {
+				var d writeBuf
+				d.uint32(zipDataDescriptorSig)
+				d.uint32(crc)
+				if zip64 {
+					d.uint64(uint64(size))
+					d.uint64(uint64(size))
+				} else {
+					d.uint32(uint32(size))
+					d.uint32(uint32(size))
+				}
+				return d
+			}})
+		}
+
+		var centralExtra writeBuf
+		var zip64Fields writeBuf
+		sizeField, offsetField := uint32(size), uint32(offset)
+		if zip64 {
+			sizeField = uint32max
+			zip64Fields.uint64(uint64(size))
+			zip64Fields.uint64(uint64(size))
+		}
+		if offset >= uint32max {
+			offsetField = uint32max
+			zip64Fields.uint64(uint64(offset))
+		}
+		if len(zip64Fields) > 0 {
+			centralExtra.uint16(zip64ExtraID)
+			centralExtra.uint16(uint16(len(zip64Fields)))
+			centralExtra = append(centralExtra, zip64Fields...)
+		}
+		centralExtra = append(centralExtra, extTime...)
+		externalAttrs := uint32(zipFileMode) << 16
+		if e.IsDir {
+			externalAttrs = uint32(zipDirMode)<<16 | zipMSDOSDirAttr
+		}
+		build := func(crc uint32) []byte {
+			var h writeBuf
+			h.uint32(zipCentralHeaderSig)
+			h.uint16(zipCreatorUnix | zipVersion45)
+			h.uint16(version)
+			h.uint16(flags)
+			h.uint16(0) // store
+			h.uint16(dosTime)
+			h.uint16(dosDate)
+			h.uint32(crc)
+			h.uint32(sizeField)
+			h.uint32(sizeField)
+			h.uint16(uint16(len(name)))
+			h.uint16(uint16(len(centralExtra)))
+			h.uint16(0) // comment
+			h.uint16(0) // disk number
+			h.uint16(0) // internal attributes
+			h.uint32(externalAttrs)
+			h.uint32(offsetField)
+			h.string(name)
+			return append(h, centralExtra...)
+		}
+		s := segment{size: int64(46 + len(name) + len(centralExtra))}
+		if size > 0 {
+			s.file, s.build = f, build
+		} else {
+			s.data = build(0)
+		}
+		central = append(central, s)
+	}
+
+	centralOffset := b.size
+	for _, s := range central {
+		b.add(s)
+	}
+	centralSize := b.size - centralOffset
+	count := uint64(len(entries))
+
+	var end writeBuf
+	if count >= uint16max || centralSize >= uint32max || centralOffset >= uint32max {
+		zip64EndOffset := b.size
+		end.uint32(zip64EndSig)
+		end.uint64(44) // size of the remaining record
+		end.uint16(zipCreatorUnix | zipVersion45)
+		end.uint16(zipVersion45)
+		end.uint32(0) // disk number
+		end.uint32(0) // disk with the central directory
+		end.uint64(count)
+		end.uint64(count)
+		end.uint64(uint64(centralSize))
+		end.uint64(uint64(centralOffset))
+
+		end.uint32(zip64LocatorSig)
+		end.uint32(0) // disk with the zip64 end of central directory
+		end.uint64(uint64(zip64EndOffset))
+		end.uint32(1) // total disks
+	}
+	end.uint32(zipEndSig)
+	end.uint16(0) // disk number
+	end.uint16(0) // disk with the central directory
+	end.uint16(uint16(min(count, uint16max)))
+	end.uint16(uint16(min(count, uint16max)))
+	end.uint32(uint32(min(uint64(centralSize), uint32max)))
+	end.uint32(uint32(min(uint64(centralOffset), uint32max)))
+	end.uint16(0) // comment
+	b.add(segment{data: end})
+	return b.archive()
+}
+
+func msDosTime(t time.Time) (date, tm uint16) {
+	if t.Year() < 1980 {
+		t = time.Date(1980, 1, 1, 0, 0, 0, 0, time.UTC)
+	}
+	date = uint16(t.Day() + int(t.Month())<<5 + (t.Year()-1980)<<9)
+	tm = uint16(t.Second()/2 + t.Minute()<<5 + t.Hour()<<11)
+	return
+}
+
+// extTimeExtra is the extended timestamp extra field holding the modification time
+// in unix time, which isn't affected by time zones as the MS-DOS time is
+func extTimeExtra(t time.Time) []byte {
+	unix := t.Unix()
+	if t.IsZero() || unix < 0 || unix > uint32max {
+		return nil
+	}
+	var b writeBuf
+	b.uint16(extTimeExtraID)
+	b.uint16(5)
+	b.uint8(1) // only the modification time
+	b.uint32(uint32(unix))
+	return b
+}
@@ -0,0 +1,36 @@
+package bootstrap
+
+import (
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/OpenListTeam/OpenList/v4/pkg/cron"
+	log "github.com/sirupsen/logrus"
+)
+
+var auditCron *cron.Cron
+
+// InitAuditPurge starts the job deleting the audit logs older than the retention days
+func InitAuditPurge() {
+	auditCron = cron.NewCron(time.Hour)
+	auditCron.Do(purgeAuditLogs)
+}
+
+func StopAuditPurge() {
+	if auditCron != nil {
+		auditCron.Stop()
+		auditCron = nil
+	}
+}
+
+func purgeAuditLogs() {
+	days := setting.GetInt(conf.AuditRetentionDays, 90)
+	if days <= 0 {
+		return
+	}
+	if err := op.DeleteAuditLogsBefore(time.Now().AddDate(0, 0, -days)); err != nil {
+		log.Errorf("failed purge audit logs: %+v", err)
+	}
+}
@@ -0,0 +1,13 @@
+package bootstrap
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	log "github.com/sirupsen/logrus"
+)
+
+// InitDedupe marks the dedupe scans stopped by the last shutdown as interrupted
+func InitDedupe() {
+	if err := db.InterruptRunningDedupeReports(); err != nil {
+		log.Errorf("failed update dedupe reports: %+v", err)
+	}
+}
@@ -92,6 +92,11 @@ func Start() {
 	InitOfflineDownloadTools()
 	LoadStorages()
 	InitTaskManager()
+	InitTrashPurge()
+	InitAuditPurge()
+	InitSharingAccessPurge()
+	InitSyncJobs()
+	InitDedupe()
 	if !flags.Debug && !flags.Dev {
 		gin.SetMode(gin.ReleaseMode)
 	}
@@ -274,6 +279,10 @@ func Start() {
 func Shutdown(timeout time.Duration) {
 	utils.Log.Println("Shutdown server...")
 	fs.ArchiveContentUploadTaskManager.RemoveAll()
+	StopTrashPurge()
+	StopAuditPurge()
+	StopSharingAccessPurge()
+	StopSyncJobs()
 	ctx, cancel := context.WithTimeout(context.Background(), timeout)
 	defer cancel()
 	var wg sync.WaitGroup
@@ -0,0 +1,36 @@
+package bootstrap
+
+import (
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/OpenListTeam/OpenList/v4/pkg/cron"
+	log "github.com/sirupsen/logrus"
+)
+
+var sharingAccessCron *cron.Cron
+
+// InitSharingAccessPurge starts the job deleting the sharing accesses older than the retention days
+func InitSharingAccessPurge() {
+	sharingAccessCron = cron.NewCron(time.Hour)
+	sharingAccessCron.Do(purgeSharingAccesses)
+}
+
+func StopSharingAccessPurge() {
+	if sharingAccessCron != nil {
+		sharingAccessCron.Stop()
+		sharingAccessCron = nil
+	}
+}
+
+func purgeSharingAccesses() {
+	days := setting.GetInt(conf.ShareAccessRetentionDays, 90)
+	if days <= 0 {
+		return
+	}
+	if err := op.DeleteSharingAccessesBefore(time.Now().AddDate(0, 0, -days)); err != nil {
+		log.Errorf("failed purge sharing accesses: %+v", err)
+	}
+}
@@ -0,0 +1,23 @@
+package bootstrap
+
+import (
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/sync_job"
+	"github.com/OpenListTeam/OpenList/v4/pkg/cron"
+)
+
+var syncCron *cron.Cron
+
+// InitSyncJobs starts checking the schedules of the sync jobs every minute
+func InitSyncJobs() {
+	syncCron = cron.NewCron(time.Minute)
+	syncCron.Do(sync_job.RunDueJobs)
+}
+
+func StopSyncJobs() {
+	if syncCron != nil {
+		syncCron.Stop()
+		syncCron = nil
+	}
+}
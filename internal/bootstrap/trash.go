@@ -0,0 +1,39 @@
+package bootstrap
+
+import (
+	"context"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/OpenListTeam/OpenList/v4/pkg/cron"
+	log "github.com/sirupsen/logrus"
+)
+
+var trashCron *cron.Cron
+
+// InitTrashPurge starts the job deleting objects which stay in the
+// recycle bin longer than the retention days permanently
+func InitTrashPurge() {
+	trashCron = cron.NewCron(time.Hour)
+	trashCron.Do(purgeTrash)
+}
+
+func StopTrashPurge() {
+	if trashCron != nil {
+		trashCron.Stop()
+		trashCron = nil
+	}
+}
+
+func purgeTrash() {
+	days := setting.GetInt(conf.TrashRetentionDays, 30)
+	if days <= 0 {
+		return
+	}
+	before := time.Now().AddDate(0, 0, -days)
+	if err := op.PurgeTrashBefore(context.Background(), before); err != nil {
+		log.Errorf("failed purge trash: %+v", err)
+	}
+}
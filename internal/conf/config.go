@@ -107,6 +107,11 @@ type SFTP struct {
 	Listen string `json:"listen" env:"LISTEN"`
 }
 
+type WebDAV struct {
+	// LockSystem is "memory" or "database", the latter survives restarts and is shared by the instances using the same database
+	LockSystem string `json:"lock_system" env:"LOCK_SYSTEM"`
+}
+
 type MCP struct {
 	Enable bool `json:"enable" env:"ENABLE"`
 }
@@ -138,6 +143,7 @@ type Config struct {
 	FTP                   FTP         `json:"ftp" envPrefix:"FTP_"`
 	SFTP                  SFTP        `json:"sftp" envPrefix:"SFTP_"`
 	MCP                   MCP         `json:"mcp" envPrefix:"MCP_"`
+	WebDAV                WebDAV      `json:"webdav" envPrefix:"WEBDAV_"`
 	LastLaunchedVersion   string      `json:"last_launched_version"`
 	ProxyAddress          string      `json:"proxy_address" env:"PROXY_ADDRESS"`
 }
@@ -255,6 +261,9 @@ func DefaultConfig(dataDir string) *Config {
 		MCP: MCP{
 			Enable: false,
 		},
+		WebDAV: WebDAV{
+			LockSystem: "memory",
+		},
 		LastLaunchedVersion: "",
 		ProxyAddress:        "",
 	}
@@ -41,30 +41,39 @@ const (
 	NonEFSZipEncoding             = "non_efs_zip_encoding"
 
 	// global
-	HideFiles               = "hide_files"
-	CustomizeHead           = "customize_head"
-	CustomizeBody           = "customize_body"
-	LinkExpiration          = "link_expiration"
-	SignAll                 = "sign_all"
-	PrivacyRegs             = "privacy_regs"
-	OcrApi                  = "ocr_api"
-	FilenameCharMapping     = "filename_char_mapping"
-	ForwardDirectLinkParams = "forward_direct_link_params"
-	IgnoreDirectLinkParams  = "ignore_direct_link_params"
-	WebauthnLoginEnabled    = "webauthn_login_enabled"
-	SharePreview            = "share_preview"
-	ShareArchivePreview     = "share_archive_preview"
-	ShareForceProxy         = "share_force_proxy"
-	ShareSummaryContent     = "share_summary_content"
-	HandleHookAfterWriting  = "handle_hook_after_writing"
-	HandleHookRateLimit     = "handle_hook_rate_limit"
-	IgnoreSystemFiles       = "ignore_system_files"
+	HideFiles                = "hide_files"
+	CustomizeHead            = "customize_head"
+	CustomizeBody            = "customize_body"
+	LinkExpiration           = "link_expiration"
+	SignAll                  = "sign_all"
+	PrivacyRegs              = "privacy_regs"
+	OcrApi                   = "ocr_api"
+	FilenameCharMapping      = "filename_char_mapping"
+	ForwardDirectLinkParams  = "forward_direct_link_params"
+	IgnoreDirectLinkParams   = "ignore_direct_link_params"
+	WebauthnLoginEnabled     = "webauthn_login_enabled"
+	SharePreview             = "share_preview"
+	ShareArchivePreview      = "share_archive_preview"
+	ShareForceProxy          = "share_force_proxy"
+	ShareSummaryContent      = "share_summary_content"
+	HandleHookAfterWriting   = "handle_hook_after_writing"
+	HandleHookRateLimit      = "handle_hook_rate_limit"
+	IgnoreSystemFiles        = "ignore_system_files"
+	TrashRetentionDays       = "trash_retention_days"
+	MetricsToken             = "metrics_token"
+	AuditRetentionDays       = "audit_retention_days"
+	ShareAccessRetentionDays = "share_access_retention_days"
+	WebdavSetModTime         = "webdav_set_mod_time"
 
 	// index
 	SearchIndex     = "search_index"
 	AutoUpdateIndex = "auto_update_index"
 	IgnorePaths     = "ignore_paths"
 	MaxIndexDepth   = "max_index_depth"
+	// content index
+	IndexContent          = "index_content"
+	IndexContentDocuments = "index_content_documents"
+	IndexContentMaxSize   = "index_content_max_size"
 
 	// aria2
 	Aria2Uri    = "aria2_uri"
@@ -114,6 +123,7 @@ const (
 	SSODefaultDir        = "sso_default_dir"
 	SSODefaultPermission = "sso_default_permission"
 	SSOCompatibilityMode = "sso_compatibility_mode"
+	SSOGroupsKey         = "sso_groups_key"
 
 	// ldap
 	LdapLoginEnabled      = "ldap_login_enabled"
@@ -126,6 +136,7 @@ const (
 	LdapDefaultPermission = "ldap_default_permission"
 	LdapDefaultDir        = "ldap_default_dir"
 	LdapLoginTips         = "ldap_login_tips"
+	LdapGroupAttribute    = "ldap_group_attribute"
 
 	// s3
 	S3Buckets         = "s3_buckets"
@@ -193,4 +204,7 @@ const (
 	PathKey
 	SharingIDKey
 	SkipHookKey
+	ProtocolKey
+	TokenScopeKey
+	SystemPathKey
 )
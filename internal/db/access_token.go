@@ -0,0 +1,52 @@
+package db
+
+import (
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetAccessTokenByKeyId(keyID string) (*model.AccessToken, error) {
+	var t model.AccessToken
+	if err := db.Where("key_id = ?", keyID).First(&t).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get access token")
+	}
+	return &t, nil
+}
+
+func GetAccessTokenById(id uint) (*model.AccessToken, error) {
+	var t model.AccessToken
+	if err := db.First(&t, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get access token")
+	}
+	return &t, nil
+}
+
+func GetAccessTokensByUserId(userID uint) ([]model.AccessToken, error) {
+	var tokens []model.AccessToken
+	if err := db.Where("user_id = ?", userID).Order(columnName("id")).Find(&tokens).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find access tokens")
+	}
+	return tokens, nil
+}
+
+func CreateAccessToken(t *model.AccessToken) error {
+	return errors.WithStack(db.Create(t).Error)
+}
+
+func UpdateAccessToken(t *model.AccessToken) error {
+	return errors.WithStack(db.Save(t).Error)
+}
+
+func UpdateAccessTokenLastUsed(id uint, t time.Time) error {
+	return errors.WithStack(db.Model(&model.AccessToken{ID: id}).Update("last_used_at", t).Error)
+}
+
+func DeleteAccessTokenById(id uint) error {
+	return errors.WithStack(db.Delete(&model.AccessToken{}, id).Error)
+}
+
+func DeleteAccessTokensByUserId(userID uint) error {
+	return errors.WithStack(db.Where("user_id = ?", userID).Delete(&model.AccessToken{}).Error)
+}
@@ -0,0 +1,59 @@
+package db
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+func CreateAuditLog(l *model.AuditLog) error {
+	return errors.WithStack(db.Create(l).Error)
+}
+
+func filterAuditLogs(filter model.AuditLogFilter) *gorm.DB {
+	auditDB := db.Model(&model.AuditLog{})
+	if filter.Username != "" {
+		auditDB = auditDB.Where(fmt.Sprintf("%s = ?", columnName("username")), filter.Username)
+	}
+	if filter.Action != "" {
+		auditDB = auditDB.Where(fmt.Sprintf("%s = ?", columnName("action")), filter.Action)
+	}
+	if filter.Path != "" && filter.Path != "/" {
+		auditDB = auditDB.Where(db.Where(fmt.Sprintf("%s LIKE ? ESCAPE '!'", columnName("path")), likeEscaper.Replace(filter.Path)+"/%").
+			Or(fmt.Sprintf("%s = ?", columnName("path")), filter.Path))
+	}
+	if filter.Start > 0 {
+		auditDB = auditDB.Where(fmt.Sprintf("%s >= ?", columnName("time")), time.Unix(filter.Start, 0))
+	}
+	if filter.End > 0 {
+		auditDB = auditDB.Where(fmt.Sprintf("%s < ?", columnName("time")), time.Unix(filter.End, 0))
+	}
+	return auditDB
+}
+
+func GetAuditLogs(filter model.AuditLogFilter, pageIndex, pageSize int) (logs []model.AuditLog, count int64, err error) {
+	auditDB := filterAuditLogs(filter)
+	if err = auditDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get audit logs count")
+	}
+	if err = auditDB.Order(columnName("id") + " DESC").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&logs).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find audit logs")
+	}
+	return logs, count, nil
+}
+
+// FindAuditLogsInBatches calls fn with the matched logs in batches, from the oldest to the latest
+func FindAuditLogsInBatches(filter model.AuditLogFilter, batchSize int, fn func([]model.AuditLog) error) error {
+	var logs []model.AuditLog
+	err := filterAuditLogs(filter).FindInBatches(&logs, batchSize, func(tx *gorm.DB, batch int) error {
+		return fn(logs)
+	}).Error
+	return errors.Wrapf(err, "failed find audit logs")
+}
+
+func DeleteAuditLogsBefore(t time.Time) error {
+	return errors.WithStack(db.Where(fmt.Sprintf("%s < ?", columnName("time")), t).Delete(&model.AuditLog{}).Error)
+}
@@ -12,7 +12,7 @@ var db *gorm.DB
 
 func Init(d *gorm.DB) {
 	db = d
-	err := AutoMigrate(new(model.Storage), new(model.User), new(model.Meta), new(model.SettingItem), new(model.SearchNode), new(model.TaskItem), new(model.SSHPublicKey), new(model.SharingDB))
+	err := AutoMigrate(new(model.Storage), new(model.User), new(model.Group), new(model.Meta), new(model.SettingItem), new(model.SearchNode), new(model.TaskItem), new(model.SSHPublicKey), new(model.SharingDB), new(model.SharingUpload), new(model.SharingAccess), new(model.OAuthClient), new(model.OAuthToken), new(model.AccessToken), new(model.TrashItem), new(model.DirUsage), new(model.Webhook), new(model.WebhookDelivery), new(model.AuditLog), new(model.SyncJob), new(model.DedupeReport), new(model.DuplicateFile), new(model.WebDAVLock), new(model.DeadProp))
 	if err != nil {
 		log.Fatalf("failed migrate database: %s", err.Error())
 	}
@@ -0,0 +1,103 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+func CreateDedupeReport(r *model.DedupeReport) error {
+	return errors.WithStack(db.Create(r).Error)
+}
+
+func UpdateDedupeReport(r *model.DedupeReport) error {
+	return errors.WithStack(db.Save(r).Error)
+}
+
+func GetDedupeReportById(id uint) (*model.DedupeReport, error) {
+	var r model.DedupeReport
+	if err := db.First(&r, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get dedupe report")
+	}
+	return &r, nil
+}
+
+func GetDedupeReports(pageIndex, pageSize int) (reports []model.DedupeReport, count int64, err error) {
+	reportDB := db.Model(&model.DedupeReport{})
+	if err = reportDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get dedupe reports count")
+	}
+	if err = reportDB.Order(columnName("id") + " DESC").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&reports).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find dedupe reports")
+	}
+	return reports, count, nil
+}
+
+// InterruptRunningDedupeReports marks the reports left running by the last process
+func InterruptRunningDedupeReports() error {
+	return errors.WithStack(db.Model(&model.DedupeReport{}).
+		Where(columnName("status")+" = ?", model.DedupeRunning).
+		Update("status", model.DedupeInterrupted).Error)
+}
+
+func DeleteDedupeReportById(id uint) error {
+	if err := db.Where(columnName("report_id")+" = ?", id).Delete(&model.DuplicateFile{}).Error; err != nil {
+		return errors.Wrapf(err, "failed delete duplicate files")
+	}
+	return errors.WithStack(db.Delete(&model.DedupeReport{}, id).Error)
+}
+
+func CreateDuplicateFiles(files []model.DuplicateFile) error {
+	if len(files) == 0 {
+		return nil
+	}
+	return errors.WithStack(db.CreateInBatches(files, 100).Error)
+}
+
+func GetDuplicateFilesByIds(ids []uint) ([]model.DuplicateFile, error) {
+	var files []model.DuplicateFile
+	if err := db.Find(&files, ids).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find duplicate files")
+	}
+	return files, nil
+}
+
+func GetDuplicateFilesByGroups(reportID uint, keys []string) ([]model.DuplicateFile, error) {
+	var files []model.DuplicateFile
+	err := db.Where(fmt.Sprintf("%s = ? AND %s IN ?", columnName("report_id"), columnName("group_key")), reportID, keys).
+		Order(columnName("id")).Find(&files).Error
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed find duplicate files")
+	}
+	return files, nil
+}
+
+// GetDuplicateGroups returns the groups of a report without files, the largest first
+func GetDuplicateGroups(reportID uint, pageIndex, pageSize int) (groups []model.DuplicateGroup, count int64, err error) {
+	groupDB := db.Model(&model.DuplicateFile{}).Where(columnName("report_id")+" = ?", reportID)
+	if err = groupDB.Distinct(columnName("group_key")).Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get duplicate groups count")
+	}
+	var rows []struct {
+		Key  string
+		Size int64
+	}
+	groupDB = db.Model(&model.DuplicateFile{}).Where(columnName("report_id")+" = ?", reportID)
+	err = groupDB.Select(fmt.Sprintf("%s AS %s, MAX(%s) AS %s", columnName("group_key"), columnName("key"), columnName("size"), columnName("size"))).
+		Group(columnName("group_key")).
+		Order(fmt.Sprintf("%s DESC, %s", columnName("size"), columnName("key"))).
+		Offset((pageIndex - 1) * pageSize).Limit(pageSize).Scan(&rows).Error
+	if err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find duplicate groups")
+	}
+	groups = make([]model.DuplicateGroup, 0, len(rows))
+	for _, row := range rows {
+		groups = append(groups, model.DuplicateGroup{Key: row.Key, Size: row.Size})
+	}
+	return groups, count, nil
+}
+
+func UpdateDuplicateFile(f *model.DuplicateFile) error {
+	return errors.WithStack(db.Save(f).Error)
+}
@@ -0,0 +1,77 @@
+package db
+
+import (
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+func GetOAuthClientById(id uint) (*model.OAuthClient, error) {
+	var c model.OAuthClient
+	if err := db.First(&c, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get oauth client")
+	}
+	return &c, nil
+}
+
+func GetOAuthClientByClientId(clientID string) (*model.OAuthClient, error) {
+	var c model.OAuthClient
+	if err := db.Where("client_id = ?", clientID).First(&c).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get oauth client")
+	}
+	return &c, nil
+}
+
+func GetOAuthClients(pageIndex, pageSize int) (clients []model.OAuthClient, count int64, err error) {
+	clientDB := db.Model(&model.OAuthClient{})
+	if err = clientDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get oauth clients count")
+	}
+	if err = clientDB.Order(columnName("id")).Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&clients).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find oauth clients")
+	}
+	return clients, count, nil
+}
+
+func CreateOAuthClient(c *model.OAuthClient) error {
+	return errors.WithStack(db.Create(c).Error)
+}
+
+func UpdateOAuthClient(c *model.OAuthClient) error {
+	return errors.WithStack(db.Save(c).Error)
+}
+
+func DeleteOAuthClientById(id uint) error {
+	c, err := GetOAuthClientById(id)
+	if err != nil {
+		return err
+	}
+	if err = db.Where("client_id = ?", c.ClientID).Delete(&model.OAuthToken{}).Error; err != nil {
+		return errors.Wrapf(err, "failed delete oauth tokens")
+	}
+	return errors.WithStack(db.Delete(c).Error)
+}
+
+func CreateOAuthToken(t *model.OAuthToken) error {
+	return errors.WithStack(db.Create(t).Error)
+}
+
+func GetOAuthTokenByHash(hash string) (*model.OAuthToken, error) {
+	var t model.OAuthToken
+	if err := db.Where("token_hash = ?", hash).First(&t).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get oauth token")
+	}
+	return &t, nil
+}
+
+// DeleteOAuthToken deletes the token and reports whether it existed,
+// so that a refresh token can only be used once
+func DeleteOAuthToken(id uint) (bool, error) {
+	res := db.Delete(&model.OAuthToken{}, id)
+	return res.RowsAffected > 0, errors.WithStack(res.Error)
+}
+
+func DeleteExpiredOAuthTokens() error {
+	return errors.WithStack(db.Where("expires_at < ?", time.Now()).Delete(&model.OAuthToken{}).Error)
+}
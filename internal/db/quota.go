@@ -0,0 +1,69 @@
+package db
+
+import (
+	"fmt"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+func whereInPath(path string) *gorm.DB {
+	if path == "/" {
+		return db.Where("1 = 1")
+	}
+	return db.Where(fmt.Sprintf("%s LIKE ? ESCAPE '!'", columnName("path")),
+		likeEscaper.Replace(path)+"/%").
+		Or(fmt.Sprintf("%s = ?", columnName("path")), path)
+}
+
+// GetDirUsage returns nil if the usage of the folder has not been recorded
+func GetDirUsage(path string) (*model.DirUsage, error) {
+	var usages []model.DirUsage
+	if err := db.Where(fmt.Sprintf("%s = ?", columnName("path")), path).Limit(1).Find(&usages).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get dir usage")
+	}
+	if len(usages) == 0 {
+		return nil, nil
+	}
+	return &usages[0], nil
+}
+
+func SaveDirUsage(u *model.DirUsage) error {
+	return errors.WithStack(db.Save(u).Error)
+}
+
+func GetDirUsagesByParent(parent string) ([]model.DirUsage, error) {
+	var usages []model.DirUsage
+	if err := db.Where(fmt.Sprintf("%s = ?", columnName("parent")), parent).Find(&usages).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get dir usages")
+	}
+	return usages, nil
+}
+
+// DeleteDirUsages deletes the usage of the folder and all its sub folders
+func DeleteDirUsages(path string) error {
+	return errors.WithStack(db.Where(whereInPath(path)).Delete(&model.DirUsage{}).Error)
+}
+
+// GetUsage sums up the usage of the folder and all its sub folders
+func GetUsage(path string) (*model.Usage, error) {
+	var usage model.Usage
+	err := db.Model(&model.DirUsage{}).Where(whereInPath(path)).
+		Select(fmt.Sprintf("COALESCE(SUM(%s), 0) AS %s, COALESCE(SUM(%s), 0) AS %s",
+			columnName("size"), columnName("size"), columnName("files"), columnName("files"))).
+		Scan(&usage).Error
+	if err != nil {
+		return nil, errors.Wrapf(err, "failed get usage")
+	}
+	return &usage, nil
+}
+
+// HasUserQuotas reports whether any user has a byte or file quota
+func HasUserQuotas() (bool, error) {
+	var count int64
+	err := db.Model(&model.User{}).
+		Where(fmt.Sprintf("%s > 0 OR %s > 0", columnName("quota_bytes"), columnName("quota_files"))).
+		Limit(1).Count(&count).Error
+	return count > 0, errors.WithStack(err)
+}
@@ -35,10 +35,10 @@ func DeleteSearchNodesByParent(path string) error {
 	if err != nil {
 		return err
 	}
-	dir, name := stdpath.Split(path)
+	// the parents are saved without the trailing slash
 	return db.Where(fmt.Sprintf("%s = ? AND %s = ?",
 		columnName("parent"), columnName("name")),
-		dir, name).Delete(&model.SearchNode{}).Error
+		stdpath.Dir(path), stdpath.Base(path)).Delete(&model.SearchNode{}).Error
 }
 
 func ClearSearchNodes() error {
@@ -77,6 +77,7 @@ func SearchNode(req model.SearchReq, useFullText bool) ([]model.SearchNode, int6
 		isDir := req.Scope == 1
 		searchDB.Where(db.Where("is_dir = ?", isDir))
 	}
+	searchDB = searchDB.Where(whereMatchFilters(req)).Where(whereRestricted(req.Restriction))
 
 	var count int64
 	if err := searchDB.Count(&count).Error; err != nil {
@@ -89,3 +90,62 @@ func SearchNode(req model.SearchReq, useFullText bool) ([]model.SearchNode, int6
 	}
 	return files, count, nil
 }
+
+// inFolderClause matches the nodes whose parents are the folder or its sub folders
+func inFolderClause(folder string) (string, []any) {
+	if folder == "/" {
+		return "1 = 1", nil
+	}
+	return fmt.Sprintf("(%s = ? OR %s LIKE ? ESCAPE '!')", columnName("parent"), columnName("parent")),
+		[]any{folder, likeEscaper.Replace(folder) + "/%"}
+}
+
+// whereRestricted excludes the nodes in the denied folders, the hidden nodes are matched
+// by regular expressions which can't be queried in all the databases
+func whereRestricted(r *model.SearchRestriction) *gorm.DB {
+	tx := db.Where("1 = 1")
+	if r == nil {
+		return tx
+	}
+	for _, rule := range r.Denied {
+		clause, args := inFolderClause(rule.Path)
+		if !rule.Sub {
+			clause, args = fmt.Sprintf("%s = ?", columnName("parent")), []any{rule.Path}
+		}
+		for _, except := range rule.Except {
+			exceptClause, exceptArgs := inFolderClause(except)
+			clause += " AND NOT " + exceptClause
+			args = append(args, exceptArgs...)
+		}
+		tx = tx.Where("NOT ("+clause+")", args...)
+	}
+	return tx
+}
+
+// whereMatchFilters filters the nodes by size, modified time, extension and file type
+func whereMatchFilters(req model.SearchReq) *gorm.DB {
+	tx := db.Where("1 = 1")
+	if req.MinSize > 0 {
+		tx = tx.Where(fmt.Sprintf("%s >= ?", columnName("size")), req.MinSize)
+	}
+	if req.MaxSize > 0 {
+		tx = tx.Where(fmt.Sprintf("%s <= ?", columnName("size")), req.MaxSize)
+	}
+	if req.ModifiedAfter != nil {
+		tx = tx.Where(fmt.Sprintf("%s >= ?", columnName("modified")), req.ModifiedAfter.UTC())
+	}
+	if req.ModifiedBefore != nil {
+		tx = tx.Where(fmt.Sprintf("%s <= ?", columnName("modified")), req.ModifiedBefore.UTC())
+	}
+	if len(req.Exts) > 0 {
+		extsClause := db.Where("1 = 0")
+		for _, ext := range req.Exts {
+			extsClause = extsClause.Or(fmt.Sprintf("LOWER(%s) LIKE ? ESCAPE '!'", columnName("name")), "%."+likeEscaper.Replace(ext))
+		}
+		tx = tx.Where(extsClause)
+	}
+	if len(req.Types) > 0 {
+		tx = tx.Where(fmt.Sprintf("%s IN ?", columnName("file_type")), req.Types)
+	}
+	return tx
+}
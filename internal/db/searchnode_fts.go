@@ -0,0 +1,135 @@
+package db
+
+import (
+	"fmt"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"gorm.io/gorm"
+)
+
+// searchNodesTable is the table of the nodes named by the naming strategy of the database
+func searchNodesTable() string {
+	stmt := &gorm.Statement{DB: db}
+	if err := stmt.Parse(&model.SearchNode{}); err != nil {
+		return conf.Conf.Database.TablePrefix + "search_nodes"
+	}
+	return stmt.Schema.Table
+}
+
+func searchNodesFTSTable() string {
+	return searchNodesTable() + "_fts"
+}
+
+// InitSearchNodesFTS creates the FTS5 table indexing the names of the search nodes by trigrams
+// and the triggers keeping it in sync. It's rebuilt when it doesn't match the nodes, since
+// the rowids of the nodes may change when their table is recreated or vacuumed.
+func InitSearchNodesFTS() error {
+	if conf.Conf.Database.Type != "sqlite3" {
+		return errors.Errorf("fts5 isn't supported by %s", conf.Conf.Database.Type)
+	}
+	nodes, fts := searchNodesTable(), searchNodesFTSTable()
+	stmts := []string{
+		fmt.Sprintf("CREATE VIRTUAL TABLE IF NOT EXISTS `%s` USING fts5(keywords, tokenize = 'trigram')", fts),
+		fmt.Sprintf("CREATE TRIGGER IF NOT EXISTS `%s_ai` AFTER INSERT ON `%s` BEGIN "+
+			"INSERT INTO `%s`(rowid, keywords) VALUES (new.rowid, new.name); END", fts, nodes, fts),
+		fmt.Sprintf("CREATE TRIGGER IF NOT EXISTS `%s_ad` AFTER DELETE ON `%s` BEGIN "+
+			"DELETE FROM `%s` WHERE rowid = old.rowid; END", fts, nodes, fts),
+		fmt.Sprintf("CREATE TRIGGER IF NOT EXISTS `%s_au` AFTER UPDATE OF name ON `%s` BEGIN "+
+			"UPDATE `%s` SET keywords = new.name WHERE rowid = old.rowid; END", fts, nodes, fts),
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, stmt := range stmts {
+			if err := tx.Exec(stmt).Error; err != nil {
+				return errors.Wrap(err, "failed to create the fts5 table")
+			}
+		}
+		var nodeCount, ftsCount, mismatched int64
+		if err := tx.Raw(fmt.Sprintf("SELECT COUNT(*) FROM `%s`", nodes)).Scan(&nodeCount).Error; err != nil {
+			return err
+		}
+		if err := tx.Raw(fmt.Sprintf("SELECT COUNT(*) FROM `%s`", fts)).Scan(&ftsCount).Error; err != nil {
+			return err
+		}
+		if err := tx.Raw(fmt.Sprintf("SELECT COUNT(*) FROM `%s` n JOIN `%s` f ON f.rowid = n.rowid "+
+			"WHERE f.keywords <> n.name", nodes, fts)).Scan(&mismatched).Error; err != nil {
+			return err
+		}
+		if nodeCount == ftsCount && mismatched == 0 {
+			return nil
+		}
+		log.Infof("rebuilding the fts5 table of %d search nodes", nodeCount)
+		if err := tx.Exec(fmt.Sprintf("DELETE FROM `%s`", fts)).Error; err != nil {
+			return errors.Wrap(err, "failed to clear the fts5 table")
+		}
+		err := tx.Exec(fmt.Sprintf("INSERT INTO `%s`(rowid, keywords) SELECT rowid, name FROM `%s`", fts, nodes)).Error
+		return errors.Wrap(err, "failed to rebuild the fts5 table")
+	})
+}
+
+// DropSearchNodesFTS drops the triggers and the FTS5 table of the search nodes,
+// the triggers would keep writing to the table after another searcher is chosen
+func DropSearchNodesFTS() error {
+	if conf.Conf.Database.Type != "sqlite3" {
+		return nil
+	}
+	fts := searchNodesFTSTable()
+	stmts := []string{
+		fmt.Sprintf("DROP TRIGGER IF EXISTS `%s_ai`", fts),
+		fmt.Sprintf("DROP TRIGGER IF EXISTS `%s_ad`", fts),
+		fmt.Sprintf("DROP TRIGGER IF EXISTS `%s_au`", fts),
+		fmt.Sprintf("DROP TABLE IF EXISTS `%s`", fts),
+	}
+	return db.Transaction(func(tx *gorm.DB) error {
+		for _, stmt := range stmts {
+			if err := tx.Exec(stmt).Error; err != nil {
+				return errors.Wrap(err, "failed to drop the fts5 table")
+			}
+		}
+		return nil
+	})
+}
+
+// searchNodeFTSQuery matches the keywords of at least 3 characters with the FTS5 table and
+// orders the nodes by rank, the shorter keywords can't be looked up by trigrams and use LIKE
+func searchNodeFTSQuery(req model.SearchReq) *gorm.DB {
+	nodes, fts := searchNodesTable(), searchNodesFTSTable()
+	tx := db.Model(&model.SearchNode{}).Where(whereInParent(req.Parent))
+	var phrases []string
+	for _, keyword := range strings.Fields(req.Keywords) {
+		if utf8.RuneCountInString(keyword) < 3 {
+			tx = tx.Where(fmt.Sprintf("`%s`.name LIKE ? ESCAPE '!'", nodes), "%"+likeEscaper.Replace(keyword)+"%")
+			continue
+		}
+		phrases = append(phrases, `"`+strings.ReplaceAll(keyword, `"`, `""`)+`"`)
+	}
+	if len(phrases) > 0 {
+		tx = tx.Joins(fmt.Sprintf("JOIN `%s` ON `%s`.rowid = `%s`.rowid", fts, fts, nodes)).
+			Where(fmt.Sprintf("`%s` MATCH ?", fts), strings.Join(phrases, " AND ")).
+			Order(fmt.Sprintf("`%s`.rank", fts))
+	}
+	if req.Scope != 0 {
+		tx = tx.Where("is_dir = ?", req.Scope == 1)
+	}
+	return tx.Where(whereMatchFilters(req)).Where(whereRestricted(req.Restriction)).Order(fmt.Sprintf("`%s`.name", nodes))
+}
+
+func SearchNodeFTS(req model.SearchReq) ([]model.SearchNode, int64, error) {
+	var count int64
+	if err := searchNodeFTSQuery(req).Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get search items count")
+	}
+	files, err := SearchNodeFTSRange(req, (req.Page-1)*req.PerPage, req.PerPage)
+	return files, count, err
+}
+
+// SearchNodeFTSRange returns the nodes in the range of the ordered results
+func SearchNodeFTSRange(req model.SearchReq, offset, limit int) ([]model.SearchNode, error) {
+	var files []model.SearchNode
+	err := searchNodeFTSQuery(req).Offset(offset).Limit(limit).Find(&files).Error
+	return files, err
+}
@@ -1,9 +1,16 @@
 package db
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"time"
+
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils/random"
 	"github.com/pkg/errors"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 func GetSharingById(id string) (*model.SharingDB, error) {
@@ -64,19 +71,141 @@ func UpdateSharing(s *model.SharingDB) error {
 	return errors.WithStack(db.Save(s).Error)
 }
 
+// ReserveSharingUpload adds size to the uploaded bytes of the sharing in one statement
+// if the upload quota allows it, reserved is false otherwise
+func ReserveSharingUpload(id string, size int64) (reserved bool, err error) {
+	uploaded, quota := columnName("uploaded"), columnName("upload_quota")
+	res := db.Model(&model.SharingDB{}).
+		Where(fmt.Sprintf("id = ? AND (%s <= 0 OR %s + ? <= %s)", quota, uploaded, quota), id, size).
+		UpdateColumn("uploaded", gorm.Expr(uploaded+" + ?", size))
+	if res.Error != nil {
+		return false, errors.WithStack(res.Error)
+	}
+	return res.RowsAffected == 1, nil
+}
+
+// ReleaseSharingUpload gives back the bytes reserved for a failed upload
+func ReleaseSharingUpload(id string, size int64) error {
+	uploaded := columnName("uploaded")
+	return errors.WithStack(db.Model(&model.SharingDB{}).Where("id = ?", id).
+		UpdateColumn("uploaded", gorm.Expr(fmt.Sprintf("CASE WHEN %s > ? THEN %s - ? ELSE 0 END", uploaded, uploaded), size, size)).Error)
+}
+
 func UpdateSharingId(oldId, newId string) error {
 	// Check if new ID already exists
 	if err := db.Where("id = ?", newId).First(&model.SharingDB{}).Error; err == nil {
 		return errors.New("sharing id already exists")
 	}
-	return errors.WithStack(db.Model(&model.SharingDB{}).Where("id = ?", oldId).Update("id", newId).Error)
+	if err := db.Model(&model.SharingDB{}).Where("id = ?", oldId).Update("id", newId).Error; err != nil {
+		return errors.WithStack(err)
+	}
+	if err := db.Model(&model.SharingUpload{}).Where("sharing_id = ?", oldId).Update("sharing_id", newId).Error; err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(db.Model(&model.SharingAccess{}).Where("sharing_id = ?", oldId).Update("sharing_id", newId).Error)
 }
 
 func DeleteSharingById(id string) error {
 	s := model.SharingDB{ID: id}
-	return errors.WithStack(db.Where(s).Delete(&s).Error)
+	if err := db.Where(s).Delete(&s).Error; err != nil {
+		return errors.WithStack(err)
+	}
+	if err := db.Where("sharing_id = ?", id).Delete(&model.SharingUpload{}).Error; err != nil {
+		return errors.WithStack(err)
+	}
+	return errors.WithStack(db.Where("sharing_id = ?", id).Delete(&model.SharingAccess{}).Error)
 }
 
 func DeleteSharingsByCreatorId(creatorId uint) error {
 	return errors.WithStack(db.Where("creator_id = ?", creatorId).Delete(&model.SharingDB{}).Error)
 }
+
+// CreateSharingUpload records an upload in progress holding its destination path,
+// created is false if the path is held by another upload
+func CreateSharingUpload(u *model.SharingUpload, dstPath string) (created bool, err error) {
+	sum := sha256.Sum256([]byte(dstPath))
+	reservation := hex.EncodeToString(sum[:])
+	u.Reservation = &reservation
+	res := db.Clauses(clause.OnConflict{DoNothing: true}).Create(u)
+	if res.Error != nil {
+		return false, errors.WithStack(res.Error)
+	}
+	return res.RowsAffected == 1, nil
+}
+
+// FinishSharingUpload releases the destination path held by the upload
+func FinishSharingUpload(id uint) error {
+	return errors.WithStack(db.Model(&model.SharingUpload{}).Where("id = ?", id).UpdateColumn("reservation", nil).Error)
+}
+
+func DeleteSharingUpload(id uint) error {
+	return errors.WithStack(db.Delete(&model.SharingUpload{}, id).Error)
+}
+
+// GetUnfinishedSharingUploads returns the uploads still holding their destination paths
+func GetUnfinishedSharingUploads() ([]model.SharingUpload, error) {
+	var uploads []model.SharingUpload
+	if err := db.Where(columnName("reservation") + " IS NOT NULL").Find(&uploads).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find unfinished sharing uploads")
+	}
+	return uploads, nil
+}
+
+func GetSharingUploads(sid string, pageIndex, pageSize int) (uploads []model.SharingUpload, count int64, err error) {
+	uploadDB := db.Model(&model.SharingUpload{}).Where("sharing_id = ?", sid)
+	if err := uploadDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get sharing uploads count")
+	}
+	if err := uploadDB.Order(columnName("id") + " desc").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&uploads).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find sharing uploads")
+	}
+	return uploads, count, nil
+}
+
+func CreateSharingAccess(a *model.SharingAccess) error {
+	return errors.WithStack(db.Create(a).Error)
+}
+
+func HasSharingVisitor(sid, ip string) (bool, error) {
+	var count int64
+	err := db.Model(&model.SharingAccess{}).Where("sharing_id = ? AND ip = ?", sid, ip).Limit(1).Count(&count).Error
+	return count > 0, errors.WithStack(err)
+}
+
+// AddSharingVisitor counts a new visitor of the sharing in one statement
+// if the visitor limit allows it, added is false otherwise
+func AddSharingVisitor(id string) (added bool, err error) {
+	visitors, limit := columnName("visitors"), columnName("max_visitors")
+	res := db.Model(&model.SharingDB{}).
+		Where(fmt.Sprintf("id = ? AND (%s <= 0 OR %s < %s)", limit, visitors, limit), id).
+		UpdateColumn("visitors", gorm.Expr(visitors+" + 1"))
+	if res.Error != nil {
+		return false, errors.WithStack(res.Error)
+	}
+	return res.RowsAffected == 1, nil
+}
+
+func AddSharingAccessed(id string) error {
+	return errors.WithStack(db.Model(&model.SharingDB{}).Where("id = ?", id).
+		UpdateColumn("accessed", gorm.Expr(columnName("accessed")+" + 1")).Error)
+}
+
+func AddSharingServed(id string, bytes int64) error {
+	return errors.WithStack(db.Model(&model.SharingDB{}).Where("id = ?", id).
+		UpdateColumn("served", gorm.Expr(columnName("served")+" + ?", bytes)).Error)
+}
+
+func DeleteSharingAccessesBefore(t time.Time) error {
+	return errors.WithStack(db.Where(fmt.Sprintf("%s < ?", columnName("time")), t).Delete(&model.SharingAccess{}).Error)
+}
+
+// FindSharingAccessesInBatches calls fn with the accesses of the sharing in [start, end) in batches
+func FindSharingAccessesInBatches(sid string, start, end time.Time, batchSize int, fn func([]model.SharingAccess) error) error {
+	var accesses []model.SharingAccess
+	err := db.Model(&model.SharingAccess{}).
+		Where(fmt.Sprintf("sharing_id = ? AND %s >= ? AND %s < ?", columnName("time"), columnName("time")), sid, start, end).
+		FindInBatches(&accesses, batchSize, func(tx *gorm.DB, batch int) error {
+			return fn(accesses)
+		}).Error
+	return errors.Wrapf(err, "failed find sharing accesses")
+}
@@ -0,0 +1,43 @@
+package db
+
+import (
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+func CreateTrashItem(t *model.TrashItem) error {
+	return errors.WithStack(db.Create(t).Error)
+}
+
+func GetTrashItemById(id uint) (*model.TrashItem, error) {
+	var t model.TrashItem
+	if err := db.First(&t, id).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get trash item")
+	}
+	return &t, nil
+}
+
+func GetTrashItems(pageIndex, pageSize int) (items []model.TrashItem, count int64, err error) {
+	trashDB := db.Model(&model.TrashItem{})
+	if err := trashDB.Count(&count).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed get trash items count")
+	}
+	if err := trashDB.Order(columnName("deleted_at") + " DESC").Offset((pageIndex - 1) * pageSize).Limit(pageSize).Find(&items).Error; err != nil {
+		return nil, 0, errors.Wrapf(err, "failed find trash items")
+	}
+	return items, count, nil
+}
+
+func GetTrashItemsDeletedBefore(t time.Time) ([]model.TrashItem, error) {
+	var items []model.TrashItem
+	if err := db.Where(columnName("deleted_at")+" < ?", t).Find(&items).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed find expired trash items")
+	}
+	return items, nil
+}
+
+func DeleteTrashItemById(id uint) error {
+	return errors.WithStack(db.Delete(&model.TrashItem{}, id).Error)
+}
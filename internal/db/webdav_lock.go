@@ -0,0 +1,97 @@
+package db
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+)
+
+// GetWebDAVLock returns nil if there is no lock with the token
+func GetWebDAVLock(token string) (*model.WebDAVLock, error) {
+	var locks []model.WebDAVLock
+	if err := db.Where(fmt.Sprintf("%s = ?", columnName("token")), token).Limit(1).Find(&locks).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get webdav lock")
+	}
+	if len(locks) == 0 {
+		return nil, nil
+	}
+	return &locks[0], nil
+}
+
+func webDAVRootHash(root string) string {
+	sum := sha256.Sum256([]byte(root))
+	return hex.EncodeToString(sum[:])
+}
+
+func GetWebDAVLocksByRoots(roots []string) ([]model.WebDAVLock, error) {
+	hashes := make([]string, len(roots))
+	for i, root := range roots {
+		hashes[i] = webDAVRootHash(root)
+	}
+	var locks []model.WebDAVLock
+	if err := db.Where(fmt.Sprintf("%s IN ?", columnName("root_hash")), hashes).Find(&locks).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get webdav locks")
+	}
+	return locks, nil
+}
+
+// HasWebDAVLockUnder reports whether a descendant of the path is locked by another lock than token
+func HasWebDAVLockUnder(path, token string) (bool, error) {
+	prefix := path + "/"
+	if path == "/" {
+		prefix = path
+	}
+	var count int64
+	// compare the prefix instead of LIKE, the names can contain the wildcards
+	err := db.Model(&model.WebDAVLock{}).
+		Where(fmt.Sprintf("SUBSTR(%s, 1, ?) = ?", columnName("root")), utf8.RuneCountInString(prefix), prefix).
+		Where(fmt.Sprintf("%s <> ? AND %s <> ?", columnName("root"), columnName("token")), path, token).
+		Count(&count).Error
+	if err != nil {
+		return false, errors.Wrapf(err, "failed count webdav locks")
+	}
+	return count > 0, nil
+}
+
+func CreateWebDAVLock(l *model.WebDAVLock) error {
+	l.RootHash = webDAVRootHash(l.Root)
+	return errors.WithStack(db.Create(l).Error)
+}
+
+func RefreshWebDAVLock(token string, duration, expiry int64) error {
+	return errors.WithStack(db.Model(&model.WebDAVLock{}).
+		Where(fmt.Sprintf("%s = ?", columnName("token")), token).
+		Updates(map[string]any{"duration": duration, "expiry": expiry}).Error)
+}
+
+// HoldWebDAVLock holds the lock until the given time, false if it is already held
+func HoldWebDAVLock(token string, now, until int64) (bool, error) {
+	res := db.Model(&model.WebDAVLock{}).
+		Where(fmt.Sprintf("%s = ? AND %s <= ?", columnName("token"), columnName("held_until")), token, now).
+		Update("held_until", until)
+	if res.Error != nil {
+		return false, errors.Wrapf(res.Error, "failed hold webdav lock")
+	}
+	return res.RowsAffected > 0, nil
+}
+
+func ReleaseWebDAVLock(token string) error {
+	return errors.WithStack(db.Model(&model.WebDAVLock{}).
+		Where(fmt.Sprintf("%s = ?", columnName("token")), token).
+		Update("held_until", 0).Error)
+}
+
+func DeleteWebDAVLock(token string) error {
+	return errors.WithStack(db.Where(fmt.Sprintf("%s = ?", columnName("token")), token).Delete(&model.WebDAVLock{}).Error)
+}
+
+// DeleteExpiredWebDAVLocks deletes the locks expired and not held at now
+func DeleteExpiredWebDAVLocks(now int64) error {
+	return errors.WithStack(db.Where(fmt.Sprintf("%s >= 0 AND %s <= ? AND %s <= ?",
+		columnName("duration"), columnName("expiry"), columnName("held_until")), now, now).
+		Delete(&model.WebDAVLock{}).Error)
+}
@@ -0,0 +1,84 @@
+package db
+
+import (
+	"fmt"
+	"unicode/utf8"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// whereSelfOrUnder matches the path and its descendants by the prefix instead of LIKE,
+// the names can contain the wildcards
+func whereSelfOrUnder(tx *gorm.DB, path string) *gorm.DB {
+	if path == "/" {
+		return tx.Where("1 = 1")
+	}
+	prefix := path + "/"
+	return tx.Where(fmt.Sprintf("%s = ? OR SUBSTR(%s, 1, ?) = ?", columnName("path"), columnName("path")),
+		path, utf8.RuneCountInString(prefix), prefix)
+}
+
+// GetDeadPropsUnder returns the dead properties of the path and its descendants
+func GetDeadPropsUnder(path string) ([]model.DeadProp, error) {
+	var props []model.DeadProp
+	if err := whereSelfOrUnder(db, path).Find(&props).Error; err != nil {
+		return nil, errors.Wrapf(err, "failed get dead props")
+	}
+	return props, nil
+}
+
+// PatchDeadProps removes the properties named in remove and sets the properties in set
+func PatchDeadProps(path string, set, remove []model.DeadProp) error {
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		for _, p := range append(remove, set...) {
+			err := tx.Where(fmt.Sprintf("%s = ? AND %s = ? AND %s = ?", columnName("path"), columnName("space"), columnName("local")),
+				path, p.Space, p.Local).Delete(&model.DeadProp{}).Error
+			if err != nil {
+				return err
+			}
+		}
+		for i := range set {
+			set[i].ID = 0
+			set[i].Path = path
+		}
+		if len(set) == 0 {
+			return nil
+		}
+		return tx.Create(&set).Error
+	}))
+}
+
+// MoveDeadProps moves the properties of the path and its descendants to dst,
+// the properties already at dst are replaced
+func MoveDeadProps(src, dst string) error {
+	if src == dst {
+		return nil
+	}
+	return errors.WithStack(db.Transaction(func(tx *gorm.DB) error {
+		var props []model.DeadProp
+		if err := whereSelfOrUnder(tx, src).Find(&props).Error; err != nil {
+			return err
+		}
+		if len(props) == 0 {
+			return nil
+		}
+		if err := whereSelfOrUnder(tx, dst).Delete(&model.DeadProp{}).Error; err != nil {
+			return err
+		}
+		for _, p := range props {
+			err := tx.Model(&model.DeadProp{}).Where("id = ?", p.ID).
+				Update("path", dst+p.Path[len(src):]).Error
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	}))
+}
+
+// DeleteDeadProps deletes the properties of the path and its descendants
+func DeleteDeadProps(path string) error {
+	return errors.WithStack(whereSelfOrUnder(db, path).Delete(&model.DeadProp{}).Error)
+}
@@ -0,0 +1,252 @@
+package dedupe
+
+import (
+	"context"
+	"fmt"
+	stdpath "path"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/pkg/errors"
+)
+
+// Result is the result of an action on a duplicate file
+type Result struct {
+	ID    uint   `json:"id"`
+	Path  string `json:"path"`
+	Error string `json:"error,omitempty"`
+}
+
+func GetReports(pageIndex, pageSize int) ([]model.DedupeReport, int64, error) {
+	return db.GetDedupeReports(pageIndex, pageSize)
+}
+
+func DeleteReport(id uint) error {
+	report, err := db.GetDedupeReportById(id)
+	if err != nil {
+		return err
+	}
+	if report.Status == model.DedupeRunning && Running() {
+		return errors.New("the report is being scanned")
+	}
+	return db.DeleteDedupeReportById(id)
+}
+
+// GetGroups returns the duplicates groups of a report with the files, the largest first
+func GetGroups(reportID uint, pageIndex, pageSize int) ([]model.DuplicateGroup, int64, error) {
+	groups, total, err := db.GetDuplicateGroups(reportID, pageIndex, pageSize)
+	if err != nil || len(groups) == 0 {
+		return groups, total, err
+	}
+	keys := make([]string, 0, len(groups))
+	index := make(map[string]int, len(groups))
+	for i, g := range groups {
+		keys = append(keys, g.Key)
+		index[g.Key] = i
+	}
+	files, err := db.GetDuplicateFilesByGroups(reportID, keys)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, f := range files {
+		i := index[f.GroupKey]
+		groups[i].Files = append(groups[i].Files, f)
+	}
+	return groups, total, nil
+}
+
+// getTargets returns the files of ids, every group of them must keep a file that is not in ids
+func getTargets(ids []uint) ([]model.DuplicateFile, map[string][]model.DuplicateFile, error) {
+	targets, err := db.GetDuplicateFilesByIds(ids)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(targets) == 0 {
+		return nil, nil, errors.New("no duplicate file found")
+	}
+	selected := make(map[uint]bool, len(targets))
+	byReport := make(map[uint][]string)
+	for _, t := range targets {
+		selected[t.ID] = true
+		byReport[t.ReportID] = append(byReport[t.ReportID], t.GroupKey)
+	}
+	// the files of the groups that are kept
+	kept := make(map[string][]model.DuplicateFile)
+	for reportID, keys := range byReport {
+		files, err := db.GetDuplicateFilesByGroups(reportID, keys)
+		if err != nil {
+			return nil, nil, err
+		}
+		for _, f := range files {
+			if !f.Removed && !selected[f.ID] {
+				kept[groupID(f)] = append(kept[groupID(f)], f)
+			}
+		}
+	}
+	for _, t := range targets {
+		if len(kept[groupID(t)]) == 0 {
+			return nil, nil, errors.Errorf("all files of the group of [%s] are selected, keep one at least", t.Path)
+		}
+	}
+	return targets, kept, nil
+}
+
+func groupID(f model.DuplicateFile) string {
+	return fmt.Sprintf("%d/%s", f.ReportID, f.GroupKey)
+}
+
+// getFile returns the file at the path as it is now, the dir is refreshed
+// since the file may have been changed after the scan
+func getFile(ctx context.Context, path string) (*file, error) {
+	storage, actualPath, err := op.GetStorageAndActualPath(path)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed get storage")
+	}
+	objs, err := op.List(ctx, storage, stdpath.Dir(actualPath), model.ListArgs{Refresh: true})
+	if err != nil {
+		return nil, err
+	}
+	for _, obj := range objs {
+		if obj.GetName() == stdpath.Base(actualPath) && !obj.IsDir() {
+			return &file{path: path, storage: storage, actualPath: actualPath, obj: obj}, nil
+		}
+	}
+	return nil, errors.WithStack(errs.ObjectNotFound)
+}
+
+// verify checks that the target and the kept file still have the size of the scan and the same content,
+// by the hashes of the storages if they have the same types, otherwise by hashing the contents
+func verify(ctx context.Context, kept, target *model.DuplicateFile) (*file, *file, error) {
+	k, err := getFile(ctx, kept.Path)
+	if err != nil {
+		return nil, nil, errors.WithMessagef(err, "failed get kept file [%s]", kept.Path)
+	}
+	t, err := getFile(ctx, target.Path)
+	if err != nil {
+		return nil, nil, errors.WithMessagef(err, "failed get file [%s]", target.Path)
+	}
+	same := k.obj.GetSize() == target.Size && t.obj.GetSize() == target.Size
+	if same {
+		var known bool
+		if same, known = compareKeys(hashKeys(k.obj), hashKeys(t.obj)); !known {
+			kh, err := contentHash(ctx, k, false)
+			if err != nil {
+				return nil, nil, errors.WithMessagef(err, "failed hash [%s]", kept.Path)
+			}
+			th, err := contentHash(ctx, t, false)
+			if err != nil {
+				return nil, nil, errors.WithMessagef(err, "failed hash [%s]", target.Path)
+			}
+			same = kh == th
+		}
+	}
+	if !same {
+		return nil, nil, errors.Errorf("[%s] is not the same as [%s] anymore", target.Path, kept.Path)
+	}
+	return k, t, nil
+}
+
+// compareKeys compares the hashes of the same types, known is false if there is none
+func compareKeys(a, b []string) (same, known bool) {
+	for _, ka := range a {
+		ht, _, _ := strings.Cut(ka, ":")
+		for _, kb := range b {
+			if strings.HasPrefix(kb, ht+":") {
+				if ka != kb {
+					return false, true
+				}
+				same, known = true, true
+			}
+		}
+	}
+	return same, known
+}
+
+// verifyAny verifies the target against the kept files until one of them is the same
+func verifyAny(ctx context.Context, target *model.DuplicateFile, kept []model.DuplicateFile) error {
+	err := errors.New("no kept file")
+	for i := range kept {
+		if _, _, err = verify(ctx, &kept[i], target); err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// Remove removes the duplicate files of ids, a file of each group is kept
+func Remove(ctx context.Context, ids []uint) ([]Result, error) {
+	targets, kept, err := getTargets(ids)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]Result, 0, len(targets))
+	for _, t := range targets {
+		r := Result{ID: t.ID, Path: t.Path}
+		if t.Removed {
+			results = append(results, r)
+			continue
+		}
+		if err := verifyAny(ctx, &t, kept[groupID(t)]); err != nil {
+			r.Error = err.Error()
+		} else if err = fs.Remove(ctx, t.Path); err != nil {
+			r.Error = err.Error()
+		} else {
+			t.Removed = true
+			if err = db.UpdateDuplicateFile(&t); err != nil {
+				r.Error = err.Error()
+			}
+		}
+		results = append(results, r)
+	}
+	return results, nil
+}
+
+// Link replaces the duplicate files of ids with hard links of a kept file in the same storage,
+// the storage must support hard links
+func Link(ctx context.Context, ids []uint) ([]Result, error) {
+	targets, kept, err := getTargets(ids)
+	if err != nil {
+		return nil, err
+	}
+	results := make([]Result, 0, len(targets))
+	for _, t := range targets {
+		r := Result{ID: t.ID, Path: t.Path}
+		if err := link(ctx, &t, kept[groupID(t)]); err != nil {
+			r.Error = err.Error()
+		}
+		results = append(results, r)
+	}
+	return results, nil
+}
+
+func link(ctx context.Context, t *model.DuplicateFile, kept []model.DuplicateFile) error {
+	if t.Removed {
+		return errors.New("the file has been removed")
+	}
+	storage, _, err := op.GetStorageAndActualPath(t.Path)
+	if err != nil {
+		return errors.WithMessage(err, "failed get storage")
+	}
+	err = errors.New("no kept file in the same storage")
+	for i := range kept {
+		keptStorage, _, e := op.GetStorageAndActualPath(kept[i].Path)
+		if e != nil || keptStorage != storage {
+			continue
+		}
+		k, f, e := verify(ctx, &kept[i], t)
+		if e != nil {
+			err = e
+			continue
+		}
+		if err = op.ReplaceWithHardLink(ctx, storage, k.actualPath, f.actualPath); err != nil {
+			return err
+		}
+		t.Linked = true
+		return db.UpdateDuplicateFile(t)
+	}
+	return err
+}
@@ -0,0 +1,87 @@
+package dedupe
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	_ "github.com/OpenListTeam/OpenList/v4/drivers/local"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database: " + err.Error())
+	}
+	conf.Conf = conf.DefaultConfig("data")
+	db.Init(dB)
+}
+
+func TestDedupe(t *testing.T) {
+	root := t.TempDir()
+	// the big files only differ in the middle, which isn't in the partial hashes
+	big := strings.Repeat("x", 3*partialSize)
+	files := map[string]string{
+		"a.txt":     "same",
+		"dir/b.txt": "same",
+		"c.txt":     "diff",
+		"big1.bin":  big,
+		"big2.bin":  big[:len(big)/2] + "y" + big[len(big)/2+1:],
+	}
+	for name, content := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err := op.CreateStorage(context.Background(), model.Storage{
+		Driver:    "Local",
+		MountPath: "/dedupe",
+		Addition:  `{"root_folder_path":"` + filepath.ToSlash(root) + `"}`,
+	})
+	if err != nil {
+		t.Fatalf("failed to create storage: %+v", err)
+	}
+
+	report, err := Scan([]string{"/dedupe"})
+	if err != nil {
+		t.Fatalf("failed to scan: %+v", err)
+	}
+	for deadline := time.Now().Add(5 * time.Second); Running() && time.Now().Before(deadline); {
+		time.Sleep(10 * time.Millisecond)
+	}
+	groups, total, err := GetGroups(report.ID, 1, 10)
+	if err != nil || total != 1 || len(groups[0].Files) != 2 {
+		t.Fatalf("expected 1 group of 2 files, got %d %+v, %+v", total, groups, err)
+	}
+	dups := groups[0].Files
+	if dups[0].Path != "/dedupe/a.txt" || dups[1].Path != "/dedupe/dir/b.txt" {
+		t.Fatalf("unexpected files: %+v", dups)
+	}
+
+	ctx := context.WithValue(context.Background(), conf.UserKey, &model.User{Role: model.ADMIN, BasePath: "/"})
+	if _, err = Remove(ctx, []uint{dups[0].ID, dups[1].ID}); err == nil {
+		t.Fatal("expected removing all files of a group refused")
+	}
+	results, err := Link(ctx, []uint{dups[1].ID})
+	if err != nil || results[0].Error != "" {
+		t.Fatalf("failed to link: %+v, %+v", results, err)
+	}
+	a, _ := os.Stat(filepath.Join(root, "a.txt"))
+	b, _ := os.Stat(filepath.Join(root, "dir", "b.txt"))
+	if a == nil || b == nil || !os.SameFile(a, b) {
+		t.Fatalf("expected the duplicate replaced with a hard link")
+	}
+}
@@ -0,0 +1,326 @@
+package dedupe
+
+import (
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"io"
+	stdpath "path"
+	"slices"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/stream"
+	"github.com/OpenListTeam/OpenList/v4/pkg/http_range"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// partialSize is the size of the head and the tail hashed to find the candidates of the files without a hash
+const partialSize = 64 * 1024
+
+var scanCancel atomic.Pointer[context.CancelFunc]
+
+func Running() bool {
+	return scanCancel.Load() != nil
+}
+
+func Cancel() {
+	if c := scanCancel.Load(); c != nil {
+		(*c)()
+	}
+}
+
+type file struct {
+	path       string
+	storage    driver.Driver
+	actualPath string
+	obj        model.Obj
+	// hashes of the file, like sha1:xxx
+	keys []string
+}
+
+// Scan starts finding the duplicate files under the mount paths in background,
+// only one scan runs at a time
+func Scan(paths []string) (*model.DedupeReport, error) {
+	if len(paths) == 0 {
+		return nil, errors.New("no path to scan")
+	}
+	for i := range paths {
+		paths[i] = utils.FixAndCleanPath(paths[i])
+	}
+	for i := range paths {
+		for j := range paths {
+			// the same file would be found twice
+			if i != j && utils.IsSubPath(paths[i], paths[j]) {
+				return nil, errors.Errorf("path [%s] contains [%s]", paths[i], paths[j])
+			}
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	if !scanCancel.CompareAndSwap(nil, &cancel) {
+		cancel()
+		return nil, errors.New("dedupe scan is running, please try later")
+	}
+	report := &model.DedupeReport{
+		Paths:     strings.Join(paths, "\n"),
+		Status:    model.DedupeRunning,
+		StartedAt: time.Now(),
+	}
+	if err := db.CreateDedupeReport(report); err != nil {
+		(*scanCancel.Swap(nil))()
+		return nil, err
+	}
+	go func() {
+		defer func() { (*scanCancel.Swap(nil))() }()
+		scan(ctx, report, paths)
+	}()
+	return report, nil
+}
+
+func scan(ctx context.Context, report *model.DedupeReport, paths []string) {
+	var files []*file
+	for _, path := range paths {
+		files = append(files, collect(ctx, path)...)
+	}
+	report.Scanned = int64(len(files))
+	var err error
+	if err = ctx.Err(); err == nil {
+		err = save(report, group(ctx, files))
+	}
+	if err == nil {
+		err = ctx.Err()
+	}
+	now := time.Now()
+	report.FinishedAt = &now
+	switch {
+	case err == nil:
+		report.Status = model.DedupeDone
+	case errors.Is(err, context.Canceled):
+		report.Status = model.DedupeCanceled
+	default:
+		report.Status, report.Error = model.DedupeFailed, err.Error()
+		log.Errorf("failed dedupe scan: %+v", err)
+	}
+	if err = db.UpdateDedupeReport(report); err != nil {
+		log.Errorf("failed update dedupe report: %+v", err)
+	}
+}
+
+// collect lists the files in the storages under path
+func collect(ctx context.Context, path string) []*file {
+	var files []*file
+	walk := func(storage driver.Driver, actualPath string) {
+		mountPath := storage.GetStorage().MountPath
+		op.RecursivelyWalkStorage(ctx, storage, actualPath, nil, func(dirPath string, objs []model.Obj) {
+			if op.IsSystemPath(storage, dirPath) {
+				return
+			}
+			for _, obj := range objs {
+				if obj.IsDir() || obj.GetSize() <= 0 {
+					continue
+				}
+				p := stdpath.Join(dirPath, obj.GetName())
+				files = append(files, &file{
+					path:       utils.GetFullPath(mountPath, p),
+					storage:    storage,
+					actualPath: p,
+					obj:        obj,
+				})
+			}
+		})
+	}
+	if storage, actualPath, err := op.GetStorageAndActualPath(path); err == nil {
+		walk(storage, actualPath)
+	}
+	// the storages mounted under the path
+	for _, storage := range op.GetAllStorages() {
+		mountPath := storage.GetStorage().MountPath
+		if mountPath != path && utils.IsSubPath(path, mountPath) && !storage.GetStorage().Disabled {
+			walk(storage, "/")
+		}
+	}
+	return files
+}
+
+func hashKeys(obj model.Obj) []string {
+	var keys []string
+	for ht, v := range obj.GetHash().All() {
+		if v != "" {
+			keys = append(keys, ht.Name+":"+strings.ToLower(v))
+		}
+	}
+	return keys
+}
+
+// group groups the files of the same size by the hashes, the files that share any hash are
+// in a group. The heads and tails of the files without a hash are hashed to find the candidates,
+// whose whole contents are hashed then, the files are never grouped by the partial hashes.
+func group(ctx context.Context, files []*file) [][]*file {
+	bySize := make(map[int64][]*file)
+	for _, f := range files {
+		bySize[f.obj.GetSize()] = append(bySize[f.obj.GetSize()], f)
+	}
+	var groups [][]*file
+	for _, sameSize := range bySize {
+		if len(sameSize) < 2 || ctx.Err() != nil {
+			continue
+		}
+		needPartial := false
+		for _, f := range sameSize {
+			f.keys = hashKeys(f.obj)
+			needPartial = needPartial || len(f.keys) == 0
+		}
+		if needPartial {
+			hashCandidates(ctx, sameSize)
+		}
+		groups = append(groups, union(sameSize)...)
+	}
+	return groups
+}
+
+// hashCandidates hashes the whole contents of the files whose heads and tails are the same
+// as those of a file without a hash, so that they can be compared with the full hashes
+func hashCandidates(ctx context.Context, files []*file) {
+	byPartial := make(map[string][]*file)
+	for _, f := range files {
+		h, err := contentHash(ctx, f, true)
+		if err != nil {
+			log.Warnf("failed hash [%s]: %+v", f.path, err)
+			continue
+		}
+		byPartial[h] = append(byPartial[h], f)
+	}
+	for partial, candidates := range byPartial {
+		if len(candidates) < 2 || !slices.ContainsFunc(candidates, func(f *file) bool { return len(f.keys) == 0 }) {
+			continue
+		}
+		for _, f := range candidates {
+			if slices.ContainsFunc(f.keys, func(k string) bool { return strings.HasPrefix(k, utils.SHA1.Name+":") }) {
+				continue
+			}
+			h := partial
+			if f.obj.GetSize() > 2*partialSize {
+				var err error
+				if h, err = contentHash(ctx, f, false); err != nil {
+					log.Warnf("failed hash [%s]: %+v", f.path, err)
+					continue
+				}
+			}
+			f.keys = append(f.keys, utils.SHA1.Name+":"+h)
+		}
+	}
+}
+
+// union returns the groups of more than one file connected by the keys
+func union(files []*file) [][]*file {
+	parent := make([]int, len(files))
+	for i := range parent {
+		parent[i] = i
+	}
+	var find func(int) int
+	find = func(i int) int {
+		if parent[i] != i {
+			parent[i] = find(parent[i])
+		}
+		return parent[i]
+	}
+	first := make(map[string]int)
+	for i, f := range files {
+		for _, k := range f.keys {
+			if j, ok := first[k]; ok {
+				parent[find(i)] = find(j)
+			} else {
+				first[k] = i
+			}
+		}
+	}
+	components := make(map[int][]*file)
+	for i, f := range files {
+		root := find(i)
+		components[root] = append(components[root], f)
+	}
+	var groups [][]*file
+	for _, c := range components {
+		if len(c) > 1 {
+			groups = append(groups, c)
+		}
+	}
+	return groups
+}
+
+// contentHash returns the sha1 of the content of the file, only of the head and the tail if partial
+func contentHash(ctx context.Context, f *file, partial bool) (string, error) {
+	link, obj, err := op.Link(ctx, f.storage, f.actualPath, model.LinkArgs{})
+	if err != nil {
+		return "", err
+	}
+	defer link.Close()
+	size := obj.GetSize()
+	rr, err := stream.GetRangeReaderFromLink(size, link)
+	if err != nil {
+		return "", err
+	}
+	ranges := []http_range.Range{{Start: 0, Length: size}}
+	if partial && size > 2*partialSize {
+		ranges = []http_range.Range{{Start: 0, Length: partialSize}, {Start: size - partialSize, Length: partialSize}}
+	}
+	h := sha1.New()
+	for _, r := range ranges {
+		rc, err := rr.RangeRead(ctx, r)
+		if err != nil {
+			return "", err
+		}
+		n, err := utils.CopyWithBuffer(h, io.LimitReader(rc, r.Length))
+		_ = rc.Close()
+		if err != nil {
+			return "", err
+		}
+		if n != r.Length {
+			return "", errors.Errorf("read %d bytes, expect %d", n, r.Length)
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func save(report *model.DedupeReport, groups [][]*file) error {
+	var dups []model.DuplicateFile
+	for _, g := range groups {
+		slices.SortFunc(g, func(a, b *file) int { return strings.Compare(a.path, b.path) })
+		size := g[0].obj.GetSize()
+		key := fmt.Sprintf("%d:%s", size, groupKey(g))
+		for _, f := range g {
+			dups = append(dups, model.DuplicateFile{
+				ReportID: report.ID,
+				GroupKey: key,
+				Path:     f.path,
+				Size:     size,
+				Modified: f.obj.ModTime(),
+			})
+		}
+		report.Groups++
+		report.Duplicates += int64(len(g) - 1)
+		report.Wasted += size * int64(len(g)-1)
+	}
+	return db.CreateDuplicateFiles(dups)
+}
+
+// groupKey is the smallest hash of the files in the group
+func groupKey(g []*file) string {
+	var key string
+	for _, f := range g {
+		for _, k := range f.keys {
+			if key == "" || k < key {
+				key = k
+			}
+		}
+	}
+	return key
+}
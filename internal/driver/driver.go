@@ -2,6 +2,7 @@ package driver
 
 import (
 	"context"
+	"time"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 )
@@ -72,10 +73,28 @@ type Copy interface {
 	Copy(ctx context.Context, srcObj, dstDir model.Obj) error
 }
 
+type HardLink interface {
+	// HardLink creates a file named name in dstDir sharing the content of srcObj
+	HardLink(ctx context.Context, srcObj, dstDir model.Obj, name string) error
+}
+
+type SetModTime interface {
+	// SetModTime changes the modification time of obj
+	SetModTime(ctx context.Context, obj model.Obj, modTime time.Time) error
+}
+
 type Remove interface {
 	Remove(ctx context.Context, obj model.Obj) error
 }
 
+type Watcher interface {
+	// Watch reports the folders whose children changed until ctx is done,
+	// the folders are actual paths in the storage. It can be implemented with
+	// a change feed of the storage, such as a delta api or file system events.
+	// Only the local driver implements it on linux, the others are polled by the index
+	Watch(ctx context.Context, changed func(dirs []string)) error
+}
+
 type Put interface {
 	// Put a file (provided as a FileStreamer) into the driver
 	// Besides the most basic upload functionality, the following features also need to be implemented:
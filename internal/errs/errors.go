@@ -26,6 +26,8 @@ var (
 	WrongShareCode  = errors.New("wrong share code")
 	InvalidSharing  = errors.New("invalid sharing")
 	SharingNotFound = errors.New("sharing not found")
+	SharingNoRead   = errors.New("the share only accepts uploads")
+	SharingDenied   = errors.New("access to the share is denied")
 )
 
 // NewErr wrap constant error with an extra message
@@ -7,4 +7,5 @@ var (
 	EmptyPassword      = errors.New("password is empty")
 	WrongPassword      = errors.New("password is incorrect")
 	DeleteAdminOrGuest = errors.New("cannot delete admin or guest")
+	QuotaExceeded      = errors.New("quota exceeded")
 )
@@ -348,7 +348,7 @@ var ArchiveContentUploadTaskManager = &archiveContentUploadTaskManagerType{
 }
 
 func archiveMeta(ctx context.Context, path string, args model.ArchiveMetaArgs) (*model.ArchiveMetaProvider, error) {
-	storage, actualPath, err := op.GetStorageAndActualPath(path)
+	storage, actualPath, err := getStorageAndActualPath(ctx, path)
 	if err != nil {
 		return nil, errors.WithMessage(err, "failed get storage")
 	}
@@ -356,7 +356,7 @@ func archiveMeta(ctx context.Context, path string, args model.ArchiveMetaArgs) (
 }
 
 func archiveList(ctx context.Context, path string, args model.ArchiveListArgs) ([]model.Obj, error) {
-	storage, actualPath, err := op.GetStorageAndActualPath(path)
+	storage, actualPath, err := getStorageAndActualPath(ctx, path)
 	if err != nil {
 		return nil, errors.WithMessage(err, "failed get storage")
 	}
@@ -364,11 +364,11 @@ func archiveList(ctx context.Context, path string, args model.ArchiveListArgs) (
 }
 
 func archiveDecompress(ctx context.Context, srcObjPath, dstDirPath string, args model.ArchiveDecompressArgs, lazyCache ...bool) (task.TaskExtensionInfo, error) {
-	srcStorage, srcObjActualPath, err := op.GetStorageAndActualPath(srcObjPath)
+	srcStorage, srcObjActualPath, err := getStorageAndActualPath(ctx, srcObjPath)
 	if err != nil {
 		return nil, errors.WithMessage(err, "failed get src storage")
 	}
-	dstStorage, dstDirActualPath, err := op.GetStorageAndActualPath(dstDirPath)
+	dstStorage, dstDirActualPath, err := getStorageAndActualPath(ctx, dstDirPath)
 	if err != nil {
 		return nil, errors.WithMessage(err, "failed get dst storage")
 	}
@@ -422,7 +422,7 @@ func archiveDecompress(ctx context.Context, srcObjPath, dstDirPath string, args
 }
 
 func archiveDriverExtract(ctx context.Context, path string, args model.ArchiveInnerArgs) (*model.Link, model.Obj, error) {
-	storage, actualPath, err := op.GetStorageAndActualPath(path)
+	storage, actualPath, err := getStorageAndActualPath(ctx, path)
 	if err != nil {
 		return nil, nil, errors.WithMessage(err, "failed get storage")
 	}
@@ -430,7 +430,7 @@ func archiveDriverExtract(ctx context.Context, path string, args model.ArchiveIn
 }
 
 func archiveInternalExtract(ctx context.Context, path string, args model.ArchiveInnerArgs) (io.ReadCloser, int64, error) {
-	storage, actualPath, err := op.GetStorageAndActualPath(path)
+	storage, actualPath, err := getStorageAndActualPath(ctx, path)
 	if err != nil {
 		return nil, 0, errors.WithMessage(err, "failed get storage")
 	}
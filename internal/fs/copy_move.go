@@ -13,6 +13,7 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/internal/stream"
 	"github.com/OpenListTeam/OpenList/v4/internal/task"
 	"github.com/OpenListTeam/OpenList/v4/internal/task_group"
+	"github.com/OpenListTeam/OpenList/v4/internal/webhook"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
 	"github.com/OpenListTeam/OpenList/v4/server/common"
 	"github.com/OpenListTeam/tache"
@@ -34,6 +35,13 @@ func (t taskType) String() string {
 	}
 }
 
+func (t taskType) event() string {
+	if t == move {
+		return webhook.EventMove
+	}
+	return webhook.EventCopy
+}
+
 const (
 	copy taskType = iota
 	move
@@ -80,10 +88,12 @@ func (t *FileTransferTask) Run() error {
 
 func (t *FileTransferTask) OnSucceeded() {
 	task_group.TransferCoordinator.Done(context.WithoutCancel(t.Ctx()), t.groupID, true)
+	webhook.EmitTask(t.TaskType.event(), t)
 }
 
 func (t *FileTransferTask) OnFailed() {
 	task_group.TransferCoordinator.Done(context.WithoutCancel(t.Ctx()), t.groupID, false)
+	webhook.EmitTask(t.TaskType.event(), t)
 }
 
 func (t *FileTransferTask) SetRetry(retry int, maxRetry int) {
@@ -101,11 +111,11 @@ func (t *FileTransferTask) SetRetry(retry int, maxRetry int) {
 }
 
 func transfer(ctx context.Context, taskType taskType, srcObjPath, dstDirPath string, skipHook ...bool) (task.TaskExtensionInfo, error) {
-	srcStorage, srcObjActualPath, err := op.GetStorageAndActualPath(srcObjPath)
+	srcStorage, srcObjActualPath, err := getStorageAndActualPath(ctx, srcObjPath)
 	if err != nil {
 		return nil, errors.WithMessage(err, "failed get src storage")
 	}
-	dstStorage, dstDirActualPath, err := op.GetStorageAndActualPath(dstDirPath)
+	dstStorage, dstDirActualPath, err := getStorageAndActualPath(ctx, dstDirPath)
 	if err != nil {
 		return nil, errors.WithMessage(err, "failed get dst storage")
 	}
@@ -116,14 +126,18 @@ func transfer(ctx context.Context, taskType taskType, srcObjPath, dstDirPath str
 		}
 		if taskType == copy || taskType == merge {
 			err = op.Copy(ctx, srcStorage, srcObjActualPath, dstDirActualPath)
-			if !errors.Is(err, errs.NotImplement) && !errors.Is(err, errs.NotSupport) {
-				return nil, err
-			}
 		} else {
 			err = op.Move(ctx, srcStorage, srcObjActualPath, dstDirActualPath)
-			if !errors.Is(err, errs.NotImplement) && !errors.Is(err, errs.NotSupport) {
-				return nil, err
+		}
+		if !errors.Is(err, errs.NotImplement) && !errors.Is(err, errs.NotSupport) {
+			if err == nil {
+				// done without a task
+				webhook.Emit(ctx, taskType.event(), webhook.TaskData{
+					Name:    fmt.Sprintf("%s %s to %s", taskType, srcObjPath, dstDirPath),
+					Success: true,
+				})
 			}
+			return nil, err
 		}
 	}
 
@@ -176,6 +190,38 @@ func transfer(ctx context.Context, taskType taskType, srcObjPath, dstDirPath str
 	return t, nil
 }
 
+// CopyAsTask copies the object with a task even if the source and the destination are in
+// the same storage, the files existing in the destination are overwritten
+func CopyAsTask(ctx context.Context, srcObjPath, dstDirPath string) (task.TaskExtensionInfo, error) {
+	srcStorage, srcObjActualPath, err := getStorageAndActualPath(ctx, srcObjPath)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed get src storage")
+	}
+	dstStorage, dstDirActualPath, err := getStorageAndActualPath(ctx, dstDirPath)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed get dst storage")
+	}
+	t := &FileTransferTask{
+		TaskData: TaskData{
+			TaskExtension: task.TaskExtension{
+				ApiUrl: common.GetApiUrl(ctx),
+			},
+			SrcStorage:    srcStorage,
+			DstStorage:    dstStorage,
+			SrcActualPath: srcObjActualPath,
+			DstActualPath: dstDirActualPath,
+			SrcStorageMp:  srcStorage.GetStorage().MountPath,
+			DstStorageMp:  dstStorage.GetStorage().MountPath,
+		},
+		TaskType: copy,
+	}
+	t.Creator, _ = ctx.Value(conf.UserKey).(*model.User)
+	t.groupID = stdpath.Join(t.DstStorageMp, t.DstActualPath)
+	task_group.TransferCoordinator.AddTask(t.groupID, nil)
+	CopyTaskManager.Add(t)
+	return t, nil
+}
+
 func (t *FileTransferTask) RunWithNextTaskCallback(f func(nextTask *FileTransferTask) error) error {
 	t.Status = "getting src object"
 	srcObj, err := op.Get(t.Ctx(), t.SrcStorage, t.SrcActualPath)
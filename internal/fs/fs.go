@@ -3,14 +3,19 @@ package fs
 import (
 	"context"
 	"io"
+	stdpath "path"
+	"time"
 
 	log "github.com/sirupsen/logrus"
 
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/driver"
 	"github.com/OpenListTeam/OpenList/v4/internal/errs"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/internal/task"
+	"github.com/OpenListTeam/OpenList/v4/internal/webhook"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
 	"github.com/pkg/errors"
 )
 
@@ -65,6 +70,7 @@ func MakeDir(ctx context.Context, path string) error {
 	if err != nil {
 		log.Errorf("failed make dir %s: %+v", path, err)
 	}
+	audit(ctx, op.AuditMakeDir, path, "", err)
 	return err
 }
 
@@ -72,7 +78,11 @@ func Move(ctx context.Context, srcPath, dstDirPath string, skipHook ...bool) (ta
 	req, err := transfer(ctx, move, srcPath, dstDirPath, skipHook...)
 	if err != nil {
 		log.Errorf("failed move %s to %s: %+v", srcPath, dstDirPath, err)
+	} else if req == nil {
+		// moved without a task, otherwise the props are moved once the task removes the source
+		op.MoveDeadProps(srcPath, stdpath.Join(dstDirPath, stdpath.Base(srcPath)))
 	}
+	audit(ctx, op.AuditMove, srcPath, dstDirPath, err)
 	return req, err
 }
 
@@ -81,6 +91,7 @@ func Copy(ctx context.Context, srcObjPath, dstDirPath string, skipHook ...bool)
 	if err != nil {
 		log.Errorf("failed copy %s to %s: %+v", srcObjPath, dstDirPath, err)
 	}
+	audit(ctx, op.AuditCopy, srcObjPath, dstDirPath, err)
 	return res, err
 }
 
@@ -89,6 +100,7 @@ func Merge(ctx context.Context, srcObjPath, dstDirPath string, skipHook ...bool)
 	if err != nil {
 		log.Errorf("failed merge %s to %s: %+v", srcObjPath, dstDirPath, err)
 	}
+	audit(ctx, op.AuditMerge, srcObjPath, dstDirPath, err)
 	return res, err
 }
 
@@ -96,7 +108,11 @@ func Rename(ctx context.Context, srcPath, dstName string, skipHook ...bool) erro
 	err := rename(ctx, srcPath, dstName, skipHook...)
 	if err != nil {
 		log.Errorf("failed rename %s to %s: %+v", srcPath, dstName, err)
+	} else {
+		op.MoveDeadProps(srcPath, stdpath.Join(stdpath.Dir(srcPath), dstName))
+		webhook.Emit(ctx, webhook.EventRename, webhook.RenameData{Path: srcPath, NewName: dstName})
 	}
+	audit(ctx, op.AuditRename, srcPath, stdpath.Join(stdpath.Dir(srcPath), dstName), err)
 	return err
 }
 
@@ -104,6 +120,19 @@ func Remove(ctx context.Context, path string) error {
 	err := remove(ctx, path)
 	if err != nil {
 		log.Errorf("failed remove %s: %+v", path, err)
+	} else {
+		op.DeleteDeadProps(path)
+		webhook.Emit(ctx, webhook.EventRemove, webhook.FileData{Path: path})
+	}
+	audit(ctx, op.AuditRemove, path, "", err)
+	return err
+}
+
+// SetModTime sets the modification time of the object, errs.NotImplement is returned if the storage can't set it
+func SetModTime(ctx context.Context, path string, modTime time.Time) error {
+	err := setModTime(ctx, path, modTime)
+	if err != nil && !errors.Is(err, errs.NotImplement) {
+		log.Errorf("failed set mod time of %s: %+v", path, err)
 	}
 	return err
 }
@@ -112,15 +141,21 @@ func PutDirectly(ctx context.Context, dstDirPath string, file model.FileStreamer
 	err := putDirectly(ctx, dstDirPath, file, skipHook...)
 	if err != nil {
 		log.Errorf("failed put %s: %+v", dstDirPath, err)
+	} else {
+		webhook.Emit(ctx, webhook.EventUpload, webhook.FileData{Path: stdpath.Join(dstDirPath, file.GetName()), Size: file.GetSize()})
 	}
+	audit(ctx, op.AuditUpload, stdpath.Join(dstDirPath, file.GetName()), "", err)
 	return err
 }
 
-func PutAsTask(ctx context.Context, dstDirPath string, file model.FileStreamer) (task.TaskExtensionInfo, error) {
-	t, err := putAsTask(ctx, dstDirPath, file)
+// PutAsTask adds a task putting the file into dstDirPath, the optional onDone is called
+// with the result once the task is over
+func PutAsTask(ctx context.Context, dstDirPath string, file model.FileStreamer, onDone ...func(succeeded bool)) (task.TaskExtensionInfo, error) {
+	t, err := putAsTask(ctx, dstDirPath, file, onDone...)
 	if err != nil {
 		log.Errorf("failed put %s: %+v", dstDirPath, err)
 	}
+	audit(ctx, op.AuditUpload, stdpath.Join(dstDirPath, file.GetName()), "", err)
 	return t, err
 }
 
@@ -145,6 +180,7 @@ func ArchiveDecompress(ctx context.Context, srcObjPath, dstDirPath string, args
 	if err != nil {
 		log.Errorf("failed decompress [%s]%s: %+v", srcObjPath, args.InnerPath, err)
 	}
+	audit(ctx, op.AuditDecompress, srcObjPath, dstDirPath, err)
 	return t, err
 }
 
@@ -164,6 +200,10 @@ func ArchiveInternalExtract(ctx context.Context, path string, args model.Archive
 	return l, obj, err
 }
 
+func audit(ctx context.Context, action, path, dstPath string, err error) {
+	op.Audit(ctx, &model.AuditLog{Action: action, Path: path, DstPath: dstPath}, err)
+}
+
 type GetStoragesArgs struct {
 }
 
@@ -184,7 +224,7 @@ func Other(ctx context.Context, args model.FsOtherArgs) (interface{}, error) {
 }
 
 func PutURL(ctx context.Context, path, dstName, urlStr string) error {
-	storage, dstDirActualPath, err := op.GetStorageAndActualPath(path)
+	storage, dstDirActualPath, err := getStorageAndActualPath(ctx, path)
 	if err != nil {
 		return errors.WithMessage(err, "failed get storage")
 	}
@@ -206,3 +246,18 @@ func GetDirectUploadInfo(ctx context.Context, tool, path, dstName string, fileSi
 	}
 	return info, err
 }
+
+// getStorageAndActualPath refuses the paths in the recycle bin and the versions folder,
+// only the signed links of the versions may reach them
+func getStorageAndActualPath(ctx context.Context, path string) (driver.Driver, string, error) {
+	storage, actualPath, err := op.GetStorageAndActualPath(path)
+	if err != nil {
+		return storage, actualPath, err
+	}
+	if op.IsSystemPath(storage, actualPath) {
+		if allowed, _ := ctx.Value(conf.SystemPathKey).(string); !utils.PathEqual(allowed, path) || !op.IsVersionPath(actualPath) {
+			return nil, "", errors.WithStack(errs.PermissionDenied)
+		}
+	}
+	return storage, actualPath, nil
+}
@@ -17,7 +17,7 @@ func list(ctx context.Context, path string, args *ListArgs) ([]model.Obj, error)
 	meta, _ := ctx.Value(conf.MetaKey).(*model.Meta)
 	user, _ := ctx.Value(conf.UserKey).(*model.User)
 	virtualFiles := op.GetStorageVirtualFilesWithDetailsByPath(ctx, path, !args.WithStorageDetails, args.Refresh, "")
-	storage, actualPath, err := op.GetStorageAndActualPath(path)
+	storage, actualPath, err := getStorageAndActualPath(ctx, path)
 	if err != nil && len(virtualFiles) == 0 {
 		return nil, errors.WithMessage(err, "failed get storage")
 	}
@@ -37,6 +37,9 @@ func list(ctx context.Context, path string, args *ListArgs) ([]model.Obj, error)
 				return nil, errors.WithMessage(err, "failed get objs")
 			}
 		}
+		if actualPath == "/" {
+			_objs = op.HideSystemFolders(storage, _objs)
+		}
 	}
 
 	om := model.NewObjMerge()
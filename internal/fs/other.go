@@ -2,9 +2,12 @@ package fs
 
 import (
 	"context"
+	stdpath "path"
+	"time"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/internal/task"
@@ -13,7 +16,7 @@ import (
 )
 
 func makeDir(ctx context.Context, path string) error {
-	storage, actualPath, err := op.GetStorageAndActualPath(path)
+	storage, actualPath, err := getStorageAndActualPath(ctx, path)
 	if err != nil {
 		return errors.WithMessage(err, "failed get storage")
 	}
@@ -21,10 +24,13 @@ func makeDir(ctx context.Context, path string) error {
 }
 
 func rename(ctx context.Context, srcPath, dstName string, skipHook ...bool) error {
-	storage, srcActualPath, err := op.GetStorageAndActualPath(srcPath)
+	storage, srcActualPath, err := getStorageAndActualPath(ctx, srcPath)
 	if err != nil {
 		return errors.WithMessage(err, "failed get storage")
 	}
+	if op.IsSystemPath(storage, stdpath.Join(stdpath.Dir(srcActualPath), dstName)) {
+		return errors.WithStack(errs.PermissionDenied)
+	}
 	if utils.IsBool(skipHook...) {
 		ctx = context.WithValue(ctx, conf.SkipHookKey, struct{}{})
 	}
@@ -32,15 +38,23 @@ func rename(ctx context.Context, srcPath, dstName string, skipHook ...bool) erro
 }
 
 func remove(ctx context.Context, path string) error {
-	storage, actualPath, err := op.GetStorageAndActualPath(path)
+	storage, actualPath, err := getStorageAndActualPath(ctx, path)
+	if err != nil {
+		return errors.WithMessage(err, "failed get storage")
+	}
+	return op.Trash(ctx, storage, actualPath)
+}
+
+func setModTime(ctx context.Context, path string, modTime time.Time) error {
+	storage, actualPath, err := getStorageAndActualPath(ctx, path)
 	if err != nil {
 		return errors.WithMessage(err, "failed get storage")
 	}
-	return op.Remove(ctx, storage, actualPath)
+	return op.SetModTime(ctx, storage, actualPath, modTime)
 }
 
 func other(ctx context.Context, args model.FsOtherArgs) (interface{}, error) {
-	storage, actualPath, err := op.GetStorageAndActualPath(args.Path)
+	storage, actualPath, err := getStorageAndActualPath(ctx, args.Path)
 	if err != nil {
 		return nil, errors.WithMessage(err, "failed get storage")
 	}
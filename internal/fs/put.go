@@ -16,6 +16,7 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/internal/task"
 	"github.com/OpenListTeam/OpenList/v4/internal/task_group"
+	"github.com/OpenListTeam/OpenList/v4/internal/webhook"
 	"github.com/OpenListTeam/tache"
 	"github.com/pkg/errors"
 )
@@ -25,6 +26,8 @@ type UploadTask struct {
 	storage          driver.Driver
 	dstDirActualPath string
 	file             model.FileStreamer
+	// onDone is called once the task succeeded or failed for good
+	onDone func(succeeded bool)
 }
 
 func (t *UploadTask) GetName() string {
@@ -43,11 +46,19 @@ func (t *UploadTask) Run() error {
 }
 
 func (t *UploadTask) OnSucceeded() {
-	task_group.TransferCoordinator.Done(context.WithoutCancel(t.Ctx()), stdpath.Join(t.storage.GetStorage().MountPath, t.dstDirActualPath), true)
+	dstDirPath := stdpath.Join(t.storage.GetStorage().MountPath, t.dstDirActualPath)
+	task_group.TransferCoordinator.Done(context.WithoutCancel(t.Ctx()), dstDirPath, true)
+	webhook.Emit(t.Ctx(), webhook.EventUpload, webhook.FileData{Path: stdpath.Join(dstDirPath, t.file.GetName()), Size: t.file.GetSize()})
+	if t.onDone != nil {
+		t.onDone(true)
+	}
 }
 
 func (t *UploadTask) OnFailed() {
 	task_group.TransferCoordinator.Done(context.WithoutCancel(t.Ctx()), stdpath.Join(t.storage.GetStorage().MountPath, t.dstDirActualPath), false)
+	if t.onDone != nil {
+		t.onDone(false)
+	}
 }
 
 func (t *UploadTask) SetRetry(retry int, maxRetry int) {
@@ -61,14 +72,17 @@ func (t *UploadTask) SetRetry(retry int, maxRetry int) {
 var UploadTaskManager *tache.Manager[*UploadTask]
 
 // putAsTask add as a put task and return immediately
-func putAsTask(ctx context.Context, dstDirPath string, file model.FileStreamer) (task.TaskExtensionInfo, error) {
-	storage, dstDirActualPath, err := op.GetStorageAndActualPath(dstDirPath)
+func putAsTask(ctx context.Context, dstDirPath string, file model.FileStreamer, onDone ...func(succeeded bool)) (task.TaskExtensionInfo, error) {
+	storage, dstDirActualPath, err := getStorageAndActualPath(ctx, dstDirPath)
 	if err != nil {
 		return nil, errors.WithMessage(err, "failed get storage")
 	}
 	if storage.Config().NoUpload {
 		return nil, errors.WithStack(errs.UploadNotSupported)
 	}
+	if err = op.CheckPutQuota(ctx, storage, dstDirActualPath, file.GetName(), file.GetSize()); err != nil {
+		return nil, err
+	}
 	if file.NeedStore() {
 		_, err := file.CacheFullAndWriter(nil, nil)
 		if err != nil {
@@ -87,6 +101,9 @@ func putAsTask(ctx context.Context, dstDirPath string, file model.FileStreamer)
 		dstDirActualPath: dstDirActualPath,
 		file:             file,
 	}
+	if len(onDone) > 0 {
+		t.onDone = onDone[0]
+	}
 	t.SetTotalBytes(file.GetSize())
 	task_group.TransferCoordinator.AddTask(stdpath.Join(storage.GetStorage().MountPath, dstDirActualPath), nil)
 	UploadTaskManager.Add(t)
@@ -95,7 +112,7 @@ func putAsTask(ctx context.Context, dstDirPath string, file model.FileStreamer)
 
 // putDirect put the file and return after finish
 func putDirectly(ctx context.Context, dstDirPath string, file model.FileStreamer, skipHook ...bool) error {
-	storage, dstDirActualPath, err := op.GetStorageAndActualPath(dstDirPath)
+	storage, dstDirActualPath, err := getStorageAndActualPath(ctx, dstDirPath)
 	if err != nil {
 		_ = file.Close()
 		return errors.WithMessage(err, "failed get storage")
@@ -111,7 +128,7 @@ func putDirectly(ctx context.Context, dstDirPath string, file model.FileStreamer
 }
 
 func getDirectUploadInfo(ctx context.Context, tool, dstDirPath, dstName string, fileSize int64, overwrite bool) (any, error) {
-	storage, dstDirActualPath, err := op.GetStorageAndActualPath(dstDirPath)
+	storage, dstDirActualPath, err := getStorageAndActualPath(ctx, dstDirPath)
 	if err != nil {
 		return nil, errors.WithMessage(err, "failed get storage")
 	}
@@ -0,0 +1,103 @@
+package fs
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/OpenListTeam/OpenList/v4/drivers/local"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestSystemPaths(t *testing.T) {
+	dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatal(err)
+	}
+	conf.Conf = conf.DefaultConfig("data")
+	db.Init(dB)
+
+	ctx := context.Background()
+	root := t.TempDir()
+	for _, dir := range []string{op.TrashFolder, op.VersionsFolder} {
+		if err := os.MkdirAll(filepath.Join(root, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(root, dir, "a.txt"), []byte("a"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := op.CreateStorage(ctx, model.Storage{
+		Driver:      "Local",
+		MountPath:   "/sys",
+		Addition:    `{"root_folder_path":"` + filepath.ToSlash(root) + `","show_hidden":true}`,
+		EnableTrash: true,
+		MaxVersions: 1,
+	}); err != nil {
+		t.Fatalf("failed to create storage: %+v", err)
+	}
+
+	objs, err := list(ctx, "/sys", &ListArgs{})
+	if err != nil || len(objs) != 0 {
+		t.Fatalf("expected the system folders hidden, got %d objs, %+v", len(objs), err)
+	}
+	for _, p := range []string{"/sys/" + op.TrashFolder + "/a.txt", "/sys/" + op.VersionsFolder + "/a.txt"} {
+		if _, err := get(ctx, p, &GetArgs{}); !errors.Is(err, errs.PermissionDenied) {
+			t.Errorf("expected get %s denied, got %+v", p, err)
+		}
+		if _, _, err := link(ctx, p, model.LinkArgs{}); !errors.Is(err, errs.PermissionDenied) {
+			t.Errorf("expected link %s denied, got %+v", p, err)
+		}
+		if err := remove(ctx, p); !errors.Is(err, errs.PermissionDenied) {
+			t.Errorf("expected remove %s denied, got %+v", p, err)
+		}
+	}
+	if err := makeDir(ctx, "/sys/"+op.VersionsFolder+"/b"); !errors.Is(err, errs.PermissionDenied) {
+		t.Errorf("expected make dir denied, got %+v", err)
+	}
+
+	// a signed link of a version reads it
+	p := "/sys/" + op.VersionsFolder + "/a.txt"
+	signed := context.WithValue(ctx, conf.SystemPathKey, p)
+	if _, err := get(signed, p, &GetArgs{}); err != nil {
+		t.Errorf("expected the signed version readable, got %+v", err)
+	}
+	if _, err := get(signed, "/sys/"+op.TrashFolder+"/a.txt", &GetArgs{}); !errors.Is(err, errs.PermissionDenied) {
+		t.Errorf("expected another path denied, got %+v", err)
+	}
+
+	// the folders are folders of the user when the storage doesn't enable the recycle bin and versioning
+	plain := t.TempDir()
+	for _, dir := range []string{op.TrashFolder, op.VersionsFolder} {
+		if err := os.MkdirAll(filepath.Join(plain, dir), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(filepath.Join(plain, dir, "a.txt"), []byte("a"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := op.CreateStorage(ctx, model.Storage{
+		Driver:    "Local",
+		MountPath: "/plain",
+		Addition:  `{"root_folder_path":"` + filepath.ToSlash(plain) + `","show_hidden":true}`,
+	}); err != nil {
+		t.Fatalf("failed to create storage: %+v", err)
+	}
+	objs, err = list(ctx, "/plain", &ListArgs{})
+	if err != nil || len(objs) != 2 {
+		t.Fatalf("expected the folders listed, got %d objs, %+v", len(objs), err)
+	}
+	for _, dir := range []string{op.TrashFolder, op.VersionsFolder} {
+		if _, err := get(ctx, "/plain/"+dir+"/a.txt", &GetArgs{}); err != nil {
+			t.Errorf("expected %s readable, got %+v", dir, err)
+		}
+	}
+}
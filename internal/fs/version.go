@@ -0,0 +1,42 @@
+package fs
+
+import (
+	"context"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// GetVersions returns the old versions of the file at path,
+// the paths of the versions are full paths that can be linked
+func GetVersions(ctx context.Context, path string) ([]model.FileVersion, error) {
+	storage, actualPath, err := op.GetStorageAndActualPath(path)
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed get storage")
+	}
+	versions, err := op.GetVersions(ctx, storage, actualPath)
+	if err != nil {
+		return nil, err
+	}
+	for i := range versions {
+		versions[i].Path = utils.GetFullPath(storage.GetStorage().MountPath, versions[i].Path)
+	}
+	return versions, nil
+}
+
+func RestoreVersion(ctx context.Context, path, version string) error {
+	storage, actualPath, err := op.GetStorageAndActualPath(path)
+	if err == nil {
+		err = op.RestoreVersion(ctx, storage, actualPath, version)
+	} else {
+		err = errors.WithMessage(err, "failed get storage")
+	}
+	if err != nil {
+		log.Errorf("failed restore version %s of %s: %+v", version, path, err)
+	}
+	op.Audit(ctx, &model.AuditLog{Action: op.AuditRestoreVersion, Path: path, Detail: version}, err)
+	return err
+}
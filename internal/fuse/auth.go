@@ -0,0 +1,112 @@
+package fuse
+
+import (
+	stdpath "path"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/winfsp/cgofuse/fuse"
+)
+
+func getNearestMeta(path string) (*model.Meta, error) {
+	meta, err := op.GetNearestMeta(path)
+	if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+		return nil, err
+	}
+	return meta, nil
+}
+
+// checkRead checks whether the user of the mount can access reqPath,
+// the mount has no way to ask for a meta password
+func (f *Fs) checkRead(reqPath string) error {
+	meta, err := getNearestMeta(reqPath)
+	if err != nil {
+		return err
+	}
+	if !common.CanAccess(f.User, meta, reqPath, "") {
+		return errs.PermissionDenied
+	}
+	return nil
+}
+
+// checkWrite checks whether the user can create or modify reqPath
+func (f *Fs) checkWrite(reqPath string) error {
+	parentPath := stdpath.Dir(reqPath)
+	parentMeta, err := getNearestMeta(parentPath)
+	if err != nil {
+		return err
+	}
+	if !f.User.CanWriteContent() && !common.CanWriteContentBypassUserPerms(parentMeta, parentPath) {
+		return errs.PermissionDenied
+	}
+	if !common.CanWrite(f.User, parentMeta, parentPath) {
+		return errs.PermissionDenied
+	}
+	return nil
+}
+
+func (f *Fs) checkParentWrite(reqPath string) error {
+	parentPath := stdpath.Dir(reqPath)
+	parentMeta, err := getNearestMeta(parentPath)
+	if err != nil {
+		return err
+	}
+	if !common.CanWrite(f.User, parentMeta, parentPath) {
+		return errs.PermissionDenied
+	}
+	return nil
+}
+
+func (f *Fs) checkRemove(reqPath string) error {
+	if !f.User.CanRemove() {
+		return errs.PermissionDenied
+	}
+	return f.checkParentWrite(reqPath)
+}
+
+func (f *Fs) checkRename(srcPath, dstPath string) error {
+	if stdpath.Base(srcPath) != stdpath.Base(dstPath) && !f.User.CanRename() {
+		return errs.PermissionDenied
+	}
+	if stdpath.Dir(srcPath) != stdpath.Dir(dstPath) {
+		if !f.User.CanMove() {
+			return errs.PermissionDenied
+		}
+		if err := f.checkParentWrite(dstPath); err != nil {
+			return err
+		}
+	}
+	return f.checkParentWrite(srcPath)
+}
+
+// errno converts an OpenList error to a negated FUSE error number
+func errno(err error) int {
+	if err == nil {
+		return 0
+	}
+	cause := errors.Cause(err)
+	switch {
+	case errs.IsNotFoundError(err):
+		return -fuse.ENOENT
+	case errors.Is(cause, errs.PermissionDenied), errors.Is(cause, errs.IgnoredSystemFile):
+		return -fuse.EACCES
+	case errors.Is(cause, errs.ObjectAlreadyExists):
+		return -fuse.EEXIST
+	case errors.Is(cause, errs.NotFolder):
+		return -fuse.ENOTDIR
+	case errors.Is(cause, errs.NotFile):
+		return -fuse.EISDIR
+	case errors.Is(cause, errs.UploadNotSupported):
+		return -fuse.EROFS
+	case errs.IsNotSupportError(err), errs.IsNotImplementError(err):
+		return -fuse.ENOSYS
+	case errors.Is(cause, errs.RelativePath):
+		return -fuse.EINVAL
+	}
+	log.Errorf("fuse: %+v", err)
+	return -fuse.EIO
+}
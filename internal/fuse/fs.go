@@ -1,170 +1,657 @@
 package fuse
 
-import "github.com/winfsp/cgofuse/fuse"
-
+import (
+	"context"
+	"io"
+	stdpath "path"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+	"github.com/winfsp/cgofuse/fuse"
+)
+
+// Fs exposes the OpenList virtual file system through FUSE.
+// Every path received from the kernel is relative to RootFolder,
+// which is in turn resolved against the base path of User.
 type Fs struct {
 	RootFolder string
+	User       *model.User
 	fuse.FileSystemBase
-}
-
-func (fs *Fs) Init() {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Destroy() {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Statfs(path string, stat *fuse.Statfs_t) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Mknod(path string, mode uint32, dev uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Mkdir(path string, mode uint32) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Unlink(path string) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Rmdir(path string) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Link(oldpath string, newpath string) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Symlink(target string, newpath string) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Readlink(path string) (int, string) {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Rename(oldpath string, newpath string) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Chmod(path string, mode uint32) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Chown(path string, uid uint32, gid uint32) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Utimens(path string, tmsp []fuse.Timespec) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Access(path string, mask uint32) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Create(path string, flags int, mode uint32) (int, uint64) {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Open(path string, flags int) (int, uint64) {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Getattr(path string, stat *fuse.Stat_t, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Truncate(path string, size int64, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Read(path string, buff []byte, ofst int64, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Write(path string, buff []byte, ofst int64, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Flush(path string, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Release(path string, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Fsync(path string, datasync bool, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Opendir(path string) (int, uint64) {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Readdir(path string, fill func(name string, stat *fuse.Stat_t, ofst int64) bool, ofst int64, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Releasedir(path string, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Fsyncdir(path string, datasync bool, fh uint64) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Setxattr(path string, name string, value []byte, flags int) int {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Getxattr(path string, name string) (int, []byte) {
-	//TODO implement me
-	panic("implement me")
-}
-
-func (fs *Fs) Removexattr(path string, name string) int {
-	//TODO implement me
-	panic("implement me")
-}
 
-func (fs *Fs) Listxattr(path string, fill func(name string) bool) int {
-	//TODO implement me
-	panic("implement me")
+	ctx    context.Context
+	cancel context.CancelFunc
+	uid    uint32
+	gid    uint32
+
+	mu      sync.Mutex
+	nextFh  uint64
+	handles map[uint64]*openFile
+	// files opened for writing, keyed by request path, so that
+	// Getattr and Readdir can see them before they are uploaded
+	pending map[string]*fileHandle
+}
+
+func NewFs(user *model.User, rootFolder string) *Fs {
+	ctx, cancel := context.WithCancel(context.Background())
+	ctx = context.WithValue(ctx, conf.UserKey, user)
+	ctx = context.WithValue(ctx, conf.MetaPassKey, "")
+	ctx = context.WithValue(ctx, conf.ProtocolKey, "fuse")
+	return &Fs{
+		RootFolder: utils.FixAndCleanPath(rootFolder),
+		User:       user,
+		ctx:        ctx,
+		cancel:     cancel,
+		handles:    make(map[uint64]*openFile),
+		pending:    make(map[string]*fileHandle),
+	}
+}
+
+func (f *Fs) Init() {
+	// files are owned by whoever mounted the file system
+	uid, gid, _ := fuse.Getcontext()
+	f.uid, f.gid = uid, gid
+	log.Infof("fuse: serving [%s] as user [%s]", f.RootFolder, f.User.Username)
+}
+
+func (f *Fs) Destroy() {
+	f.mu.Lock()
+	handles := f.handles
+	f.handles = make(map[uint64]*openFile)
+	f.mu.Unlock()
+	for _, h := range handles {
+		if err := h.Close(); err != nil {
+			log.Errorf("fuse: failed release [%s] on destroy: %+v", h.reqPath, err)
+		}
+	}
+	f.cancel()
+}
+
+func (f *Fs) Statfs(path string, stat *fuse.Statfs_t) int {
+	reqPath, err := f.reqPath(path)
+	if err != nil {
+		return errno(err)
+	}
+	const blockSize = 4096
+	stat.Bsize = blockSize
+	stat.Frsize = blockSize
+	stat.Namemax = 255
+	// storages without details report an effectively unlimited capacity
+	total, free := uint64(1<<50), uint64(1<<50)
+	if storage, _, err := op.GetStorageAndActualPath(reqPath); err == nil {
+		if details, err := op.GetStorageDetails(f.ctx, storage); err == nil && details.TotalSpace > 0 {
+			total = uint64(details.TotalSpace)
+			free = uint64(max(details.FreeSpace(), 0))
+		}
+	}
+	stat.Blocks = total / blockSize
+	stat.Bfree = free / blockSize
+	stat.Bavail = stat.Bfree
+	return 0
+}
+
+func (f *Fs) Mknod(path string, mode uint32, dev uint64) int {
+	if mode&fuse.S_IFMT != fuse.S_IFREG && mode&fuse.S_IFMT != 0 {
+		return -fuse.EPERM
+	}
+	rc, fh := f.Create(path, fuse.O_WRONLY, mode)
+	if rc != 0 {
+		return rc
+	}
+	return f.Release(path, fh)
+}
+
+func (f *Fs) Mkdir(path string, mode uint32) int {
+	reqPath, err := f.reqPath(path)
+	if err != nil {
+		return errno(err)
+	}
+	if err = f.checkWrite(reqPath); err != nil {
+		return errno(err)
+	}
+	return errno(f.makeDir(reqPath))
+}
+
+func (f *Fs) makeDir(reqPath string) error {
+	if _, err := f.get(reqPath); err == nil {
+		return errs.ObjectAlreadyExists
+	}
+	return fs.MakeDir(f.ctx, reqPath)
+}
+
+func (f *Fs) Unlink(path string) int {
+	reqPath, err := f.reqPath(path)
+	if err != nil {
+		return errno(err)
+	}
+	if err = f.checkRemove(reqPath); err != nil {
+		return errno(err)
+	}
+	obj, err := f.get(reqPath)
+	if err != nil {
+		return errno(err)
+	}
+	if obj.IsDir() {
+		return -fuse.EISDIR
+	}
+	if h := f.getPending(reqPath); h != nil {
+		// the file only exists locally or is about to be replaced,
+		// nothing will be uploaded for it any more
+		h.discard()
+	}
+	return errno(fs.Remove(f.ctx, reqPath))
+}
+
+func (f *Fs) Rmdir(path string) int {
+	reqPath, err := f.reqPath(path)
+	if err != nil {
+		return errno(err)
+	}
+	if err = f.checkRemove(reqPath); err != nil {
+		return errno(err)
+	}
+	obj, err := f.get(reqPath)
+	if err != nil {
+		return errno(err)
+	}
+	if !obj.IsDir() {
+		return -fuse.ENOTDIR
+	}
+	objs, err := fs.List(f.ctx, reqPath, &fs.ListArgs{NoLog: true, Refresh: true})
+	if err != nil {
+		return errno(err)
+	}
+	if len(objs) > 0 {
+		return -fuse.ENOTEMPTY
+	}
+	return errno(fs.Remove(f.ctx, reqPath))
+}
+
+func (f *Fs) Link(oldpath string, newpath string) int {
+	return -fuse.ENOSYS
+}
+
+func (f *Fs) Symlink(target string, newpath string) int {
+	return -fuse.ENOSYS
+}
+
+func (f *Fs) Readlink(path string) (int, string) {
+	return -fuse.EINVAL, ""
+}
+
+func (f *Fs) Rename(oldpath string, newpath string) int {
+	srcPath, err := f.reqPath(oldpath)
+	if err != nil {
+		return errno(err)
+	}
+	dstPath, err := f.reqPath(newpath)
+	if err != nil {
+		return errno(err)
+	}
+	if srcPath == dstPath {
+		return 0
+	}
+	srcDir := stdpath.Dir(srcPath)
+	dstDir := stdpath.Dir(dstPath)
+	if err = f.checkRename(srcPath, dstPath); err != nil {
+		return errno(err)
+	}
+	if h := f.getPending(srcPath); h != nil {
+		// flush local changes first so that the driver sees the latest content
+		if err = h.upload(); err != nil {
+			return errno(err)
+		}
+	}
+	srcObj, err := f.get(srcPath)
+	if err != nil {
+		return errno(err)
+	}
+	if srcDir != dstDir {
+		srcStorage, _, err := op.GetStorageAndActualPath(srcPath)
+		if err != nil {
+			return errno(err)
+		}
+		dstStorage, _, err := op.GetStorageAndActualPath(dstDir)
+		if err != nil {
+			return errno(err)
+		}
+		// a move across storages is a copy task in OpenList,
+		// let the kernel fall back to copy and unlink instead
+		if srcStorage.GetStorage() != dstStorage.GetStorage() || !canMove(srcStorage) {
+			return -fuse.EXDEV
+		}
+	}
+	// POSIX rename replaces an existing destination atomically,
+	// the closest we can get is removing it beforehand
+	dstObj, err := f.get(dstPath)
+	if err != nil {
+		if !errs.IsNotFoundError(err) {
+			return errno(err)
+		}
+		dstObj = nil
+	}
+	if dstObj != nil {
+		if dstObj.IsDir() != srcObj.IsDir() {
+			if dstObj.IsDir() {
+				return -fuse.EISDIR
+			}
+			return -fuse.ENOTDIR
+		}
+		if err = f.checkRemove(dstPath); err != nil {
+			return errno(err)
+		}
+		if dstObj.IsDir() {
+			objs, err := fs.List(f.ctx, dstPath, &fs.ListArgs{NoLog: true, Refresh: true})
+			if err != nil {
+				return errno(err)
+			}
+			if len(objs) > 0 {
+				return -fuse.ENOTEMPTY
+			}
+		}
+	}
+	if dstObj != nil {
+		if err = fs.Remove(f.ctx, dstPath); err != nil {
+			return errno(err)
+		}
+	}
+	if err = f.move(srcPath, dstPath); err != nil {
+		return errno(err)
+	}
+	if h := f.getPending(dstPath); h != nil {
+		// the replaced file was still being written
+		h.discard()
+	}
+	// the files still open are uploaded to where they are now
+	f.movePending(srcPath, dstPath)
+	return 0
+}
+
+// move renames and moves the object at srcPath to dstPath in the same storage
+func (f *Fs) move(srcPath, dstPath string) error {
+	srcDir, srcName := stdpath.Split(srcPath)
+	dstDir, dstName := stdpath.Split(dstPath)
+	if srcDir == dstDir {
+		return fs.Rename(f.ctx, srcPath, dstName)
+	}
+	movedPath := srcPath
+	if srcName != dstName {
+		if err := fs.Rename(f.ctx, srcPath, dstName); err != nil {
+			return err
+		}
+		movedPath = stdpath.Join(srcDir, dstName)
+	}
+	if _, err := fs.Move(f.ctx, movedPath, dstDir); err != nil {
+		if movedPath != srcPath {
+			_ = fs.Rename(f.ctx, movedPath, srcName)
+		}
+		return err
+	}
+	return nil
+}
+
+func canMove(storage driver.Driver) bool {
+	switch storage.(type) {
+	case driver.Move, driver.MoveResult:
+		return true
+	}
+	return false
+}
+
+func (f *Fs) Chmod(path string, mode uint32) int {
+	// permissions are controlled by OpenList users, not by the file mode
+	return 0
+}
+
+func (f *Fs) Chown(path string, uid uint32, gid uint32) int {
+	return 0
+}
+
+func (f *Fs) Utimens(path string, tmsp []fuse.Timespec) int {
+	// drivers can not set the modification time, accept and ignore it
+	// so that tools like touch and cp -p keep working
+	return 0
+}
+
+func (f *Fs) Access(path string, mask uint32) int {
+	reqPath, err := f.reqPath(path)
+	if err != nil {
+		return errno(err)
+	}
+	if err = f.checkRead(reqPath); err != nil {
+		return errno(err)
+	}
+	if mask&2 != 0 { // W_OK
+		if err = f.checkWrite(reqPath); err != nil {
+			return errno(err)
+		}
+	}
+	return 0
+}
+
+func (f *Fs) Create(path string, flags int, mode uint32) (int, uint64) {
+	reqPath, err := f.reqPath(path)
+	if err != nil {
+		return errno(err), ^uint64(0)
+	}
+	if err = f.checkWrite(reqPath); err != nil {
+		return errno(err), ^uint64(0)
+	}
+	if obj, err := f.get(reqPath); err == nil && obj.IsDir() {
+		return -fuse.EISDIR, ^uint64(0)
+	}
+	h, err := newWriteHandle(f, reqPath, nil)
+	if err != nil {
+		return errno(err), ^uint64(0)
+	}
+	// an empty file must be uploaded even if nothing is written
+	h.dirty = true
+	return 0, f.addHandle(h, true)
+}
+
+func (f *Fs) Open(path string, flags int) (int, uint64) {
+	reqPath, err := f.reqPath(path)
+	if err != nil {
+		return errno(err), ^uint64(0)
+	}
+	if err = f.checkRead(reqPath); err != nil {
+		return errno(err), ^uint64(0)
+	}
+	// a file being written may not have been uploaded yet
+	pending := f.getPending(reqPath)
+	var obj model.Obj
+	if pending == nil {
+		if obj, err = f.get(reqPath); err != nil {
+			return errno(err), ^uint64(0)
+		}
+		if obj.IsDir() {
+			return -fuse.EISDIR, ^uint64(0)
+		}
+	}
+	if flags&fuse.O_ACCMODE == fuse.O_RDONLY {
+		if pending != nil {
+			// read back what has been written but not uploaded yet
+			return 0, f.addHandle(pending.share(), false)
+		}
+		return 0, f.addHandle(newReadHandle(f, reqPath, obj), false)
+	}
+	if err = f.checkWrite(reqPath); err != nil {
+		return errno(err), ^uint64(0)
+	}
+	if pending != nil {
+		if flags&fuse.O_TRUNC != 0 {
+			if err = pending.truncate(0); err != nil {
+				return errno(err), ^uint64(0)
+			}
+		}
+		return 0, f.addHandle(pending.share(), true)
+	}
+	var src model.Obj
+	if flags&fuse.O_TRUNC == 0 {
+		src = obj
+	}
+	h, err := newWriteHandle(f, reqPath, src)
+	if err != nil {
+		return errno(err), ^uint64(0)
+	}
+	if flags&fuse.O_TRUNC != 0 {
+		h.dirty = true
+	}
+	return 0, f.addHandle(h, true)
+}
+
+func (f *Fs) Getattr(path string, stat *fuse.Stat_t, fh uint64) int {
+	reqPath, err := f.reqPath(path)
+	if err != nil {
+		return errno(err)
+	}
+	if h := f.getPending(reqPath); h != nil {
+		f.fillPending(stat, h)
+		return 0
+	}
+	if err = f.checkRead(reqPath); err != nil {
+		return errno(err)
+	}
+	obj, err := f.get(reqPath)
+	if err != nil {
+		return errno(err)
+	}
+	f.fillStat(stat, obj)
+	return 0
+}
+
+func (f *Fs) Truncate(path string, size int64, fh uint64) int {
+	if h := f.getHandle(fh); h != nil && h.write {
+		return errno(h.truncate(size))
+	}
+	reqPath, err := f.reqPath(path)
+	if err != nil {
+		return errno(err)
+	}
+	if err = f.checkWrite(reqPath); err != nil {
+		return errno(err)
+	}
+	if h := f.getPending(reqPath); h != nil {
+		return errno(h.truncate(size))
+	}
+	obj, err := f.get(reqPath)
+	if err != nil {
+		return errno(err)
+	}
+	if obj.IsDir() {
+		return -fuse.EISDIR
+	}
+	var src model.Obj
+	if size > 0 {
+		src = obj
+	}
+	h, err := newWriteHandle(f, reqPath, src)
+	if err != nil {
+		return errno(err)
+	}
+	err = h.truncate(size)
+	if e := h.Close(); err == nil {
+		err = e
+	}
+	return errno(err)
+}
+
+func (f *Fs) Read(path string, buff []byte, ofst int64, fh uint64) int {
+	h := f.getHandle(fh)
+	if h == nil {
+		return -fuse.EBADF
+	}
+	n, err := h.ReadAt(buff, ofst)
+	if err != nil && !errors.Is(err, io.EOF) {
+		log.Errorf("fuse: failed read [%s] at %d: %+v", h.reqPath, ofst, err)
+		if n == 0 {
+			return errno(err)
+		}
+	}
+	return n
+}
+
+func (f *Fs) Write(path string, buff []byte, ofst int64, fh uint64) int {
+	h := f.getHandle(fh)
+	if h == nil {
+		return -fuse.EBADF
+	}
+	if !h.write {
+		return -fuse.EBADF
+	}
+	n, err := h.WriteAt(buff, ofst)
+	if err != nil {
+		log.Errorf("fuse: failed write [%s] at %d: %+v", h.reqPath, ofst, err)
+		if n == 0 {
+			return errno(err)
+		}
+	}
+	return n
+}
+
+func (f *Fs) Flush(path string, fh uint64) int {
+	h := f.getHandle(fh)
+	if h == nil {
+		return -fuse.EBADF
+	}
+	if !h.write {
+		return 0
+	}
+	return errno(h.upload())
+}
+
+func (f *Fs) Release(path string, fh uint64) int {
+	h := f.removeHandle(fh)
+	if h == nil {
+		return -fuse.EBADF
+	}
+	return errno(h.Close())
+}
+
+func (f *Fs) Fsync(path string, datasync bool, fh uint64) int {
+	return f.Flush(path, fh)
+}
+
+func (f *Fs) Opendir(path string) (int, uint64) {
+	reqPath, err := f.reqPath(path)
+	if err != nil {
+		return errno(err), ^uint64(0)
+	}
+	if err = f.checkRead(reqPath); err != nil {
+		return errno(err), ^uint64(0)
+	}
+	obj, err := f.get(reqPath)
+	if err != nil {
+		return errno(err), ^uint64(0)
+	}
+	if !obj.IsDir() {
+		return -fuse.ENOTDIR, ^uint64(0)
+	}
+	return 0, 0
+}
+
+func (f *Fs) Readdir(path string, fill func(name string, stat *fuse.Stat_t, ofst int64) bool, ofst int64, fh uint64) int {
+	reqPath, err := f.reqPath(path)
+	if err != nil {
+		return errno(err)
+	}
+	if err = f.checkRead(reqPath); err != nil {
+		return errno(err)
+	}
+	meta, err := getNearestMeta(reqPath)
+	if err != nil {
+		return errno(err)
+	}
+	// the meta hides the objects matching its rules
+	ctx := context.WithValue(f.ctx, conf.MetaKey, meta)
+	objs, err := fs.List(ctx, reqPath, &fs.ListArgs{NoLog: true, Refresh: false})
+	if err != nil {
+		return errno(err)
+	}
+	fill(".", nil, 0)
+	fill("..", nil, 0)
+	listed := make(map[string]struct{}, len(objs))
+	for _, obj := range objs {
+		listed[obj.GetName()] = struct{}{}
+		stat := &fuse.Stat_t{}
+		if h := f.getPending(stdpath.Join(reqPath, obj.GetName())); h != nil {
+			f.fillPending(stat, h)
+		} else {
+			f.fillStat(stat, obj)
+		}
+		if !fill(obj.GetName(), stat, 0) {
+			return 0
+		}
+	}
+	// files created locally but not uploaded yet
+	for name, h := range f.pendingIn(reqPath) {
+		if _, ok := listed[name]; ok {
+			continue
+		}
+		stat := &fuse.Stat_t{}
+		f.fillPending(stat, h)
+		if !fill(name, stat, 0) {
+			return 0
+		}
+	}
+	return 0
+}
+
+func (f *Fs) Releasedir(path string, fh uint64) int {
+	return 0
+}
+
+func (f *Fs) Fsyncdir(path string, datasync bool, fh uint64) int {
+	return 0
+}
+
+func (f *Fs) Setxattr(path string, name string, value []byte, flags int) int {
+	return -fuse.ENOTSUP
+}
+
+func (f *Fs) Getxattr(path string, name string) (int, []byte) {
+	return -fuse.ENOATTR, nil
+}
+
+func (f *Fs) Removexattr(path string, name string) int {
+	return -fuse.ENOTSUP
+}
+
+func (f *Fs) Listxattr(path string, fill func(name string) bool) int {
+	return 0
+}
+
+func (f *Fs) reqPath(path string) (string, error) {
+	return f.User.JoinPath(stdpath.Join(f.RootFolder, path))
+}
+
+func (f *Fs) get(reqPath string) (model.Obj, error) {
+	return fs.Get(f.ctx, reqPath, &fs.GetArgs{NoLog: true})
+}
+
+func (f *Fs) fillStat(stat *fuse.Stat_t, obj model.Obj) {
+	*stat = fuse.Stat_t{}
+	if obj.IsDir() {
+		stat.Mode = fuse.S_IFDIR | 0o755
+		stat.Nlink = 2
+	} else {
+		stat.Mode = fuse.S_IFREG | 0o644
+		stat.Nlink = 1
+		stat.Size = max(obj.GetSize(), 0)
+		stat.Blocks = (stat.Size + 511) / 512
+	}
+	stat.Uid = f.uid
+	stat.Gid = f.gid
+	stat.Blksize = 4096
+	modified := obj.ModTime()
+	created := obj.CreateTime()
+	if modified.IsZero() {
+		modified = created
+	}
+	if created.IsZero() {
+		created = modified
+	}
+	stat.Mtim = fuse.NewTimespec(modified)
+	stat.Atim = stat.Mtim
+	stat.Ctim = stat.Mtim
+	stat.Birthtim = fuse.NewTimespec(created)
+}
+
+func (f *Fs) fillPending(stat *fuse.Stat_t, h *fileHandle) {
+	now := time.Now()
+	f.fillStat(stat, &model.Object{
+		Size:     h.size(),
+		Modified: now,
+		Ctime:    now,
+	})
 }
 
 var _ fuse.FileSystemInterface = (*Fs)(nil)
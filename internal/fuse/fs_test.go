@@ -0,0 +1,281 @@
+package fuse
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sort"
+	"testing"
+
+	_ "github.com/OpenListTeam/OpenList/v4/drivers/local"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/glebarez/sqlite"
+	"github.com/winfsp/cgofuse/fuse"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dataDir, err := os.MkdirTemp("", "openlist-fuse-*")
+	if err != nil {
+		panic(err)
+	}
+	conf.Conf = conf.DefaultConfig(dataDir)
+	if err := os.MkdirAll(conf.Conf.TempDir, 0o755); err != nil {
+		panic("mkdir temp dir: " + err.Error())
+	}
+	dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database: " + err.Error())
+	}
+	db.Init(dB)
+}
+
+// setupFs mounts a Local storage at /local and returns an Fs serving it
+// with the given permission, plus the local root directory on disk.
+func setupFs(t *testing.T, permission int32) (*Fs, string) {
+	t.Helper()
+	localRoot := t.TempDir()
+	storage, err := op.GetStorageByMountPath("/local")
+	if err == nil {
+		if err = op.DeleteStorageById(context.Background(), storage.GetStorage().ID); err != nil {
+			t.Fatalf("delete storage: %+v", err)
+		}
+	}
+	_, err = op.CreateStorage(context.Background(), model.Storage{
+		Driver:    "Local",
+		MountPath: "/local",
+		Addition:  `{"root_folder_path":"` + filepath.ToSlash(localRoot) + `","thumbnail":false}`,
+	})
+	if err != nil {
+		t.Fatalf("create local storage: %+v", err)
+	}
+	user := &model.User{Username: "fuse", Role: model.GENERAL, BasePath: "/", Permission: permission}
+	f := NewFs(user, "/local")
+	t.Cleanup(f.Destroy)
+	return f, localRoot
+}
+
+func readDir(t *testing.T, f *Fs, path string) []string {
+	t.Helper()
+	var names []string
+	rc := f.Readdir(path, func(name string, stat *fuse.Stat_t, ofst int64) bool {
+		if name != "." && name != ".." {
+			names = append(names, name)
+		}
+		return true
+	}, 0, 0)
+	if rc != 0 {
+		t.Fatalf("Readdir %s: %d", path, rc)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func TestReadWrite(t *testing.T) {
+	// write content, rename and remove
+	f, localRoot := setupFs(t, 8|16|32|128)
+
+	if rc := f.Mkdir("/dir", 0o755); rc != 0 {
+		t.Fatalf("Mkdir: %d", rc)
+	}
+	if rc := f.Mkdir("/dir", 0o755); rc != -fuse.EEXIST {
+		t.Fatalf("Mkdir existing = %d, want EEXIST", rc)
+	}
+
+	rc, fh := f.Create("/dir/a.txt", fuse.O_WRONLY, 0o644)
+	if rc != 0 {
+		t.Fatalf("Create: %d", rc)
+	}
+	if n := f.Write("/dir/a.txt", []byte("hello world"), 0, fh); n != 11 {
+		t.Fatalf("Write = %d", n)
+	}
+	// the file is visible before it is uploaded
+	stat := &fuse.Stat_t{}
+	if rc := f.Getattr("/dir/a.txt", stat, fh); rc != 0 || stat.Size != 11 {
+		t.Fatalf("Getattr pending: rc=%d size=%d", rc, stat.Size)
+	}
+	if names := readDir(t, f, "/dir"); len(names) != 1 || names[0] != "a.txt" {
+		t.Fatalf("Readdir pending = %v", names)
+	}
+	// and can be opened again before it is uploaded
+	rc, rfh := f.Open("/dir/a.txt", fuse.O_RDONLY)
+	if rc != 0 {
+		t.Fatalf("Open pending: %d", rc)
+	}
+	buf := make([]byte, 5)
+	if n := f.Read("/dir/a.txt", buf, 0, rfh); n != 5 || string(buf) != "hello" {
+		t.Fatalf("Read pending = %d %q", n, buf)
+	}
+	f.Release("/dir/a.txt", rfh)
+	if rc := f.Release("/dir/a.txt", fh); rc != 0 {
+		t.Fatalf("Release: %d", rc)
+	}
+	data, err := os.ReadFile(filepath.Join(localRoot, "dir", "a.txt"))
+	if err != nil || string(data) != "hello world" {
+		t.Fatalf("uploaded content = %q, %v", data, err)
+	}
+
+	rc, fh = f.Open("/dir/a.txt", fuse.O_RDONLY)
+	if rc != 0 {
+		t.Fatalf("Open: %d", rc)
+	}
+	if n := f.Read("/dir/a.txt", buf, 6, fh); n != 5 || string(buf) != "world" {
+		t.Fatalf("Read = %d %q", n, buf)
+	}
+	if n := f.Write("/dir/a.txt", buf, 0, fh); n != -fuse.EBADF {
+		t.Fatalf("Write read-only = %d, want EBADF", n)
+	}
+	f.Release("/dir/a.txt", fh)
+
+	// partial overwrite keeps the rest of the content
+	rc, fh = f.Open("/dir/a.txt", fuse.O_RDWR)
+	if rc != 0 {
+		t.Fatalf("Open rw: %d", rc)
+	}
+	f.Write("/dir/a.txt", []byte("HELLO"), 0, fh)
+	if rc := f.Release("/dir/a.txt", fh); rc != 0 {
+		t.Fatalf("Release rw: %d", rc)
+	}
+	data, _ = os.ReadFile(filepath.Join(localRoot, "dir", "a.txt"))
+	if string(data) != "HELLO world" {
+		t.Fatalf("overwritten content = %q", data)
+	}
+
+	if rc := f.Truncate("/dir/a.txt", 5, ^uint64(0)); rc != 0 {
+		t.Fatalf("Truncate: %d", rc)
+	}
+	data, _ = os.ReadFile(filepath.Join(localRoot, "dir", "a.txt"))
+	if string(data) != "HELLO" {
+		t.Fatalf("truncated content = %q", data)
+	}
+
+	if rc := f.Rename("/dir/a.txt", "/dir/b.txt"); rc != 0 {
+		t.Fatalf("Rename: %d", rc)
+	}
+	if rc := f.Rename("/dir/b.txt", "/b.txt"); rc != 0 {
+		t.Fatalf("Move: %d", rc)
+	}
+	if names := readDir(t, f, "/"); len(names) != 2 || names[0] != "b.txt" || names[1] != "dir" {
+		t.Fatalf("Readdir / = %v", names)
+	}
+	if rc := f.Rmdir("/b.txt"); rc != -fuse.ENOTDIR {
+		t.Fatalf("Rmdir file = %d, want ENOTDIR", rc)
+	}
+	if rc := f.Unlink("/b.txt"); rc != 0 {
+		t.Fatalf("Unlink: %d", rc)
+	}
+	if rc := f.Rmdir("/dir"); rc != 0 {
+		t.Fatalf("Rmdir: %d", rc)
+	}
+	if rc := f.Getattr("/dir", stat, ^uint64(0)); rc != -fuse.ENOENT {
+		t.Fatalf("Getattr removed = %d, want ENOENT", rc)
+	}
+}
+
+func TestPermission(t *testing.T) {
+	// read only
+	f, localRoot := setupFs(t, 0)
+	if err := os.WriteFile(filepath.Join(localRoot, "a.txt"), []byte("a"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if names := readDir(t, f, "/"); len(names) != 1 || names[0] != "a.txt" {
+		t.Fatalf("Readdir = %v", names)
+	}
+	if rc := f.Mkdir("/dir", 0o755); rc != -fuse.EACCES {
+		t.Fatalf("Mkdir = %d, want EACCES", rc)
+	}
+	if rc, _ := f.Create("/b.txt", fuse.O_WRONLY, 0o644); rc != -fuse.EACCES {
+		t.Fatalf("Create = %d, want EACCES", rc)
+	}
+	if rc, _ := f.Open("/a.txt", fuse.O_WRONLY); rc != -fuse.EACCES {
+		t.Fatalf("Open for write = %d, want EACCES", rc)
+	}
+	if rc := f.Rename("/a.txt", "/b.txt"); rc != -fuse.EACCES {
+		t.Fatalf("Rename = %d, want EACCES", rc)
+	}
+	if rc := f.Unlink("/a.txt"); rc != -fuse.EACCES {
+		t.Fatalf("Unlink = %d, want EACCES", rc)
+	}
+	if _, err := os.Stat(filepath.Join(localRoot, "a.txt")); err != nil {
+		t.Fatalf("file changed: %v", err)
+	}
+}
+
+func TestHide(t *testing.T) {
+	f, localRoot := setupFs(t, 0)
+	for _, name := range []string{"a.txt", "secret.txt"} {
+		if err := os.WriteFile(filepath.Join(localRoot, name), []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	meta := &model.Meta{Path: "/local", Hide: "secret", HSub: true}
+	if err := op.CreateMeta(meta); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { _ = op.DeleteMetaById(meta.ID) })
+	if names := readDir(t, f, "/"); len(names) != 1 || names[0] != "a.txt" {
+		t.Fatalf("Readdir = %v, want the hidden file left out", names)
+	}
+}
+
+func TestRenameOpen(t *testing.T) {
+	// a file renamed while it's being written is uploaded to the new path
+	f, localRoot := setupFs(t, 8|16|32|128)
+	rc, fh := f.Create("/a.txt", fuse.O_WRONLY, 0o644)
+	if rc != 0 {
+		t.Fatalf("Create: %d", rc)
+	}
+	f.Write("/a.txt", []byte("one"), 0, fh)
+	if rc := f.Rename("/a.txt", "/b.txt"); rc != 0 {
+		t.Fatalf("Rename: %d", rc)
+	}
+	f.Write("/b.txt", []byte("two"), 0, fh)
+	if rc := f.Release("/b.txt", fh); rc != 0 {
+		t.Fatalf("Release: %d", rc)
+	}
+	if _, err := os.Stat(filepath.Join(localRoot, "a.txt")); !os.IsNotExist(err) {
+		t.Fatalf("the old path came back: %v", err)
+	}
+	if data, _ := os.ReadFile(filepath.Join(localRoot, "b.txt")); string(data) != "two" {
+		t.Fatalf("renamed content = %q", data)
+	}
+	if names := readDir(t, f, "/"); len(names) != 1 || names[0] != "b.txt" {
+		t.Fatalf("Readdir = %v", names)
+	}
+}
+
+func TestRenameReplace(t *testing.T) {
+	// replacing the destination removes it, which needs the remove permission
+	f, localRoot := setupFs(t, 8|16|32)
+	for _, name := range []string{"a.txt", "b.txt"} {
+		if err := os.WriteFile(filepath.Join(localRoot, name), []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if rc := f.Rename("/a.txt", "/b.txt"); rc != -fuse.EACCES {
+		t.Fatalf("Rename = %d, want EACCES", rc)
+	}
+	if data, _ := os.ReadFile(filepath.Join(localRoot, "b.txt")); string(data) != "b.txt" {
+		t.Fatalf("destination changed: %q", data)
+	}
+
+	f, localRoot = setupFs(t, 8|16|32|128)
+	for _, name := range []string{"src/x.txt", "dst/y.txt"} {
+		p := filepath.Join(localRoot, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(name), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if rc := f.Rename("/src", "/dst"); rc != -fuse.ENOTEMPTY {
+		t.Fatalf("Rename onto a non-empty dir = %d, want ENOTEMPTY", rc)
+	}
+	if _, err := os.Stat(filepath.Join(localRoot, "dst", "y.txt")); err != nil {
+		t.Fatalf("destination changed: %v", err)
+	}
+}
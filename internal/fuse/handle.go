@@ -0,0 +1,344 @@
+package fuse
+
+import (
+	"io"
+	"os"
+	stdpath "path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/stream"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// fileHandle is the state behind one or more open file descriptors.
+// Files opened read-only are served by ranged reads from the link of the object,
+// files opened for writing are backed by a local temp file which is uploaded
+// with fs.PutDirectly when it is flushed or released (write-back).
+type fileHandle struct {
+	fs      *Fs
+	reqPath string
+	obj     model.Obj
+
+	mu   sync.Mutex
+	refs int
+
+	// read-only side
+	reader model.File
+	closer io.Closer
+
+	// write-back side
+	file      *os.File
+	dirty     bool
+	discarded bool
+}
+
+// openFile is what a FUSE file handle number refers to
+type openFile struct {
+	*fileHandle
+	write bool
+}
+
+func newReadHandle(f *Fs, reqPath string, obj model.Obj) *fileHandle {
+	return &fileHandle{fs: f, reqPath: reqPath, obj: obj, refs: 1}
+}
+
+// newWriteHandle creates a write-back handle, the content of src is downloaded
+// into the temp file first if src is not nil
+func newWriteHandle(f *Fs, reqPath string, src model.Obj) (*fileHandle, error) {
+	file, err := os.CreateTemp(conf.Conf.TempDir, "fuse-*")
+	if err != nil {
+		return nil, errors.WithStack(err)
+	}
+	h := &fileHandle{fs: f, reqPath: reqPath, file: file, refs: 1}
+	if src != nil && src.GetSize() > 0 {
+		if err = h.download(src); err != nil {
+			_ = h.removeFile()
+			return nil, err
+		}
+	}
+	f.mu.Lock()
+	f.pending[reqPath] = h
+	f.mu.Unlock()
+	return h, nil
+}
+
+func (h *fileHandle) download(src model.Obj) error {
+	r := newReadHandle(h.fs, h.reqPath, src)
+	defer r.closeReader()
+	if err := r.openReader(); err != nil {
+		return err
+	}
+	_, err := utils.CopyWithBuffer(h.file, io.NewSectionReader(r.reader, 0, src.GetSize()))
+	return errors.WithStack(err)
+}
+
+func (h *fileHandle) openReader() error {
+	ctx := h.fs.ctx
+	link, obj, err := fs.Link(ctx, h.reqPath, model.LinkArgs{})
+	if err != nil {
+		return err
+	}
+	ss, err := stream.NewSeekableStream(&stream.FileStream{
+		Obj: obj,
+		Ctx: ctx,
+	}, link)
+	if err != nil {
+		_ = link.Close()
+		return err
+	}
+	reader, err := stream.NewReadAtSeeker(ss, 0)
+	if err != nil {
+		_ = ss.Close()
+		return err
+	}
+	h.obj = obj
+	h.reader = reader
+	h.closer = ss
+	return nil
+}
+
+func (h *fileHandle) closeReader() {
+	if h.closer != nil {
+		if err := h.closer.Close(); err != nil {
+			log.Warnf("fuse: failed close reader of [%s]: %+v", h.reqPath, err)
+		}
+		h.closer = nil
+		h.reader = nil
+	}
+}
+
+func (h *fileHandle) share() *fileHandle {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.refs++
+	return h
+}
+
+func (h *fileHandle) ReadAt(p []byte, off int64) (int, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.file != nil {
+		return h.file.ReadAt(p, off)
+	}
+	if h.obj != nil && off >= h.obj.GetSize() {
+		return 0, io.EOF
+	}
+	if h.reader == nil {
+		if err := h.openReader(); err != nil {
+			return 0, err
+		}
+	}
+	n, err := h.reader.ReadAt(p, off)
+	if n > 0 && stream.ClientDownloadLimit != nil {
+		if e := stream.ClientDownloadLimit.WaitN(h.fs.ctx, n); e != nil {
+			return n, e
+		}
+	}
+	return n, err
+}
+
+func (h *fileHandle) WriteAt(p []byte, off int64) (int, error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.file == nil {
+		return 0, errs.NotSupport
+	}
+	n, err := h.file.WriteAt(p, off)
+	if n > 0 {
+		h.dirty = true
+		if stream.ClientUploadLimit != nil {
+			if e := stream.ClientUploadLimit.WaitN(h.fs.ctx, n); e != nil && err == nil {
+				err = e
+			}
+		}
+	}
+	return n, err
+}
+
+func (h *fileHandle) truncate(size int64) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.file == nil {
+		return errs.NotSupport
+	}
+	if err := h.file.Truncate(size); err != nil {
+		return errors.WithStack(err)
+	}
+	h.dirty = true
+	return nil
+}
+
+func (h *fileHandle) size() int64 {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.file == nil {
+		if h.obj != nil {
+			return h.obj.GetSize()
+		}
+		return 0
+	}
+	stat, err := h.file.Stat()
+	if err != nil {
+		return 0
+	}
+	return stat.Size()
+}
+
+// discard drops local changes, used when the file is removed while still open
+func (h *fileHandle) discard() {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.dirty = false
+	h.discarded = true
+	h.fs.mu.Lock()
+	if h.fs.pending[h.reqPath] == h {
+		delete(h.fs.pending, h.reqPath)
+	}
+	h.fs.mu.Unlock()
+}
+
+// move points the handle at the path of the file after it's renamed from srcPath to dstPath,
+// srcPath is the file itself or one of its parent folders
+func (h *fileHandle) move(srcPath, dstPath string) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.fs.mu.Lock()
+	defer h.fs.mu.Unlock()
+	if h.file == nil || h.discarded || h.fs.pending[h.reqPath] != h {
+		return
+	}
+	delete(h.fs.pending, h.reqPath)
+	h.reqPath = stdpath.Join(dstPath, strings.TrimPrefix(h.reqPath, srcPath))
+	h.fs.pending[h.reqPath] = h
+}
+
+// upload writes the temp file back to the storage if it has been modified
+func (h *fileHandle) upload() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.file == nil || !h.dirty || h.discarded {
+		return nil
+	}
+	stat, err := h.file.Stat()
+	if err != nil {
+		return errors.WithStack(err)
+	}
+	dir, name := stdpath.Split(h.reqPath)
+	s := &stream.FileStream{
+		Obj: &model.Object{
+			Name:     name,
+			Size:     stat.Size(),
+			Modified: time.Now(),
+		},
+		Mimetype: utils.GetMimeType(name),
+		// a SectionReader is a model.File, so the stream won't be cached again,
+		// and closing the stream won't close the temp file
+		Reader: io.NewSectionReader(h.file, 0, stat.Size()),
+	}
+	if err = fs.PutDirectly(h.fs.ctx, dir, s); err != nil {
+		return err
+	}
+	h.dirty = false
+	return nil
+}
+
+// Close drops one reference, the last one uploads pending changes
+// and releases all resources
+func (h *fileHandle) Close() error {
+	h.mu.Lock()
+	h.refs--
+	last := h.refs <= 0
+	h.mu.Unlock()
+	if !last {
+		return nil
+	}
+	err := h.upload()
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.closeReader()
+	if h.file != nil {
+		h.fs.mu.Lock()
+		if h.fs.pending[h.reqPath] == h {
+			delete(h.fs.pending, h.reqPath)
+		}
+		h.fs.mu.Unlock()
+		if e := h.removeFile(); e != nil {
+			log.Warnf("fuse: failed remove temp file of [%s]: %+v", h.reqPath, e)
+		}
+	}
+	return err
+}
+
+func (h *fileHandle) removeFile() error {
+	name := h.file.Name()
+	err := h.file.Close()
+	h.file = nil
+	if e := os.Remove(name); err == nil {
+		err = e
+	}
+	return errors.WithStack(err)
+}
+
+func (f *Fs) addHandle(h *fileHandle, write bool) uint64 {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.nextFh++
+	f.handles[f.nextFh] = &openFile{fileHandle: h, write: write}
+	return f.nextFh
+}
+
+func (f *Fs) getHandle(fh uint64) *openFile {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.handles[fh]
+}
+
+func (f *Fs) removeHandle(fh uint64) *openFile {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	h := f.handles[fh]
+	delete(f.handles, fh)
+	return h
+}
+
+func (f *Fs) getPending(reqPath string) *fileHandle {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.pending[reqPath]
+}
+
+// movePending makes the files being written at or under srcPath follow its rename to dstPath
+func (f *Fs) movePending(srcPath, dstPath string) {
+	f.mu.Lock()
+	var moved []*fileHandle
+	for p, h := range f.pending {
+		if utils.IsSubPath(srcPath, p) {
+			moved = append(moved, h)
+		}
+	}
+	f.mu.Unlock()
+	for _, h := range moved {
+		h.move(srcPath, dstPath)
+	}
+}
+
+// pendingIn returns the files being written directly under dir by their names
+func (f *Fs) pendingIn(dir string) map[string]*fileHandle {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	ret := make(map[string]*fileHandle)
+	for p, h := range f.pending {
+		if stdpath.Dir(p) == dir {
+			ret[stdpath.Base(p)] = h
+		}
+	}
+	return ret
+}
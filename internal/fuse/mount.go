@@ -1,9 +1,21 @@
 package fuse
 
-import "github.com/winfsp/cgofuse/fuse"
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/winfsp/cgofuse/fuse"
+)
 
-func Mount(mountSrc, mountDst string, opts []string) {
-	fs := &Fs{RootFolder: mountSrc}
+// Mount mounts mountSrc of user at mountDst in the background.
+// The returned channel receives the result of the mount once the file system
+// is unmounted, either by host.Unmount or externally (e.g. fusermount -u).
+func Mount(user *model.User, mountSrc, mountDst string, opts []string) (*fuse.FileSystemHost, <-chan bool) {
+	fs := NewFs(user, mountSrc)
 	host := fuse.NewFileSystemHost(fs)
-	go host.Mount(mountDst, opts)
+	// Readdir fills the attributes of every entry
+	host.SetCapReaddirPlus(true)
+	done := make(chan bool, 1)
+	go func() {
+		done <- host.Mount(mountDst, opts)
+	}()
+	return host, done
 }
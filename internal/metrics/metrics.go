@@ -0,0 +1,72 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+)
+
+const namespace = "openlist"
+
+// Registry holds all the metrics served at /metrics
+var Registry = prometheus.NewRegistry()
+
+var (
+	DriverRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "driver_requests_total",
+		Help:      "Number of requests to storage drivers.",
+	}, []string{"driver", "method", "result"})
+	DriverRequestDuration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "driver_request_duration_seconds",
+		Help:      "Latency of requests to storage drivers.",
+		Buckets:   []float64{.01, .05, .1, .25, .5, 1, 2.5, 5, 10, 30, 60, 300},
+	}, []string{"driver", "method"})
+	ServedBytes = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "served_bytes_total",
+		Help:      "Number of bytes sent to clients.",
+	}, []string{"server"})
+	CacheRequests = prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "cache_requests_total",
+		Help:      "Number of cache lookups, the hit rate is the ratio of hits to all lookups.",
+	}, []string{"cache", "result"})
+)
+
+// ObserveDriverRequest records a request to the driver started at start
+func ObserveDriverRequest(driver, method string, start time.Time, err error) {
+	result := "success"
+	if err != nil {
+		result = "error"
+	}
+	DriverRequests.WithLabelValues(driver, method, result).Inc()
+	DriverRequestDuration.WithLabelValues(driver, method).Observe(time.Since(start).Seconds())
+}
+
+func CacheHit(cache string, hit bool) {
+	if hit {
+		CacheRequests.WithLabelValues(cache, "hit").Inc()
+	} else {
+		CacheRequests.WithLabelValues(cache, "miss").Inc()
+	}
+}
+
+func AddServedBytes(server string, n int64) {
+	if n > 0 {
+		ServedBytes.WithLabelValues(server).Add(float64(n))
+	}
+}
+
+func init() {
+	Registry.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+		DriverRequests,
+		DriverRequestDuration,
+		ServedBytes,
+		CacheRequests,
+	)
+}
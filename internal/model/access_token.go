@@ -0,0 +1,30 @@
+package model
+
+import "time"
+
+const (
+	AccessTokenPrefix = "olpat_"
+	// AccessTokenS3KeyPrefix is the prefix of the S3 access key ids of the tokens
+	AccessTokenS3KeyPrefix = "ol_"
+)
+
+// AccessToken is a personal access token of a user, only the hash of the token is saved.
+// Path limits the token to a sub-path of the BasePath of the user, empty for the BasePath,
+// and Permission to a subset of the permissions of the user
+type AccessToken struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	UserID     uint       `json:"user_id" gorm:"index"`
+	Name       string     `json:"name" binding:"required"`
+	KeyID      string     `json:"key_id" gorm:"type:varchar(32);uniqueIndex"` // the S3 access key id without the prefix
+	TokenHash  string     `json:"-"`
+	Path       string     `json:"path"`
+	Permission int32      `json:"permission"`
+	Admin      bool       `json:"admin"` // keeps the admin role of an admin user
+	ExpiresAt  *time.Time `json:"expires_at"`
+	LastUsedAt *time.Time `json:"last_used_at"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+func (t *AccessToken) Expired() bool {
+	return t.ExpiresAt != nil && !t.ExpiresAt.IsZero() && t.ExpiresAt.Before(time.Now())
+}
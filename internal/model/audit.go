@@ -0,0 +1,33 @@
+package model
+
+import "time"
+
+// AuditLog records an action of a user, Path is a mount path,
+// DstPath is the destination of copying, moving or renaming.
+type AuditLog struct {
+	ID       uint      `json:"id" gorm:"primaryKey"`
+	Time     time.Time `json:"time" gorm:"index"`
+	Username string    `json:"username" gorm:"index"`
+	Action   string    `json:"action" gorm:"index"`
+	Path     string    `json:"path" gorm:"type:text"`
+	DstPath  string    `json:"dst_path" gorm:"type:text"`
+	Detail   string    `json:"detail"`
+	Protocol string    `json:"protocol"`
+	Success  bool      `json:"success"`
+	Error    string    `json:"error" gorm:"type:text"`
+	IP       string    `json:"ip"`
+	Asn      uint      `json:"asn"`
+	Aso      string    `json:"aso"`
+	Country  string    `json:"country"`
+	Region   string    `json:"region"`
+	City     string    `json:"city"`
+	Isp      string    `json:"isp"`
+}
+
+type AuditLogFilter struct {
+	Username string `json:"username" form:"username"`
+	Path     string `json:"path" form:"path"` // prefix of the path
+	Action   string `json:"action" form:"action"`
+	Start    int64  `json:"start" form:"start"` // unix seconds, 0 for unlimited
+	End      int64  `json:"end" form:"end"`
+}
@@ -0,0 +1,44 @@
+package model
+
+import "time"
+
+const (
+	DedupeRunning     = "running"
+	DedupeDone        = "done"
+	DedupeFailed      = "failed"
+	DedupeCanceled    = "canceled"
+	DedupeInterrupted = "interrupted"
+)
+
+// DedupeReport is the result of a scan for duplicate files under Paths,
+// Paths is a newline separated list of mount paths
+type DedupeReport struct {
+	ID         uint       `json:"id" gorm:"primaryKey"`
+	Paths      string     `json:"paths" gorm:"type:text"`
+	Status     string     `json:"status"`
+	Error      string     `json:"error" gorm:"type:text"`
+	Scanned    int64      `json:"scanned"`
+	Groups     int64      `json:"groups"`
+	Duplicates int64      `json:"duplicates"`
+	Wasted     int64      `json:"wasted"`
+	StartedAt  time.Time  `json:"started_at"`
+	FinishedAt *time.Time `json:"finished_at"`
+}
+
+// DuplicateFile is a file of a duplicates group, the files of a group have the same GroupKey
+type DuplicateFile struct {
+	ID       uint      `json:"id" gorm:"primaryKey"`
+	ReportID uint      `json:"report_id" gorm:"index"`
+	GroupKey string    `json:"group_key" gorm:"index"`
+	Path     string    `json:"path" gorm:"type:text"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+	Removed  bool      `json:"removed"`
+	Linked   bool      `json:"linked"`
+}
+
+type DuplicateGroup struct {
+	Key   string          `json:"key"`
+	Size  int64           `json:"size"`
+	Files []DuplicateFile `json:"files"`
+}
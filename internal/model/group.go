@@ -0,0 +1,35 @@
+package model
+
+import "strings"
+
+// the sources of the external group names
+const (
+	GroupSourceLdap = "ldap"
+	GroupSourceSSO  = "sso"
+)
+
+// Group grants its permissions to all its users, see User.Permission for the bits
+type Group struct {
+	ID         uint   `json:"id" gorm:"primaryKey"`
+	Name       string `json:"name" gorm:"unique" binding:"required"`
+	Permission int32  `json:"permission"`
+	// the base path of the users created in the group without one
+	BasePath string `json:"base_path"`
+	// comma separated names of the LDAP/SSO groups mapped to this group
+	ExternalNames string `json:"external_names"`
+}
+
+// External reports whether the members of the group are managed by the LDAP/SSO group claims
+func (g *Group) External() bool {
+	return strings.TrimSpace(g.ExternalNames) != ""
+}
+
+// MatchExternal reports whether the LDAP/SSO group name maps to the group
+func (g *Group) MatchExternal(name string) bool {
+	for n := range strings.SplitSeq(g.ExternalNames, ",") {
+		if n = strings.TrimSpace(n); n != "" && strings.EqualFold(n, name) {
+			return true
+		}
+	}
+	return false
+}
@@ -1,5 +1,7 @@
 package model
 
+import "slices"
+
 type Meta struct {
 	ID            uint   `json:"id" gorm:"primaryKey"`
 	Path          string `json:"path" gorm:"unique" binding:"required"`
@@ -7,6 +9,8 @@ type Meta struct {
 	ReadUsersSub  bool   `json:"read_users_sub"`
 	WriteUsers    []uint `json:"write_users" gorm:"serializer:json"`
 	WriteUsersSub bool   `json:"write_users_sub"`
+	ReadGroups    []uint `json:"read_groups" gorm:"serializer:json"`
+	WriteGroups   []uint `json:"write_groups" gorm:"serializer:json"`
 	Password      string `json:"password"`
 	PSub          bool   `json:"p_sub"`
 	Write         bool   `json:"write"`
@@ -18,3 +22,21 @@ type Meta struct {
 	Header        string `json:"header"`
 	HeaderSub     bool   `json:"header_sub"`
 }
+
+// AllowRead reports whether the read users and groups of the meta allow the user,
+// ReadUsersSub also applies to the groups
+func (m *Meta) AllowRead(u *User) bool {
+	return allowMember(m.ReadUsers, m.ReadGroups, u)
+}
+
+// AllowWrite is AllowRead for the write users and groups
+func (m *Meta) AllowWrite(u *User) bool {
+	return allowMember(m.WriteUsers, m.WriteGroups, u)
+}
+
+func allowMember(users, groups []uint, u *User) bool {
+	if len(users) == 0 && len(groups) == 0 {
+		return true
+	}
+	return slices.Contains(users, u.ID) || slices.ContainsFunc(groups, u.InGroup)
+}
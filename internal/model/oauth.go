@@ -0,0 +1,39 @@
+package model
+
+import (
+	"slices"
+	"strings"
+	"time"
+)
+
+// OAuthClient is an application logging in with OpenList accounts,
+// RedirectURIs and Scopes are space separated, empty Scopes allows all scopes
+type OAuthClient struct {
+	ID           uint   `json:"id" gorm:"primaryKey"`
+	Name         string `json:"name"`
+	ClientID     string `json:"client_id" gorm:"type:varchar(64);uniqueIndex"`
+	SecretHash   string `json:"-"`
+	Public       bool   `json:"public"` // public clients have no secret and must use PKCE
+	RedirectURIs string `json:"redirect_uris" gorm:"type:text" binding:"required"`
+	Scopes       string `json:"scopes"`
+	Disabled     bool   `json:"disabled"`
+}
+
+func (c *OAuthClient) AllowRedirectURI(uri string) bool {
+	return uri != "" && slices.Contains(strings.Fields(c.RedirectURIs), uri)
+}
+
+func (c *OAuthClient) AllowScope(scope string) bool {
+	return strings.TrimSpace(c.Scopes) == "" || slices.Contains(strings.Fields(c.Scopes), scope)
+}
+
+// OAuthToken is a refresh token issued to a client, only the hash of the token is saved
+type OAuthToken struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	TokenHash string    `json:"-" gorm:"type:varchar(64);uniqueIndex"`
+	ClientID  string    `json:"client_id" gorm:"type:varchar(64);index"`
+	UserID    uint      `json:"user_id" gorm:"index"`
+	Scope     string    `json:"scope"`
+	ExpiresAt time.Time `json:"expires_at"`
+	CreatedAt time.Time `json:"created_at"`
+}
@@ -0,0 +1,16 @@
+package model
+
+// DirUsage is the total size and count of the files directly in a folder,
+// it's refreshed whenever the objects of the folder are updated
+type DirUsage struct {
+	ID     uint   `gorm:"primaryKey"`
+	Path   string `gorm:"index"`
+	Parent string `gorm:"index"`
+	Size   int64
+	Files  int64
+}
+
+type Usage struct {
+	Size  int64 `json:"size"`
+	Files int64 `json:"files"`
+}
@@ -2,7 +2,11 @@ package model
 
 import (
 	"fmt"
+	"math"
 	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/dlclark/regexp2"
 )
 
 type IndexProgress struct {
@@ -17,14 +21,97 @@ type SearchReq struct {
 	Keywords string `json:"keywords"`
 	// 0 for all, 1 for dir, 2 for file
 	Scope int `json:"scope"`
+	// the size range in bytes, 0 for no limit
+	MinSize        int64      `json:"min_size"`
+	MaxSize        int64      `json:"max_size"`
+	ModifiedAfter  *time.Time `json:"modified_after"`
+	ModifiedBefore *time.Time `json:"modified_before"`
+	// Exts are the extensions without dot, case-insensitive
+	Exts []string `json:"exts"`
+	// Types are the file types in conf, such as conf.VIDEO
+	Types []int `json:"types"`
+	// Restriction excludes the nodes the user can't access, it's set by the server
+	Restriction *SearchRestriction `json:"-" form:"-"`
 	PageReq
 }
 
+// SearchRestriction excludes the nodes in the denied folders and the hidden nodes
+// from the results, the searchers exclude them in their queries as far as they can
+type SearchRestriction struct {
+	Denied []SearchRule
+	Hidden []SearchRule
+}
+
+// SearchRule applies to the nodes whose parents are the folder, or its sub folders if Sub,
+// the sub folders in Except have their own metas and are not covered
+type SearchRule struct {
+	Path   string
+	Sub    bool
+	Except []string
+	// Patterns match the names of the hidden nodes
+	Patterns []*regexp2.Regexp
+}
+
+func (r SearchRule) Covers(parent string) bool {
+	if utils.PathEqual(r.Path, parent) {
+		return true
+	}
+	if !r.Sub || !utils.IsSubPath(r.Path, parent) {
+		return false
+	}
+	for _, except := range r.Except {
+		if utils.IsSubPath(except, parent) {
+			return false
+		}
+	}
+	return true
+}
+
+// Allows reports whether the node can be in the results
+func (r *SearchRestriction) Allows(node SearchNode) bool {
+	if r == nil {
+		return true
+	}
+	for _, rule := range r.Denied {
+		if rule.Covers(node.Parent) {
+			return false
+		}
+	}
+	for _, rule := range r.Hidden {
+		if !rule.Covers(node.Parent) {
+			continue
+		}
+		for _, pattern := range rule.Patterns {
+			if isMatch, _ := pattern.MatchString(node.Name); isMatch {
+				return false
+			}
+		}
+	}
+	return true
+}
+
 type SearchNode struct {
-	Parent string `json:"parent" gorm:"index"`
-	Name   string `json:"name"`
-	IsDir  bool   `json:"is_dir"`
-	Size   int64  `json:"size"`
+	Parent   string    `json:"parent" gorm:"index"`
+	Name     string    `json:"name"`
+	IsDir    bool      `json:"is_dir"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+	FileType int       `json:"file_type"`
+	// Content is the text extracted from the file for the searchers indexing content
+	Content string `json:"-" gorm:"-"`
+	// Highlights are the snippets of the content matching the keywords
+	Highlights []string `json:"highlights,omitempty" gorm:"-"`
+}
+
+func NewSearchNode(parent string, obj Obj) SearchNode {
+	return SearchNode{
+		Parent:   parent,
+		Name:     obj.GetName(),
+		IsDir:    obj.IsDir(),
+		Size:     obj.GetSize(),
+		Modified: obj.ModTime().UTC(),
+		FileType: utils.GetObjType(obj.GetName(), obj.IsDir()),
+	}
 }
 
 func (p *SearchReq) Validate() error {
@@ -34,9 +121,83 @@ func (p *SearchReq) Validate() error {
 	if p.PerPage < 1 {
 		return fmt.Errorf("per_page can't < 1")
 	}
+	if p.MinSize < 0 || p.MaxSize < 0 || p.MaxSize > 0 && p.MinSize > p.MaxSize {
+		return fmt.Errorf("invalid size range")
+	}
+	if p.ModifiedAfter != nil && p.ModifiedBefore != nil && p.ModifiedAfter.After(*p.ModifiedBefore) {
+		return fmt.Errorf("invalid modified range")
+	}
+	for i := range p.Exts {
+		p.Exts[i] = utils.Ext("." + p.Exts[i])
+	}
 	return nil
 }
 
 func (s *SearchNode) Type() string {
 	return "SearchNode"
 }
+
+// SearchFacets counts the search results by file type, extension,
+// size and modified time, the keys of the last two are in SearchSizeFacets and SearchModifiedFacets
+type SearchFacets struct {
+	Types    map[int]int64    `json:"types"`
+	Exts     map[string]int64 `json:"exts"`
+	Sizes    map[string]int64 `json:"sizes"`
+	Modified map[string]int64 `json:"modified"`
+	now      time.Time
+}
+
+type SearchFacetRange struct {
+	Key string
+	// Max is the exclusive upper bound
+	Max int64
+}
+
+var SearchSizeFacets = []SearchFacetRange{
+	{Key: "<1MB", Max: 1 << 20},
+	{Key: "1MB-100MB", Max: 100 << 20},
+	{Key: "100MB-1GB", Max: 1 << 30},
+	{Key: "1GB-10GB", Max: 10 << 30},
+	{Key: ">10GB", Max: math.MaxInt64},
+}
+
+// SearchModifiedFacets are ranges of the time since modified
+var SearchModifiedFacets = []SearchFacetRange{
+	{Key: "day", Max: int64(24 * time.Hour)},
+	{Key: "week", Max: int64(7 * 24 * time.Hour)},
+	{Key: "month", Max: int64(30 * 24 * time.Hour)},
+	{Key: "year", Max: int64(365 * 24 * time.Hour)},
+	{Key: "older", Max: math.MaxInt64},
+}
+
+func NewSearchFacets() *SearchFacets {
+	return &SearchFacets{
+		Types:    make(map[int]int64),
+		Exts:     make(map[string]int64),
+		Sizes:    make(map[string]int64),
+		Modified: make(map[string]int64),
+		now:      time.Now(),
+	}
+}
+
+func (f *SearchFacets) Add(node SearchNode) {
+	// the nodes indexed before the file type was added have no type
+	f.Types[utils.GetObjType(node.Name, node.IsDir)]++
+	if node.IsDir {
+		return
+	}
+	f.Exts[utils.Ext(node.Name)]++
+	f.Sizes[facetKey(SearchSizeFacets, node.Size)]++
+	if !node.Modified.IsZero() {
+		f.Modified[facetKey(SearchModifiedFacets, int64(f.now.Sub(node.Modified)))]++
+	}
+}
+
+func facetKey(ranges []SearchFacetRange, v int64) string {
+	for _, r := range ranges {
+		if v < r.Max {
+			return r.Key
+		}
+	}
+	return ranges[len(ranges)-1].Key
+}
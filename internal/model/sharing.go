@@ -1,6 +1,16 @@
 package model
 
-import "time"
+import (
+	stdpath "path"
+	"strings"
+	"time"
+)
+
+const (
+	SharingModeRead = iota
+	SharingModeUpload
+	SharingModeReadWrite
+)
 
 type SharingDB struct {
 	ID          string     `json:"id" gorm:"type:varchar(64);primaryKey"`
@@ -14,6 +24,18 @@ type SharingDB struct {
 	Remark      string     `json:"remark"`
 	Readme      string     `json:"readme" gorm:"type:text"`
 	Header      string     `json:"header" gorm:"type:text"`
+	Mode        int        `json:"mode"`
+	MaxFileSize int64      `json:"max_file_size"`
+	AllowedExts string     `json:"allowed_exts"`
+	UploadQuota int64      `json:"upload_quota"`
+	Uploaded    int64      `json:"uploaded"`
+	// MaxVisitors limits the number of unique ips
+	MaxVisitors int   `json:"max_visitors"`
+	Visitors    int   `json:"visitors"`
+	MaxServed   int64 `json:"max_served"`
+	Served      int64 `json:"served"`
+	// AllowedCountries is a comma separated list of country codes, empty means any
+	AllowedCountries string `json:"allowed_countries"`
 	Sort
 }
 
@@ -30,6 +52,9 @@ func (s *Sharing) Valid() bool {
 	if s.MaxAccessed > 0 && s.Accessed >= s.MaxAccessed {
 		return false
 	}
+	if s.MaxServed > 0 && s.Served >= s.MaxServed {
+		return false
+	}
 	if len(s.Files) == 0 {
 		return false
 	}
@@ -45,3 +70,91 @@ func (s *Sharing) Valid() bool {
 func (s *Sharing) Verify(pwd string) bool {
 	return s.Pwd == "" || s.Pwd == pwd
 }
+
+func (s *Sharing) CanRead() bool {
+	return s.Mode != SharingModeUpload
+}
+
+func (s *Sharing) CanUpload() bool {
+	return s.Mode == SharingModeUpload || s.Mode == SharingModeReadWrite
+}
+
+// AllowExt reports whether a file named name may be uploaded to the sharing,
+// AllowedExts is a comma separated list of extensions, empty means any
+func (s *Sharing) AllowExt(name string) bool {
+	if strings.TrimSpace(s.AllowedExts) == "" {
+		return true
+	}
+	ext := strings.ToLower(strings.TrimPrefix(stdpath.Ext(name), "."))
+	for _, e := range strings.Split(s.AllowedExts, ",") {
+		if strings.ToLower(strings.TrimPrefix(strings.TrimSpace(e), ".")) == ext {
+			return true
+		}
+	}
+	return false
+}
+
+func (s *Sharing) AllowCountry(code string) bool {
+	if strings.TrimSpace(s.AllowedCountries) == "" {
+		return true
+	}
+	for _, c := range strings.Split(s.AllowedCountries, ",") {
+		if code != "" && strings.EqualFold(strings.TrimSpace(c), code) {
+			return true
+		}
+	}
+	return false
+}
+
+// AllowSize reports whether a file of the given size fits the size and quota limits
+func (s *Sharing) AllowSize(size int64) bool {
+	if s.MaxFileSize > 0 && size > s.MaxFileSize {
+		return false
+	}
+	if s.UploadQuota > 0 && s.Uploaded+size > s.UploadQuota {
+		return false
+	}
+	return true
+}
+
+type SharingUpload struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	SharingID string    `json:"sharing_id" gorm:"type:varchar(64);index"`
+	Path      string    `json:"path" gorm:"type:text"`
+	Size      int64     `json:"size"`
+	IP        string    `json:"ip"`
+	CreatedAt time.Time `json:"created_at"`
+	// Reservation is the hash of the destination path while the file is being uploaded,
+	// it's unique so that a name can't be written by two uploads at once
+	Reservation *string `json:"-" gorm:"uniqueIndex;size:64"`
+}
+
+// SharingAccess records an access to a sharing, Path is the path inside the sharing
+type SharingAccess struct {
+	ID          uint      `json:"id" gorm:"primaryKey"`
+	SharingID   string    `json:"sharing_id" gorm:"type:varchar(64);index:idx_sharing_access"`
+	Time        time.Time `json:"time" gorm:"index:idx_sharing_access"`
+	IP          string    `json:"ip" gorm:"index"`
+	Asn         uint      `json:"asn"`
+	Aso         string    `json:"aso"`
+	Country     string    `json:"country"`
+	CountryCode string    `json:"country_code"`
+	UserAgent   string    `json:"user_agent" gorm:"type:text"`
+	Path        string    `json:"path" gorm:"type:text"`
+	Bytes       int64     `json:"bytes"`
+}
+
+type SharingStat struct {
+	Time     time.Time `json:"time"`
+	Accesses int64     `json:"accesses"`
+	Visitors int64     `json:"visitors"`
+	Bytes    int64     `json:"bytes"`
+}
+
+type SharingStats struct {
+	Series    []SharingStat    `json:"series"`
+	Countries map[string]int64 `json:"countries"`
+	Accesses  int64            `json:"accesses"`
+	Visitors  int64            `json:"visitors"`
+	Bytes     int64            `json:"bytes"`
+}
@@ -19,6 +19,10 @@ type Storage struct {
 	Disabled            bool      `json:"disabled"` // if disabled
 	DisableIndex        bool      `json:"disable_index"`
 	EnableSign          bool      `json:"enable_sign"`
+	EnableTrash         bool      `json:"enable_trash"`
+	MaxVersions         int       `json:"max_versions"`
+	IndexInterval       int       `json:"index_interval"` // minutes between rescans for the search index
+	WatchChanges        bool      `json:"watch_changes"`  // index the changes reported by the driver, or poll them if it can't
 	Sort
 	Proxy
 }
@@ -0,0 +1,59 @@
+package model
+
+import "time"
+
+const (
+	// SyncMirror makes the destination the same as the source, extra objects are removed
+	SyncMirror = "mirror"
+	// SyncCopy copies the new and changed objects of the source to the destination
+	SyncCopy = "copy"
+	// SyncBidirectional copies the new and changed objects in both directions,
+	// removals are not synced as there is no state of the last run
+	SyncBidirectional = "bidirectional"
+)
+
+const (
+	SyncCompareSize  = "size"
+	SyncCompareMtime = "mtime"
+	SyncCompareHash  = "hash"
+)
+
+// conflict policies of the bidirectional sync, used when a file differs on both sides
+const (
+	SyncConflictNewer = "newer"
+	SyncConflictSrc   = "src"
+	SyncConflictDst   = "dst"
+	SyncConflictSkip  = "skip"
+)
+
+// SyncJob syncs two mount paths on the cron schedule, an empty Cron means the job is run manually
+type SyncJob struct {
+	ID       uint   `json:"id" gorm:"primaryKey"`
+	Name     string `json:"name"`
+	SrcPath  string `json:"src_path" binding:"required"`
+	DstPath  string `json:"dst_path" binding:"required"`
+	Mode     string `json:"mode"`
+	Compare  string `json:"compare"`
+	Conflict string `json:"conflict"`
+	Cron     string `json:"cron"`
+	Disabled bool   `json:"disabled"`
+
+	LastRun     *time.Time `json:"last_run"`
+	LastActions int        `json:"last_actions"`
+	LastError   string     `json:"last_error" gorm:"type:text"`
+}
+
+// SyncAction is an operation planned by a sync job, paths are mount paths
+type SyncAction struct {
+	Action string `json:"action"`
+	Src    string `json:"src,omitempty"`
+	Dst    string `json:"dst"`
+	IsDir  bool   `json:"is_dir"`
+	Size   int64  `json:"size"`
+	Reason string `json:"reason"`
+}
+
+const (
+	SyncActionCopy   = "copy"
+	SyncActionRemove = "remove"
+)
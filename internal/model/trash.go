@@ -0,0 +1,24 @@
+package model
+
+import "time"
+
+// TrashItem is an object removed from a storage with the recycle bin enabled.
+// TrashPath is the actual path of the object inside the recycle bin of the storage,
+// it is empty for a tombstone, which only records the deletion
+// on storages that can't move objects.
+type TrashItem struct {
+	ID        uint      `json:"id" gorm:"primaryKey"`
+	StorageID uint      `json:"storage_id" gorm:"index"`
+	MountPath string    `json:"mount_path"`
+	Path      string    `json:"path" gorm:"type:text"` // original actual path
+	Name      string    `json:"name"`
+	IsDir     bool      `json:"is_dir"`
+	Size      int64     `json:"size"`
+	TrashPath string    `json:"trash_path" gorm:"type:text"`
+	Deleter   string    `json:"deleter"`
+	DeletedAt time.Time `json:"deleted_at" gorm:"index"`
+}
+
+func (t *TrashItem) IsTombstone() bool {
+	return t.TrashPath == ""
+}
@@ -4,6 +4,7 @@ import (
 	"encoding/binary"
 	"encoding/json"
 	"fmt"
+	"slices"
 	"time"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/errs"
@@ -69,6 +70,14 @@ type User struct {
 	SsoID      string `json:"sso_id"` // unique by sso platform
 	Authn      string `gorm:"type:text" json:"-"`
 	AllowLdap  bool   `json:"allow_ldap" gorm:"default:true"`
+	// quotas of the files under BasePath, 0 means unlimited
+	QuotaBytes int64  `json:"quota_bytes"`
+	QuotaFiles int64  `json:"quota_files"`
+	GroupIDs   []uint `json:"group_ids" gorm:"serializer:json"`
+	// the groups added by each LDAP/SSO source, a source only removes the groups it added
+	ExternalGroupIDs map[string][]uint `json:"-" gorm:"serializer:json"`
+	// the union of the permissions of the groups, filled when the user is loaded
+	GroupPermission int32 `json:"-" gorm:"-"`
 }
 
 func (u *User) IsGuest() bool {
@@ -79,6 +88,15 @@ func (u *User) IsAdmin() bool {
 	return u.Role == ADMIN
 }
 
+// EffectivePermission is the union of the permissions of the user and its groups
+func (u *User) EffectivePermission() int32 {
+	return u.Permission | u.GroupPermission
+}
+
+func (u *User) InGroup(id uint) bool {
+	return slices.Contains(u.GroupIDs, id)
+}
+
 func (u *User) ValidateRawPassword(password string) error {
 	return u.ValidatePwdStaticHash(StaticHash(password))
 }
@@ -105,7 +123,7 @@ func CanSeeHides(permission int32) bool {
 }
 
 func (u *User) CanSeeHides() bool {
-	return CanSeeHides(u.Permission)
+	return CanSeeHides(u.EffectivePermission())
 }
 
 func CanAccessWithoutPassword(permission int32) bool {
@@ -113,7 +131,7 @@ func CanAccessWithoutPassword(permission int32) bool {
 }
 
 func (u *User) CanAccessWithoutPassword() bool {
-	return CanAccessWithoutPassword(u.Permission)
+	return CanAccessWithoutPassword(u.EffectivePermission())
 }
 
 func CanAddOfflineDownloadTasks(permission int32) bool {
@@ -121,7 +139,7 @@ func CanAddOfflineDownloadTasks(permission int32) bool {
 }
 
 func (u *User) CanAddOfflineDownloadTasks() bool {
-	return CanAddOfflineDownloadTasks(u.Permission)
+	return CanAddOfflineDownloadTasks(u.EffectivePermission())
 }
 
 func CanWriteContent(permission int32) bool {
@@ -129,7 +147,7 @@ func CanWriteContent(permission int32) bool {
 }
 
 func (u *User) CanWriteContent() bool {
-	return CanWriteContent(u.Permission)
+	return CanWriteContent(u.EffectivePermission())
 }
 
 func CanRename(permission int32) bool {
@@ -137,7 +155,7 @@ func CanRename(permission int32) bool {
 }
 
 func (u *User) CanRename() bool {
-	return CanRename(u.Permission)
+	return CanRename(u.EffectivePermission())
 }
 
 func CanMove(permission int32) bool {
@@ -145,7 +163,7 @@ func CanMove(permission int32) bool {
 }
 
 func (u *User) CanMove() bool {
-	return CanMove(u.Permission)
+	return CanMove(u.EffectivePermission())
 }
 
 func CanCopy(permission int32) bool {
@@ -153,7 +171,7 @@ func CanCopy(permission int32) bool {
 }
 
 func (u *User) CanCopy() bool {
-	return CanCopy(u.Permission)
+	return CanCopy(u.EffectivePermission())
 }
 
 func CanRemove(permission int32) bool {
@@ -161,7 +179,7 @@ func CanRemove(permission int32) bool {
 }
 
 func (u *User) CanRemove() bool {
-	return CanRemove(u.Permission)
+	return CanRemove(u.EffectivePermission())
 }
 
 func CanWebdavRead(permission int32) bool {
@@ -169,7 +187,7 @@ func CanWebdavRead(permission int32) bool {
 }
 
 func (u *User) CanWebdavRead() bool {
-	return CanWebdavRead(u.Permission)
+	return CanWebdavRead(u.EffectivePermission())
 }
 
 func CanWebdavManage(permission int32) bool {
@@ -177,7 +195,7 @@ func CanWebdavManage(permission int32) bool {
 }
 
 func (u *User) CanWebdavManage() bool {
-	return CanWebdavManage(u.Permission)
+	return CanWebdavManage(u.EffectivePermission())
 }
 
 func CanFTPAccess(permission int32) bool {
@@ -185,7 +203,7 @@ func CanFTPAccess(permission int32) bool {
 }
 
 func (u *User) CanFTPAccess() bool {
-	return CanFTPAccess(u.Permission)
+	return CanFTPAccess(u.EffectivePermission())
 }
 
 func CanFTPManage(permission int32) bool {
@@ -193,7 +211,7 @@ func CanFTPManage(permission int32) bool {
 }
 
 func (u *User) CanFTPManage() bool {
-	return CanFTPManage(u.Permission)
+	return CanFTPManage(u.EffectivePermission())
 }
 
 func CanReadArchives(permission int32) bool {
@@ -201,7 +219,7 @@ func CanReadArchives(permission int32) bool {
 }
 
 func (u *User) CanReadArchives() bool {
-	return CanReadArchives(u.Permission)
+	return CanReadArchives(u.EffectivePermission())
 }
 
 func CanDecompress(permission int32) bool {
@@ -209,7 +227,7 @@ func CanDecompress(permission int32) bool {
 }
 
 func (u *User) CanDecompress() bool {
-	return CanDecompress(u.Permission)
+	return CanDecompress(u.EffectivePermission())
 }
 
 func CanShare(permission int32) bool {
@@ -217,7 +235,7 @@ func CanShare(permission int32) bool {
 }
 
 func (u *User) CanShare() bool {
-	return CanShare(u.Permission)
+	return CanShare(u.EffectivePermission())
 }
 
 func CanCustomizeShareID(permission int32) bool {
@@ -225,7 +243,11 @@ func CanCustomizeShareID(permission int32) bool {
 }
 
 func (u *User) CanCustomizeShareID() bool {
-	return CanCustomizeShareID(u.Permission)
+	return CanCustomizeShareID(u.EffectivePermission())
+}
+
+func (u *User) HasQuota() bool {
+	return u.QuotaBytes > 0 || u.QuotaFiles > 0
 }
 
 func (u *User) JoinPath(reqPath string) (string, error) {
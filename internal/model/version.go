@@ -0,0 +1,12 @@
+package model
+
+import "time"
+
+// FileVersion is an old version of an overwritten file,
+// Version is the time of the overwriting in unix nanoseconds
+type FileVersion struct {
+	Version  string    `json:"version"`
+	Path     string    `json:"path"`
+	Size     int64     `json:"size"`
+	Modified time.Time `json:"modified"`
+}
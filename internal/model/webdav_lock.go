@@ -0,0 +1,21 @@
+package model
+
+// WebDAVLock is a WebDAV lock shared by all the instances using the same database,
+// times are unix nanoseconds
+type WebDAVLock struct {
+	Token string `gorm:"primaryKey"`
+	Root  string `gorm:"type:text"`
+	// RootHash is the unique index of Root, which can be too long to be indexed
+	RootHash  string `gorm:"uniqueIndex;size:64"`
+	ZeroDepth bool
+	OwnerXML  string `gorm:"type:text"`
+	// Duration is negative for the locks that never expire
+	Duration int64
+	Expiry   int64
+	// HeldUntil is set while a request is holding the lock, it bounds the hold of a crashed instance
+	HeldUntil int64
+}
+
+func (l *WebDAVLock) Held(now int64) bool {
+	return l.HeldUntil > now
+}
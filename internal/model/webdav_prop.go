@@ -0,0 +1,11 @@
+package model
+
+// DeadProp is a WebDAV property set by PROPPATCH, it's stored by the mount path of the object
+type DeadProp struct {
+	ID       uint   `gorm:"primaryKey"`
+	Path     string `gorm:"index"`
+	Space    string
+	Local    string
+	Lang     string
+	InnerXML string `gorm:"type:text"`
+}
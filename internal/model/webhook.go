@@ -0,0 +1,46 @@
+package model
+
+import (
+	"strings"
+	"time"
+)
+
+// Webhook is an HTTP endpoint notified of the events it subscribes to,
+// Events is a comma separated list of event names, empty for all events
+type Webhook struct {
+	ID       uint   `json:"id" gorm:"primaryKey"`
+	Name     string `json:"name"`
+	URL      string `json:"url" binding:"required"`
+	Secret   string `json:"secret"`
+	Events   string `json:"events"`
+	Disabled bool   `json:"disabled"`
+}
+
+func (w *Webhook) Subscribes(event string) bool {
+	if w.Disabled {
+		return false
+	}
+	if strings.TrimSpace(w.Events) == "" {
+		return true
+	}
+	for e := range strings.SplitSeq(w.Events, ",") {
+		if strings.TrimSpace(e) == event {
+			return true
+		}
+	}
+	return false
+}
+
+// WebhookDelivery records the delivery of an event to a webhook
+type WebhookDelivery struct {
+	ID         uint      `json:"id" gorm:"primaryKey"`
+	WebhookID  uint      `json:"webhook_id" gorm:"index"`
+	Event      string    `json:"event"`
+	Payload    string    `json:"payload" gorm:"type:text"`
+	Attempts   int       `json:"attempts"`
+	StatusCode int       `json:"status_code"`
+	Error      string    `json:"error" gorm:"type:text"`
+	Success    bool      `json:"success"`
+	CreatedAt  time.Time `json:"created_at" gorm:"index"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
@@ -63,6 +63,9 @@ var putFile = func(ctx context.Context, dstDirPath string, fs *stream.FileStream
 	if storage.Config().NoUpload {
 		return errs.UploadNotSupported
 	}
+	if op.IsSystemPath(storage, dstDirActualPath) {
+		return errs.PermissionDenied
+	}
 	return op.Put(ctx, storage, dstDirActualPath, fs, up)
 }
 
@@ -283,6 +286,13 @@ func (m *Manager) remove(s *Session) {
 	}
 }
 
+// Count returns the number of live sessions.
+func (m *Manager) Count() int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return len(m.byID)
+}
+
 func (m *Manager) get(user *model.User, id string) (*Session, error) {
 	m.mu.Lock()
 	s, ok := m.byID[id]
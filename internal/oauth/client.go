@@ -0,0 +1,81 @@
+package oauth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils/random"
+	"github.com/pkg/errors"
+)
+
+func hash(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func GetClients(pageIndex, pageSize int) ([]model.OAuthClient, int64, error) {
+	return db.GetOAuthClients(pageIndex, pageSize)
+}
+
+// CreateClient creates the client with a random client id, the secret is only returned here
+func CreateClient(c *model.OAuthClient) (secret string, err error) {
+	c.ID = 0
+	c.ClientID = random.String(24)
+	if !c.Public {
+		secret = random.String(48)
+		c.SecretHash = hash(secret)
+	}
+	return secret, db.CreateOAuthClient(c)
+}
+
+func UpdateClient(c *model.OAuthClient) error {
+	old, err := db.GetOAuthClientById(c.ID)
+	if err != nil {
+		return err
+	}
+	c.ClientID, c.SecretHash = old.ClientID, old.SecretHash
+	if c.Public {
+		c.SecretHash = ""
+	}
+	return db.UpdateOAuthClient(c)
+}
+
+func ResetClientSecret(id uint) (string, error) {
+	c, err := db.GetOAuthClientById(id)
+	if err != nil {
+		return "", err
+	}
+	if c.Public {
+		return "", errors.New("public clients have no secret")
+	}
+	secret := random.String(48)
+	c.SecretHash = hash(secret)
+	return secret, db.UpdateOAuthClient(c)
+}
+
+func DeleteClientById(id uint) error {
+	return db.DeleteOAuthClientById(id)
+}
+
+func GetClient(clientID string) (*model.OAuthClient, error) {
+	c, err := db.GetOAuthClientByClientId(clientID)
+	if err != nil || c.Disabled {
+		return nil, NewError(ErrInvalidClient, "unknown client")
+	}
+	return c, nil
+}
+
+// AuthenticateClient checks the secret of a confidential client, public clients have no secret
+func AuthenticateClient(clientID, secret string) (*model.OAuthClient, error) {
+	c, err := GetClient(clientID)
+	if err != nil {
+		return nil, err
+	}
+	if !c.Public && subtle.ConstantTimeCompare([]byte(hash(secret)), []byte(c.SecretHash)) != 1 {
+		return nil, NewError(ErrInvalidClient, "client authentication failed")
+	}
+	return c, nil
+}
@@ -0,0 +1,94 @@
+package oauth
+
+import (
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils/random"
+	"github.com/OpenListTeam/go-cache"
+)
+
+const CodeExpire = 10 * time.Minute
+
+// AuthRequest is the authorization request of a client approved by the user
+type AuthRequest struct {
+	ResponseType        string `json:"response_type" form:"response_type"`
+	ClientID            string `json:"client_id" form:"client_id"`
+	RedirectURI         string `json:"redirect_uri" form:"redirect_uri"`
+	Scope               string `json:"scope" form:"scope"`
+	State               string `json:"state" form:"state"`
+	Nonce               string `json:"nonce" form:"nonce"`
+	CodeChallenge       string `json:"code_challenge" form:"code_challenge"`
+	CodeChallengeMethod string `json:"code_challenge_method" form:"code_challenge_method"`
+}
+
+type authCode struct {
+	AuthRequest
+	UserID   uint
+	AuthTime time.Time
+}
+
+var codeCache = cache.NewMemCache[*authCode]()
+
+// Validate checks the request against the registered client and normalizes the scope
+func (r *AuthRequest) Validate() (*model.OAuthClient, error) {
+	client, err := GetClient(r.ClientID)
+	if err != nil {
+		return nil, err
+	}
+	if !client.AllowRedirectURI(r.RedirectURI) {
+		return nil, NewError(ErrInvalidRequest, "redirect_uri is not registered")
+	}
+	if r.ResponseType != "code" {
+		return nil, NewError(ErrUnsupportedResponse, "only the authorization code flow is supported")
+	}
+	if r.Scope, err = FilterScope(client, r.Scope); err != nil {
+		return nil, err
+	}
+	// an empty method means plain, which doesn't protect a leaked code
+	if r.CodeChallenge != "" && r.CodeChallengeMethod != "S256" || r.CodeChallenge == "" && r.CodeChallengeMethod != "" {
+		return nil, NewError(ErrInvalidRequest, "code_challenge_method must be S256")
+	}
+	if client.Public && r.CodeChallenge == "" {
+		return nil, NewError(ErrInvalidRequest, "public clients must use PKCE")
+	}
+	return client, nil
+}
+
+// NewCode issues an authorization code of the validated request for the user
+func NewCode(r *AuthRequest, user *model.User) string {
+	code := random.String(48)
+	codeCache.Set(code, &authCode{
+		AuthRequest: *r,
+		UserID:      user.ID,
+		AuthTime:    time.Now(),
+	}, cache.WithEx[*authCode](CodeExpire))
+	return code
+}
+
+// exchangeCode consumes the code, the client and the redirect uri must match the authorization request
+func exchangeCode(client *model.OAuthClient, code, redirectURI, verifier string) (*authCode, error) {
+	c, ok := codeCache.Get(code)
+	// only the request deleting the code may use it
+	if !ok || codeCache.Del(code) == 0 {
+		return nil, NewError(ErrInvalidGrant, "invalid or expired code")
+	}
+	if c.ClientID != client.ClientID || c.RedirectURI != redirectURI {
+		return nil, NewError(ErrInvalidGrant, "the code was issued to another client")
+	}
+	if c.CodeChallenge != "" && !verifyChallenge(c.CodeChallenge, verifier) {
+		return nil, NewError(ErrInvalidGrant, "invalid code_verifier")
+	}
+	return c, nil
+}
+
+func verifyChallenge(challenge, verifier string) bool {
+	if verifier == "" {
+		return false
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	return subtle.ConstantTimeCompare([]byte(challenge), []byte(base64.RawURLEncoding.EncodeToString(sum[:]))) == 1
+}
@@ -0,0 +1,29 @@
+package oauth
+
+import "fmt"
+
+// the error codes of RFC 6749
+const (
+	ErrInvalidRequest       = "invalid_request"
+	ErrInvalidClient        = "invalid_client"
+	ErrInvalidGrant         = "invalid_grant"
+	ErrInvalidScope         = "invalid_scope"
+	ErrUnauthorizedClient   = "unauthorized_client"
+	ErrUnsupportedGrantType = "unsupported_grant_type"
+	ErrUnsupportedResponse  = "unsupported_response_type"
+	ErrServerError          = "server_error"
+	ErrAccessDenied         = "access_denied"
+)
+
+type Error struct {
+	Code        string `json:"error"`
+	Description string `json:"error_description,omitempty"`
+}
+
+func NewError(code, format string, a ...any) *Error {
+	return &Error{Code: code, Description: fmt.Sprintf(format, a...)}
+}
+
+func (e *Error) Error() string {
+	return e.Code + ": " + e.Description
+}
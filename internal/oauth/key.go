@@ -0,0 +1,87 @@
+package oauth
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/OpenListTeam/OpenList/v4/cmd/flags"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+var (
+	keyMu sync.Mutex
+	key   *rsa.PrivateKey
+	keyID string
+)
+
+// signingKey loads the key signing the tokens from the data directory, it is generated on the first use
+func signingKey() (*rsa.PrivateKey, string, error) {
+	keyMu.Lock()
+	defer keyMu.Unlock()
+	if key != nil {
+		return key, keyID, nil
+	}
+	dir := filepath.Join(flags.DataDir, "oauth")
+	if err := utils.CreateNestedDirectory(dir); err != nil {
+		return nil, "", errors.WithMessage(err, "failed create oauth directory")
+	}
+	path := filepath.Join(dir, "oidc_rsa_key")
+	k, err := loadKey(path)
+	if err != nil {
+		if k, err = rsa.GenerateKey(rand.Reader, 2048); err != nil {
+			return nil, "", errors.Wrap(err, "failed generate oidc key")
+		}
+		block := &pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(k)}
+		if err = os.WriteFile(path, pem.EncodeToMemory(block), 0600); err != nil {
+			return nil, "", errors.Wrap(err, "failed write oidc key")
+		}
+	}
+	sum := sha256.Sum256(k.PublicKey.N.Bytes())
+	key, keyID = k, base64.RawURLEncoding.EncodeToString(sum[:16])
+	return key, keyID, nil
+}
+
+func loadKey(path string) (*rsa.PrivateKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("invalid pem")
+	}
+	return x509.ParsePKCS1PrivateKey(block.Bytes)
+}
+
+type JWK struct {
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+}
+
+func JWKS() (map[string][]JWK, error) {
+	k, kid, err := signingKey()
+	if err != nil {
+		return nil, err
+	}
+	return map[string][]JWK{"keys": {{
+		Kty: "RSA",
+		Use: "sig",
+		Alg: "RS256",
+		Kid: kid,
+		N:   base64.RawURLEncoding.EncodeToString(k.PublicKey.N.Bytes()),
+		E:   base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.PublicKey.E)).Bytes()),
+	}}}, nil
+}
@@ -0,0 +1,103 @@
+package oauth
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"os"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/cmd/flags"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/glebarez/sqlite"
+	"github.com/golang-jwt/jwt/v4"
+	"gorm.io/gorm"
+)
+
+func TestMain(m *testing.M) {
+	conf.Conf = conf.DefaultConfig("data")
+	dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database: " + err.Error())
+	}
+	db.Init(dB)
+	dir, err := os.MkdirTemp("", "oauth")
+	if err != nil {
+		panic(err)
+	}
+	flags.DataDir = dir
+	code := m.Run()
+	_ = os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+func TestAuthorizationCodeFlow(t *testing.T) {
+	user := &model.User{Username: "oauth", BasePath: "/oauth", Role: model.GENERAL, Permission: 1<<3 | 1<<7 | 1<<14}
+	if err := op.CreateUser(user); err != nil {
+		t.Fatalf("failed create user: %+v", err)
+	}
+	client := &model.OAuthClient{Name: "tool", Public: true, RedirectURIs: "http://tool/cb", Scopes: "openid profile read write"}
+	if _, err := CreateClient(client); err != nil {
+		t.Fatalf("failed create client: %+v", err)
+	}
+	req := &AuthRequest{ResponseType: "code", ClientID: client.ClientID, RedirectURI: "http://tool/cb", Scope: "openid read share"}
+	if _, err := req.Validate(); err == nil {
+		t.Fatal("expected the share scope to be rejected")
+	}
+	req.Scope = "openid profile read write"
+	if _, err := req.Validate(); err == nil {
+		t.Fatal("expected a public client without PKCE to be rejected")
+	}
+	req.CodeChallenge, req.CodeChallengeMethod = "a-verifier-of-the-pkce-challenge-that-is-long-enough", "plain"
+	if _, err := req.Validate(); err == nil {
+		t.Fatal("expected the plain PKCE method to be rejected")
+	}
+	verifier := "a-verifier-of-the-pkce-challenge-that-is-long-enough"
+	sum := sha256.Sum256([]byte(verifier))
+	req.CodeChallenge, req.CodeChallengeMethod = base64.RawURLEncoding.EncodeToString(sum[:]), "S256"
+	if _, err := req.Validate(); err != nil {
+		t.Fatalf("failed validate request: %+v", err)
+	}
+	code := NewCode(req, user)
+	if _, err := ExchangeCode("http://openlist", client, code, "http://tool/cb", "wrong"); err == nil {
+		t.Fatal("expected a wrong verifier to be rejected")
+	}
+	code = NewCode(req, user)
+	resp, err := ExchangeCode("http://openlist", client, code, "http://tool/cb", verifier)
+	if err != nil {
+		t.Fatalf("failed exchange code: %+v", err)
+	}
+	if _, err = ExchangeCode("http://openlist", client, code, "http://tool/cb", verifier); err == nil {
+		t.Error("expected the code to be used only once")
+	}
+
+	claims, err := ParseAccessToken(resp.AccessToken)
+	if err != nil {
+		t.Fatalf("failed parse access token: %+v", err)
+	}
+	u := TokenUser(user, claims.Scope)
+	if !u.CanWriteContent() || !u.CanRemove() || u.CanShare() {
+		t.Errorf("expected write permissions without share, got %b", u.Permission)
+	}
+	k, _, _ := signingKey()
+	var id IDClaims
+	if _, err = jwt.ParseWithClaims(resp.IDToken, &id, func(*jwt.Token) (interface{}, error) { return &k.PublicKey, nil }); err != nil {
+		t.Fatalf("failed parse id token: %+v", err)
+	}
+	if id.PreferredUsername != "oauth" || id.BasePath != "/oauth" || !id.VerifyAudience(client.ClientID, true) {
+		t.Errorf("unexpected id token claims: %+v", id)
+	}
+
+	refreshed, err := Refresh("http://openlist", client, resp.RefreshToken, "read")
+	if err != nil {
+		t.Fatalf("failed refresh: %+v", err)
+	}
+	if refreshed.Scope != "read" || refreshed.IDToken != "" {
+		t.Errorf("expected the scope to be narrowed to read, got %+v", refreshed)
+	}
+	if _, err = Refresh("http://openlist", client, resp.RefreshToken, ""); err == nil {
+		t.Error("expected the rotated refresh token to be rejected")
+	}
+}
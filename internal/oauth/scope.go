@@ -0,0 +1,63 @@
+package oauth
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+)
+
+const (
+	ScopeOpenID          = "openid"
+	ScopeProfile         = "profile"
+	ScopeRead            = "read"
+	ScopeWrite           = "write"
+	ScopeShare           = "share"
+	ScopeOfflineDownload = "offline_download"
+)
+
+var Scopes = []string{ScopeOpenID, ScopeProfile, ScopeRead, ScopeWrite, ScopeShare, ScopeOfflineDownload}
+
+// scopePermissions maps the scopes to the bits of model.User.Permission they grant
+var scopePermissions = map[string]int32{
+	ScopeRead:            1<<0 | 1<<1 | 1<<8 | 1<<10 | 1<<12,
+	ScopeWrite:           1<<3 | 1<<4 | 1<<5 | 1<<6 | 1<<7 | 1<<9 | 1<<11 | 1<<13,
+	ScopeShare:           1<<14 | 1<<15,
+	ScopeOfflineDownload: 1 << 2,
+}
+
+func HasScope(scope, s string) bool {
+	return slices.Contains(strings.Fields(scope), s)
+}
+
+// FilterScope checks the requested scope against the scopes allowed for the client
+func FilterScope(client *model.OAuthClient, scope string) (string, error) {
+	var ret []string
+	for _, s := range strings.Fields(scope) {
+		if !slices.Contains(Scopes, s) || !client.AllowScope(s) {
+			return "", NewError(ErrInvalidScope, "scope [%s] is not allowed", s)
+		}
+		if !slices.Contains(ret, s) {
+			ret = append(ret, s)
+		}
+	}
+	return strings.Join(ret, " "), nil
+}
+
+// TokenUser returns a copy of the user limited to the permissions granted by the scope,
+// a token never carries the admin role
+func TokenUser(user *model.User, scope string) *model.User {
+	u := *user
+	var mask int32
+	for _, s := range strings.Fields(scope) {
+		mask |= scopePermissions[s]
+	}
+	if u.IsAdmin() {
+		u.Role = model.GENERAL
+		u.Permission = mask
+	} else {
+		u.Permission = u.EffectivePermission() & mask
+	}
+	u.GroupPermission = 0
+	return &u
+}
@@ -0,0 +1,198 @@
+package oauth
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils/random"
+	"github.com/golang-jwt/jwt/v4"
+	"github.com/pkg/errors"
+)
+
+var (
+	AccessTokenExpire  = time.Hour
+	RefreshTokenExpire = 30 * 24 * time.Hour
+)
+
+type AccessClaims struct {
+	Scope    string `json:"scope"`
+	ClientID string `json:"client_id"`
+	PwdTS    int64  `json:"pwd_ts"`
+	jwt.RegisteredClaims
+}
+
+type IDClaims struct {
+	Nonce             string           `json:"nonce,omitempty"`
+	AuthTime          *jwt.NumericDate `json:"auth_time,omitempty"`
+	PreferredUsername string           `json:"preferred_username,omitempty"`
+	BasePath          string           `json:"base_path,omitempty"`
+	jwt.RegisteredClaims
+}
+
+type TokenResp struct {
+	AccessToken  string `json:"access_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int64  `json:"expires_in"`
+	RefreshToken string `json:"refresh_token,omitempty"`
+	IDToken      string `json:"id_token,omitempty"`
+	Scope        string `json:"scope"`
+}
+
+func sign(claims jwt.Claims) (string, error) {
+	k, kid, err := signingKey()
+	if err != nil {
+		return "", err
+	}
+	token := jwt.NewWithClaims(jwt.SigningMethodRS256, claims)
+	token.Header["kid"] = kid
+	return token.SignedString(k)
+}
+
+// ParseAccessToken verifies an access token issued by this server
+func ParseAccessToken(tokenString string) (*AccessClaims, error) {
+	k, _, err := signingKey()
+	if err != nil {
+		return nil, err
+	}
+	claims := &AccessClaims{}
+	_, err = jwt.ParseWithClaims(tokenString, claims, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodRSA); !ok {
+			return nil, errors.Errorf("unexpected signing method %v", token.Header["alg"])
+		}
+		return &k.PublicKey, nil
+	})
+	if err != nil {
+		return nil, errors.Wrap(err, "invalid access token")
+	}
+	if claims.ClientID == "" {
+		return nil, errors.New("not an access token")
+	}
+	return claims, nil
+}
+
+// ExchangeCode issues the tokens for an authorization code
+func ExchangeCode(issuer string, client *model.OAuthClient, code, redirectURI, verifier string) (*TokenResp, error) {
+	c, err := exchangeCode(client, code, redirectURI, verifier)
+	if err != nil {
+		return nil, err
+	}
+	user, err := op.GetUserById(c.UserID)
+	if err != nil || user.Disabled {
+		return nil, NewError(ErrInvalidGrant, "the user is not available")
+	}
+	return issue(issuer, client, user, c.Scope, c.Nonce, c.AuthTime)
+}
+
+// Refresh rotates the refresh token, the scope may only be narrowed
+func Refresh(issuer string, client *model.OAuthClient, refreshToken, scope string) (*TokenResp, error) {
+	t, err := db.GetOAuthTokenByHash(hash(refreshToken))
+	if err != nil || t.ClientID != client.ClientID {
+		return nil, NewError(ErrInvalidGrant, "invalid refresh token")
+	}
+	if deleted, err := db.DeleteOAuthToken(t.ID); err != nil {
+		return nil, err
+	} else if !deleted {
+		return nil, NewError(ErrInvalidGrant, "invalid refresh token")
+	}
+	if t.ExpiresAt.Before(time.Now()) {
+		return nil, NewError(ErrInvalidGrant, "the refresh token is expired")
+	}
+	if scope == "" {
+		scope = t.Scope
+	}
+	for _, s := range strings.Fields(scope) {
+		if !HasScope(t.Scope, s) {
+			return nil, NewError(ErrInvalidScope, "scope [%s] was not granted", s)
+		}
+	}
+	user, err := op.GetUserById(t.UserID)
+	if err != nil || user.Disabled {
+		return nil, NewError(ErrInvalidGrant, "the user is not available")
+	}
+	return issue(issuer, client, user, scope, "", time.Time{})
+}
+
+// Revoke deletes a refresh token of the client, unknown tokens are ignored as RFC 7009 requires
+func Revoke(client *model.OAuthClient, refreshToken string) error {
+	t, err := db.GetOAuthTokenByHash(hash(refreshToken))
+	if err != nil || t.ClientID != client.ClientID {
+		return nil
+	}
+	_, err = db.DeleteOAuthToken(t.ID)
+	return err
+}
+
+func issue(issuer string, client *model.OAuthClient, user *model.User, scope, nonce string, authTime time.Time) (*TokenResp, error) {
+	now := time.Now()
+	sub := strconv.FormatUint(uint64(user.ID), 10)
+	access, err := sign(AccessClaims{
+		Scope:    scope,
+		ClientID: client.ClientID,
+		PwdTS:    user.PwdTS,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    issuer,
+			Subject:   sub,
+			ExpiresAt: jwt.NewNumericDate(now.Add(AccessTokenExpire)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	refresh := random.String(64)
+	if err = db.CreateOAuthToken(&model.OAuthToken{
+		TokenHash: hash(refresh),
+		ClientID:  client.ClientID,
+		UserID:    user.ID,
+		Scope:     scope,
+		ExpiresAt: now.Add(RefreshTokenExpire),
+	}); err != nil {
+		return nil, err
+	}
+	resp := &TokenResp{
+		AccessToken:  access,
+		TokenType:    "Bearer",
+		ExpiresIn:    int64(AccessTokenExpire.Seconds()),
+		RefreshToken: refresh,
+		Scope:        scope,
+	}
+	if HasScope(scope, ScopeOpenID) {
+		claims := IDClaims{
+			Nonce: nonce,
+			RegisteredClaims: jwt.RegisteredClaims{
+				Issuer:    issuer,
+				Subject:   sub,
+				Audience:  jwt.ClaimStrings{client.ClientID},
+				ExpiresAt: jwt.NewNumericDate(now.Add(AccessTokenExpire)),
+				IssuedAt:  jwt.NewNumericDate(now),
+			},
+		}
+		if !authTime.IsZero() {
+			claims.AuthTime = jwt.NewNumericDate(authTime)
+		}
+		if HasScope(scope, ScopeProfile) {
+			claims.PreferredUsername, claims.BasePath = user.Username, user.BasePath
+		}
+		if resp.IDToken, err = sign(claims); err != nil {
+			return nil, err
+		}
+	}
+	return resp, nil
+}
+
+// UserInfo returns the claims of the user visible to the scope
+func UserInfo(user *model.User, scope string) map[string]any {
+	info := map[string]any{"sub": strconv.FormatUint(uint64(user.ID), 10)}
+	if HasScope(scope, ScopeProfile) {
+		info["preferred_username"] = user.Username
+		info["base_path"] = user.BasePath
+	}
+	if HasScope(scope, ScopeRead) {
+		info["permission"] = TokenUser(user, scope).Permission
+	}
+	return info
+}
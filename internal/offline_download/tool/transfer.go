@@ -17,6 +17,7 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/internal/stream"
 	"github.com/OpenListTeam/OpenList/v4/internal/task"
 	"github.com/OpenListTeam/OpenList/v4/internal/task_group"
+	"github.com/OpenListTeam/OpenList/v4/internal/webhook"
 	"github.com/OpenListTeam/OpenList/v4/pkg/http_range"
 	"github.com/OpenListTeam/OpenList/v4/pkg/torrent"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
@@ -98,6 +99,7 @@ func (t *TransferTask) OnSucceeded() {
 		}
 	}
 	task_group.TransferCoordinator.Done(context.WithoutCancel(t.Ctx()), t.groupID, true)
+	webhook.EmitTask(webhook.EventOfflineDownload, t)
 }
 
 func (t *TransferTask) OnFailed() {
@@ -109,6 +111,7 @@ func (t *TransferTask) OnFailed() {
 		}
 	}
 	task_group.TransferCoordinator.Done(context.WithoutCancel(t.Ctx()), t.groupID, false)
+	webhook.EmitTask(webhook.EventOfflineDownload, t)
 }
 
 func (t *TransferTask) SetRetry(retry int, maxRetry int) {
@@ -206,6 +209,11 @@ func transferStdFile(t *TransferTask) error {
 		rc.Close()
 		return errors.Wrapf(err, "failed to get file %s", t.SrcActualPath)
 	}
+	// the rapid upload doesn't go through op.Put
+	if err = op.CheckPutQuota(t.Ctx(), t.DstStorage, t.DstActualPath, filepath.Base(t.SrcActualPath), info.Size()); err != nil {
+		rc.Close()
+		return err
+	}
 
 	// 尝试对天翼云进行秒传（计算 MD5 + sliceMD5）
 	if rapidObj, rapidErr := tryRapidUpload189(t, rc, info.Size()); rapidErr == nil && rapidObj != nil {
@@ -0,0 +1,151 @@
+package op
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils/random"
+	"github.com/pkg/errors"
+)
+
+// the last used time is saved at most once in accessTokenUseInterval
+const accessTokenUseInterval = time.Minute
+
+func IsAccessToken(token string) bool {
+	return strings.HasPrefix(token, model.AccessTokenPrefix)
+}
+
+func hashAccessToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// AccessTokenS3KeyId returns the S3 access key id of the token
+func AccessTokenS3KeyId(t *model.AccessToken) string {
+	return model.AccessTokenS3KeyPrefix + t.KeyID
+}
+
+// AccessTokenS3Secret returns the S3 secret access key of the token,
+// it is derived from the saved hash so it can be verified without the token
+func AccessTokenS3Secret(t *model.AccessToken) string {
+	mac := hmac.New(sha256.New, []byte(conf.Conf.JwtSecret))
+	mac.Write([]byte(t.TokenHash))
+	return hex.EncodeToString(mac.Sum(nil))[:40]
+}
+
+func checkAccessToken(user *model.User, t *model.AccessToken) error {
+	t.Path = utils.FixAndCleanPath(t.Path)
+	if t.Path == "/" {
+		t.Path = ""
+	}
+	if t.Path != "" && !utils.IsSubPath(user.BasePath, t.Path) {
+		return errors.Errorf("path [%s] is outside the base path", t.Path)
+	}
+	t.Permission &= user.EffectivePermission()
+	t.Admin = t.Admin && user.IsAdmin()
+	return nil
+}
+
+// CreateAccessToken creates the token for the user, the token is only returned here
+func CreateAccessToken(user *model.User, t *model.AccessToken) (string, error) {
+	if err := checkAccessToken(user, t); err != nil {
+		return "", err
+	}
+	t.ID, t.UserID, t.LastUsedAt = 0, user.ID, nil
+	t.KeyID = random.String(20)
+	token := model.AccessTokenPrefix + t.KeyID + "_" + random.String(40)
+	t.TokenHash = hashAccessToken(token)
+	return token, db.CreateAccessToken(t)
+}
+
+func GetAccessTokens(userID uint) ([]model.AccessToken, error) {
+	return db.GetAccessTokensByUserId(userID)
+}
+
+func getUserAccessToken(userID, id uint) (*model.AccessToken, error) {
+	t, err := db.GetAccessTokenById(id)
+	if err != nil {
+		return nil, err
+	}
+	if t.UserID != userID {
+		return nil, errors.New("access token not found")
+	}
+	return t, nil
+}
+
+// UpdateAccessToken updates the name, the expiry and the scope of the token
+func UpdateAccessToken(user *model.User, t *model.AccessToken) error {
+	old, err := getUserAccessToken(user.ID, t.ID)
+	if err != nil {
+		return err
+	}
+	if err = checkAccessToken(user, t); err != nil {
+		return err
+	}
+	old.Name, old.ExpiresAt = t.Name, t.ExpiresAt
+	old.Path, old.Permission, old.Admin = t.Path, t.Permission, t.Admin
+	return db.UpdateAccessToken(old)
+}
+
+func DeleteAccessToken(userID, id uint) error {
+	if _, err := getUserAccessToken(userID, id); err != nil {
+		return err
+	}
+	return db.DeleteAccessTokenById(id)
+}
+
+// GetAccessTokenUser returns the user of the token limited to the scope of the token
+func GetAccessTokenUser(t *model.AccessToken) (*model.User, error) {
+	if t.Expired() {
+		return nil, errors.New("access token is expired")
+	}
+	user, err := GetUserById(t.UserID)
+	if err != nil {
+		return nil, err
+	}
+	if user.Disabled {
+		return nil, errors.New("current user is disabled")
+	}
+	u := *user
+	if t.Path != "" {
+		if !utils.IsSubPath(user.BasePath, t.Path) {
+			return nil, errors.WithStack(errs.PermissionDenied)
+		}
+		u.BasePath = t.Path
+	}
+	u.Permission = u.EffectivePermission() & t.Permission
+	u.GroupPermission = 0
+	if u.IsAdmin() && !t.Admin {
+		u.Role = model.GENERAL
+	}
+	if t.LastUsedAt == nil || time.Since(*t.LastUsedAt) > accessTokenUseInterval {
+		now := time.Now()
+		if err = db.UpdateAccessTokenLastUsed(t.ID, now); err == nil {
+			t.LastUsedAt = &now
+		}
+	}
+	return &u, nil
+}
+
+func GetAccessTokenByKeyId(keyID string) (*model.AccessToken, error) {
+	return db.GetAccessTokenByKeyId(keyID)
+}
+
+// AuthenticateAccessToken returns the user of a personal access token
+func AuthenticateAccessToken(token string) (*model.User, error) {
+	keyID, _, _ := strings.Cut(strings.TrimPrefix(token, model.AccessTokenPrefix), "_")
+	t, err := db.GetAccessTokenByKeyId(keyID)
+	if err != nil || subtle.ConstantTimeCompare([]byte(hashAccessToken(token)), []byte(t.TokenHash)) != 1 {
+		return nil, errors.New("invalid access token")
+	}
+	return GetAccessTokenUser(t)
+}
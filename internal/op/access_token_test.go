@@ -0,0 +1,53 @@
+package op_test
+
+import (
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+)
+
+func TestAccessToken(t *testing.T) {
+	user := &model.User{Username: "pat", BasePath: "/pat", Role: model.ADMIN, Permission: 1<<3 | 1<<7 | 1<<8}
+	if err := op.CreateUser(user); err != nil {
+		t.Fatalf("failed create user: %+v", err)
+	}
+	if _, err := op.CreateAccessToken(user, &model.AccessToken{Name: "out", Path: "/other"}); err == nil {
+		t.Error("expected a path outside the base path to be rejected")
+	}
+	pat := &model.AccessToken{Name: "backup", Path: "/pat/backup", Permission: 1<<3 | 1<<14}
+	token, err := op.CreateAccessToken(user, pat)
+	if err != nil {
+		t.Fatalf("failed create token: %+v", err)
+	}
+	u, err := op.AuthenticateAccessToken(token)
+	if err != nil {
+		t.Fatalf("failed authenticate token: %+v", err)
+	}
+	if u.BasePath != "/pat/backup" || u.Permission != 1<<3 || u.IsAdmin() {
+		t.Errorf("expected a non-admin user limited to /pat/backup and mkdir/upload, got %+v", u)
+	}
+	if _, err = op.AuthenticateAccessToken(token + "x"); err == nil {
+		t.Error("expected a wrong token to be rejected")
+	}
+	tokens, _ := op.GetAccessTokens(user.ID)
+	if len(tokens) != 1 || tokens[0].LastUsedAt == nil {
+		t.Errorf("expected the last used time to be saved, got %+v", tokens)
+	}
+
+	expired := time.Now().Add(-time.Minute)
+	pat.ExpiresAt = &expired
+	if err = op.UpdateAccessToken(user, pat); err != nil {
+		t.Fatalf("failed update token: %+v", err)
+	}
+	if _, err = op.AuthenticateAccessToken(token); err == nil {
+		t.Error("expected an expired token to be rejected")
+	}
+	if err = op.DeleteAccessToken(user.ID+1, pat.ID); err == nil {
+		t.Error("expected the token of another user not to be deleted")
+	}
+	if err = op.DeleteAccessToken(user.ID, pat.ID); err != nil {
+		t.Errorf("failed delete token: %+v", err)
+	}
+}
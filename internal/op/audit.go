@@ -0,0 +1,72 @@
+package op
+
+import (
+	"context"
+	"net"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	AuditLogin          = "login"
+	AuditEnable2FA      = "enable_2fa"
+	AuditAddWebAuthn    = "add_webauthn"
+	AuditDeleteWebAuthn = "delete_webauthn"
+	AuditMakeDir        = "mkdir"
+	AuditUpload         = "upload"
+	AuditRename         = "rename"
+	AuditMove           = "move"
+	AuditCopy           = "copy"
+	AuditMerge          = "merge"
+	AuditRemove         = "remove"
+	AuditDecompress     = "decompress"
+	AuditRestoreVersion = "restore_version"
+)
+
+// Audit records the action, the user is taken from ctx if l.Username is empty,
+// the protocol and the ip of the client are taken from ctx as well
+func Audit(ctx context.Context, l *model.AuditLog, err error) {
+	l.Time = time.Now()
+	if l.Username == "" {
+		if user, ok := ctx.Value(conf.UserKey).(*model.User); ok {
+			l.Username = user.Username
+		}
+	}
+	if l.Protocol == "" {
+		l.Protocol, _ = ctx.Value(conf.ProtocolKey).(string)
+	}
+	if l.IP == "" {
+		l.IP, _ = ctx.Value(conf.ClientIPKey).(string)
+	}
+	if host, _, e := net.SplitHostPort(l.IP); e == nil {
+		l.IP = host
+	}
+	if l.IP != "" && db.GetIPDB().HasData() {
+		info := GetIPInfo(l.IP)
+		l.Asn, l.Aso = info.Asn, info.Aso
+		l.Country, l.Region, l.City, l.Isp = info.Country, info.Region, info.City, info.Isp
+	}
+	l.Success = err == nil
+	if err != nil {
+		l.Error = err.Error()
+	}
+	if e := db.CreateAuditLog(l); e != nil {
+		log.Errorf("failed record audit log of [%s] %s: %+v", l.Username, l.Action, e)
+	}
+}
+
+func GetAuditLogs(filter model.AuditLogFilter, pageIndex, pageSize int) ([]model.AuditLog, int64, error) {
+	return db.GetAuditLogs(filter, pageIndex, pageSize)
+}
+
+func ExportAuditLogs(filter model.AuditLogFilter, fn func([]model.AuditLog) error) error {
+	return db.FindAuditLogsInBatches(filter, 500, fn)
+}
+
+func DeleteAuditLogsBefore(t time.Time) error {
+	return db.DeleteAuditLogsBefore(t)
+}
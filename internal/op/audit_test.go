@@ -0,0 +1,32 @@
+package op_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+)
+
+func TestAudit(t *testing.T) {
+	ctx := context.WithValue(context.Background(), conf.UserKey, &model.User{Username: "audit"})
+	ctx = context.WithValue(ctx, conf.ProtocolKey, "webdav")
+	ctx = context.WithValue(ctx, conf.ClientIPKey, "127.0.0.1:2345")
+	op.Audit(ctx, &model.AuditLog{Action: op.AuditRemove, Path: "/audit/a/b.txt"}, nil)
+	op.Audit(ctx, &model.AuditLog{Action: op.AuditRemove, Path: "/audit/ab.txt"}, errors.New("denied"))
+	op.Audit(ctx, &model.AuditLog{Action: op.AuditMove, Path: "/audit/a", DstPath: "/audit/c"}, nil)
+
+	logs, total, err := op.GetAuditLogs(model.AuditLogFilter{Username: "audit", Path: "/audit/a"}, 1, 10)
+	if err != nil || total != 2 {
+		t.Fatalf("expected 2 logs under /audit/a, got %d, %+v", total, err)
+	}
+	if l := logs[1]; l.Action != op.AuditRemove || l.Protocol != "webdav" || l.IP != "127.0.0.1" || !l.Success {
+		t.Errorf("unexpected log: %+v", l)
+	}
+	logs, total, _ = op.GetAuditLogs(model.AuditLogFilter{Username: "audit", Action: op.AuditRemove}, 1, 10)
+	if total != 2 || logs[0].Success || logs[0].Error != "denied" {
+		t.Errorf("expected 2 remove logs with the latest failed, got %+v", logs)
+	}
+}
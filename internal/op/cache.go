@@ -98,6 +98,11 @@ func (cm *CacheManager) DeleteUser(username string) {
 	cm.userCache.Delete(username)
 }
 
+// remove all the users from cache
+func (cm *CacheManager) ClearUsers() {
+	cm.userCache.Clear()
+}
+
 // caches setting
 func (cm *CacheManager) SetSetting(key string, setting *model.SettingItem) {
 	cm.settingCache.Set(key, setting)
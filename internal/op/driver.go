@@ -173,6 +173,32 @@ func getMainItems(config driver.Config) []driver.Item {
 		Default:  "false",
 		Required: true,
 	})
+	items = append(items, driver.Item{
+		Name:     "enable_trash",
+		Type:     conf.TypeBool,
+		Default:  "false",
+		Required: true,
+		Help:     "Move removed objects to the recycle bin instead of deleting them",
+	})
+	items = append(items, driver.Item{
+		Name:    "max_versions",
+		Type:    conf.TypeNumber,
+		Default: "0",
+		Help:    "Number of old versions kept for overwritten files, 0 to disable versioning",
+	})
+	items = append(items, driver.Item{
+		Name:    "index_interval",
+		Type:    conf.TypeNumber,
+		Default: "0",
+		Help:    "Minutes between rescans which update the search index with the changes, 0 to disable",
+	})
+	items = append(items, driver.Item{
+		Name:     "watch_changes",
+		Type:     conf.TypeBool,
+		Default:  "false",
+		Required: true,
+		Help:     "Update the search index with the changes reported by the storage, if supported by the driver",
+	})
 	return items
 }
 func getAdditionalItems(t reflect.Type, defaultRoot string) []driver.Item {
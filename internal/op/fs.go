@@ -10,6 +10,7 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/driver"
 	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/metrics"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/stream"
 	"github.com/OpenListTeam/OpenList/v4/pkg/singleflight"
@@ -35,7 +36,9 @@ func list(ctx context.Context, storage driver.Driver, path string, args model.Li
 	log.Debugf("op.List %s", path)
 	key := Key(storage, path)
 	if !args.Refresh {
-		if dirCache, exists := Cache.dirCache.Get(key); exists {
+		dirCache, exists := Cache.dirCache.Get(key)
+		metrics.CacheHit("dir", exists)
+		if exists {
 			log.Debugf("use cache when list %s", path)
 			objs := dirCache.GetSortedObjects(storage)
 			if resultValidator != nil {
@@ -57,7 +60,9 @@ func list(ctx context.Context, storage driver.Driver, path string, args model.Li
 		if !dir.IsDir() {
 			return nil, errors.WithStack(errs.NotFolder)
 		}
+		start := time.Now()
 		files, err := storage.List(ctx, dir, args)
+		metrics.ObserveDriverRequest(storage.Config().Name, "list", start, err)
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed to list objs")
 		}
@@ -241,7 +246,9 @@ func Link(ctx context.Context, storage driver.Driver, path string, args model.Li
 		typeKey += "/" + args.Header.Get("User-Agent")
 	}
 	key := Key(storage, path)
-	if ol, exists := Cache.linkCache.GetType(key, typeKey); exists {
+	ol, exists := Cache.linkCache.GetType(key, typeKey)
+	metrics.CacheHit("link", exists)
+	if exists {
 		if ol.link.Expiration != nil ||
 			ol.link.SyncClosers.AcquireReference() || !ol.link.RequireReference {
 			return ol.link, ol.obj, nil
@@ -257,7 +264,9 @@ func Link(ctx context.Context, storage driver.Driver, path string, args model.Li
 			return nil, errors.WithStack(errs.NotFile)
 		}
 
+		start := time.Now()
 		link, err := storage.Link(ctx, file, args)
+		metrics.ObserveDriverRequest(storage.Config().Name, "link", start, err)
 		if err != nil {
 			return nil, errors.Wrapf(err, "failed get link")
 		}
@@ -422,6 +431,9 @@ func Move(ctx context.Context, storage driver.Driver, srcPath, dstDirPath string
 	if model.ObjHasMask(dstDir, model.NoWrite) {
 		return errors.WithStack(errs.PermissionDenied)
 	}
+	if err = checkTransferQuota(ctx, storage, srcPath, srcObj, dstDirPath, true); err != nil {
+		return err
+	}
 
 	var newObj model.Obj
 	switch s := storage.(type) {
@@ -458,6 +470,9 @@ func Move(ctx context.Context, storage driver.Driver, srcPath, dstDirPath string
 			cache.UpdateObject(srcRawObj.GetName(), newObj)
 		}
 	}
+	refreshDirUsage(ctx, storage, srcDirPath)
+	refreshDirUsage(ctx, storage, dstDirPath)
+	moveVersions(ctx, storage, srcPath, stdpath.Join(dstDirPath, srcRawObj.GetName()), srcRawObj.IsDir())
 
 	if ctx.Value(conf.SkipHookKey) != nil || !needHandleObjsUpdateHook() {
 		return nil
@@ -518,6 +533,7 @@ func Rename(ctx context.Context, storage driver.Driver, srcPath, dstName string)
 			cache.UpdateObject(oldName, newObj)
 		}
 	}
+	moveVersions(ctx, storage, srcPath, stdpath.Join(stdpath.Dir(srcPath), dstName), srcRawObj.IsDir())
 
 	if ctx.Value(conf.SkipHookKey) != nil || !needHandleObjsUpdateHook() {
 		return nil
@@ -556,6 +572,9 @@ func Copy(ctx context.Context, storage driver.Driver, srcPath, dstDirPath string
 	if model.ObjHasMask(dstDir, model.NoWrite) {
 		return errors.WithStack(errs.PermissionDenied)
 	}
+	if err = checkTransferQuota(ctx, storage, srcPath, srcObj, dstDirPath, false); err != nil {
+		return err
+	}
 
 	var newObj model.Obj
 	switch s := storage.(type) {
@@ -584,6 +603,7 @@ func Copy(ctx context.Context, storage driver.Driver, srcPath, dstDirPath string
 			cache.UpdateObject(srcRawObj.GetName(), newObj)
 		}
 	}
+	refreshDirUsage(ctx, storage, dstDirPath)
 
 	if ctx.Value(conf.SkipHookKey) != nil || !needHandleObjsUpdateHook() {
 		return nil
@@ -623,6 +643,7 @@ func Remove(ctx context.Context, storage driver.Driver, path string) error {
 		err = s.Remove(ctx, model.UnwrapObjName(rawObj))
 		if err == nil {
 			Cache.removeDirectoryObject(storage, dirPath, rawObj)
+			refreshDirUsage(ctx, storage, dirPath)
 		}
 	default:
 		return errs.NotImplement
@@ -651,7 +672,19 @@ func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file mod
 	tempName := file.GetName() + ".openlist_to_delete"
 	tempPath := stdpath.Join(dstDirPath, tempName)
 	fi, err := GetUnwrap(ctx, storage, dstPath)
-	if err == nil {
+	if qErr := checkReplaceQuota(ctx, fi, file.GetSize()); qErr != nil {
+		return qErr
+	}
+	var versionDir string
+	if err == nil && needVersion(storage, dstPath, fi) {
+		// keep the old file as a version instead of overwriting it
+		versionDir, err = saveVersion(ctx, storage, dstPath)
+		if err != nil {
+			return err
+		}
+		fi = nil
+	}
+	if err == nil && fi != nil {
 		if fi.GetSize() == 0 {
 			err = Remove(ctx, storage, dstPath)
 			if err != nil {
@@ -691,6 +724,7 @@ func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file mod
 	}
 
 	var newObj model.Obj
+	start := time.Now()
 	switch s := storage.(type) {
 	case driver.PutResult:
 		newObj, err = s.Put(ctx, parentDir, file, up)
@@ -699,6 +733,7 @@ func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file mod
 	default:
 		return errs.NotImplement
 	}
+	metrics.ObserveDriverRequest(storage.Config().Name, "put", start, err)
 	if err == nil {
 		Cache.linkCache.DeleteKey(Key(storage, dstPath))
 		if !storage.Config().NoCache {
@@ -716,12 +751,20 @@ func Put(ctx context.Context, storage driver.Driver, dstDirPath string, file mod
 				cache.UpdateObject(newObj.GetName(), newObj)
 			}
 		}
+		refreshDirUsage(ctx, storage, dstDirPath)
 
 		if ctx.Value(conf.SkipHookKey) == nil && needHandleObjsUpdateHook() {
 			go objsUpdateHook(context.WithoutCancel(ctx), storage, dstDirPath, false)
 		}
 	}
 	log.Debugf("put file [%s] done", file.GetName())
+	if versionDir != "" {
+		if err != nil {
+			dropVersion(ctx, storage, dstPath, versionDir)
+		} else {
+			pruneVersions(ctx, storage, dstPath)
+		}
+	}
 	if storage.Config().NoOverwriteUpload && fi != nil && fi.GetSize() > 0 {
 		if err != nil {
 			// upload failed, recover old obj
@@ -0,0 +1,237 @@
+package op
+
+import (
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+var (
+	groupsMu sync.RWMutex
+	groups   map[uint]*model.Group
+)
+
+func getGroups() (map[uint]*model.Group, error) {
+	groupsMu.RLock()
+	m := groups
+	groupsMu.RUnlock()
+	if m != nil {
+		return m, nil
+	}
+	all, _, err := db.GetGroups(1, -1)
+	if err != nil {
+		return nil, err
+	}
+	m = make(map[uint]*model.Group, len(all))
+	for i := range all {
+		m[all[i].ID] = &all[i]
+	}
+	groupsMu.Lock()
+	groups = m
+	groupsMu.Unlock()
+	return m, nil
+}
+
+// clearGroups drops the loaded groups and the users whose group permission depends on them
+func clearGroups() {
+	groupsMu.Lock()
+	groups = nil
+	groupsMu.Unlock()
+	adminUser, guestUser = nil, nil
+	Cache.ClearUsers()
+}
+
+// applyGroups fills the group permission of the loaded user
+func applyGroups(u *model.User) error {
+	u.GroupPermission = 0
+	if len(u.GroupIDs) == 0 {
+		return nil
+	}
+	m, err := getGroups()
+	if err != nil {
+		return err
+	}
+	for _, id := range u.GroupIDs {
+		if g, ok := m[id]; ok {
+			u.GroupPermission |= g.Permission
+		}
+	}
+	return nil
+}
+
+func checkGroupIds(ids []uint) error {
+	m, err := getGroups()
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		if _, ok := m[id]; !ok {
+			return errors.Errorf("group [%d] does not exist", id)
+		}
+	}
+	return nil
+}
+
+// GroupBasePath returns the first base path of the groups, empty if none has one
+func GroupBasePath(ids []uint) string {
+	m, err := getGroups()
+	if err != nil {
+		return ""
+	}
+	for _, id := range ids {
+		if g, ok := m[id]; ok && g.BasePath != "" {
+			return g.BasePath
+		}
+	}
+	return ""
+}
+
+// MatchExternalGroups returns the ids of the groups mapped from the LDAP/SSO group names
+func MatchExternalGroups(names []string) ([]uint, error) {
+	m, err := getGroups()
+	if err != nil {
+		return nil, err
+	}
+	var ids []uint
+	for _, id := range slices.Sorted(maps.Keys(m)) {
+		if g := m[id]; g.External() && slices.ContainsFunc(names, g.MatchExternal) {
+			ids = append(ids, id)
+		}
+	}
+	return ids, nil
+}
+
+// groupSourceKeys are the settings telling where each source reads the group names from
+var groupSourceKeys = map[string]string{
+	model.GroupSourceLdap: conf.LdapGroupAttribute,
+	model.GroupSourceSSO:  conf.SSOGroupsKey,
+}
+
+// SyncUserGroups replaces the groups of the user added by the LDAP/SSO source with the ones matching the names.
+// The groups added by the other sources and the ones without external names are kept,
+// nothing is synced if the source doesn't read the group names as they are always empty then
+func SyncUserGroups(u *model.User, source string, names []string) error {
+	if item, _ := GetSettingItemByKey(groupSourceKeys[source]); item == nil || strings.TrimSpace(item.Value) == "" {
+		return nil
+	}
+	m, err := getGroups()
+	if err != nil {
+		return err
+	}
+	owned := u.ExternalGroupIDs[source]
+	var others []uint
+	for s, ids := range u.ExternalGroupIDs {
+		if s != source {
+			others = append(others, ids...)
+		}
+	}
+	var ids []uint
+	for _, id := range u.GroupIDs {
+		g, ok := m[id]
+		if !ok {
+			continue
+		}
+		// the external groups without a source were added before the sources were recorded
+		if !slices.Contains(others, id) && (slices.Contains(owned, id) || g.External()) {
+			continue
+		}
+		ids = append(ids, id)
+	}
+	matched, err := MatchExternalGroups(names)
+	if err != nil {
+		return err
+	}
+	for _, id := range matched {
+		if !slices.Contains(ids, id) {
+			ids = append(ids, id)
+		}
+	}
+	if slices.Equal(ids, u.GroupIDs) && slices.Equal(matched, owned) {
+		return nil
+	}
+	u.GroupIDs = ids
+	u.ExternalGroupIDs = maps.Clone(u.ExternalGroupIDs)
+	if u.ExternalGroupIDs == nil {
+		u.ExternalGroupIDs = make(map[string][]uint)
+	}
+	if len(matched) == 0 {
+		delete(u.ExternalGroupIDs, source)
+	} else {
+		u.ExternalGroupIDs[source] = matched
+	}
+	if err = UpdateUser(u); err != nil {
+		return err
+	}
+	return applyGroups(u)
+}
+
+func GetGroups(pageIndex, pageSize int) ([]model.Group, int64, error) {
+	return db.GetGroups(pageIndex, pageSize)
+}
+
+func GetGroupById(id uint) (*model.Group, error) {
+	return db.GetGroupById(id)
+}
+
+func CreateGroup(g *model.Group) error {
+	if g.BasePath != "" {
+		g.BasePath = utils.FixAndCleanPath(g.BasePath)
+	}
+	defer clearGroups()
+	return db.CreateGroup(g)
+}
+
+func UpdateGroup(g *model.Group) error {
+	if _, err := db.GetGroupById(g.ID); err != nil {
+		return err
+	}
+	if g.BasePath != "" {
+		g.BasePath = utils.FixAndCleanPath(g.BasePath)
+	}
+	defer clearGroups()
+	return db.UpdateGroup(g)
+}
+
+// DeleteGroupById deletes the group and removes it from the users. A group still used by the
+// metas is refused, since removing it could leave a meta without any allowed member, which
+// would allow everyone.
+func DeleteGroupById(id uint) error {
+	if _, err := db.GetGroupById(id); err != nil {
+		return err
+	}
+	metas, _, err := db.GetMetas(1, -1)
+	if err != nil {
+		return err
+	}
+	for _, meta := range metas {
+		if slices.Contains(meta.ReadGroups, id) || slices.Contains(meta.WriteGroups, id) {
+			return errors.Errorf("the group is used by the meta of [%s], remove it from the meta first", meta.Path)
+		}
+	}
+	defer clearGroups()
+	users, _, err := db.GetUsers(1, -1)
+	if err != nil {
+		return err
+	}
+	for i := range users {
+		u := &users[i]
+		if !u.InGroup(id) {
+			continue
+		}
+		u.GroupIDs = slices.DeleteFunc(u.GroupIDs, func(g uint) bool { return g == id })
+		for source, ids := range u.ExternalGroupIDs {
+			u.ExternalGroupIDs[source] = slices.DeleteFunc(ids, func(g uint) bool { return g == id })
+		}
+		if err := db.UpdateUser(u); err != nil {
+			return err
+		}
+	}
+	return db.DeleteGroupById(id)
+}
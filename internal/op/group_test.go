@@ -0,0 +1,98 @@
+package op_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+)
+
+func TestGroups(t *testing.T) {
+	editors := &model.Group{Name: "editors", Permission: 1<<3 | 1<<4, BasePath: "/team"}
+	ldap := &model.Group{Name: "ldap-readers", Permission: 1 << 8, ExternalNames: "readers, cn=viewers"}
+	for _, g := range []*model.Group{editors, ldap} {
+		if err := op.CreateGroup(g); err != nil {
+			t.Fatalf("failed create group: %+v", err)
+		}
+	}
+	if err := op.CreateUser(&model.User{Username: "nogroup", GroupIDs: []uint{ldap.ID + 100}}); err == nil {
+		t.Error("expected a missing group to be rejected")
+	}
+	if err := op.CreateUser(&model.User{Username: "member", Permission: 1, GroupIDs: []uint{editors.ID}}); err != nil {
+		t.Fatalf("failed create user: %+v", err)
+	}
+	user, err := op.GetUserByName("member")
+	if err != nil {
+		t.Fatalf("failed get user: %+v", err)
+	}
+	if user.BasePath != "/team" || user.EffectivePermission() != 1|1<<3|1<<4 || !user.CanRename() {
+		t.Errorf("expected the base path and the permissions of the group, got %+v", user)
+	}
+
+	if err = op.SaveSettingItems([]model.SettingItem{
+		{Key: conf.SSOGroupsKey, Value: "groups", Type: conf.TypeString, Group: model.SSO},
+		{Key: conf.LdapGroupAttribute, Value: "memberOf", Type: conf.TypeString, Group: model.LDAP},
+	}); err != nil {
+		t.Fatalf("failed save settings: %+v", err)
+	}
+	if err = op.SyncUserGroups(user, model.GroupSourceSSO, []string{"Readers"}); err != nil {
+		t.Fatalf("failed sync groups: %+v", err)
+	}
+	if !slices.Equal(user.GroupIDs, []uint{editors.ID, ldap.ID}) || !user.CanWebdavRead() {
+		t.Errorf("expected the mapped group to be added, got %+v", user)
+	}
+	admins := &model.Group{Name: "ldap-admins", ExternalNames: "admins"}
+	if err = op.CreateGroup(admins); err != nil {
+		t.Fatalf("failed create group: %+v", err)
+	}
+	if err = op.SyncUserGroups(user, model.GroupSourceLdap, []string{"admins"}); err != nil {
+		t.Fatalf("failed sync groups: %+v", err)
+	}
+	if !slices.Equal(user.GroupIDs, []uint{editors.ID, ldap.ID, admins.ID}) {
+		t.Errorf("expected the groups of the other source to be kept, got %v", user.GroupIDs)
+	}
+	if err = op.SyncUserGroups(user, model.GroupSourceSSO, nil); err != nil {
+		t.Fatalf("failed sync groups: %+v", err)
+	}
+	if !slices.Equal(user.GroupIDs, []uint{editors.ID, admins.ID}) {
+		t.Errorf("expected only the group of the source to be removed, got %v", user.GroupIDs)
+	}
+	if err = op.SaveSettingItem(&model.SettingItem{Key: conf.LdapGroupAttribute, Value: "", Type: conf.TypeString, Group: model.LDAP}); err != nil {
+		t.Fatalf("failed save setting: %+v", err)
+	}
+	if err = op.SyncUserGroups(user, model.GroupSourceLdap, nil); err != nil {
+		t.Fatalf("failed sync groups: %+v", err)
+	}
+	if !slices.Equal(user.GroupIDs, []uint{editors.ID, admins.ID}) {
+		t.Errorf("expected no sync without the group attribute, got %v", user.GroupIDs)
+	}
+	if err = op.DeleteGroupById(admins.ID); err != nil {
+		t.Fatalf("failed delete group: %+v", err)
+	}
+	user, _ = op.GetUserByName("member")
+
+	meta := &model.Meta{Path: "/team/private", ReadGroups: []uint{editors.ID}, ReadUsersSub: true}
+	if err = op.CreateMeta(meta); err != nil {
+		t.Fatalf("failed create meta: %+v", err)
+	}
+	if !meta.AllowRead(user) || meta.AllowRead(&model.User{ID: user.ID + 1}) {
+		t.Error("expected the meta to allow the members of the group only")
+	}
+	if err = op.DeleteGroupById(editors.ID); err == nil {
+		t.Fatal("expected deleting a group used by a meta to be refused, the meta would allow everyone")
+	}
+	meta.ReadGroups = nil
+	meta.ReadUsers = []uint{user.ID}
+	if err = op.UpdateMeta(meta); err != nil {
+		t.Fatalf("failed update meta: %+v", err)
+	}
+	if err = op.DeleteGroupById(editors.ID); err != nil {
+		t.Fatalf("failed delete group: %+v", err)
+	}
+	user, _ = op.GetUserByName("member")
+	if len(user.GroupIDs) != 0 || user.EffectivePermission() != 1 {
+		t.Errorf("expected the group to be removed from the user, got %+v", user)
+	}
+}
@@ -0,0 +1,61 @@
+package op
+
+import (
+	"context"
+	stdpath "path"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// ReplaceWithHardLink replaces the file at dstPath with a hard link of the file at srcPath,
+// the storage must support hard links
+func ReplaceWithHardLink(ctx context.Context, storage driver.Driver, srcPath, dstPath string) error {
+	if storage.Config().CheckStatus && storage.GetStorage().Status != WORK {
+		return errors.WithMessagef(errs.StorageNotInit, "storage status: %s", storage.GetStorage().Status)
+	}
+	linker, ok := storage.(driver.HardLink)
+	if !ok {
+		return errors.WithStack(errs.NotImplement)
+	}
+	srcPath = utils.FixAndCleanPath(srcPath)
+	dstPath = utils.FixAndCleanPath(dstPath)
+	srcObj, err := GetUnwrap(ctx, storage, srcPath)
+	if err != nil {
+		return errors.WithMessage(err, "failed to get src object")
+	}
+	if srcObj.IsDir() {
+		return errors.WithStack(errs.NotFile)
+	}
+	dstRawObj, err := Get(ctx, storage, dstPath)
+	if err != nil {
+		return errors.WithMessage(err, "failed to get dst object")
+	}
+	if model.ObjHasMask(dstRawObj, model.NoRemove) {
+		return errors.WithStack(errs.PermissionDenied)
+	}
+	dstDirPath := stdpath.Dir(dstPath)
+	dstDir, err := GetUnwrap(ctx, storage, dstDirPath)
+	if err != nil {
+		return errors.WithMessage(err, "failed to get dst dir")
+	}
+	// keep the file until the link is created
+	tempName := dstRawObj.GetName() + ".openlist_to_delete"
+	if err = Rename(ctx, storage, dstPath, tempName); err != nil {
+		return errors.WithMessage(err, "failed to rename dst object")
+	}
+	tempPath := stdpath.Join(dstDirPath, tempName)
+	if err = linker.HardLink(ctx, srcObj, dstDir, model.UnwrapObjName(dstRawObj).GetName()); err != nil {
+		if err := Rename(ctx, storage, tempPath, dstRawObj.GetName()); err != nil {
+			log.Errorf("failed recover [%s]: %+v", dstPath, err)
+		}
+		return errors.WithStack(err)
+	}
+	Cache.linkCache.DeleteKey(Key(storage, dstPath))
+	Cache.DeleteDirectory(storage, dstDirPath)
+	return Remove(ctx, storage, tempPath)
+}
@@ -72,6 +72,9 @@ func DeleteMetaById(id uint) error {
 }
 
 func UpdateMeta(u *model.Meta) error {
+	if err := checkMetaGroups(u); err != nil {
+		return err
+	}
 	u.Path = utils.FixAndCleanPath(u.Path)
 	old, err := db.GetMetaById(u.ID)
 	if err != nil {
@@ -83,6 +86,9 @@ func UpdateMeta(u *model.Meta) error {
 }
 
 func CreateMeta(u *model.Meta) error {
+	if err := checkMetaGroups(u); err != nil {
+		return err
+	}
 	u.Path = utils.FixAndCleanPath(u.Path)
 	metaCache.Del(u.Path)
 	return db.CreateMeta(u)
@@ -95,3 +101,10 @@ func GetMetaById(id uint) (*model.Meta, error) {
 func GetMetas(pageIndex, pageSize int) (metas []model.Meta, count int64, err error) {
 	return db.GetMetas(pageIndex, pageSize)
 }
+
+func checkMetaGroups(u *model.Meta) error {
+	if err := checkGroupIds(u.ReadGroups); err != nil {
+		return err
+	}
+	return checkGroupIds(u.WriteGroups)
+}
@@ -0,0 +1,35 @@
+package op
+
+import (
+	"context"
+	stdpath "path"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+)
+
+// SetModTime sets the modification time of the object at path,
+// errs.NotImplement is returned if the storage can't set it
+func SetModTime(ctx context.Context, storage driver.Driver, path string, modTime time.Time) error {
+	if storage.Config().CheckStatus && storage.GetStorage().Status != WORK {
+		return errors.WithMessagef(errs.StorageNotInit, "storage status: %s", storage.GetStorage().Status)
+	}
+	setter, ok := storage.(driver.SetModTime)
+	if !ok {
+		return errors.WithStack(errs.NotImplement)
+	}
+	path = utils.FixAndCleanPath(path)
+	obj, err := GetUnwrap(ctx, storage, path)
+	if err != nil {
+		return errors.WithMessage(err, "failed to get object")
+	}
+	if err = setter.SetModTime(ctx, obj, modTime); err != nil {
+		return errors.WithStack(err)
+	}
+	Cache.linkCache.DeleteKey(Key(storage, path))
+	Cache.DeleteDirectory(storage, stdpath.Dir(path))
+	return nil
+}
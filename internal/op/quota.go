@@ -0,0 +1,218 @@
+package op
+
+import (
+	"context"
+	stdpath "path"
+	"sync"
+	"sync/atomic"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// The usage of a folder is recorded when it's listed, and the base path of a user is scanned
+// when the user gets a quota. The changes made to the storages outside OpenList are only
+// counted once the folders are listed again.
+
+var (
+	usageMu sync.Mutex
+	// hasQuotas caches whether any user has a quota, nil until it's loaded
+	hasQuotas atomic.Pointer[bool]
+	// scanning holds the paths whose usage is being scanned
+	scanning sync.Map
+)
+
+// quotasEnabled reports whether any user has a quota, the usage is only recorded then
+func quotasEnabled() bool {
+	if ok := hasQuotas.Load(); ok != nil {
+		return *ok
+	}
+	ok, err := db.HasUserQuotas()
+	if err != nil {
+		log.Errorf("failed check user quotas: %+v", err)
+		return true
+	}
+	hasQuotas.Store(&ok)
+	return ok
+}
+
+func resetQuotas() {
+	hasQuotas.Store(nil)
+}
+
+// updateDirUsage records the usage of the files directly in parent,
+// the usage of a user is the sum of the folders under the base path
+func updateDirUsage(ctx context.Context, parent string, objs []model.Obj) {
+	if !quotasEnabled() {
+		return
+	}
+	var size, files int64
+	dirs := make(map[string]struct{})
+	for _, obj := range objs {
+		if obj.IsDir() {
+			dirs[obj.GetName()] = struct{}{}
+			continue
+		}
+		size += max(obj.GetSize(), 0)
+		files++
+	}
+	usageMu.Lock()
+	defer usageMu.Unlock()
+	children, err := db.GetDirUsagesByParent(parent)
+	if err != nil {
+		log.Errorf("failed update usage of [%s]: %+v", parent, err)
+		return
+	}
+	// folders that no longer exist take the usage of their sub folders along
+	for _, child := range children {
+		if child.Path == parent {
+			continue
+		}
+		if _, ok := dirs[stdpath.Base(child.Path)]; ok || HasStorage(child.Path) {
+			continue
+		}
+		if err = db.DeleteDirUsages(child.Path); err != nil {
+			log.Errorf("failed delete usage of [%s]: %+v", child.Path, err)
+		}
+	}
+	usage, err := db.GetDirUsage(parent)
+	if err != nil {
+		log.Errorf("failed update usage of [%s]: %+v", parent, err)
+		return
+	}
+	if usage == nil {
+		usage = &model.DirUsage{Path: parent, Parent: stdpath.Dir(parent)}
+	} else if usage.Size == size && usage.Files == files {
+		return
+	}
+	usage.Size, usage.Files = size, files
+	if err = db.SaveDirUsage(usage); err != nil {
+		log.Errorf("failed update usage of [%s]: %+v", parent, err)
+	}
+}
+
+// refreshDirUsage updates the usage of a folder after writing, so that quotas are
+// up to date even if the hooks are not handled after writing
+func refreshDirUsage(ctx context.Context, storage driver.Driver, dirPath string) {
+	if !quotasEnabled() {
+		return
+	}
+	var objs []model.Obj
+	if dirCache, exist := Cache.dirCache.Get(Key(storage, dirPath)); exist {
+		objs = dirCache.GetSortedObjects(storage)
+	} else if user, ok := ctx.Value(conf.UserKey).(*model.User); ok && user.HasQuota() {
+		var err error
+		objs, err = List(ctx, storage, dirPath, model.ListArgs{SkipHook: true})
+		if err != nil {
+			return
+		}
+	} else {
+		return
+	}
+	updateDirUsage(ctx, utils.GetFullPath(storage.GetStorage().MountPath, dirPath), objs)
+}
+
+// scanUsage records the usage of all the folders under path in the background,
+// so that the folders nobody has listed are counted as well
+func scanUsage(path string) {
+	if _, loaded := scanning.LoadOrStore(path, struct{}{}); loaded {
+		return
+	}
+	go func() {
+		defer scanning.Delete(path)
+		ctx := context.Background()
+		walk := func(storage driver.Driver, actualPath string) {
+			mountPath := storage.GetStorage().MountPath
+			RecursivelyWalkStorage(ctx, storage, actualPath, nil, func(dirPath string, objs []model.Obj) {
+				updateDirUsage(ctx, utils.GetFullPath(mountPath, dirPath), objs)
+			})
+		}
+		if storage, actualPath, err := GetStorageAndActualPath(path); err == nil {
+			walk(storage, actualPath)
+		}
+		// the storages mounted under the path
+		for _, storage := range GetAllStorages() {
+			mountPath := storage.GetStorage().MountPath
+			if mountPath != path && utils.IsSubPath(path, mountPath) && !storage.GetStorage().Disabled {
+				walk(storage, "/")
+			}
+		}
+		log.Infof("scanned the usage of [%s]", path)
+	}()
+}
+
+func GetUserUsage(user *model.User) (*model.Usage, error) {
+	return db.GetUsage(utils.FixAndCleanPath(user.BasePath))
+}
+
+// CheckQuota checks whether the user in ctx can store size bytes in files more
+func CheckQuota(ctx context.Context, size, files int64) error {
+	user, ok := ctx.Value(conf.UserKey).(*model.User)
+	if !ok || !user.HasQuota() || size <= 0 && files <= 0 {
+		return nil
+	}
+	usage, err := GetUserUsage(user)
+	if err != nil {
+		return err
+	}
+	if user.QuotaBytes > 0 && usage.Size+size > user.QuotaBytes {
+		return errors.WithMessagef(errs.QuotaExceeded, "%d of %d bytes used", usage.Size, user.QuotaBytes)
+	}
+	if user.QuotaFiles > 0 && usage.Files+files > user.QuotaFiles {
+		return errors.WithMessagef(errs.QuotaExceeded, "%d of %d files used", usage.Files, user.QuotaFiles)
+	}
+	return nil
+}
+
+// CheckPutQuota checks whether the user in ctx can put size bytes as name in dirPath of the storage,
+// the size of an existing file of the name is freed by the overwriting
+func CheckPutQuota(ctx context.Context, storage driver.Driver, dirPath, name string, size int64) error {
+	if user, ok := ctx.Value(conf.UserKey).(*model.User); !ok || !user.HasQuota() {
+		return nil
+	}
+	old, err := GetUnwrap(ctx, storage, stdpath.Join(dirPath, name))
+	if err != nil && !errs.IsObjectNotFound(err) {
+		return err
+	}
+	return checkReplaceQuota(ctx, old, size)
+}
+
+func checkReplaceQuota(ctx context.Context, old model.Obj, size int64) error {
+	if old != nil && !old.IsDir() {
+		return CheckQuota(ctx, size-max(old.GetSize(), 0), 0)
+	}
+	return CheckQuota(ctx, size, 1)
+}
+
+// checkTransferQuota checks the quota for copying or moving the object at srcPath into dstDirPath
+// in the storage, moving inside the base path of the user doesn't change the usage.
+// A folder is counted by the recorded usage instead of listing it all, the folders nobody has listed
+// aren't counted. The recycle bin and the versions are not counted either
+func checkTransferQuota(ctx context.Context, storage driver.Driver, srcPath string, srcObj model.Obj, dstDirPath string, move bool) error {
+	user, ok := ctx.Value(conf.UserKey).(*model.User)
+	if !ok || !user.HasQuota() || IsSystemPath(storage, srcPath) || IsSystemPath(storage, dstDirPath) {
+		return nil
+	}
+	srcFullPath := utils.GetFullPath(storage.GetStorage().MountPath, srcPath)
+	if move && utils.IsSubPath(user.BasePath, srcFullPath) {
+		return nil
+	}
+	if !srcObj.IsDir() {
+		return CheckPutQuota(ctx, storage, dstDirPath, srcObj.GetName(), srcObj.GetSize())
+	}
+	usage, err := db.GetUsage(srcFullPath)
+	if err != nil {
+		return err
+	}
+	return CheckQuota(ctx, usage.Size, usage.Files)
+}
+
+func init() {
+	RegisterObjsUpdateHook(updateDirUsage)
+}
@@ -0,0 +1,126 @@
+package op_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/stream"
+	"github.com/pkg/errors"
+)
+
+func TestQuota(t *testing.T) {
+	_, err := op.CreateStorage(context.Background(), model.Storage{
+		Driver:    "Local",
+		MountPath: "/quota",
+		Addition:  `{"root_folder_path":"` + filepath.ToSlash(t.TempDir()) + `"}`,
+	})
+	if err != nil {
+		t.Fatalf("failed to create storage: %+v", err)
+	}
+	storage, err := op.GetStorageByMountPath("/quota")
+	if err != nil {
+		t.Fatal(err)
+	}
+	user := &model.User{Username: "quota", BasePath: "/quota", QuotaBytes: 10, QuotaFiles: 2}
+	if err = op.CreateUser(user); err != nil {
+		t.Fatalf("failed to create user: %+v", err)
+	}
+	ctx := context.WithValue(context.Background(), conf.UserKey, user)
+	err = op.Put(ctx, storage, "/", &stream.FileStream{
+		Obj:    &model.Object{Name: "a.txt", Size: 6},
+		Reader: strings.NewReader("abcdef"),
+	}, nil)
+	if err != nil {
+		t.Fatalf("failed to put: %+v", err)
+	}
+	usage, err := op.GetUserUsage(user)
+	if err != nil || usage.Size != 6 || usage.Files != 1 {
+		t.Fatalf("expected 6 bytes in 1 file used, got %+v, %+v", usage, err)
+	}
+	if err = op.CheckQuota(ctx, 4, 1); err != nil {
+		t.Errorf("expected quota not exceeded, got %+v", err)
+	}
+	if err = op.CheckQuota(ctx, 5, 1); !errors.Is(errors.Cause(err), errs.QuotaExceeded) {
+		t.Errorf("expected bytes quota exceeded, got %+v", err)
+	}
+	if err = op.CheckQuota(ctx, 1, 2); !errors.Is(errors.Cause(err), errs.QuotaExceeded) {
+		t.Errorf("expected files quota exceeded, got %+v", err)
+	}
+	// overwriting frees the size of the old file
+	err = op.Put(ctx, storage, "/", &stream.FileStream{
+		Obj:    &model.Object{Name: "a.txt", Size: 9},
+		Reader: strings.NewReader("abcdefghi"),
+	}, nil)
+	if err != nil {
+		t.Fatalf("failed to overwrite: %+v", err)
+	}
+	if err = op.MakeDir(ctx, storage, "/dir"); err != nil {
+		t.Fatal(err)
+	}
+	if err = op.Copy(ctx, storage, "/a.txt", "/dir"); !errors.Is(errors.Cause(err), errs.QuotaExceeded) {
+		t.Errorf("expected copy quota exceeded, got %+v", err)
+	}
+	if err = op.Move(ctx, storage, "/a.txt", "/dir"); err != nil {
+		t.Errorf("expected move inside the base path allowed, got %+v", err)
+	}
+	if err = op.Remove(ctx, storage, "/dir"); err != nil {
+		t.Fatal(err)
+	}
+	if usage, _ = op.GetUserUsage(user); usage.Size != 0 || usage.Files != 0 {
+		t.Errorf("expected nothing used after remove, got %+v", usage)
+	}
+}
+
+func TestQuotaScan(t *testing.T) {
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "x", "y"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for name, content := range map[string]string{"x/y/a.txt": "abcde", "b.txt": "abc"} {
+		if err := os.WriteFile(filepath.Join(root, filepath.FromSlash(name)), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err := op.CreateStorage(context.Background(), model.Storage{
+		Driver:    "Local",
+		MountPath: "/scan",
+		Addition:  `{"root_folder_path":"` + filepath.ToSlash(root) + `"}`,
+	})
+	if err != nil {
+		t.Fatalf("failed to create storage: %+v", err)
+	}
+	storage, err := op.GetStorageByMountPath("/scan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	// the folders nobody has listed are counted once the user gets a quota
+	user := &model.User{Username: "scan", BasePath: "/scan", QuotaBytes: 10}
+	if err = op.CreateUser(user); err != nil {
+		t.Fatalf("failed to create user: %+v", err)
+	}
+	var usage *model.Usage
+	for i := 0; i < 100; i++ {
+		if usage, err = op.GetUserUsage(user); err == nil && usage.Files == 2 {
+			break
+		}
+		time.Sleep(20 * time.Millisecond)
+	}
+	if usage == nil || usage.Size != 8 || usage.Files != 2 {
+		t.Fatalf("expected 8 bytes in 2 files used, got %+v, %+v", usage, err)
+	}
+	ctx := context.WithValue(context.Background(), conf.UserKey, user)
+	if err = op.MakeDir(ctx, storage, "/z"); err != nil {
+		t.Fatal(err)
+	}
+	if err = op.Copy(ctx, storage, "/x", "/z"); !errors.Is(errors.Cause(err), errs.QuotaExceeded) {
+		t.Errorf("expected folder copy quota exceeded, got %+v", err)
+	}
+}
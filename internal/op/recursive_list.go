@@ -97,6 +97,15 @@ func recursivelyListVirtual(ctx context.Context, rawPath string, limit rate.Limi
 }
 
 func RecursivelyListStorage(ctx context.Context, storage driver.Driver, actualPath string, limiter *rate.Limiter, counter *atomic.Uint64) {
+	RecursivelyWalkStorage(ctx, storage, actualPath, limiter, func(_ string, objs []model.Obj) {
+		if counter != nil {
+			counter.Add(uint64(len(objs)))
+		}
+	})
+}
+
+// RecursivelyWalkStorage refreshes the folders under actualPath, fn is called with the objects of every folder
+func RecursivelyWalkStorage(ctx context.Context, storage driver.Driver, actualPath string, limiter *rate.Limiter, fn func(dirPath string, objs []model.Obj)) {
 	objs, err := List(ctx, storage, actualPath, model.ListArgs{Refresh: true})
 	if err != nil {
 		if !errors.Is(err, context.Canceled) {
@@ -104,9 +113,7 @@ func RecursivelyListStorage(ctx context.Context, storage driver.Driver, actualPa
 		}
 		return
 	}
-	if counter != nil {
-		counter.Add(uint64(len(objs)))
-	}
+	fn(actualPath, objs)
 	for _, obj := range objs {
 		if utils.IsCanceled(ctx) {
 			return
@@ -120,6 +127,6 @@ func RecursivelyListStorage(ctx context.Context, storage driver.Driver, actualPa
 			}
 		}
 		nextPath := stdpath.Join(actualPath, obj.GetName())
-		RecursivelyListStorage(ctx, storage, nextPath, limiter, counter)
+		RecursivelyWalkStorage(ctx, storage, nextPath, limiter, fn)
 	}
 }
@@ -6,6 +6,7 @@ import (
 	"strings"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/pkg/singleflight"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
@@ -102,7 +103,7 @@ func GetSharingUnwrapPath(sharing *model.Sharing, path string) (unwrapPath strin
 		}
 	}
 	if len(sharing.Files) == 1 {
-		return stdpath.Join(sharing.Files[0], path), nil
+		return checkSharingUnwrapPath(stdpath.Join(sharing.Files[0], path))
 	}
 	path = utils.FixAndCleanPath(path)[1:]
 	if len(path) == 0 {
@@ -119,7 +120,15 @@ func GetSharingUnwrapPath(sharing *model.Sharing, path string) (unwrapPath strin
 	if mapPath == "" {
 		return "", fmt.Errorf("failed find child [%s] of sharing [%s]", child, sharing.ID)
 	}
-	return stdpath.Join(mapPath, rest), nil
+	return checkSharingUnwrapPath(stdpath.Join(mapPath, rest))
+}
+
+// checkSharingUnwrapPath refuses the recycle bin and the versions folder below the shared files
+func checkSharingUnwrapPath(unwrapPath string) (string, error) {
+	if storage, actualPath, err := GetStorageAndActualPath(unwrapPath); err == nil && IsSystemPath(storage, actualPath) {
+		return "", errors.WithStack(errs.PermissionDenied)
+	}
+	return unwrapPath, nil
 }
 
 func CreateSharing(sharing *model.Sharing) (id string, err error) {
@@ -160,3 +169,62 @@ func DeleteSharing(sid string) error {
 func DeleteSharingsByCreatorId(creatorId uint) error {
 	return db.DeleteSharingsByCreatorId(creatorId)
 }
+
+// ReserveSharingUpload adds the size to the usage of the sharing before the upload,
+// reserved is false if it exceeds the upload quota
+func ReserveSharingUpload(sharing *model.Sharing, size int64) (bool, error) {
+	defer sharingCache.Del(sharing.ID)
+	return db.ReserveSharingUpload(sharing.ID, size)
+}
+
+// ReleaseSharingUpload gives back the size reserved for an upload that failed
+func ReleaseSharingUpload(sharing *model.Sharing, size int64) error {
+	defer sharingCache.Del(sharing.ID)
+	return db.ReleaseSharingUpload(sharing.ID, size)
+}
+
+// StartSharingUpload records an upload through the sharing before it's written to dstPath,
+// the record holds dstPath until it's finished so started is false if another upload holds it
+func StartSharingUpload(sharing *model.Sharing, path, dstPath string, size int64, ip string) (u *model.SharingUpload, started bool, err error) {
+	u = &model.SharingUpload{
+		SharingID: sharing.ID,
+		Path:      path,
+		Size:      size,
+		IP:        ip,
+	}
+	started, err = db.CreateSharingUpload(u, dstPath)
+	return u, started, err
+}
+
+// FinishSharingUpload releases the destination path of an upload that succeeded
+func FinishSharingUpload(u *model.SharingUpload) error {
+	return db.FinishSharingUpload(u.ID)
+}
+
+// CancelSharingUpload deletes the record of an upload that failed and gives back its size
+func CancelSharingUpload(u *model.SharingUpload) error {
+	defer sharingCache.Del(u.SharingID)
+	if err := db.ReleaseSharingUpload(u.SharingID, u.Size); err != nil {
+		return err
+	}
+	return db.DeleteSharingUpload(u.ID)
+}
+
+// CancelUnfinishedSharingUploads cancels the uploads interrupted by a restart,
+// the upload tasks aren't persisted so they never finish
+func CancelUnfinishedSharingUploads() error {
+	uploads, err := db.GetUnfinishedSharingUploads()
+	if err != nil {
+		return err
+	}
+	for i := range uploads {
+		if err = CancelSharingUpload(&uploads[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func GetSharingUploads(sid string, pageIndex, pageSize int) ([]model.SharingUpload, int64, error) {
+	return db.GetSharingUploads(sid, pageIndex, pageSize)
+}
@@ -0,0 +1,148 @@
+package op
+
+import (
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/go-cache"
+	"github.com/pkg/errors"
+)
+
+func clientIP(ctx context.Context) string {
+	ip, _ := ctx.Value(conf.ClientIPKey).(string)
+	if host, _, err := net.SplitHostPort(ip); err == nil {
+		return host
+	}
+	return ip
+}
+
+// CheckSharingAccess checks the allowed countries and the visitor limit of the sharing
+// against the client ip in ctx, a new ip is counted as a visitor here
+func CheckSharingAccess(ctx context.Context, sharing *model.Sharing) error {
+	ip := clientIP(ctx)
+	if sharing.AllowedCountries != "" && !sharing.AllowCountry(GetIPInfo(ip).CountryCode) {
+		return errors.WithStack(errs.NewErr(errs.SharingDenied, "not allowed in your country"))
+	}
+	return claimSharingVisitor(sharing.ID, ip)
+}
+
+var (
+	// knownVisitors remembers the claimed visitors until their accesses are recorded
+	knownVisitors   = cache.NewMemCache[struct{}]()
+	knownVisitorTTL = time.Hour
+	visitorMu       sync.Mutex
+)
+
+func claimSharingVisitor(sid, ip string) error {
+	key := sid + ":" + ip
+	if _, ok := knownVisitors.Get(key); ok {
+		return nil
+	}
+	visitorMu.Lock()
+	defer visitorMu.Unlock()
+	if _, ok := knownVisitors.Get(key); ok {
+		return nil
+	}
+	visited, err := db.HasSharingVisitor(sid, ip)
+	if err != nil {
+		return err
+	}
+	if !visited {
+		added, err := db.AddSharingVisitor(sid)
+		if err != nil {
+			return err
+		}
+		if !added {
+			return errors.WithStack(errs.NewErr(errs.SharingDenied, "the visitor limit is reached"))
+		}
+		sharingCache.Del(sid)
+	}
+	knownVisitors.Set(key, struct{}{}, cache.WithEx[struct{}](knownVisitorTTL))
+	return nil
+}
+
+// RecordSharingAccess logs the access and adds it to the served bytes of the sharing
+func RecordSharingAccess(sharing *model.Sharing, a *model.SharingAccess) error {
+	a.SharingID = sharing.ID
+	a.Time = time.Now()
+	if host, _, err := net.SplitHostPort(a.IP); err == nil {
+		a.IP = host
+	}
+	if a.IP != "" && db.GetIPDB().HasData() {
+		info := GetIPInfo(a.IP)
+		a.Asn, a.Aso = info.Asn, info.Aso
+		a.Country, a.CountryCode = info.Country, info.CountryCode
+	}
+	if err := db.CreateSharingAccess(a); err != nil {
+		return err
+	}
+	if a.Bytes == 0 {
+		return nil
+	}
+	defer sharingCache.Del(sharing.ID)
+	return db.AddSharingServed(sharing.ID, a.Bytes)
+}
+
+func AddSharingAccessed(sid string) error {
+	defer sharingCache.Del(sid)
+	return db.AddSharingAccessed(sid)
+}
+
+func DeleteSharingAccessesBefore(t time.Time) error {
+	return db.DeleteSharingAccessesBefore(t)
+}
+
+// GetSharingStats returns the accesses in [start, end) grouped by interval
+func GetSharingStats(sid string, start, end time.Time, interval time.Duration) (*model.SharingStats, error) {
+	if interval <= 0 || !start.Before(end) {
+		return nil, errors.New("invalid time range")
+	}
+	n := int(end.Sub(start)/interval) + 1
+	if n > 10000 {
+		return nil, errors.New("too many intervals")
+	}
+	stats := &model.SharingStats{
+		Series:    make([]model.SharingStat, 0, n),
+		Countries: make(map[string]int64),
+	}
+	for t := start; t.Before(end); t = t.Add(interval) {
+		stats.Series = append(stats.Series, model.SharingStat{Time: t})
+	}
+	visitors := make(map[string]struct{})
+	seriesVisitors := make([]map[string]struct{}, len(stats.Series))
+	err := db.FindSharingAccessesInBatches(sid, start, end, 1000, func(accesses []model.SharingAccess) error {
+		for _, a := range accesses {
+			i := int(a.Time.Sub(start) / interval)
+			if i < 0 || i >= len(stats.Series) {
+				continue
+			}
+			if seriesVisitors[i] == nil {
+				seriesVisitors[i] = make(map[string]struct{})
+			}
+			seriesVisitors[i][a.IP] = struct{}{}
+			visitors[a.IP] = struct{}{}
+			stats.Series[i].Accesses++
+			stats.Series[i].Bytes += a.Bytes
+			stats.Accesses++
+			stats.Bytes += a.Bytes
+			if a.CountryCode != "" {
+				stats.Countries[a.CountryCode]++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	for i := range stats.Series {
+		stats.Series[i].Visitors = int64(len(seriesVisitors[i]))
+	}
+	stats.Visitors = int64(len(visitors))
+	return stats, nil
+}
@@ -0,0 +1,64 @@
+package op_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+)
+
+func TestSharingAccess(t *testing.T) {
+	s := &model.Sharing{
+		SharingDB: &model.SharingDB{ID: "access", MaxVisitors: 2},
+		Files:     []string{"/a"},
+		Creator:   &model.User{ID: 1},
+	}
+	if _, err := op.CreateSharing(s); err != nil {
+		t.Fatalf("failed create sharing: %+v", err)
+	}
+	for _, a := range []model.SharingAccess{
+		{IP: "10.0.0.1", Path: "/a.txt", Bytes: 10},
+		{IP: "10.0.0.1", Path: "/b.txt", Bytes: 20},
+		{IP: "10.0.0.2:1234", Path: "/a.txt"},
+	} {
+		ctx := context.WithValue(context.Background(), conf.ClientIPKey, a.IP)
+		if err := op.CheckSharingAccess(ctx, s); err != nil {
+			t.Fatalf("failed check access: %+v", err)
+		}
+		if err := op.RecordSharingAccess(s, &a); err != nil {
+			t.Fatalf("failed record access: %+v", err)
+		}
+	}
+	got, err := op.GetSharingById(s.ID, true)
+	if err != nil {
+		t.Fatalf("failed get sharing: %+v", err)
+	}
+	if got.Visitors != 2 || got.Served != 30 {
+		t.Errorf("expected 2 visitors and 30 bytes served, got %d and %d", got.Visitors, got.Served)
+	}
+	ctx := context.WithValue(context.Background(), conf.ClientIPKey, "10.0.0.2")
+	if err := op.CheckSharingAccess(ctx, got); err != nil {
+		t.Errorf("expected a known visitor to be allowed, got %+v", err)
+	}
+	ctx = context.WithValue(context.Background(), conf.ClientIPKey, "10.0.0.3")
+	if err := op.CheckSharingAccess(ctx, got); !errors.Is(err, errs.SharingDenied) {
+		t.Errorf("expected a new visitor to be denied, got %+v", err)
+	}
+
+	end := time.Now().Add(time.Hour).Truncate(time.Hour)
+	stats, err := op.GetSharingStats(s.ID, end.Add(-3*time.Hour), end, time.Hour)
+	if err != nil {
+		t.Fatalf("failed get stats: %+v", err)
+	}
+	if len(stats.Series) != 3 || stats.Accesses != 3 || stats.Visitors != 2 || stats.Bytes != 30 {
+		t.Fatalf("unexpected stats: %+v", stats)
+	}
+	if last := stats.Series[2]; last.Accesses != 3 || last.Visitors != 2 {
+		t.Errorf("expected all accesses in the last hour, got %+v", last)
+	}
+}
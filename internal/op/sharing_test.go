@@ -0,0 +1,73 @@
+package op_test
+
+import (
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+)
+
+func TestReserveSharingUpload(t *testing.T) {
+	s := &model.Sharing{
+		SharingDB: &model.SharingDB{ID: "upload", UploadQuota: 10},
+		Files:     []string{"/a"},
+		Creator:   &model.User{ID: 1},
+	}
+	if _, err := op.CreateSharing(s); err != nil {
+		t.Fatalf("failed create sharing: %+v", err)
+	}
+	for i, want := range []bool{true, false} {
+		if reserved, err := op.ReserveSharingUpload(s, 6); err != nil || reserved != want {
+			t.Fatalf("reserve #%d: got %v, %+v, want %v", i, reserved, err, want)
+		}
+	}
+	if err := op.ReleaseSharingUpload(s, 6); err != nil {
+		t.Fatalf("failed release: %+v", err)
+	}
+	if reserved, err := op.ReserveSharingUpload(s, 10); err != nil || !reserved {
+		t.Fatalf("expected the released size to be reserved again, got %v, %+v", reserved, err)
+	}
+	if s, err := op.GetSharingById("upload"); err != nil || s.Uploaded != 10 {
+		t.Fatalf("expected 10 bytes uploaded, got %+v, %+v", s, err)
+	}
+}
+
+func TestStartSharingUpload(t *testing.T) {
+	s := &model.Sharing{
+		SharingDB: &model.SharingDB{ID: "names"},
+		Files:     []string{"/a"},
+		Creator:   &model.User{ID: 1},
+	}
+	if _, err := op.CreateSharing(s); err != nil {
+		t.Fatalf("failed create sharing: %+v", err)
+	}
+	if _, err := op.ReserveSharingUpload(s, 4); err != nil {
+		t.Fatalf("failed reserve: %+v", err)
+	}
+	first, started, err := op.StartSharingUpload(s, "/x.txt", "/a/x.txt", 4, "")
+	if err != nil || !started {
+		t.Fatalf("failed start upload: %v, %+v", started, err)
+	}
+	if _, started, err = op.StartSharingUpload(s, "/x.txt", "/a/x.txt", 4, ""); err != nil || started {
+		t.Fatalf("expected the name to be held by the first upload, got %v, %+v", started, err)
+	}
+	if err = op.CancelSharingUpload(first); err != nil {
+		t.Fatalf("failed cancel upload: %+v", err)
+	}
+	if s, err := op.GetSharingById("names"); err != nil || s.Uploaded != 0 {
+		t.Fatalf("expected the size to be given back, got %+v, %+v", s, err)
+	}
+	second, started, err := op.StartSharingUpload(s, "/x.txt", "/a/x.txt", 4, "")
+	if err != nil || !started {
+		t.Fatalf("expected the name to be released by the cancel, got %v, %+v", started, err)
+	}
+	if err = op.FinishSharingUpload(second); err != nil {
+		t.Fatalf("failed finish upload: %+v", err)
+	}
+	if uploads, total, err := op.GetSharingUploads("names", 1, 10); err != nil || total != 1 || uploads[0].ID != second.ID {
+		t.Fatalf("expected only the finished upload to be recorded, got %+v, %d, %+v", uploads, total, err)
+	}
+	if _, started, err = op.StartSharingUpload(s, "/x.txt", "/a/x.txt", 4, ""); err != nil || !started {
+		t.Fatalf("expected the name to be released by the finish, got %v, %+v", started, err)
+	}
+}
@@ -0,0 +1,186 @@
+package op
+
+import (
+	"context"
+	stdpath "path"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// TrashFolder is the folder at the root of a storage that holds removed objects,
+// every removal gets its own sub folder named by the time of the removal
+const TrashFolder = ".openlist_trash"
+
+func IsTrashPath(path string) bool {
+	return utils.IsSubPath("/"+TrashFolder, path)
+}
+
+// IsSystemPath reports whether the actual path is in the recycle bin or the versions folder,
+// they are only reachable through the trash and versions api.
+// The folders are only reserved when the storage enables them, otherwise they're folders of the user
+func IsSystemPath(storage driver.Driver, path string) bool {
+	return storage.GetStorage().EnableTrash && IsTrashPath(path) ||
+		isVersionsEnabled(storage) && IsVersionPath(path)
+}
+
+// HideSystemFolders hides the recycle bin and the versions folder from the objects at the root of a storage
+func HideSystemFolders(storage driver.Driver, objs []model.Obj) []model.Obj {
+	return slices.DeleteFunc(slices.Clone(objs), func(obj model.Obj) bool {
+		return obj.IsDir() && IsSystemPath(storage, "/"+obj.GetName())
+	})
+}
+
+// Trash removes the object at path, it's moved into the recycle bin if the storage enables it.
+// Objects are moved when the driver supports moving, otherwise they are deleted
+// and a tombstone is recorded.
+func Trash(ctx context.Context, storage driver.Driver, path string) error {
+	path = utils.FixAndCleanPath(path)
+	if !storage.GetStorage().EnableTrash || IsTrashPath(path) {
+		return Remove(ctx, storage, path)
+	}
+	if utils.PathEqual(path, "/") {
+		return errors.New("delete root folder is not allowed")
+	}
+	obj, err := Get(ctx, storage, path, true)
+	if err != nil {
+		if errs.IsObjectNotFound(err) {
+			log.Debugf("%s have been removed", path)
+			return nil
+		}
+		return errors.WithMessage(err, "failed to get object")
+	}
+	if model.ObjHasMask(obj, model.NoRemove) {
+		return errors.WithStack(errs.PermissionDenied)
+	}
+	now := time.Now()
+	item := &model.TrashItem{
+		StorageID: storage.GetStorage().ID,
+		MountPath: storage.GetStorage().MountPath,
+		Path:      path,
+		Name:      obj.GetName(),
+		IsDir:     obj.IsDir(),
+		Size:      obj.GetSize(),
+		DeletedAt: now,
+	}
+	if user, ok := ctx.Value(conf.UserKey).(*model.User); ok {
+		item.Deleter = user.Username
+	}
+	if canMove(storage) {
+		dir := stdpath.Join("/", TrashFolder, strconv.FormatInt(now.UnixNano(), 10))
+		// the recycle bin is not indexed, and the object is not in the index any more
+		hookCtx := context.WithValue(ctx, conf.SkipHookKey, struct{}{})
+		if err = MakeDir(hookCtx, storage, dir); err != nil {
+			return errors.WithMessage(err, "failed to make trash dir")
+		}
+		if err = Move(hookCtx, storage, path, dir); err != nil {
+			_ = Remove(ctx, storage, dir)
+			return errors.WithMessage(err, "failed to move object to trash")
+		}
+		item.TrashPath = stdpath.Join(dir, obj.GetName())
+	} else if err = Remove(ctx, storage, path); err != nil {
+		return err
+	}
+	if err = db.CreateTrashItem(item); err != nil {
+		return errors.WithMessage(err, "failed to record trash item")
+	}
+	return nil
+}
+
+func canMove(storage driver.Driver) bool {
+	switch storage.(type) {
+	case driver.Move, driver.MoveResult:
+		return true
+	}
+	return false
+}
+
+func GetTrashItems(pageIndex, pageSize int) ([]model.TrashItem, int64, error) {
+	return db.GetTrashItems(pageIndex, pageSize)
+}
+
+func getTrashStorage(item *model.TrashItem) (driver.Driver, error) {
+	storage, err := GetStorageByMountPath(item.MountPath)
+	if err != nil || storage.GetStorage().ID != item.StorageID {
+		return nil, errors.WithStack(errs.StorageNotFound)
+	}
+	return storage, nil
+}
+
+// RestoreTrashItem moves a removed object back to its original path
+func RestoreTrashItem(ctx context.Context, id uint) error {
+	item, err := db.GetTrashItemById(id)
+	if err != nil {
+		return err
+	}
+	if item.IsTombstone() {
+		return errors.WithMessage(errs.NotSupport, "the object was deleted permanently")
+	}
+	storage, err := getTrashStorage(item)
+	if err != nil {
+		return err
+	}
+	if _, err = Get(ctx, storage, item.Path); err == nil {
+		return errors.WithStack(errs.ObjectAlreadyExists)
+	} else if !errs.IsObjectNotFound(err) {
+		return errors.WithMessage(err, "failed to check original path")
+	}
+	dir := stdpath.Dir(item.Path)
+	if err = MakeDir(ctx, storage, dir); err != nil {
+		return errors.WithMessage(err, "failed to make original dir")
+	}
+	if err = Move(ctx, storage, item.TrashPath, dir); err != nil {
+		return errors.WithMessage(err, "failed to move object out of trash")
+	}
+	trashDir := stdpath.Dir(item.TrashPath)
+	if err = Remove(ctx, storage, trashDir); err != nil {
+		log.Warnf("failed to remove trash dir [%s]: %+v", trashDir, err)
+	}
+	return db.DeleteTrashItemById(item.ID)
+}
+
+// PurgeTrashItem deletes a removed object permanently
+func PurgeTrashItem(ctx context.Context, id uint) error {
+	item, err := db.GetTrashItemById(id)
+	if err != nil {
+		return err
+	}
+	return purgeTrashItem(ctx, item)
+}
+
+func purgeTrashItem(ctx context.Context, item *model.TrashItem) error {
+	if !item.IsTombstone() {
+		storage, err := getTrashStorage(item)
+		if err == nil {
+			err = Remove(ctx, storage, stdpath.Dir(item.TrashPath))
+		}
+		// the record is useless if the storage has gone
+		if err != nil && !errs.IsNotFoundError(err) {
+			return err
+		}
+	}
+	return db.DeleteTrashItemById(item.ID)
+}
+
+// PurgeTrashBefore deletes the objects removed before t permanently
+func PurgeTrashBefore(ctx context.Context, t time.Time) error {
+	items, err := db.GetTrashItemsDeletedBefore(t)
+	if err != nil {
+		return err
+	}
+	for i := range items {
+		if err := purgeTrashItem(ctx, &items[i]); err != nil {
+			log.Errorf("failed to purge trash item [%s] of storage [%s]: %+v", items[i].Path, items[i].MountPath, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package op_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+)
+
+func TestTrash(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(root, "dir"), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(root, "dir", "a.txt"), []byte("a"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	_, err := op.CreateStorage(ctx, model.Storage{
+		Driver:      "Local",
+		MountPath:   "/trash",
+		EnableTrash: true,
+		Addition:    `{"root_folder_path":"` + filepath.ToSlash(root) + `"}`,
+	})
+	if err != nil {
+		t.Fatalf("failed to create storage: %+v", err)
+	}
+	storage, err := op.GetStorageByMountPath("/trash")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	trash := func() model.TrashItem {
+		t.Helper()
+		if err := op.Trash(ctx, storage, "/dir/a.txt"); err != nil {
+			t.Fatalf("failed to trash: %+v", err)
+		}
+		if _, err := os.Stat(filepath.Join(root, "dir", "a.txt")); !os.IsNotExist(err) {
+			t.Fatalf("expected file removed, got %v", err)
+		}
+		items, total, err := op.GetTrashItems(1, 10)
+		if err != nil || total != 1 {
+			t.Fatalf("expected 1 trash item, got %d, %+v", total, err)
+		}
+		if items[0].Path != "/dir/a.txt" || items[0].IsTombstone() {
+			t.Fatalf("unexpected trash item: %+v", items[0])
+		}
+		return items[0]
+	}
+
+	item := trash()
+	if err = op.RestoreTrashItem(ctx, item.ID); err != nil {
+		t.Fatalf("failed to restore: %+v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(root, "dir", "a.txt")); err != nil || string(data) != "a" {
+		t.Fatalf("expected file restored, got %q, %v", data, err)
+	}
+
+	trash()
+	if err = op.PurgeTrashBefore(ctx, time.Now()); err != nil {
+		t.Fatalf("failed to purge: %+v", err)
+	}
+	if _, total, _ := op.GetTrashItems(1, 10); total != 0 {
+		t.Fatalf("expected trash empty, got %d items", total)
+	}
+	entries, _ := os.ReadDir(filepath.Join(root, op.TrashFolder))
+	if len(entries) != 0 {
+		t.Fatalf("expected trash folder empty, got %d entries", len(entries))
+	}
+}
@@ -15,7 +15,7 @@ var adminUser *model.User
 
 func GetAdmin() (*model.User, error) {
 	if adminUser == nil {
-		user, err := db.GetUserByRole(model.ADMIN)
+		user, err := GetUserByRole(model.ADMIN)
 		if err != nil {
 			return nil, err
 		}
@@ -26,7 +26,7 @@ func GetAdmin() (*model.User, error) {
 
 func GetGuest() (*model.User, error) {
 	if guestUser == nil {
-		user, err := db.GetUserByRole(model.GUEST)
+		user, err := GetUserByRole(model.GUEST)
 		if err != nil {
 			return nil, err
 		}
@@ -36,7 +36,7 @@ func GetGuest() (*model.User, error) {
 }
 
 func GetUserByRole(role int) (*model.User, error) {
-	return db.GetUserByRole(role)
+	return withGroups(db.GetUserByRole(role))
 }
 
 func GetUserByName(username string) (*model.User, error) {
@@ -47,7 +47,7 @@ func GetUserByName(username string) (*model.User, error) {
 		return user, nil
 	}
 	user, err, _ := userG.Do(username, func() (*model.User, error) {
-		_user, err := db.GetUserByName(username)
+		_user, err := withGroups(db.GetUserByName(username))
 		if err != nil {
 			return nil, err
 		}
@@ -58,16 +58,46 @@ func GetUserByName(username string) (*model.User, error) {
 }
 
 func GetUserById(id uint) (*model.User, error) {
-	return db.GetUserById(id)
+	return withGroups(db.GetUserById(id))
 }
 
 func GetUsers(pageIndex, pageSize int) (users []model.User, count int64, err error) {
-	return db.GetUsers(pageIndex, pageSize)
+	users, count, err = db.GetUsers(pageIndex, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	for i := range users {
+		if err = applyGroups(&users[i]); err != nil {
+			return nil, 0, err
+		}
+	}
+	return users, count, nil
+}
+
+func withGroups(u *model.User, err error) (*model.User, error) {
+	if err != nil {
+		return nil, err
+	}
+	if err = applyGroups(u); err != nil {
+		return nil, err
+	}
+	return u, nil
 }
 
 func CreateUser(u *model.User) error {
+	if err := checkGroupIds(u.GroupIDs); err != nil {
+		return err
+	}
+	if u.BasePath == "" {
+		u.BasePath = GroupBasePath(u.GroupIDs)
+	}
 	u.BasePath = utils.FixAndCleanPath(u.BasePath)
-	return db.CreateUser(u)
+	err := db.CreateUser(u)
+	resetQuotas()
+	if err == nil && u.HasQuota() {
+		scanUsage(u.BasePath)
+	}
+	return err
 }
 
 func DeleteUserById(id uint) error {
@@ -82,6 +112,10 @@ func DeleteUserById(id uint) error {
 	if err := DeleteSharingsByCreatorId(id); err != nil {
 		return errors.WithMessage(err, "failed to delete user's sharings")
 	}
+	if err := db.DeleteAccessTokensByUserId(id); err != nil {
+		return errors.WithMessage(err, "failed to delete user's access tokens")
+	}
+	defer resetQuotas()
 	return db.DeleteUserById(id)
 }
 
@@ -96,9 +130,18 @@ func UpdateUser(u *model.User) error {
 	if u.IsGuest() {
 		guestUser = nil
 	}
+	if err := checkGroupIds(u.GroupIDs); err != nil {
+		return err
+	}
 	Cache.DeleteUser(old.Username)
 	u.BasePath = utils.FixAndCleanPath(u.BasePath)
-	return db.UpdateUser(u)
+	err = db.UpdateUser(u)
+	resetQuotas()
+	// the usage of the folders nobody has listed isn't recorded yet
+	if err == nil && u.HasQuota() && (!old.HasQuota() || old.BasePath != u.BasePath) {
+		scanUsage(u.BasePath)
+	}
+	return err
 }
 
 func Cancel2FAByUser(u *model.User) error {
@@ -0,0 +1,223 @@
+package op
+
+import (
+	"cmp"
+	"context"
+	stdpath "path"
+	"slices"
+	"strconv"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// VersionsFolder is the folder at the root of a storage that holds old versions of files,
+// the versions of /a/b.txt are kept as /.openlist_versions/a/b.txt/<timestamp>/b.txt.
+// Versioning is enabled per storage by MaxVersions, the meta paths can't opt in
+const VersionsFolder = ".openlist_versions"
+
+func IsVersionPath(path string) bool {
+	return utils.IsSubPath("/"+VersionsFolder, path)
+}
+
+// isVersionsEnabled reports whether the versions folder of the storage is reserved,
+// it's a folder of the user when the storage doesn't enable versioning
+func isVersionsEnabled(storage driver.Driver) bool {
+	return storage.GetStorage().MaxVersions > 0
+}
+
+func versionsDir(path string) string {
+	return stdpath.Join("/", VersionsFolder, path)
+}
+
+func needVersion(storage driver.Driver, path string, old model.Obj) bool {
+	return isVersionsEnabled(storage) && !old.IsDir() && old.GetSize() > 0 &&
+		!IsSystemPath(storage, path) && canMove(storage)
+}
+
+// saveVersion moves the file at path into the versions folder and returns the folder of the version
+func saveVersion(ctx context.Context, storage driver.Driver, path string) (string, error) {
+	dir := stdpath.Join(versionsDir(path), strconv.FormatInt(time.Now().UnixNano(), 10))
+	// versions are not indexed
+	ctx = context.WithValue(ctx, conf.SkipHookKey, struct{}{})
+	if err := MakeDir(ctx, storage, dir); err != nil {
+		return "", errors.WithMessage(err, "failed to make version dir")
+	}
+	if err := Move(ctx, storage, path, dir); err != nil {
+		_ = Remove(ctx, storage, dir)
+		return "", errors.WithMessage(err, "failed to move file to versions")
+	}
+	return dir, nil
+}
+
+// dropVersion moves the file saved by saveVersion back, used when the overwriting fails
+func dropVersion(ctx context.Context, storage driver.Driver, path, dir string) {
+	ctx = context.WithValue(ctx, conf.SkipHookKey, struct{}{})
+	if err := Move(ctx, storage, stdpath.Join(dir, stdpath.Base(path)), stdpath.Dir(path)); err != nil {
+		log.Errorf("failed recover old version of [%s]: %+v", path, err)
+		return
+	}
+	if err := Remove(ctx, storage, dir); err != nil {
+		log.Warnf("failed to remove version dir [%s]: %+v", dir, err)
+	}
+}
+
+// moveVersions makes the versions follow the object moved or renamed from srcPath to dstPath,
+// the copies in the versions of a file are named after it and renamed as well.
+// The versions aren't moved to other storages, they stay with the removed source
+func moveVersions(ctx context.Context, storage driver.Driver, srcPath, dstPath string, isDir bool) {
+	if !isVersionsEnabled(storage) || IsSystemPath(storage, srcPath) || IsSystemPath(storage, dstPath) {
+		return
+	}
+	src, dst := versionsDir(srcPath), versionsDir(dstPath)
+	if _, err := Get(ctx, storage, src); err != nil {
+		if !errs.IsObjectNotFound(err) {
+			log.Warnf("failed to get versions of [%s]: %+v", srcPath, err)
+		}
+		return
+	}
+	ctx = context.WithValue(ctx, conf.SkipHookKey, struct{}{})
+	err := func() error {
+		// the versions left at dst belong to a removed object
+		if err := Remove(ctx, storage, dst); err != nil {
+			return err
+		}
+		if err := MakeDir(ctx, storage, stdpath.Dir(dst)); err != nil {
+			return err
+		}
+		if stdpath.Dir(src) != stdpath.Dir(dst) {
+			if err := Move(ctx, storage, src, stdpath.Dir(dst)); err != nil {
+				return err
+			}
+		}
+		if stdpath.Base(src) != stdpath.Base(dst) {
+			return Rename(ctx, storage, stdpath.Join(stdpath.Dir(dst), stdpath.Base(src)), stdpath.Base(dst))
+		}
+		return nil
+	}()
+	if err != nil {
+		log.Errorf("failed to move versions of [%s] to [%s]: %+v", srcPath, dstPath, err)
+		return
+	}
+	if isDir || stdpath.Base(srcPath) == stdpath.Base(dstPath) {
+		return
+	}
+	dirs, err := listVersionDirs(ctx, storage, dstPath)
+	if err != nil {
+		log.Warnf("failed to list versions of [%s]: %+v", dstPath, err)
+		return
+	}
+	for _, dir := range dirs {
+		p := stdpath.Join(dst, dir.GetName(), stdpath.Base(srcPath))
+		if err = Rename(ctx, storage, p, stdpath.Base(dstPath)); err != nil {
+			log.Warnf("failed to rename version [%s]: %+v", p, err)
+		}
+	}
+}
+
+func listVersionDirs(ctx context.Context, storage driver.Driver, path string) ([]model.Obj, error) {
+	objs, err := List(ctx, storage, versionsDir(path), model.ListArgs{SkipHook: true})
+	if err != nil {
+		return nil, err
+	}
+	dirs := slices.DeleteFunc(slices.Clone(objs), func(obj model.Obj) bool {
+		_, err := strconv.ParseInt(obj.GetName(), 10, 64)
+		return !obj.IsDir() || err != nil
+	})
+	// the latest first
+	slices.SortFunc(dirs, func(a, b model.Obj) int {
+		x, _ := strconv.ParseInt(a.GetName(), 10, 64)
+		y, _ := strconv.ParseInt(b.GetName(), 10, 64)
+		return cmp.Compare(y, x)
+	})
+	return dirs, nil
+}
+
+// pruneVersions removes the oldest versions of the file exceeding the limit of the storage
+func pruneVersions(ctx context.Context, storage driver.Driver, path string) {
+	dirs, err := listVersionDirs(ctx, storage, path)
+	if err != nil {
+		log.Warnf("failed to list versions of [%s]: %+v", path, err)
+		return
+	}
+	ctx = context.WithValue(ctx, conf.SkipHookKey, struct{}{})
+	for i := storage.GetStorage().MaxVersions; i < len(dirs); i++ {
+		dir := stdpath.Join(versionsDir(path), dirs[i].GetName())
+		if err = Remove(ctx, storage, dir); err != nil {
+			log.Warnf("failed to remove version [%s]: %+v", dir, err)
+		}
+	}
+}
+
+// GetVersions returns the old versions of the file at path, the latest first
+func GetVersions(ctx context.Context, storage driver.Driver, path string) ([]model.FileVersion, error) {
+	path = utils.FixAndCleanPath(path)
+	if !isVersionsEnabled(storage) {
+		return []model.FileVersion{}, nil
+	}
+	dirs, err := listVersionDirs(ctx, storage, path)
+	if err != nil {
+		if errs.IsObjectNotFound(err) {
+			return []model.FileVersion{}, nil
+		}
+		return nil, err
+	}
+	versions := make([]model.FileVersion, 0, len(dirs))
+	for _, dir := range dirs {
+		versionPath := stdpath.Join(versionsDir(path), dir.GetName(), stdpath.Base(path))
+		obj, err := Get(ctx, storage, versionPath)
+		if err != nil {
+			log.Warnf("failed to get version [%s]: %+v", versionPath, err)
+			continue
+		}
+		versions = append(versions, model.FileVersion{
+			Version:  dir.GetName(),
+			Path:     versionPath,
+			Size:     obj.GetSize(),
+			Modified: obj.ModTime(),
+		})
+	}
+	return versions, nil
+}
+
+// RestoreVersion brings back an old version of the file at path,
+// the current file becomes a version in turn
+func RestoreVersion(ctx context.Context, storage driver.Driver, path, version string) error {
+	path = utils.FixAndCleanPath(path)
+	if !isVersionsEnabled(storage) {
+		return errors.WithMessage(errs.NotSupport, "the storage doesn't enable versioning")
+	}
+	if _, err := strconv.ParseInt(version, 10, 64); err != nil {
+		return errors.WithStack(errs.ObjectNotFound)
+	}
+	versionDir := stdpath.Join(versionsDir(path), version)
+	versionPath := stdpath.Join(versionDir, stdpath.Base(path))
+	if _, err := Get(ctx, storage, versionPath); err != nil {
+		return errors.WithMessage(err, "failed to get version")
+	}
+	if cur, err := Get(ctx, storage, path); err == nil {
+		if cur.IsDir() {
+			return errors.WithStack(errs.NotFile)
+		}
+		if _, err = saveVersion(ctx, storage, path); err != nil {
+			return err
+		}
+	} else if !errs.IsObjectNotFound(err) {
+		return errors.WithMessage(err, "failed to get current file")
+	}
+	if err := Move(ctx, storage, versionPath, stdpath.Dir(path)); err != nil {
+		return errors.WithMessage(err, "failed to move version back")
+	}
+	hookCtx := context.WithValue(ctx, conf.SkipHookKey, struct{}{})
+	if err := Remove(hookCtx, storage, versionDir); err != nil {
+		log.Warnf("failed to remove version dir [%s]: %+v", versionDir, err)
+	}
+	pruneVersions(ctx, storage, path)
+	return nil
+}
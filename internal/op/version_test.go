@@ -0,0 +1,86 @@
+package op_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/stream"
+)
+
+func TestVersions(t *testing.T) {
+	ctx := context.Background()
+	root := t.TempDir()
+	_, err := op.CreateStorage(ctx, model.Storage{
+		Driver:      "Local",
+		MountPath:   "/versions",
+		MaxVersions: 2,
+		Addition:    `{"root_folder_path":"` + filepath.ToSlash(root) + `"}`,
+	})
+	if err != nil {
+		t.Fatalf("failed to create storage: %+v", err)
+	}
+	storage, err := op.GetStorageByMountPath("/versions")
+	if err != nil {
+		t.Fatal(err)
+	}
+	put := func(content string) {
+		t.Helper()
+		err := op.Put(ctx, storage, "/dir", &stream.FileStream{
+			Obj:    &model.Object{Name: "a.txt", Size: int64(len(content))},
+			Reader: strings.NewReader(content),
+		}, nil)
+		if err != nil {
+			t.Fatalf("failed to put: %+v", err)
+		}
+	}
+	for _, content := range []string{"1", "22", "333", "4444"} {
+		put(content)
+	}
+	versions, err := op.GetVersions(ctx, storage, "/dir/a.txt")
+	if err != nil {
+		t.Fatalf("failed to get versions: %+v", err)
+	}
+	if len(versions) != 2 || versions[0].Size != 3 || versions[1].Size != 2 {
+		t.Fatalf("expected the latest 2 versions, got %+v", versions)
+	}
+
+	if err = op.RestoreVersion(ctx, storage, "/dir/a.txt", versions[1].Version); err != nil {
+		t.Fatalf("failed to restore: %+v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(root, "dir", "a.txt")); err != nil || string(data) != "22" {
+		t.Fatalf("expected version restored, got %q, %v", data, err)
+	}
+	versions, _ = op.GetVersions(ctx, storage, "/dir/a.txt")
+	if len(versions) != 2 || versions[0].Size != 4 || versions[1].Size != 3 {
+		t.Fatalf("expected the overwritten file kept as a version, got %+v", versions)
+	}
+
+	// the versions follow the renames and the moves
+	if err = op.Rename(ctx, storage, "/dir/a.txt", "b.txt"); err != nil {
+		t.Fatalf("failed to rename: %+v", err)
+	}
+	if err = op.MakeDir(ctx, storage, "/other"); err != nil {
+		t.Fatal(err)
+	}
+	if err = op.Move(ctx, storage, "/dir", "/other"); err != nil {
+		t.Fatalf("failed to move: %+v", err)
+	}
+	if versions, _ = op.GetVersions(ctx, storage, "/dir/a.txt"); len(versions) != 0 {
+		t.Fatalf("expected no versions left behind, got %+v", versions)
+	}
+	versions, _ = op.GetVersions(ctx, storage, "/other/dir/b.txt")
+	if len(versions) != 2 || versions[0].Size != 4 || versions[1].Size != 3 {
+		t.Fatalf("expected the versions moved, got %+v", versions)
+	}
+	if err = op.RestoreVersion(ctx, storage, "/other/dir/b.txt", versions[0].Version); err != nil {
+		t.Fatalf("failed to restore: %+v", err)
+	}
+	if data, err := os.ReadFile(filepath.Join(root, "other", "dir", "b.txt")); err != nil || string(data) != "4444" {
+		t.Fatalf("expected moved version restored, got %q, %v", data, err)
+	}
+}
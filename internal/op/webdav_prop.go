@@ -0,0 +1,32 @@
+package op
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// the paths of the dead properties are mount paths
+
+func GetDeadPropsUnder(path string) ([]model.DeadProp, error) {
+	return db.GetDeadPropsUnder(utils.FixAndCleanPath(path))
+}
+
+func PatchDeadProps(path string, set, remove []model.DeadProp) error {
+	return db.PatchDeadProps(utils.FixAndCleanPath(path), set, remove)
+}
+
+// MoveDeadProps makes the dead properties follow the object moved or renamed from src to dst
+func MoveDeadProps(src, dst string) {
+	if err := db.MoveDeadProps(utils.FixAndCleanPath(src), utils.FixAndCleanPath(dst)); err != nil {
+		log.Errorf("failed move dead props of %s to %s: %+v", src, dst, err)
+	}
+}
+
+// DeleteDeadProps deletes the dead properties of the removed object and its children
+func DeleteDeadProps(path string) {
+	if err := db.DeleteDeadProps(utils.FixAndCleanPath(path)); err != nil {
+		log.Errorf("failed delete dead props of %s: %+v", path, err)
+	}
+}
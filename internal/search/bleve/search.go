@@ -3,6 +3,7 @@ package bleve
 import (
 	"context"
 	"os"
+	"time"
 
 	query2 "github.com/blevesearch/bleve/v2/search/query"
 
@@ -13,6 +14,7 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
 	"github.com/blevesearch/bleve/v2"
 	search2 "github.com/blevesearch/bleve/v2/search"
+	"github.com/blevesearch/bleve/v2/search/highlight/highlighter/html"
 	"github.com/google/uuid"
 	log "github.com/sirupsen/logrus"
 )
@@ -31,7 +33,8 @@ func (b *Bleve) Search(ctx context.Context, req model.SearchReq) ([]model.Search
 	search.SortBy([]string{"name", "_id"})
 	search.From = (req.Page - 1) * req.PerPage
 	search.Size = req.PerPage
-	search.Fields = []string{"*"}
+	search.Fields = searchFields
+	search.Highlight = contentHighlight()
 	searchResults, err := b.BIndex.Search(search)
 	if err != nil {
 		log.Errorf("search error: %+v", err)
@@ -58,7 +61,8 @@ func (b *Bleve) SearchFiltered(ctx context.Context, req model.SearchReq, filter
 		search := bleve.NewSearchRequest(reqQuery)
 		search.SortBy([]string{"name", "_id"})
 		search.Size = searchBatchSize
-		search.Fields = []string{"*"}
+		search.Fields = searchFields
+		search.Highlight = contentHighlight()
 		if searchAfter != nil {
 			search.SetSearchAfter(searchAfter)
 		}
@@ -69,7 +73,8 @@ func (b *Bleve) SearchFiltered(ctx context.Context, req model.SearchReq, filter
 		}
 		for _, hit := range searchResults.Hits {
 			node := searchNodeFromHit(hit)
-			if !utils.IsSubPath(req.Parent, node.Parent) || filter != nil && !filter(node) {
+			if !utils.IsSubPath(req.Parent, node.Parent) || !req.Restriction.Allows(node) ||
+				filter != nil && !filter(node) {
 				continue
 			}
 			if total >= from && total < to {
@@ -86,36 +91,166 @@ func (b *Bleve) SearchFiltered(ctx context.Context, req model.SearchReq, filter
 	return result, total, nil
 }
 
+// searchFields are the stored fields of the nodes, the content is only used for highlights
+var searchFields = []string{"parent", "name", "is_dir", "size", "modified", "file_type"}
+
+func contentHighlight() *bleve.HighlightRequest {
+	highlight := bleve.NewHighlightWithStyle(html.Name)
+	highlight.AddField("content")
+	return highlight
+}
+
 func buildQuery(req model.SearchReq) query2.Query {
 	var queries []query2.Query
 	query := bleve.NewMatchQuery(req.Keywords)
 	query.SetField("name")
-	queries = append(queries, query)
+	contentQuery := bleve.NewMatchQuery(req.Keywords)
+	contentQuery.SetField("content")
+	queries = append(queries, bleve.NewDisjunctionQuery(query, contentQuery))
 	if req.Scope != 0 {
 		isDir := req.Scope == 1
 		isDirQuery := bleve.NewBoolFieldQuery(isDir)
 		queries = append(queries, isDirQuery)
 	}
+	inclusive := true
+	if req.MinSize > 0 || req.MaxSize > 0 {
+		var minSize, maxSize *float64
+		if req.MinSize > 0 {
+			v := float64(req.MinSize)
+			minSize = &v
+		}
+		if req.MaxSize > 0 {
+			v := float64(req.MaxSize)
+			maxSize = &v
+		}
+		sizeQuery := bleve.NewNumericRangeInclusiveQuery(minSize, maxSize, &inclusive, &inclusive)
+		sizeQuery.SetField("size")
+		queries = append(queries, sizeQuery)
+	}
+	if req.ModifiedAfter != nil || req.ModifiedBefore != nil {
+		var after, before time.Time
+		if req.ModifiedAfter != nil {
+			after = *req.ModifiedAfter
+		}
+		if req.ModifiedBefore != nil {
+			before = *req.ModifiedBefore
+		}
+		modifiedQuery := bleve.NewDateRangeInclusiveQuery(after, before, &inclusive, &inclusive)
+		modifiedQuery.SetField("modified")
+		queries = append(queries, modifiedQuery)
+	}
+	if len(req.Exts) > 0 {
+		extQueries := make([]query2.Query, 0, len(req.Exts))
+		for _, ext := range req.Exts {
+			extQuery := bleve.NewTermQuery(ext)
+			extQuery.SetField("ext")
+			extQueries = append(extQueries, extQuery)
+		}
+		queries = append(queries, bleve.NewDisjunctionQuery(extQueries...))
+	}
+	if len(req.Types) > 0 {
+		typeQueries := make([]query2.Query, 0, len(req.Types))
+		for _, t := range req.Types {
+			v := float64(t)
+			typeQuery := bleve.NewNumericRangeInclusiveQuery(&v, &v, &inclusive, &inclusive)
+			typeQuery.SetField("file_type")
+			typeQueries = append(typeQueries, typeQuery)
+		}
+		queries = append(queries, bleve.NewDisjunctionQuery(typeQueries...))
+	}
+	if denied := deniedQuery(req.Restriction); denied != nil {
+		queries = append(queries, denied)
+	}
 	return bleve.NewConjunctionQuery(queries...)
 }
 
+// deniedQuery excludes the nodes in the denied folders by the hashes of their parent paths,
+// the nodes indexed without the hashes are checked with the restriction after the search
+func deniedQuery(r *model.SearchRestriction) query2.Query {
+	if r == nil {
+		return nil
+	}
+	var denied []query2.Query
+	for _, rule := range r.Denied {
+		if !rule.Sub {
+			continue
+		}
+		inFolder := bleve.NewBooleanQuery()
+		inFolder.AddMust(parentPathQuery(rule.Path))
+		for _, except := range rule.Except {
+			inFolder.AddMustNot(parentPathQuery(except))
+		}
+		denied = append(denied, inFolder)
+	}
+	if len(denied) == 0 {
+		return nil
+	}
+	q := bleve.NewBooleanQuery()
+	q.AddMustNot(denied...)
+	return q
+}
+
+func parentPathQuery(folder string) query2.Query {
+	q := bleve.NewTermQuery(hashPath(folder))
+	q.SetField("parent_path_hashes")
+	return q
+}
+
+func hashPath(path string) string {
+	return utils.HashData(utils.SHA1, []byte(path))
+}
+
+// document is the indexed node, the extension is kept for filtering by extension
+type document struct {
+	model.SearchNode
+	Ext     string `json:"ext"`
+	Content string `json:"content,omitempty"`
+	// ParentPathHashes are the hashes of the parent and its ancestors, for excluding folders
+	ParentPathHashes []string `json:"parent_path_hashes"`
+}
+
+func newDocument(node model.SearchNode) document {
+	d := document{SearchNode: node, Content: node.Content}
+	for _, parentPath := range utils.GetPathHierarchy(node.Parent) {
+		d.ParentPathHashes = append(d.ParentPathHashes, hashPath(parentPath))
+	}
+	if !node.IsDir {
+		d.Ext = utils.Ext(node.Name)
+	}
+	return d
+}
+
 func searchNodeFromHit(src *search2.DocumentMatch) model.SearchNode {
-	return model.SearchNode{
+	node := model.SearchNode{
 		Parent: src.Fields["parent"].(string),
 		Name:   src.Fields["name"].(string),
 		IsDir:  src.Fields["is_dir"].(bool),
 		Size:   int64(src.Fields["size"].(float64)),
 	}
+	// the fields are missing in the nodes indexed by previous versions
+	if modified, ok := src.Fields["modified"].(string); ok {
+		node.Modified, _ = time.Parse(time.RFC3339, modified)
+	}
+	if fileType, ok := src.Fields["file_type"].(float64); ok {
+		node.FileType = int(fileType)
+	}
+	for _, fragment := range src.Fragments["content"] {
+		if fragment != "" {
+			node.Highlights = append(node.Highlights, fragment)
+		}
+	}
+	return node
 }
 
 func (b *Bleve) Index(ctx context.Context, node model.SearchNode) error {
-	return b.BIndex.Index(uuid.NewString(), node)
+	return b.BatchIndex(ctx, []model.SearchNode{node})
 }
 
 func (b *Bleve) BatchIndex(ctx context.Context, nodes []model.SearchNode) error {
+	searcher.LoadContent(ctx, nodes)
 	batch := b.BIndex.NewBatch()
 	for _, node := range nodes {
-		batch.Index(uuid.NewString(), node)
+		batch.Index(uuid.NewString(), newDocument(node))
 	}
 	return b.BIndex.Batch(batch)
 }
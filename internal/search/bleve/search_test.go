@@ -3,10 +3,14 @@ package bleve
 import (
 	"context"
 	"fmt"
+	"strings"
 	"testing"
+	"time"
 
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	blevelib "github.com/blevesearch/bleve/v2"
+	"github.com/dlclark/regexp2"
 )
 
 func TestSearchFilteredKeepsDuplicateSortValuesAcrossBatches(t *testing.T) {
@@ -50,3 +54,128 @@ func TestSearchFilteredKeepsDuplicateSortValuesAcrossBatches(t *testing.T) {
 		t.Fatalf("SearchFiltered() returned %d nodes, want %d", len(nodes), searchBatchSize+1)
 	}
 }
+
+func TestSearchFilters(t *testing.T) {
+	index, err := blevelib.NewMemOnly(blevelib.NewIndexMapping())
+	if err != nil {
+		t.Fatalf("NewMemOnly() error = %v", err)
+	}
+	t.Cleanup(func() { _ = index.Close() })
+	b := &Bleve{BIndex: index}
+	now := time.Now().UTC().Truncate(time.Second)
+	err = b.BatchIndex(context.Background(), []model.SearchNode{
+		{Parent: "/movies", Name: "movie big.mp4", Size: 3 << 30, Modified: now, FileType: conf.VIDEO},
+		{Parent: "/movies", Name: "movie old.MKV", Size: 3 << 30, Modified: now.AddDate(-2, 0, 0), FileType: conf.VIDEO},
+		{Parent: "/movies", Name: "movie small.mp4", Size: 1 << 20, Modified: now, FileType: conf.VIDEO},
+		{Parent: "/movies", Name: "movie notes.txt", Size: 3 << 30, Modified: now, FileType: conf.TEXT},
+	})
+	if err != nil {
+		t.Fatalf("BatchIndex() error = %v", err)
+	}
+	after := now.AddDate(0, -1, 0)
+	tests := []struct {
+		name string
+		req  model.SearchReq
+		want int
+	}{
+		{"size", model.SearchReq{MinSize: 2 << 30}, 3},
+		{"modified", model.SearchReq{ModifiedAfter: &after}, 3},
+		{"exts", model.SearchReq{Exts: []string{"mkv", "txt"}}, 2},
+		{"types", model.SearchReq{Types: []int{conf.VIDEO}}, 3},
+		{"all", model.SearchReq{MinSize: 2 << 30, ModifiedAfter: &after, Types: []int{conf.VIDEO}}, 1},
+	}
+	for i := range tests {
+		tt := &tests[i]
+		tt.req.Parent, tt.req.Keywords, tt.req.PageReq = "/movies", "movie", model.PageReq{Page: 1, PerPage: 10}
+		nodes, total, err := b.Search(context.Background(), tt.req)
+		if err != nil {
+			t.Fatalf("%s: Search() error = %v", tt.name, err)
+		}
+		if total != int64(tt.want) {
+			t.Errorf("%s: Search() total = %d, want %d: %+v", tt.name, total, tt.want, nodes)
+		}
+	}
+	nodes, _, _ := b.Search(context.Background(), tests[4].req)
+	if len(nodes) != 1 || !nodes[0].Modified.Equal(now) || nodes[0].FileType != conf.VIDEO {
+		t.Errorf("unexpected nodes: %+v", nodes)
+	}
+}
+
+func TestSearchContent(t *testing.T) {
+	index, err := blevelib.NewMemOnly(blevelib.NewIndexMapping())
+	if err != nil {
+		t.Fatalf("NewMemOnly() error = %v", err)
+	}
+	t.Cleanup(func() { _ = index.Close() })
+	b := &Bleve{BIndex: index}
+	err = b.BatchIndex(context.Background(), []model.SearchNode{
+		{Parent: "/docs", Name: "report.md", Size: 100, Content: "# Report\nThe quarterly revenue <grew> by ten percent."},
+		{Parent: "/docs", Name: "quarterly summary.txt", Size: 10},
+		{Parent: "/docs", Name: "notes.txt", Size: 10, Content: "nothing here"},
+	})
+	if err != nil {
+		t.Fatalf("BatchIndex() error = %v", err)
+	}
+	nodes, total, err := b.SearchFiltered(context.Background(), model.SearchReq{
+		Parent:   "/docs",
+		Keywords: "quarterly",
+		PageReq:  model.PageReq{Page: 1, PerPage: 10},
+	}, nil)
+	if err != nil {
+		t.Fatalf("SearchFiltered() error = %v", err)
+	}
+	if total != 2 {
+		t.Fatalf("SearchFiltered() total = %d, want 2: %+v", total, nodes)
+	}
+	for _, node := range nodes {
+		if node.Name == "report.md" && (len(node.Highlights) == 0 || !strings.Contains(node.Highlights[0], "<mark>quarterly</mark>")) {
+			t.Errorf("unexpected highlights: %q", node.Highlights)
+		}
+		if node.Name == "quarterly summary.txt" && len(node.Highlights) != 0 {
+			t.Errorf("unexpected highlights of a name match: %q", node.Highlights)
+		}
+	}
+}
+
+func TestSearchRestriction(t *testing.T) {
+	index, err := blevelib.NewMemOnly(blevelib.NewIndexMapping())
+	if err != nil {
+		t.Fatalf("NewMemOnly() error = %v", err)
+	}
+	t.Cleanup(func() { _ = index.Close() })
+	b := &Bleve{BIndex: index}
+	err = b.BatchIndex(context.Background(), []model.SearchNode{
+		{Parent: "/", Name: "report one"},
+		{Parent: "/private", Name: "report two"},
+		{Parent: "/private/sub", Name: "report three"},
+		{Parent: "/private/shared", Name: "report four"},
+		{Parent: "/private/shared", Name: "report five.tmp"},
+	})
+	if err != nil {
+		t.Fatalf("BatchIndex() error = %v", err)
+	}
+	req := model.SearchReq{
+		Parent:   "/",
+		Keywords: "report",
+		PageReq:  model.PageReq{Page: 1, PerPage: 10},
+		Restriction: &model.SearchRestriction{
+			Denied: []model.SearchRule{{Path: "/private", Sub: true, Except: []string{"/private/shared"}}},
+			Hidden: []model.SearchRule{{Path: "/private/shared", Patterns: []*regexp2.Regexp{regexp2.MustCompile(`\.tmp$`, regexp2.None)}}},
+		},
+	}
+	// the denied folders are excluded by the query
+	_, total, err := b.Search(context.Background(), req)
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	if total != 3 {
+		t.Errorf("Search() total = %d, want 3", total)
+	}
+	nodes, total, err := b.SearchFiltered(context.Background(), req, nil)
+	if err != nil {
+		t.Fatalf("SearchFiltered() error = %v", err)
+	}
+	if total != 2 || len(nodes) != 2 {
+		t.Errorf("SearchFiltered() = %+v, %d, want report one and report four", nodes, total)
+	}
+}
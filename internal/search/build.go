@@ -19,6 +19,7 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/pkg/mq"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
 	mapset "github.com/deckarep/golang-set/v2"
+	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 )
 
@@ -200,19 +201,7 @@ func Config(ctx context.Context) searcher.Config {
 }
 
 func Update(ctx context.Context, parent string, objs []model.Obj) {
-	if instance == nil || !instance.Config().AutoUpdate || !setting.GetBool(conf.AutoUpdateIndex) || Running() {
-		return
-	}
-	if isIgnorePath(parent) {
-		return
-	}
-	// only update when index have built
-	progress, err := Progress()
-	if err != nil {
-		log.Errorf("update search index error while get progress: %+v", err)
-		return
-	}
-	if !progress.IsDone {
+	if !setting.GetBool(conf.AutoUpdateIndex) || !canUpdate(parent) {
 		return
 	}
 
@@ -224,55 +213,97 @@ func Update(ctx context.Context, parent string, objs []model.Obj) {
 		msInstance.EnqueueUpdate(parent, objs)
 		return
 	}
+	if _, err := updateDir(ctx, parent, objs); err != nil {
+		log.Errorf("update search index error: %+v", err)
+	}
+}
+
+// canUpdate reports whether the index of parent can be updated incrementally
+func canUpdate(parent string) bool {
+	if instance == nil || !instance.Config().AutoUpdate || Running() {
+		return false
+	}
+	if isIgnorePath(parent) {
+		return false
+	}
+	// only update when index have built
+	progress, err := Progress()
+	if err != nil {
+		log.Errorf("update search index error while get progress: %+v", err)
+		return false
+	}
+	return progress.IsDone
+}
 
+// updateDir updates the index of the children of parent to objs, the files whose size
+// or modified time changed are indexed again, it returns the folders added
+func updateDir(ctx context.Context, parent string, objs []model.Obj) ([]string, error) {
 	unlock := lockUpdate(parent)
 	defer unlock()
 
 	nodes, err := instance.Get(ctx, parent)
 	if err != nil {
-		log.Errorf("update search index error while get nodes: %+v", err)
-		return
+		return nil, errors.WithMessage(err, "failed get nodes")
+	}
+	old := make(map[string]model.SearchNode, len(nodes))
+	for i := range nodes {
+		old[nodes[i].Name] = nodes[i]
 	}
 	now := mapset.NewSet[string]()
+	var toAddObjs []ObjWithParent
+	var addedDirs []string
 	for i := range objs {
-		now.Add(objs[i].GetName())
-	}
-	old := mapset.NewSet[string]()
-	for i := range nodes {
-		old.Add(nodes[i].Name)
+		name := objs[i].GetName()
+		now.Add(name)
+		node, ok := old[name]
+		if ok && !nodeChanged(node, objs[i]) {
+			continue
+		}
+		if ok {
+			log.Debugf("update index: %s", path.Join(parent, name))
+			if err = instance.Del(ctx, path.Join(parent, name)); err != nil {
+				return nil, errors.WithMessage(err, "failed del changed node")
+			}
+		} else {
+			log.Debugf("add index: %s", path.Join(parent, name))
+		}
+		if objs[i].IsDir() {
+			addedDirs = append(addedDirs, name)
+		}
+		toAddObjs = append(toAddObjs, ObjWithParent{
+			Parent: parent,
+			Obj:    objs[i],
+		})
 	}
 	// delete data that no longer exists
-	toDelete := old.Difference(now)
-	toAdd := now.Difference(old)
 	for i := range nodes {
-		if toDelete.Contains(nodes[i].Name) && !op.HasStorage(path.Join(parent, nodes[i].Name)) {
+		if !now.Contains(nodes[i].Name) && !op.HasStorage(path.Join(parent, nodes[i].Name)) {
 			log.Debugf("delete index: %s", path.Join(parent, nodes[i].Name))
-			err = instance.Del(ctx, path.Join(parent, nodes[i].Name))
-			if err != nil {
-				log.Errorf("update search index error while del old node: %+v", err)
-				return
+			if err = instance.Del(ctx, path.Join(parent, nodes[i].Name)); err != nil {
+				return nil, errors.WithMessage(err, "failed del old node")
 			}
 		}
 	}
-	// collect files and folders to add in batch
-	var toAddObjs []ObjWithParent
-	for i := range objs {
-		if toAdd.Contains(objs[i].GetName()) {
-			log.Debugf("add index: %s", path.Join(parent, objs[i].GetName()))
-			toAddObjs = append(toAddObjs, ObjWithParent{
-				Parent: parent,
-				Obj:    objs[i],
-			})
-		}
-	}
 	// batch index all files and folders at once
 	if len(toAddObjs) > 0 {
-		err = BatchIndex(ctx, toAddObjs)
-		if err != nil {
-			log.Errorf("update search index error while batch index new nodes: %+v", err)
-			return
+		if err = BatchIndex(ctx, toAddObjs); err != nil {
+			return nil, errors.WithMessage(err, "failed batch index new nodes")
 		}
 	}
+	return addedDirs, nil
+}
+
+// nodeChanged compares the size and modified time of files in seconds,
+// since some databases don't keep the sub-second part
+func nodeChanged(node model.SearchNode, obj model.Obj) bool {
+	if node.IsDir != obj.IsDir() {
+		return true
+	}
+	if node.IsDir {
+		// the index of the children is kept
+		return false
+	}
+	return node.Size != obj.GetSize() || node.Modified.Unix() != obj.ModTime().Unix()
 }
 
 func init() {
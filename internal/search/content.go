@@ -0,0 +1,71 @@
+package search
+
+import (
+	"context"
+	"io"
+	"path"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/search/extract"
+	"github.com/OpenListTeam/OpenList/v4/internal/search/searcher"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/OpenListTeam/OpenList/v4/internal/stream"
+	"github.com/OpenListTeam/OpenList/v4/pkg/http_range"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+// loadContent extracts the text of the text files and optionally of the documents,
+// the files that fail are indexed without content
+func loadContent(ctx context.Context, nodes []model.SearchNode) {
+	if !setting.GetBool(conf.IndexContent) {
+		return
+	}
+	maxSize := int64(setting.GetInt(conf.IndexContentMaxSize, 10)) * utils.MB
+	documents := setting.GetBool(conf.IndexContentDocuments)
+	for i := range nodes {
+		node := &nodes[i]
+		if node.IsDir || node.Size <= 0 || node.Size > maxSize {
+			continue
+		}
+		ext := utils.Ext(node.Name)
+		if node.FileType != conf.TEXT && !(documents && extract.IsDocument(ext)) {
+			continue
+		}
+		if ctx.Err() != nil {
+			return
+		}
+		nodePath := path.Join(node.Parent, node.Name)
+		data, err := readContent(ctx, nodePath, node.Size)
+		if err == nil {
+			node.Content, err = extract.Text(ext, data)
+		}
+		if err != nil {
+			log.Warnf("failed to extract the content of [%s]: %+v", nodePath, err)
+		}
+	}
+}
+
+func readContent(ctx context.Context, path string, size int64) ([]byte, error) {
+	link, _, err := fs.Link(ctx, path, model.LinkArgs{})
+	if err != nil {
+		return nil, err
+	}
+	defer link.Close()
+	rr, err := stream.GetRangeReaderFromLink(size, link)
+	if err != nil {
+		return nil, err
+	}
+	rc, err := rr.RangeRead(ctx, http_range.Range{Length: size})
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(io.LimitReader(rc, size))
+}
+
+func init() {
+	searcher.RegisterContentLoader(loadContent)
+}
@@ -0,0 +1,49 @@
+package search
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+)
+
+func TestLoadContent(t *testing.T) {
+	setupIndex(t)
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "notes.md"), "meeting notes")
+	writeFile(t, filepath.Join(root, "large.md"), "too large to index")
+	writeFile(t, filepath.Join(root, "paper.docx"), "not a docx")
+	_, err := op.CreateStorage(context.Background(), model.Storage{
+		Driver:    "Local",
+		MountPath: "/content",
+		Addition:  `{"root_folder_path":"` + filepath.ToSlash(root) + `"}`,
+	})
+	if err != nil {
+		t.Fatalf("failed to create storage: %+v", err)
+	}
+	for key, value := range map[string]string{conf.IndexContent: "true", conf.IndexContentMaxSize: "1"} {
+		if err = op.SaveSettingItem(&model.SettingItem{Key: key, Value: value, Type: conf.TypeString, Group: model.INDEX}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	t.Cleanup(func() { _ = op.DeleteSettingItemByKey(conf.IndexContent) })
+
+	nodes := []model.SearchNode{
+		{Parent: "/content", Name: "notes.md", Size: 13, FileType: conf.TEXT},
+		{Parent: "/content", Name: "large.md", Size: 2 << 20, FileType: conf.TEXT},
+		{Parent: "/content", Name: "paper.docx", Size: 10, FileType: conf.UNKNOWN},
+		{Parent: "/content", Name: "missing.md", Size: 10, FileType: conf.TEXT},
+	}
+	loadContent(context.Background(), nodes)
+	if nodes[0].Content != "meeting notes" {
+		t.Errorf("unexpected content: %q", nodes[0].Content)
+	}
+	for _, node := range nodes[1:] {
+		if node.Content != "" {
+			t.Errorf("unexpected content of %s: %q", node.Name, node.Content)
+		}
+	}
+}
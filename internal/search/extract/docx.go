@@ -0,0 +1,62 @@
+package extract
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"io"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// docxText reads the text runs of the main document part, paragraphs are separated by lines
+func docxText(data []byte) (string, error) {
+	r, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+	if err != nil {
+		return "", errors.Wrap(err, "failed to open docx")
+	}
+	f, err := r.Open("word/document.xml")
+	if err != nil {
+		return "", errors.Wrap(err, "failed to open docx document")
+	}
+	defer f.Close()
+	var sb strings.Builder
+	inText := false
+	decoder := xml.NewDecoder(io.LimitReader(f, 8*MaxLength))
+	for {
+		token, err := decoder.Token()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return "", errors.Wrap(err, "failed to parse docx document")
+		}
+		switch t := token.(type) {
+		case xml.StartElement:
+			switch t.Name.Local {
+			case "t":
+				inText = true
+			case "tab":
+				sb.WriteByte('\t')
+			case "br", "cr":
+				sb.WriteByte('\n')
+			}
+		case xml.EndElement:
+			switch t.Name.Local {
+			case "t":
+				inText = false
+			case "p":
+				sb.WriteByte('\n')
+			}
+		case xml.CharData:
+			if inText {
+				sb.Write(t)
+			}
+		}
+		if sb.Len() > MaxLength {
+			break
+		}
+	}
+	return sb.String(), nil
+}
@@ -0,0 +1,44 @@
+package extract
+
+import (
+	"bytes"
+	"strings"
+	"unicode/utf8"
+)
+
+// MaxLength is the max length of the extracted text in bytes, the rest is dropped
+const MaxLength = 1 << 20
+
+// IsDocument reports whether the text of the files with ext is extracted from a document format
+func IsDocument(ext string) bool {
+	return ext == "pdf" || ext == "docx"
+}
+
+// Text extracts the text of a file by its extension, the files that are not documents are
+// read as plain text
+func Text(ext string, data []byte) (string, error) {
+	var text string
+	switch ext {
+	case "pdf":
+		text = pdfText(data)
+	case "docx":
+		var err error
+		if text, err = docxText(data); err != nil {
+			return "", err
+		}
+	default:
+		data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+		text = strings.ReplaceAll(string(data), "\x00", "")
+	}
+	return truncate(strings.ToValidUTF8(text, ""), MaxLength), nil
+}
+
+func truncate(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
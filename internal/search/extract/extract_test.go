@@ -0,0 +1,72 @@
+package extract
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+func testPDF(t *testing.T) []byte {
+	t.Helper()
+	var content bytes.Buffer
+	w := zlib.NewWriter(&content)
+	_, _ = w.Write([]byte("BT /F1 12 Tf 72 712 Td (Hello \\(PDF\\) world) Tj 0 -14 Td " +
+		"[(Kern)-20(ed)-500(words)] TJ T* <FEFF00E9007400E9> Tj ET"))
+	_ = w.Close()
+	var b bytes.Buffer
+	b.WriteString("%PDF-1.4\n1 0 obj\n<< /Type /Catalog /Pages 2 0 R >>\nendobj\n")
+	b.WriteString("3 0 obj\n<< /Length1 4 /Length 4 >>\nstream\n(no)\nendstream\nendobj\n")
+	fmt.Fprintf(&b, "4 0 obj\n<< /Length %d /Filter /FlateDecode >>\nstream\n", content.Len())
+	b.Write(content.Bytes())
+	b.WriteString("\nendstream\nendobj\ntrailer\n<< /Root 1 0 R >>\n%%EOF\n")
+	return b.Bytes()
+}
+
+func testDocx(t *testing.T) []byte {
+	t.Helper()
+	var b bytes.Buffer
+	zw := zip.NewWriter(&b)
+	f, err := zw.Create("word/document.xml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	_, _ = f.Write([]byte(`<?xml version="1.0" encoding="UTF-8"?>
+<w:document xmlns:w="http://schemas.openxmlformats.org/wordprocessingml/2006/main"><w:body>
+<w:p><w:r><w:t>First</w:t></w:r><w:r><w:tab/><w:t xml:space="preserve">paragraph &amp; more</w:t></w:r></w:p>
+<w:p><w:r><w:t>Second</w:t></w:r></w:p>
+</w:body></w:document>`))
+	if err = zw.Close(); err != nil {
+		t.Fatal(err)
+	}
+	return b.Bytes()
+}
+
+func TestText(t *testing.T) {
+	tests := []struct {
+		ext  string
+		data []byte
+		want string
+	}{
+		{"md", []byte("\xef\xbb\xbf# Title\x00\xff"), "# Title"},
+		{"pdf", testPDF(t), "Hello (PDF) world\nKerned words\nété"},
+		{"docx", testDocx(t), "First\tparagraph & more\nSecond\n"},
+	}
+	for _, tt := range tests {
+		got, err := Text(tt.ext, tt.data)
+		if err != nil {
+			t.Fatalf("failed to extract %s: %+v", tt.ext, err)
+		}
+		if strings.TrimSpace(got) != strings.TrimSpace(tt.want) {
+			t.Errorf("unexpected text of %s: %q", tt.ext, got)
+		}
+	}
+	if _, err := Text("docx", []byte("not a zip")); err == nil {
+		t.Error("expected an error for a broken docx")
+	}
+	if got, _ := Text("txt", []byte(strings.Repeat("é", MaxLength))); len(got) != MaxLength {
+		t.Errorf("expected the text truncated to %d bytes, got %d", MaxLength, len(got))
+	}
+}
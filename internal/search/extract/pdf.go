@@ -0,0 +1,343 @@
+package extract
+
+import (
+	"bytes"
+	"compress/zlib"
+	"encoding/hex"
+	"io"
+	"slices"
+	"strings"
+	"unicode/utf16"
+)
+
+// pdfText extracts the text shown by the content streams of a pdf, which are either
+// uncompressed or flate encoded. The strings are decoded as PDFDocEncoding or UTF-16,
+// the glyphs of the fonts mapped by ToUnicode CMaps are not decoded.
+func pdfText(data []byte) string {
+	var sb strings.Builder
+	for offset := 0; sb.Len() <= MaxLength; {
+		i := bytes.Index(data[offset:], []byte("stream"))
+		if i < 0 {
+			break
+		}
+		start := offset + i + len("stream")
+		offset = start
+		if bytes.HasSuffix(data[:start], []byte("endstream")) {
+			continue
+		}
+		if bytes.HasPrefix(data[start:], []byte("\r\n")) {
+			start += 2
+		} else if bytes.HasPrefix(data[start:], []byte("\n")) {
+			start++
+		} else {
+			continue
+		}
+		end := bytes.Index(data[start:], []byte("endstream"))
+		if end < 0 {
+			break
+		}
+		content := data[start : start+end]
+		offset = start + end + len("endstream")
+
+		dict := streamDict(data[:start])
+		if bytes.Contains(dict, []byte("/Type")) || bytes.Contains(dict, []byte("/Subtype")) ||
+			bytes.Contains(dict, []byte("/Length1")) {
+			// only the content streams are read, not the images, fonts and the other resources
+			continue
+		}
+		if bytes.Contains(dict, []byte("/Filter")) {
+			if !bytes.Contains(dict, []byte("/FlateDecode")) || bytes.Contains(dict, []byte("/DecodeParms")) {
+				continue
+			}
+			// the decoded part is used when the stream is truncated
+			decoded, _ := inflate(content)
+			content = decoded
+		}
+		writeContentText(&sb, content)
+	}
+	return sb.String()
+}
+
+// streamDict returns the dictionary of the stream starting at the end of data
+func streamDict(data []byte) []byte {
+	start := bytes.LastIndex(data, []byte(" obj"))
+	if start < 0 {
+		return nil
+	}
+	return data[start:]
+}
+
+func inflate(data []byte) ([]byte, error) {
+	r, err := zlib.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	defer r.Close()
+	return io.ReadAll(io.LimitReader(r, 8*MaxLength))
+}
+
+// writeContentText writes the strings shown by the text operators of a content stream
+func writeContentText(sb *strings.Builder, content []byte) {
+	var operands [][]byte
+	s := &pdfScanner{data: content}
+	for {
+		token, kind := s.next()
+		switch kind {
+		case pdfEOF:
+			return
+		case pdfString, pdfArray, pdfOperand:
+			operands = append(operands, token)
+			continue
+		}
+		switch string(token) {
+		case "Tj", "'", "\"":
+			if string(token) != "Tj" {
+				sb.WriteByte('\n')
+			}
+			if len(operands) > 0 {
+				sb.WriteString(decodePDFString(operands[len(operands)-1]))
+			}
+		case "TJ":
+			if len(operands) > 0 {
+				writeTJ(sb, operands[len(operands)-1])
+			}
+		case "Td", "TD":
+			// a move on the same line separates words
+			if len(operands) == 2 && strings.Trim(string(operands[1]), "+-0.") == "" {
+				writeSeparator(sb, ' ')
+			} else {
+				writeSeparator(sb, '\n')
+			}
+		case "T*", "ET":
+			writeSeparator(sb, '\n')
+		case "BI":
+			s.skipInlineImage()
+		}
+		operands = operands[:0]
+	}
+}
+
+func writeSeparator(sb *strings.Builder, c byte) {
+	s := sb.String()
+	if s == "" || strings.HasSuffix(s, "\n") || c == ' ' && strings.HasSuffix(s, " ") {
+		return
+	}
+	sb.WriteByte(c)
+}
+
+// writeTJ writes the strings of a TJ array, a large negative offset between them is a space
+func writeTJ(sb *strings.Builder, array []byte) {
+	s := &pdfScanner{data: array}
+	for {
+		token, kind := s.next()
+		switch kind {
+		case pdfEOF:
+			return
+		case pdfString:
+			sb.WriteString(decodePDFString(token))
+		case pdfOperand:
+			if n := string(token); strings.HasPrefix(n, "-") && len(strings.TrimLeft(n, "-0.")) >= 3 {
+				sb.WriteByte(' ')
+			}
+		}
+	}
+}
+
+const (
+	pdfEOF = iota
+	pdfString
+	pdfArray
+	pdfOperand
+	pdfOperator
+)
+
+type pdfScanner struct {
+	data []byte
+	pos  int
+}
+
+// next returns the next token, the strings are returned undecoded with their delimiters
+func (s *pdfScanner) next() ([]byte, int) {
+	for s.pos < len(s.data) {
+		c := s.data[s.pos]
+		switch {
+		case isPDFSpace(c):
+			s.pos++
+		case c == '%':
+			for s.pos < len(s.data) && s.data[s.pos] != '\n' && s.data[s.pos] != '\r' {
+				s.pos++
+			}
+		case c == '(':
+			start := s.pos
+			depth := 0
+			for ; s.pos < len(s.data); s.pos++ {
+				switch s.data[s.pos] {
+				case '\\':
+					s.pos++
+				case '(':
+					depth++
+				case ')':
+					depth--
+				}
+				if depth == 0 {
+					break
+				}
+			}
+			s.pos = min(s.pos+1, len(s.data))
+			return s.data[start:s.pos], pdfString
+		case c == '<' && s.pos+1 < len(s.data) && s.data[s.pos+1] == '<':
+			s.pos += 2
+			return s.data[s.pos-2 : s.pos], pdfOperand
+		case c == '>' && s.pos+1 < len(s.data) && s.data[s.pos+1] == '>':
+			s.pos += 2
+			return s.data[s.pos-2 : s.pos], pdfOperand
+		case c == '<':
+			start := s.pos
+			end := bytes.IndexByte(s.data[s.pos:], '>')
+			if end < 0 {
+				s.pos = len(s.data)
+			} else {
+				s.pos += end + 1
+			}
+			return s.data[start:s.pos], pdfString
+		case c == '[':
+			s.pos++
+			start := s.pos
+			for {
+				for s.pos < len(s.data) && isPDFSpace(s.data[s.pos]) {
+					s.pos++
+				}
+				if s.pos == len(s.data) || s.data[s.pos] == ']' {
+					break
+				}
+				s.next()
+			}
+			array := s.data[start:s.pos]
+			s.pos = min(s.pos+1, len(s.data))
+			return array, pdfArray
+		case c == '/' || c == '+' || c == '-' || c == '.' || c >= '0' && c <= '9':
+			start := s.pos
+			for s.pos++; s.pos < len(s.data) && !isPDFSpace(s.data[s.pos]) && !isPDFDelimiter(s.data[s.pos]); s.pos++ {
+			}
+			return s.data[start:s.pos], pdfOperand
+		case isPDFDelimiter(c):
+			s.pos++
+		default:
+			start := s.pos
+			for ; s.pos < len(s.data) && !isPDFSpace(s.data[s.pos]) && !isPDFDelimiter(s.data[s.pos]); s.pos++ {
+			}
+			return s.data[start:s.pos], pdfOperator
+		}
+	}
+	return nil, pdfEOF
+}
+
+// skipInlineImage skips the binary data of an inline image up to its EI operator
+func (s *pdfScanner) skipInlineImage() {
+	i := bytes.Index(s.data[s.pos:], []byte("ID"))
+	if i < 0 {
+		s.pos = len(s.data)
+		return
+	}
+	s.pos += i + 2
+	for {
+		i = bytes.Index(s.data[s.pos:], []byte("EI"))
+		if i < 0 {
+			s.pos = len(s.data)
+			return
+		}
+		s.pos += i + 2
+		if isPDFSpace(s.data[s.pos-3]) && (s.pos == len(s.data) || isPDFSpace(s.data[s.pos])) {
+			return
+		}
+	}
+}
+
+func isPDFSpace(c byte) bool {
+	return c == ' ' || c == '\n' || c == '\r' || c == '\t' || c == '\f' || c == 0
+}
+
+func isPDFDelimiter(c byte) bool {
+	return strings.IndexByte("()<>[]{}/%", c) >= 0
+}
+
+var utf16BOM = []byte{0xfe, 0xff}
+
+// decodePDFString decodes a literal or hex string with its delimiters
+func decodePDFString(token []byte) string {
+	var raw []byte
+	if token[0] == '<' {
+		hexDigits := bytes.Map(func(r rune) rune {
+			if strings.ContainsRune("0123456789abcdefABCDEF", r) {
+				return r
+			}
+			return -1
+		}, token)
+		if len(hexDigits)%2 == 1 {
+			hexDigits = append(hexDigits, '0')
+		}
+		raw, _ = hex.DecodeString(string(hexDigits))
+		if !bytes.HasPrefix(raw, utf16BOM) && slices.ContainsFunc(raw, func(c byte) bool {
+			return c < 0x20 && c != '\n' && c != '\r' && c != '\t'
+		}) {
+			// the glyph ids of composite fonts can't be decoded without the fonts
+			return ""
+		}
+	} else {
+		raw = unescapePDFString(token[1:max(len(token)-1, 1)])
+	}
+	if bytes.HasPrefix(raw, utf16BOM) {
+		units := make([]uint16, 0, len(raw)/2)
+		for i := 2; i+1 < len(raw); i += 2 {
+			units = append(units, uint16(raw[i])<<8|uint16(raw[i+1]))
+		}
+		return string(utf16.Decode(units))
+	}
+	runes := make([]rune, len(raw))
+	for i, c := range raw {
+		runes[i] = rune(c)
+	}
+	return string(runes)
+}
+
+func unescapePDFString(s []byte) []byte {
+	out := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		if s[i] != '\\' || i+1 == len(s) {
+			out = append(out, s[i])
+			continue
+		}
+		i++
+		switch c := s[i]; c {
+		case 'n':
+			out = append(out, '\n')
+		case 'r':
+			out = append(out, '\r')
+		case 't':
+			out = append(out, '\t')
+		case 'b':
+			out = append(out, '\b')
+		case 'f':
+			out = append(out, '\f')
+		case '\r':
+			// a line continuation
+			if i+1 < len(s) && s[i+1] == '\n' {
+				i++
+			}
+		case '\n':
+		default:
+			if c >= '0' && c <= '7' {
+				v := 0
+				for j := 0; j < 3 && i < len(s) && s[i] >= '0' && s[i] <= '7'; j++ {
+					v = v*8 + int(s[i]-'0')
+					i++
+				}
+				i--
+				out = append(out, byte(v))
+			} else {
+				out = append(out, c)
+			}
+		}
+	}
+	return out
+}
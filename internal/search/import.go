@@ -5,4 +5,5 @@ import (
 	_ "github.com/OpenListTeam/OpenList/v4/internal/search/db"
 	_ "github.com/OpenListTeam/OpenList/v4/internal/search/db_non_full_text"
 	_ "github.com/OpenListTeam/OpenList/v4/internal/search/meilisearch"
+	_ "github.com/OpenListTeam/OpenList/v4/internal/search/sqlite_fts"
 )
@@ -30,8 +30,9 @@ func init() {
 			),
 			IndexUid: indexUid,
 			FilterableAttributes: []string{"parent", "is_dir", "name",
-				"parent_hash", "parent_path_hashes"},
-			SearchableAttributes: []string{"name"},
+				"parent_hash", "parent_path_hashes",
+				"size", "modified_unix", "ext", "file_type"},
+			SearchableAttributes: []string{"name", "content"},
 		}
 
 		_, err := m.Client.GetIndex(m.IndexUid)
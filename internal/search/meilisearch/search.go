@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"path"
+	"strconv"
 	"strings"
 	"time"
 
@@ -25,9 +26,36 @@ type searchDocument struct {
 	// Can be used for filtering all descendants exactly.
 	// Storing path hashes instead of plaintext paths benefits disk usage and case-sensitive filter.
 	ParentPathHashes []string `json:"parent_path_hashes"`
+	// Extension in lower case and modified time in unix seconds, for filtering
+	Ext          string `json:"ext"`
+	ModifiedUnix int64  `json:"modified_unix"`
+	// Content is the text extracted from the file, it's searchable
+	Content string `json:"content,omitempty"`
 	model.SearchNode
 }
 
+func newSearchDocument(src model.SearchNode) (*searchDocument, error) {
+	parentPaths := utils.GetPathHierarchy(src.Parent)
+	parentPathHashes, err := utils.SliceConvert(parentPaths, func(parentPath string) (string, error) {
+		return hashPath(parentPath), nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	document := &searchDocument{
+		ID:               hashPath(path.Join(src.Parent, src.Name)),
+		ParentHash:       hashPath(src.Parent),
+		ParentPathHashes: parentPathHashes,
+		ModifiedUnix:     src.Modified.Unix(),
+		Content:          src.Content,
+		SearchNode:       src,
+	}
+	if !src.IsDir {
+		document.Ext = utils.Ext(src.Name)
+	}
+	return document, nil
+}
+
 type Meilisearch struct {
 	Client               meilisearch.ServiceManager
 	IndexUid             string
@@ -42,9 +70,14 @@ func (m *Meilisearch) Config() searcher.Config {
 
 func (m *Meilisearch) Search(ctx context.Context, req model.SearchReq) ([]model.SearchNode, int64, error) {
 	mReq := &meilisearch.SearchRequest{
-		AttributesToSearchOn: m.SearchableAttributes,
-		Page:                 int64(req.Page),
-		HitsPerPage:          int64(req.PerPage),
+		AttributesToSearchOn:  m.SearchableAttributes,
+		Page:                  int64(req.Page),
+		HitsPerPage:           int64(req.PerPage),
+		AttributesToCrop:      []string{"content"},
+		CropLength:            contentCropLength,
+		AttributesToHighlight: []string{"content"},
+		HighlightPreTag:       highlightPreTag,
+		HighlightPostTag:      highlightPostTag,
 	}
 	var filters []string
 	if req.Scope != 0 {
@@ -55,6 +88,8 @@ func (m *Meilisearch) Search(ctx context.Context, req model.SearchReq) ([]model.
 		parentHash := hashPath(req.Parent)
 		filters = append(filters, fmt.Sprintf("parent_path_hashes = '%s'", parentHash))
 	}
+	filters = append(filters, matchFilters(req)...)
+	filters = append(filters, deniedFilters(req.Restriction)...)
 	if len(filters) > 0 {
 		mReq.Filter = strings.Join(filters, " AND ")
 	}
@@ -64,13 +99,7 @@ func (m *Meilisearch) Search(ctx context.Context, req model.SearchReq) ([]model.
 		return nil, 0, err
 	}
 	nodes, err := utils.SliceConvert(search.Hits, func(src any) (model.SearchNode, error) {
-		srcMap := src.(map[string]any)
-		return model.SearchNode{
-			Parent: srcMap["parent"].(string),
-			Name:   srcMap["name"].(string),
-			IsDir:  srcMap["is_dir"].(bool),
-			Size:   int64(srcMap["size"].(float64)),
-		}, nil
+		return buildSearchDocumentFromResults(src.(map[string]any)).SearchNode, nil
 	})
 	if err != nil {
 		return nil, 0, err
@@ -78,30 +107,69 @@ func (m *Meilisearch) Search(ctx context.Context, req model.SearchReq) ([]model.
 	return nodes, search.TotalHits, nil
 }
 
+// matchFilters filters the documents by size, modified time, extension and file type
+func matchFilters(req model.SearchReq) []string {
+	var filters []string
+	if req.MinSize > 0 {
+		filters = append(filters, fmt.Sprintf("size >= %d", req.MinSize))
+	}
+	if req.MaxSize > 0 {
+		filters = append(filters, fmt.Sprintf("size <= %d", req.MaxSize))
+	}
+	if req.ModifiedAfter != nil {
+		filters = append(filters, fmt.Sprintf("modified_unix >= %d", req.ModifiedAfter.Unix()))
+	}
+	if req.ModifiedBefore != nil {
+		filters = append(filters, fmt.Sprintf("modified_unix <= %d", req.ModifiedBefore.Unix()))
+	}
+	if len(req.Exts) > 0 {
+		exts := make([]string, 0, len(req.Exts))
+		for _, ext := range req.Exts {
+			exts = append(exts, strconv.Quote(ext))
+		}
+		filters = append(filters, fmt.Sprintf("ext IN [%s]", strings.Join(exts, ", ")))
+	}
+	if len(req.Types) > 0 {
+		types := make([]string, 0, len(req.Types))
+		for _, t := range req.Types {
+			types = append(types, strconv.Itoa(t))
+		}
+		filters = append(filters, fmt.Sprintf("file_type IN [%s]", strings.Join(types, ", ")))
+	}
+	return filters
+}
+
+// deniedFilters excludes the nodes in the denied folders, the hidden nodes are matched
+// by regular expressions and checked after the search
+func deniedFilters(r *model.SearchRestriction) []string {
+	if r == nil {
+		return nil
+	}
+	var filters []string
+	for _, rule := range r.Denied {
+		inFolder := fmt.Sprintf("parent_hash = '%s'", hashPath(rule.Path))
+		if rule.Sub {
+			inFolder = fmt.Sprintf("parent_path_hashes = '%s'", hashPath(rule.Path))
+		}
+		if len(rule.Except) > 0 {
+			excepts := make([]string, 0, len(rule.Except))
+			for _, except := range rule.Except {
+				excepts = append(excepts, fmt.Sprintf("'%s'", hashPath(except)))
+			}
+			inFolder += fmt.Sprintf(" AND NOT parent_path_hashes IN [%s]", strings.Join(excepts, ", "))
+		}
+		filters = append(filters, fmt.Sprintf("NOT (%s)", inFolder))
+	}
+	return filters
+}
+
 func (m *Meilisearch) Index(ctx context.Context, node model.SearchNode) error {
 	return m.BatchIndex(ctx, []model.SearchNode{node})
 }
 
 func (m *Meilisearch) BatchIndex(ctx context.Context, nodes []model.SearchNode) error {
-	documents, err := utils.SliceConvert(nodes, func(src model.SearchNode) (*searchDocument, error) {
-		parentHash := hashPath(src.Parent)
-		nodePath := path.Join(src.Parent, src.Name)
-		nodePathHash := hashPath(nodePath)
-		parentPaths := utils.GetPathHierarchy(src.Parent)
-		parentPathHashes, err := utils.SliceConvert(parentPaths, func(parentPath string) (string, error) {
-			return hashPath(parentPath), nil
-		})
-		if err != nil {
-			return nil, err
-		}
-
-		return &searchDocument{
-			ID:               nodePathHash,
-			ParentHash:       parentHash,
-			ParentPathHashes: parentPathHashes,
-			SearchNode:       src,
-		}, nil
-	})
+	searcher.LoadContent(ctx, nodes)
+	documents, err := utils.SliceConvert(nodes, newSearchDocument)
 	if err != nil {
 		return err
 	}
@@ -245,25 +313,8 @@ func (m *Meilisearch) batchIndexWithTaskUID(ctx context.Context, nodes []model.S
 		return nil, nil
 	}
 
-	documents, err := utils.SliceConvert(nodes, func(src model.SearchNode) (*searchDocument, error) {
-		parentHash := hashPath(src.Parent)
-		nodePath := path.Join(src.Parent, src.Name)
-		nodePathHash := hashPath(nodePath)
-		parentPaths := utils.GetPathHierarchy(src.Parent)
-		parentPathHashes, err := utils.SliceConvert(parentPaths, func(parentPath string) (string, error) {
-			return hashPath(parentPath), nil
-		})
-		if err != nil {
-			return nil, err
-		}
-
-		return &searchDocument{
-			ID:               nodePathHash,
-			ParentHash:       parentHash,
-			ParentPathHashes: parentPathHashes,
-			SearchNode:       src,
-		}, nil
-	})
+	searcher.LoadContent(ctx, nodes)
+	documents, err := utils.SliceConvert(nodes, newSearchDocument)
 	if err != nil {
 		return nil, err
 	}
@@ -235,12 +235,7 @@ func (tqm *TaskQueueManager) executeTask(ctx context.Context, task *QueuedTask)
 	for i := range currentObjs {
 		if toAdd.Contains(currentObjs[i].GetName()) {
 			log.Debugf("will add index: %s", path.Join(parent, currentObjs[i].GetName()))
-			nodesToAdd = append(nodesToAdd, model.SearchNode{
-				Parent: parent,
-				Name:   currentObjs[i].GetName(),
-				IsDir:  currentObjs[i].IsDir(),
-				Size:   currentObjs[i].GetSize(),
-			})
+			nodesToAdd = append(nodesToAdd, model.NewSearchNode(parent, currentObjs[i]))
 		}
 	}
 
@@ -1,6 +1,10 @@
 package meilisearch
 
 import (
+	"html"
+	"strings"
+	"time"
+
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
 )
 
@@ -11,6 +15,27 @@ func hashPath(path string) string {
 	return utils.HashData(utils.SHA1, []byte(path))
 }
 
+const (
+	// contentCropLength is the number of words around the matches in the content
+	contentCropLength = 30
+	// the matches are marked with private use characters, which are replaced
+	// by the mark tags after escaping the content
+	highlightPreTag  = "\ue000"
+	highlightPostTag = "\ue001"
+)
+
+// contentHighlight returns the cropped content with the matches in mark tags,
+// it's empty when the content doesn't match
+func contentHighlight(results map[string]any) string {
+	formatted, _ := results["_formatted"].(map[string]any)
+	content, _ := formatted["content"].(string)
+	if !strings.Contains(content, highlightPreTag) {
+		return ""
+	}
+	return strings.NewReplacer(highlightPreTag, "<mark>", highlightPostTag, "</mark>").
+		Replace(html.EscapeString(content))
+}
+
 func buildSearchDocumentFromResults(results map[string]any) *searchDocument {
 	document := &searchDocument{}
 
@@ -22,9 +47,23 @@ func buildSearchDocumentFromResults(results map[string]any) *searchDocument {
 	if size, ok := results["size"].(float64); ok {
 		document.SearchNode.Size = int64(size)
 	}
+	if modified, ok := results["modified"].(string); ok {
+		document.SearchNode.Modified, _ = time.Parse(time.RFC3339, modified)
+	}
+	if fileType, ok := results["file_type"].(float64); ok {
+		document.SearchNode.FileType = int(fileType)
+	}
+
+	if highlight := contentHighlight(results); highlight != "" {
+		document.SearchNode.Highlights = []string{highlight}
+	}
 
 	document.ID, _ = results["id"].(string)
 	document.ParentHash, _ = results["parent_hash"].(string)
 	document.ParentPathHashes, _ = results["parent_path_hashes"].([]string)
+	document.Ext, _ = results["ext"].(string)
+	if modifiedUnix, ok := results["modified_unix"].(float64); ok {
+		document.ModifiedUnix = int64(modifiedUnix)
+	}
 	return document
 }
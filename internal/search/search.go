@@ -74,7 +74,7 @@ func SearchFiltered(ctx context.Context, req model.SearchReq, filter searcher.Fi
 			return nil, 0, err
 		}
 		for _, node := range nodes {
-			if filter != nil && !filter(node) {
+			if !req.Restriction.Allows(node) || filter != nil && !filter(node) {
 				continue
 			}
 			if filteredTotal >= from && filteredTotal < to {
@@ -91,16 +91,27 @@ func SearchFiltered(ctx context.Context, req model.SearchReq, filter searcher.Fi
 	return result, filteredTotal, nil
 }
 
+// SearchWithFacets is SearchFiltered with the facets of all the results passing filter
+func SearchWithFacets(ctx context.Context, req model.SearchReq, filter searcher.Filter) ([]model.SearchNode, int64, *model.SearchFacets, error) {
+	facets := model.NewSearchFacets()
+	nodes, total, err := SearchFiltered(ctx, req, func(node model.SearchNode) bool {
+		if filter != nil && !filter(node) {
+			return false
+		}
+		facets.Add(node)
+		return true
+	})
+	if err != nil {
+		return nil, 0, nil, err
+	}
+	return nodes, total, facets, nil
+}
+
 func Index(ctx context.Context, parent string, obj model.Obj) error {
 	if instance == nil {
 		return errs.SearchNotAvailable
 	}
-	return instance.Index(ctx, model.SearchNode{
-		Parent: parent,
-		Name:   obj.GetName(),
-		IsDir:  obj.IsDir(),
-		Size:   obj.GetSize(),
-	})
+	return instance.Index(ctx, model.NewSearchNode(parent, obj))
 }
 
 type ObjWithParent struct {
@@ -117,12 +128,7 @@ func BatchIndex(ctx context.Context, objs []ObjWithParent) error {
 	}
 	var searchNodes []model.SearchNode
 	for i := range objs {
-		searchNodes = append(searchNodes, model.SearchNode{
-			Parent: objs[i].Parent,
-			Name:   objs[i].GetName(),
-			IsDir:  objs[i].IsDir(),
-			Size:   objs[i].GetSize(),
-		})
+		searchNodes = append(searchNodes, model.NewSearchNode(objs[i].Parent, objs[i].Obj))
 	}
 	return instance.BatchIndex(ctx, searchNodes)
 }
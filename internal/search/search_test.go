@@ -3,7 +3,9 @@ package search
 import (
 	"context"
 	"testing"
+	"time"
 
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/search/searcher"
 )
@@ -59,3 +61,30 @@ func TestSearchFilteredFiltersBeforePagination(t *testing.T) {
 		t.Fatalf("SearchFiltered() nodes = %#v, want allowed-2", nodes)
 	}
 }
+
+func TestSearchWithFacets(t *testing.T) {
+	previous := instance
+	now := time.Now()
+	instance = &filteredSearchStub{nodes: []model.SearchNode{
+		{Name: "dir", IsDir: true},
+		{Name: "a.mp4", Size: 2 << 30, Modified: now},
+		{Name: "b.MP4", Size: 10, Modified: now.AddDate(-2, 0, 0)},
+		{Name: "denied.mp4", Size: 10, Modified: now},
+	}}
+	t.Cleanup(func() { instance = previous })
+
+	_, total, facets, err := SearchWithFacets(context.Background(), model.SearchReq{
+		PageReq: model.PageReq{Page: 1, PerPage: 1},
+	}, func(node model.SearchNode) bool {
+		return node.Name != "denied.mp4"
+	})
+	if err != nil {
+		t.Fatalf("SearchWithFacets() error = %v", err)
+	}
+	if total != 3 || facets.Types[conf.FOLDER] != 1 || facets.Exts["mp4"] != 2 {
+		t.Fatalf("SearchWithFacets() total = %d, facets = %+v", total, facets)
+	}
+	if facets.Sizes["1GB-10GB"] != 1 || facets.Sizes["<1MB"] != 1 || facets.Modified["day"] != 1 || facets.Modified["older"] != 1 {
+		t.Fatalf("SearchWithFacets() facets = %+v", facets)
+	}
+}
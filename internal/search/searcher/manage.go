@@ -1,5 +1,11 @@
 package searcher
 
+import (
+	"context"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+)
+
 type New func() (Searcher, error)
 
 var NewMap = map[string]New{}
@@ -7,3 +13,19 @@ var NewMap = map[string]New{}
 func RegisterSearcher(config Config, searcher New) {
 	NewMap[config.Name] = searcher
 }
+
+// ContentLoader fills the content of the file nodes before they're indexed
+type ContentLoader func(ctx context.Context, nodes []model.SearchNode)
+
+var contentLoader ContentLoader
+
+func RegisterContentLoader(loader ContentLoader) {
+	contentLoader = loader
+}
+
+// LoadContent is called by the searchers indexing content
+func LoadContent(ctx context.Context, nodes []model.SearchNode) {
+	if contentLoader != nil {
+		contentLoader(ctx, nodes)
+	}
+}
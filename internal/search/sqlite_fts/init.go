@@ -0,0 +1,20 @@
+package sqlite_fts
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/search/searcher"
+)
+
+var config = searcher.Config{
+	Name:       "sqlite_fts",
+	AutoUpdate: true,
+}
+
+func init() {
+	searcher.RegisterSearcher(config, func() (searcher.Searcher, error) {
+		if err := db.InitSearchNodesFTS(); err != nil {
+			return nil, err
+		}
+		return &DB{}, nil
+	})
+}
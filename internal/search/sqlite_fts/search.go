@@ -0,0 +1,80 @@
+package sqlite_fts
+
+import (
+	"context"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/search/searcher"
+)
+
+// DB searches the names of the nodes in the database with the SQLite FTS5 table
+type DB struct{}
+
+func (D DB) Config() searcher.Config {
+	return config
+}
+
+func (D DB) Search(ctx context.Context, req model.SearchReq) ([]model.SearchNode, int64, error) {
+	return db.SearchNodeFTS(req)
+}
+
+const searchBatchSize = 1000
+
+func (D DB) SearchFiltered(ctx context.Context, req model.SearchReq, filter searcher.Filter) ([]model.SearchNode, int64, error) {
+	from := int64(req.Page-1) * int64(req.PerPage)
+	to := from + int64(req.PerPage)
+	var (
+		result []model.SearchNode
+		total  int64
+	)
+	for offset := 0; ; offset += searchBatchSize {
+		if err := ctx.Err(); err != nil {
+			return nil, 0, err
+		}
+		nodes, err := db.SearchNodeFTSRange(req, offset, searchBatchSize)
+		if err != nil {
+			return nil, 0, err
+		}
+		for _, node := range nodes {
+			if !req.Restriction.Allows(node) || filter != nil && !filter(node) {
+				continue
+			}
+			if total >= from && total < to {
+				result = append(result, node)
+			}
+			total++
+		}
+		if len(nodes) < searchBatchSize {
+			break
+		}
+	}
+	return result, total, nil
+}
+
+func (D DB) Index(ctx context.Context, node model.SearchNode) error {
+	return db.CreateSearchNode(&node)
+}
+
+func (D DB) BatchIndex(ctx context.Context, nodes []model.SearchNode) error {
+	return db.BatchCreateSearchNodes(&nodes)
+}
+
+func (D DB) Get(ctx context.Context, parent string) ([]model.SearchNode, error) {
+	return db.GetSearchNodesByParent(parent)
+}
+
+func (D DB) Del(ctx context.Context, path string) error {
+	return db.DeleteSearchNodesByParent(path)
+}
+
+func (D DB) Release(ctx context.Context) error {
+	return db.DropSearchNodesFTS()
+}
+
+func (D DB) Clear(ctx context.Context) error {
+	return db.ClearSearchNodes()
+}
+
+var _ searcher.Searcher = (*DB)(nil)
+var _ searcher.FilteredSearcher = (*DB)(nil)
@@ -0,0 +1,156 @@
+package sqlite_fts
+
+import (
+	"context"
+	"slices"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	conf.Conf = conf.DefaultConfig("data")
+	db.Init(dB)
+}
+
+func names(nodes []model.SearchNode) []string {
+	var names []string
+	for _, node := range nodes {
+		names = append(names, node.Name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func TestSearch(t *testing.T) {
+	ctx := context.Background()
+	// the nodes indexed before are added to the fts5 table
+	nodes := []model.SearchNode{
+		{Parent: "/docs", Name: "Hello World.md"},
+		{Parent: "/docs", Name: "年度报告文档.pdf"},
+		{Parent: "/docs/old", Name: "world map.png"},
+	}
+	if err := db.BatchCreateSearchNodes(&nodes); err != nil {
+		t.Fatal(err)
+	}
+	if err := db.InitSearchNodesFTS(); err != nil {
+		t.Fatalf("failed to init fts5: %+v", err)
+	}
+	s := DB{}
+	if err := s.Index(ctx, model.SearchNode{Parent: "/docs", Name: "worldwide.txt"}); err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		keywords string
+		parent   string
+		want     []string
+	}{
+		{"WORLD", "/", []string{"Hello World.md", "world map.png", "worldwide.txt"}},
+		{"rld.m", "/", []string{"Hello World.md"}},
+		{"world", "/docs/old", []string{"world map.png"}},
+		{"报告文档", "/", []string{"年度报告文档.pdf"}},
+		{"文档 pdf", "/", []string{"年度报告文档.pdf"}},
+		{`wo"rld`, "/", nil},
+		{"_", "/", nil},
+	}
+	for _, tt := range tests {
+		got, total, err := s.Search(ctx, model.SearchReq{
+			Parent:   tt.parent,
+			Keywords: tt.keywords,
+			PageReq:  model.PageReq{Page: 1, PerPage: 10},
+		})
+		if err != nil {
+			t.Fatalf("%s: Search() error = %+v", tt.keywords, err)
+		}
+		if !slices.Equal(names(got), tt.want) || total != int64(len(tt.want)) {
+			t.Errorf("%s: Search() = %v, %d, want %v", tt.keywords, names(got), total, tt.want)
+		}
+	}
+
+	if err := s.Del(ctx, "/docs/old"); err != nil {
+		t.Fatal(err)
+	}
+	got, total, err := s.SearchFiltered(ctx, model.SearchReq{
+		Parent:   "/",
+		Keywords: "world",
+		PageReq:  model.PageReq{Page: 1, PerPage: 1},
+	}, func(node model.SearchNode) bool {
+		return node.Name != "worldwide.txt"
+	})
+	if err != nil {
+		t.Fatalf("SearchFiltered() error = %+v", err)
+	}
+	if total != 1 || !slices.Equal(names(got), []string{"Hello World.md"}) {
+		t.Errorf("SearchFiltered() = %v, %d", names(got), total)
+	}
+
+	nodes = []model.SearchNode{
+		{Parent: "/docs/private", Name: "world secret.md"},
+		{Parent: "/docs/private/shared", Name: "world shared.md"},
+		{Parent: "/docs/private/a_b", Name: "world a_b.md"},
+		{Parent: "/docs/private/axb", Name: "world axb.md"},
+	}
+	if err = s.BatchIndex(ctx, nodes); err != nil {
+		t.Fatal(err)
+	}
+	got, total, err = s.Search(ctx, model.SearchReq{
+		Parent:   "/docs",
+		Keywords: "world",
+		PageReq:  model.PageReq{Page: 1, PerPage: 10},
+		Restriction: &model.SearchRestriction{
+			Denied: []model.SearchRule{
+				{Path: "/docs/private", Sub: true, Except: []string{"/docs/private/shared", "/docs/private/a_b"}},
+				{Path: "/docs", Sub: false},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatalf("Search() error = %+v", err)
+	}
+	if total != 2 || !slices.Equal(names(got), []string{"world a_b.md", "world shared.md"}) {
+		t.Errorf("Search() with restriction = %v, %d", names(got), total)
+	}
+
+	// the wildcards of the extensions are matched literally
+	got, total, err = s.Search(ctx, model.SearchReq{
+		Parent:   "/",
+		Keywords: "world",
+		Exts:     []string{"m_"},
+		PageReq:  model.PageReq{Page: 1, PerPage: 10},
+	})
+	if err != nil {
+		t.Fatalf("Search() error = %+v", err)
+	}
+	if total != 0 {
+		t.Errorf("Search() with wildcard ext = %v, %d", names(got), total)
+	}
+}
+
+func TestRelease(t *testing.T) {
+	if err := db.InitSearchNodesFTS(); err != nil {
+		t.Fatalf("failed to init fts5: %+v", err)
+	}
+	if err := (DB{}).Release(context.Background()); err != nil {
+		t.Fatalf("Release() error = %+v", err)
+	}
+	var count int64
+	if err := db.GetDb().Raw("SELECT COUNT(*) FROM sqlite_master WHERE name LIKE '%fts%'").Scan(&count).Error; err != nil {
+		t.Fatal(err)
+	}
+	if count != 0 {
+		t.Errorf("expected the fts5 table and triggers to be dropped, %d left", count)
+	}
+	// the nodes are still indexed by the searcher chosen next
+	nodes := []model.SearchNode{{Parent: "/released", Name: "a.txt"}}
+	if err := db.BatchCreateSearchNodes(&nodes); err != nil {
+		t.Fatalf("failed to index after release: %+v", err)
+	}
+}
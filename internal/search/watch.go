@@ -0,0 +1,161 @@
+package search
+
+import (
+	"context"
+	stdpath "path"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/driver"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	watchersMu sync.Mutex
+	// watchers cancels the change detection of the storages by id
+	watchers = make(map[uint]context.CancelFunc)
+	// watchPollInterval is the interval of the rescans of the storages watched for changes
+	// whose drivers don't report them, only the local driver on linux does
+	watchPollInterval = 10 * time.Minute
+)
+
+// watchStorage starts detecting the changes of the storage for the index,
+// by rescanning it on its index interval and by the changes reported by its driver.
+// The changes of the drivers that can't report them are polled by rescanning
+func watchStorage(storage driver.Driver) {
+	s := storage.GetStorage()
+	unwatchStorage(s.ID)
+	watcher, canWatch := storage.(driver.Watcher)
+	interval := time.Duration(s.IndexInterval) * time.Minute
+	if s.WatchChanges && !canWatch && interval <= 0 {
+		interval = watchPollInterval
+	}
+	canWatch = canWatch && s.WatchChanges
+	if s.Disabled || s.DisableIndex || interval <= 0 && !canWatch {
+		return
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	watchersMu.Lock()
+	watchers[s.ID] = cancel
+	watchersMu.Unlock()
+
+	if interval > 0 {
+		go func() {
+			ticker := time.NewTicker(interval)
+			defer ticker.Stop()
+			for {
+				select {
+				case <-ctx.Done():
+					return
+				case <-ticker.C:
+					if err := rescan(ctx, storage, "/"); err != nil && ctx.Err() == nil {
+						log.Errorf("failed to rescan [%s] for the index: %+v", s.MountPath, err)
+					}
+				}
+			}
+		}()
+	}
+	if canWatch {
+		go func() {
+			err := watcher.Watch(ctx, func(dirs []string) {
+				for _, dir := range dirs {
+					if err := updateStorageDir(ctx, storage, dir); err != nil {
+						log.Warnf("failed to update the index of [%s]: %+v", utils.GetFullPath(s.MountPath, dir), err)
+					}
+				}
+			})
+			if err != nil && ctx.Err() == nil {
+				log.Errorf("failed to watch [%s] for the index: %+v", s.MountPath, err)
+			}
+		}()
+	}
+}
+
+func unwatchStorage(id uint) {
+	watchersMu.Lock()
+	defer watchersMu.Unlock()
+	if cancel, ok := watchers[id]; ok {
+		cancel()
+		delete(watchers, id)
+	}
+}
+
+// rescan lists the folders under dir of the storage again and updates the index of
+// the folders whose children changed
+func rescan(ctx context.Context, storage driver.Driver, dir string) error {
+	if !canUpdate(utils.GetFullPath(storage.GetStorage().MountPath, dir)) {
+		return nil
+	}
+	objs, err := listStorageDir(ctx, storage, dir)
+	if err != nil {
+		return err
+	}
+	if _, err = updateDir(ctx, utils.GetFullPath(storage.GetStorage().MountPath, dir), objs); err != nil {
+		return err
+	}
+	for _, obj := range objs {
+		if err = ctx.Err(); err != nil {
+			return err
+		}
+		if obj.IsDir() {
+			if err = rescan(ctx, storage, stdpath.Join(dir, obj.GetName())); err != nil {
+				log.Warnf("failed to rescan [%s]: %+v", stdpath.Join(dir, obj.GetName()), err)
+			}
+		}
+	}
+	return nil
+}
+
+// updateStorageDir updates the index of a changed folder, the folders added to it are rescanned
+// as their children may not be reported
+func updateStorageDir(ctx context.Context, storage driver.Driver, dir string) error {
+	parent := utils.GetFullPath(storage.GetStorage().MountPath, dir)
+	if !canUpdate(parent) {
+		return nil
+	}
+	objs, err := listStorageDir(ctx, storage, dir)
+	if errs.IsObjectNotFound(err) {
+		// the folder has been removed, it's updated with its parent
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+	added, err := updateDir(ctx, parent, objs)
+	if err != nil {
+		return err
+	}
+	for _, name := range added {
+		if err = rescan(ctx, storage, stdpath.Join(dir, name)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func listStorageDir(ctx context.Context, storage driver.Driver, dir string) ([]model.Obj, error) {
+	objs, err := op.List(ctx, storage, dir, model.ListArgs{Refresh: true})
+	if err != nil {
+		return nil, errors.WithMessagef(err, "failed to list [%s]", dir)
+	}
+	if utils.PathEqual(dir, "/") {
+		// the recycle bin and versions are not indexed
+		objs = op.HideSystemFolders(storage, objs)
+	}
+	return objs, nil
+}
+
+func init() {
+	op.RegisterStorageHook(func(typ string, storage driver.Driver) {
+		if typ == "del" {
+			unwatchStorage(storage.GetStorage().ID)
+		} else {
+			watchStorage(storage)
+		}
+	})
+}
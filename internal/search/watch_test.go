@@ -0,0 +1,182 @@
+package search
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/drivers/local"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func setupIndex(t *testing.T) {
+	t.Helper()
+	conf.Conf = conf.DefaultConfig("data")
+	dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect database: %+v", err)
+	}
+	db.Init(dB)
+	previous := instance
+	instance = nil
+	if err = Init("database"); err != nil {
+		t.Fatalf("failed to init searcher: %+v", err)
+	}
+	t.Cleanup(func() { instance = previous })
+	WriteProgress(&model.IndexProgress{IsDone: true})
+}
+
+func indexedNames(t *testing.T, parent string) []string {
+	t.Helper()
+	nodes, err := instance.Get(context.Background(), parent)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, node := range nodes {
+		names = append(names, node.Name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func writeFile(t *testing.T, name, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(name, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRescan(t *testing.T) {
+	setupIndex(t)
+	root := t.TempDir()
+	writeFile(t, filepath.Join(root, "a.txt"), "a")
+	writeFile(t, filepath.Join(root, "dir", "b.txt"), "b")
+	_, err := op.CreateStorage(context.Background(), model.Storage{
+		Driver:    "Local",
+		MountPath: "/rescan",
+		Addition:  `{"root_folder_path":"` + filepath.ToSlash(root) + `"}`,
+	})
+	if err != nil {
+		t.Fatalf("failed to create storage: %+v", err)
+	}
+	storage, err := op.GetStorageByMountPath("/rescan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	if err = rescan(ctx, storage, "/"); err != nil {
+		t.Fatalf("failed to rescan: %+v", err)
+	}
+	if names := indexedNames(t, "/rescan"); !slices.Equal(names, []string{"a.txt", "dir"}) {
+		t.Fatalf("unexpected nodes: %v", names)
+	}
+	if names := indexedNames(t, "/rescan/dir"); !slices.Equal(names, []string{"b.txt"}) {
+		t.Fatalf("unexpected nodes: %v", names)
+	}
+
+	writeFile(t, filepath.Join(root, "a.txt"), "changed")
+	writeFile(t, filepath.Join(root, "dir", "sub", "c.txt"), "c")
+	if err = os.Remove(filepath.Join(root, "dir", "b.txt")); err != nil {
+		t.Fatal(err)
+	}
+	if err = rescan(ctx, storage, "/"); err != nil {
+		t.Fatalf("failed to rescan: %+v", err)
+	}
+	if names := indexedNames(t, "/rescan/dir"); !slices.Equal(names, []string{"sub"}) {
+		t.Fatalf("unexpected nodes: %v", names)
+	}
+	if names := indexedNames(t, "/rescan/dir/sub"); !slices.Equal(names, []string{"c.txt"}) {
+		t.Fatalf("unexpected nodes: %v", names)
+	}
+	nodes, _ := instance.Get(ctx, "/rescan")
+	for _, node := range nodes {
+		if node.Name == "a.txt" && node.Size != int64(len("changed")) {
+			t.Errorf("expected the changed file indexed again, got %+v", node)
+		}
+	}
+}
+
+func TestWatchChanges(t *testing.T) {
+	if runtime.GOOS != "linux" {
+		t.Skip("the changes of local storages are watched on linux")
+	}
+	setupIndex(t)
+	root := t.TempDir()
+	_, err := op.CreateStorage(context.Background(), model.Storage{
+		Driver:       "Local",
+		MountPath:    "/watch",
+		WatchChanges: true,
+		Addition:     `{"root_folder_path":"` + filepath.ToSlash(root) + `"}`,
+	})
+	if err != nil {
+		t.Fatalf("failed to create storage: %+v", err)
+	}
+	storage, err := op.GetStorageByMountPath("/watch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { unwatchStorage(storage.GetStorage().ID) })
+	// wait for the watcher started by the storage hook
+	time.Sleep(200 * time.Millisecond)
+	writeFile(t, filepath.Join(root, "dir", "a.txt"), "a")
+	deadline := time.Now().Add(5 * time.Second)
+	for !slices.Equal(indexedNames(t, "/watch/dir"), []string{"a.txt"}) {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the change indexed, got %v", indexedNames(t, "/watch/dir"))
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
+
+// unwatchable hides the watcher of the local driver
+type unwatchable struct{ *local.Local }
+
+func (unwatchable) Watch() {}
+
+func TestWatchChangesPolling(t *testing.T) {
+	setupIndex(t)
+	root := t.TempDir()
+	_, err := op.CreateStorage(context.Background(), model.Storage{
+		Driver:       "Local",
+		MountPath:    "/poll",
+		WatchChanges: true,
+		Addition:     `{"root_folder_path":"` + filepath.ToSlash(root) + `"}`,
+	})
+	if err != nil {
+		t.Fatalf("failed to create storage: %+v", err)
+	}
+	storage, err := op.GetStorageByMountPath("/poll")
+	if err != nil {
+		t.Fatal(err)
+	}
+	interval := watchPollInterval
+	watchPollInterval = 100 * time.Millisecond
+	t.Cleanup(func() {
+		watchPollInterval = interval
+		unwatchStorage(storage.GetStorage().ID)
+	})
+	// wait for the watcher started by the storage hook, it's replaced by the polling
+	time.Sleep(200 * time.Millisecond)
+	watchStorage(unwatchable{storage.(*local.Local)})
+	writeFile(t, filepath.Join(root, "dir", "a.txt"), "a")
+	deadline := time.Now().Add(5 * time.Second)
+	for !slices.Equal(indexedNames(t, "/poll/dir"), []string{"a.txt"}) {
+		if time.Now().After(deadline) {
+			t.Fatalf("expected the change polled, got %v", indexedNames(t, "/poll/dir"))
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
@@ -21,6 +21,12 @@ func archiveMeta(ctx context.Context, sid, path string, args model.SharingArchiv
 	if !sharing.Verify(args.Pwd) {
 		return sharing, nil, errors.WithStack(errs.WrongShareCode)
 	}
+	if err = op.CheckSharingAccess(ctx, sharing); err != nil {
+		return sharing, nil, err
+	}
+	if !sharing.CanRead() {
+		return sharing, nil, errors.WithStack(errs.SharingNoRead)
+	}
 	path = utils.FixAndCleanPath(path)
 	if len(sharing.Files) == 1 || path != "/" {
 		unwrapPath, err := op.GetSharingUnwrapPath(sharing, path)
@@ -48,6 +54,12 @@ func archiveList(ctx context.Context, sid, path string, args model.SharingArchiv
 	if !sharing.Verify(args.Pwd) {
 		return sharing, nil, errors.WithStack(errs.WrongShareCode)
 	}
+	if err = op.CheckSharingAccess(ctx, sharing); err != nil {
+		return sharing, nil, err
+	}
+	if !sharing.CanRead() {
+		return sharing, nil, errors.WithStack(errs.SharingNoRead)
+	}
 	path = utils.FixAndCleanPath(path)
 	if len(sharing.Files) == 1 || path != "/" {
 		unwrapPath, err := op.GetSharingUnwrapPath(sharing, path)
@@ -23,7 +23,20 @@ func get(ctx context.Context, sid, path string, args model.SharingListArgs) (*mo
 	if !sharing.Verify(args.Pwd) {
 		return sharing, nil, errors.WithStack(errs.WrongShareCode)
 	}
+	if err = op.CheckSharingAccess(ctx, sharing); err != nil {
+		return sharing, nil, err
+	}
 	path = utils.FixAndCleanPath(path)
+	if !sharing.CanRead() {
+		if path != "/" {
+			return sharing, nil, errors.WithStack(errs.SharingNoRead)
+		}
+		return sharing, &model.Object{
+			Name:     sid,
+			Modified: time.Time{},
+			IsFolder: true,
+		}, nil
+	}
 	if len(sharing.Files) == 1 || path != "/" {
 		unwrapPath, err := op.GetSharingUnwrapPath(sharing, path)
 		if err != nil {
@@ -23,6 +23,12 @@ func link(ctx context.Context, sid, path string, args *LinkArgs) (*model.Sharing
 	if !sharing.Verify(args.Pwd) {
 		return sharing, nil, nil, errors.WithStack(errs.WrongShareCode)
 	}
+	if err = op.CheckSharingAccess(ctx, sharing); err != nil {
+		return sharing, nil, nil, err
+	}
+	if !sharing.CanRead() {
+		return sharing, nil, nil, errors.WithStack(errs.SharingNoRead)
+	}
 	path = utils.FixAndCleanPath(path)
 	if len(sharing.Files) == 1 || path != "/" {
 		unwrapPath, err := op.GetSharingUnwrapPath(sharing, path)
@@ -22,6 +22,12 @@ func list(ctx context.Context, sid, path string, args model.SharingListArgs) (*m
 	if !sharing.Verify(args.Pwd) {
 		return sharing, nil, errors.WithStack(errs.WrongShareCode)
 	}
+	if err = op.CheckSharingAccess(ctx, sharing); err != nil {
+		return sharing, nil, err
+	}
+	if !sharing.CanRead() {
+		return sharing, []model.Obj{}, nil
+	}
 	path = utils.FixAndCleanPath(path)
 	if len(sharing.Files) == 1 || path != "/" {
 		unwrapPath, err := op.GetSharingUnwrapPath(sharing, path)
@@ -42,6 +48,9 @@ func list(ctx context.Context, sid, path string, args model.SharingListArgs) (*m
 			if err != nil && len(virtualFiles) == 0 {
 				return nil, nil, errors.WithMessage(err, "failed list sharing")
 			}
+			if actualPath == "/" {
+				objs = op.HideSystemFolders(storage, objs)
+			}
 		}
 		om := model.NewObjMerge()
 		objs = om.Merge(objs, virtualFiles...)
@@ -0,0 +1,212 @@
+package sync_job
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/pkg/cron"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/tache"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+var (
+	// next run time of the scheduled jobs, computed when a job is seen the first time
+	nextMu   sync.Mutex
+	nextRuns = make(map[uint]time.Time)
+
+	running sync.Map
+)
+
+func GetSyncJobs(pageIndex, pageSize int) ([]model.SyncJob, int64, error) {
+	return db.GetSyncJobs(pageIndex, pageSize)
+}
+
+func GetSyncJobById(id uint) (*model.SyncJob, error) {
+	return db.GetSyncJobById(id)
+}
+
+func validate(j *model.SyncJob) error {
+	j.SrcPath = utils.FixAndCleanPath(j.SrcPath)
+	j.DstPath = utils.FixAndCleanPath(j.DstPath)
+	if utils.IsSubPath(j.SrcPath, j.DstPath) || utils.IsSubPath(j.DstPath, j.SrcPath) {
+		return errors.New("the src path and the dst path can't contain each other")
+	}
+	switch j.Mode {
+	case "":
+		j.Mode = model.SyncCopy
+	case model.SyncMirror, model.SyncCopy, model.SyncBidirectional:
+	default:
+		return fmt.Errorf("invalid mode: %s", j.Mode)
+	}
+	switch j.Compare {
+	case "":
+		j.Compare = model.SyncCompareSize
+	case model.SyncCompareSize, model.SyncCompareMtime, model.SyncCompareHash:
+	default:
+		return fmt.Errorf("invalid compare: %s", j.Compare)
+	}
+	switch j.Conflict {
+	case "":
+		j.Conflict = model.SyncConflictNewer
+	case model.SyncConflictNewer, model.SyncConflictSrc, model.SyncConflictDst, model.SyncConflictSkip:
+	default:
+		return fmt.Errorf("invalid conflict policy: %s", j.Conflict)
+	}
+	if j.Cron != "" {
+		schedule, err := cron.ParseSchedule(j.Cron)
+		if err != nil {
+			return errors.WithMessage(err, "invalid cron")
+		}
+		if schedule.Next(time.Now()).IsZero() {
+			return fmt.Errorf("invalid cron: %s never matches", j.Cron)
+		}
+	}
+	return nil
+}
+
+func resetNextRun(id uint) {
+	nextMu.Lock()
+	defer nextMu.Unlock()
+	delete(nextRuns, id)
+}
+
+func CreateSyncJob(j *model.SyncJob) error {
+	j.ID = 0
+	j.LastRun, j.LastActions, j.LastError = nil, 0, ""
+	if err := validate(j); err != nil {
+		return err
+	}
+	return db.CreateSyncJob(j)
+}
+
+func UpdateSyncJob(j *model.SyncJob) error {
+	old, err := db.GetSyncJobById(j.ID)
+	if err != nil {
+		return err
+	}
+	if err = validate(j); err != nil {
+		return err
+	}
+	j.LastRun, j.LastActions, j.LastError = old.LastRun, old.LastActions, old.LastError
+	defer resetNextRun(j.ID)
+	return db.UpdateSyncJob(j)
+}
+
+func DeleteSyncJobById(id uint) error {
+	if _, err := db.GetSyncJobById(id); err != nil {
+		return errors.WithMessage(err, "failed get sync job")
+	}
+	defer resetNextRun(id)
+	return db.DeleteSyncJobById(id)
+}
+
+// RunSyncJob plans the job and performs the actions unless dryRun is set,
+// the copies are done by the tasks of the copy task manager
+func RunSyncJob(ctx context.Context, id uint, dryRun bool) ([]model.SyncAction, error) {
+	job, err := db.GetSyncJobById(id)
+	if err != nil {
+		return nil, err
+	}
+	if dryRun {
+		return Plan(ctx, job)
+	}
+	return run(ctx, job)
+}
+
+func run(ctx context.Context, job *model.SyncJob) ([]model.SyncAction, error) {
+	if _, ok := running.LoadOrStore(job.ID, struct{}{}); ok {
+		return nil, errors.New("the sync job is running")
+	}
+	defer running.Delete(job.ID)
+	if hasUnfinishedTasks(job) {
+		return nil, errors.New("the copy tasks of the last run are not finished")
+	}
+	actions, err := Plan(ctx, job)
+	if err == nil {
+		err = perform(ctx, job, actions)
+	}
+	now := time.Now()
+	job.LastRun, job.LastActions, job.LastError = &now, len(actions), ""
+	if err != nil {
+		job.LastError = err.Error()
+	}
+	if err := db.UpdateSyncJobResult(job); err != nil {
+		log.Errorf("failed update result of sync job [%s]: %+v", job.Name, err)
+	}
+	return actions, err
+}
+
+func hasUnfinishedTasks(job *model.SyncJob) bool {
+	if fs.CopyTaskManager == nil {
+		return false
+	}
+	tasks := fs.CopyTaskManager.GetByState(tache.StatePending, tache.StateRunning, tache.StateErrored, tache.StateFailing)
+	for _, t := range tasks {
+		dst := utils.GetFullPath(t.DstStorageMp, t.DstActualPath)
+		if utils.IsSubPath(job.DstPath, dst) || utils.IsSubPath(job.SrcPath, dst) {
+			return true
+		}
+	}
+	return false
+}
+
+// RunDueJobs runs the jobs whose scheduled time has come
+func RunDueJobs() {
+	jobs, err := db.GetAllSyncJobs()
+	if err != nil {
+		log.Errorf("failed get sync jobs: %+v", err)
+		return
+	}
+	now := time.Now()
+	nextMu.Lock()
+	defer nextMu.Unlock()
+	for i := range jobs {
+		job := &jobs[i]
+		if job.Disabled || job.Cron == "" {
+			delete(nextRuns, job.ID)
+			continue
+		}
+		schedule, err := cron.ParseSchedule(job.Cron)
+		if err != nil {
+			log.Errorf("invalid cron of sync job [%s]: %+v", job.Name, err)
+			continue
+		}
+		next, ok := nextRuns[job.ID]
+		if !ok {
+			nextRuns[job.ID] = schedule.Next(now)
+			continue
+		}
+		// the zero time of a schedule that never matches, which is refused when saved
+		if next.IsZero() || now.Before(next) {
+			continue
+		}
+		nextRuns[job.ID] = schedule.Next(now)
+		go func() {
+			ctx, err := jobContext()
+			if err == nil {
+				_, err = run(ctx, job)
+			}
+			if err != nil {
+				log.Errorf("failed run sync job [%s]: %+v", job.Name, err)
+			}
+		}()
+	}
+}
+
+// jobContext is the context of the scheduled runs, they are done as the admin
+func jobContext() (context.Context, error) {
+	admin, err := op.GetAdmin()
+	if err != nil {
+		return nil, errors.WithMessage(err, "failed get admin")
+	}
+	return context.WithValue(context.Background(), conf.UserKey, admin), nil
+}
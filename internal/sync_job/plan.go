@@ -0,0 +1,220 @@
+package sync_job
+
+import (
+	"context"
+	stdpath "path"
+	"slices"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// mtimeTolerance is the difference of modified time ignored, as some storages
+// only keep the modified time in seconds
+const mtimeTolerance = 2 * time.Second
+
+type planner struct {
+	job     *model.SyncJob
+	actions []model.SyncAction
+}
+
+// Plan compares the src path and the dst path of the job and returns the actions to sync them
+func Plan(ctx context.Context, job *model.SyncJob) ([]model.SyncAction, error) {
+	p := &planner{job: job, actions: []model.SyncAction{}}
+	if err := p.diff(ctx, job.SrcPath, job.DstPath); err != nil {
+		return nil, err
+	}
+	return p.actions, nil
+}
+
+// listObjs lists the objs of the path by name, a missing path is empty only if missingAsEmpty,
+// the src must never be, or a briefly missing src would remove everything in the dst
+func listObjs(ctx context.Context, path string, missingAsEmpty bool) (map[string]model.Obj, error) {
+	objs, err := fs.List(ctx, path, &fs.ListArgs{Refresh: true, NoLog: true})
+	if err != nil {
+		if missingAsEmpty && errs.IsObjectNotFound(err) {
+			return map[string]model.Obj{}, nil
+		}
+		return nil, errors.WithMessagef(err, "failed list [%s]", path)
+	}
+	m := make(map[string]model.Obj, len(objs))
+	for _, obj := range objs {
+		m[obj.GetName()] = obj
+	}
+	return m, nil
+}
+
+func sortedNames(objs map[string]model.Obj) []string {
+	names := make([]string, 0, len(objs))
+	for name := range objs {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+func (p *planner) copy(obj model.Obj, srcDir, dstDir, reason string) {
+	p.actions = append(p.actions, model.SyncAction{
+		Action: model.SyncActionCopy,
+		Src:    stdpath.Join(srcDir, obj.GetName()),
+		Dst:    stdpath.Join(dstDir, obj.GetName()),
+		IsDir:  obj.IsDir(),
+		Size:   obj.GetSize(),
+		Reason: reason,
+	})
+}
+
+func (p *planner) remove(obj model.Obj, dir, reason string) {
+	p.actions = append(p.actions, model.SyncAction{
+		Action: model.SyncActionRemove,
+		Dst:    stdpath.Join(dir, obj.GetName()),
+		IsDir:  obj.IsDir(),
+		Size:   obj.GetSize(),
+		Reason: reason,
+	})
+}
+
+func (p *planner) diff(ctx context.Context, srcDir, dstDir string) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	srcObjs, err := listObjs(ctx, srcDir, false)
+	if err != nil {
+		return err
+	}
+	dstObjs, err := listObjs(ctx, dstDir, true)
+	if err != nil {
+		return err
+	}
+	for _, name := range sortedNames(srcObjs) {
+		s := srcObjs[name]
+		d, ok := dstObjs[name]
+		switch {
+		case !ok:
+			p.copy(s, srcDir, dstDir, "missing in dst")
+		case s.IsDir() != d.IsDir():
+			// a file and a folder of the same name, only the mirror replaces it
+			if p.job.Mode == model.SyncMirror {
+				p.remove(d, dstDir, "type differs from src")
+				p.copy(s, srcDir, dstDir, "type differs from src")
+			} else {
+				log.Warnf("sync job [%s]: skip [%s] as the type differs", p.job.Name, stdpath.Join(srcDir, name))
+			}
+		case s.IsDir():
+			if err = p.diff(ctx, stdpath.Join(srcDir, name), stdpath.Join(dstDir, name)); err != nil {
+				return err
+			}
+		default:
+			reason := p.differs(s, d)
+			if reason == "" {
+				continue
+			}
+			if p.job.Mode != model.SyncBidirectional {
+				p.copy(s, srcDir, dstDir, reason)
+				continue
+			}
+			switch p.resolve(s, d) {
+			case model.SyncConflictSrc:
+				p.copy(s, srcDir, dstDir, reason+", src wins")
+			case model.SyncConflictDst:
+				p.copy(d, dstDir, srcDir, reason+", dst wins")
+			}
+		}
+	}
+	for _, name := range sortedNames(dstObjs) {
+		if _, ok := srcObjs[name]; ok {
+			continue
+		}
+		switch p.job.Mode {
+		case model.SyncMirror:
+			p.remove(dstObjs[name], dstDir, "missing in src")
+		case model.SyncBidirectional:
+			p.copy(dstObjs[name], dstDir, srcDir, "missing in src")
+		}
+	}
+	return nil
+}
+
+// differs returns the reason why the files are different, or an empty string if they are the same
+func (p *planner) differs(s, d model.Obj) string {
+	if s.GetSize() != d.GetSize() {
+		return "size differs"
+	}
+	switch p.job.Compare {
+	case model.SyncCompareMtime:
+		if mtimeDiffers(s, d) {
+			return "modified time differs"
+		}
+	case model.SyncCompareHash:
+		for ht, sh := range s.GetHash().All() {
+			if dh := d.GetHash().GetHash(ht); sh != "" && dh != "" {
+				if sh != dh {
+					return ht.Name + " differs"
+				}
+				return ""
+			}
+		}
+		// no hash to compare, the modified time is the next best
+		if mtimeDiffers(s, d) {
+			return "modified time differs"
+		}
+	}
+	return ""
+}
+
+func mtimeDiffers(s, d model.Obj) bool {
+	st, dt := s.ModTime(), d.ModTime()
+	if st.IsZero() || dt.IsZero() {
+		return false
+	}
+	diff := st.Sub(dt)
+	return diff > mtimeTolerance || diff < -mtimeTolerance
+}
+
+// resolve returns the side that wins the conflict, or the skip policy
+func (p *planner) resolve(s, d model.Obj) string {
+	if p.job.Conflict != model.SyncConflictNewer {
+		return p.job.Conflict
+	}
+	st, dt := s.ModTime(), d.ModTime()
+	switch {
+	case st.After(dt):
+		return model.SyncConflictSrc
+	case dt.After(st):
+		return model.SyncConflictDst
+	}
+	return model.SyncConflictSkip
+}
+
+// perform removes the objects in place and adds copy tasks for the copies
+func perform(ctx context.Context, job *model.SyncJob, actions []model.SyncAction) error {
+	if err := fs.MakeDir(ctx, job.DstPath); err != nil && !errs.IsObjectAlreadyExists(err) {
+		return errors.WithMessage(err, "failed make dst dir")
+	}
+	var failed int
+	var firstErr error
+	for _, a := range actions {
+		var err error
+		switch a.Action {
+		case model.SyncActionCopy:
+			_, err = fs.CopyAsTask(ctx, a.Src, stdpath.Dir(a.Dst))
+		case model.SyncActionRemove:
+			err = fs.Remove(ctx, a.Dst)
+		}
+		if err != nil {
+			log.Errorf("sync job [%s]: failed %s [%s]: %+v", job.Name, a.Action, a.Dst, err)
+			failed++
+			if firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	if failed > 0 {
+		return errors.WithMessagef(firstErr, "%d of %d actions failed", failed, len(actions))
+	}
+	return nil
+}
@@ -0,0 +1,102 @@
+package sync_job
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	_ "github.com/OpenListTeam/OpenList/v4/drivers/local"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database: " + err.Error())
+	}
+	conf.Conf = conf.DefaultConfig("data")
+	db.Init(dB)
+}
+
+func mountLocal(t *testing.T, mountPath string, files map[string]string) {
+	t.Helper()
+	root := t.TempDir()
+	for name, content := range files {
+		p := filepath.Join(root, filepath.FromSlash(name))
+		if err := os.MkdirAll(filepath.Dir(p), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	_, err := op.CreateStorage(context.Background(), model.Storage{
+		Driver:    "Local",
+		MountPath: mountPath,
+		Addition:  `{"root_folder_path":"` + filepath.ToSlash(root) + `"}`,
+	})
+	if err != nil {
+		t.Fatalf("failed to create storage: %+v", err)
+	}
+}
+
+func TestPlan(t *testing.T) {
+	mountLocal(t, "/src", map[string]string{"same.txt": "a", "changed.txt": "new", "dir/a.txt": "a", "new/b.txt": "b"})
+	mountLocal(t, "/dst", map[string]string{"same.txt": "a", "changed.txt": "old!", "dir/a.txt": "a", "extra.txt": "x"})
+	ctx := context.WithValue(context.Background(), conf.UserKey, &model.User{Role: model.ADMIN, BasePath: "/"})
+
+	actions, err := Plan(ctx, &model.SyncJob{SrcPath: "/src", DstPath: "/dst", Mode: model.SyncMirror, Compare: model.SyncCompareSize})
+	if err != nil {
+		t.Fatalf("failed to plan: %+v", err)
+	}
+	expected := []model.SyncAction{
+		{Action: model.SyncActionCopy, Src: "/src/changed.txt", Dst: "/dst/changed.txt"},
+		{Action: model.SyncActionCopy, Src: "/src/new", Dst: "/dst/new"},
+		{Action: model.SyncActionRemove, Dst: "/dst/extra.txt"},
+	}
+	if len(actions) != len(expected) {
+		t.Fatalf("expected %d actions, got %+v", len(expected), actions)
+	}
+	for i, a := range actions {
+		if a.Action != expected[i].Action || a.Src != expected[i].Src || a.Dst != expected[i].Dst {
+			t.Errorf("expected %+v, got %+v", expected[i], a)
+		}
+	}
+
+	actions, err = Plan(ctx, &model.SyncJob{SrcPath: "/src", DstPath: "/dst", Mode: model.SyncBidirectional, Compare: model.SyncCompareSize, Conflict: model.SyncConflictDst})
+	if err != nil {
+		t.Fatalf("failed to plan: %+v", err)
+	}
+	if len(actions) != 3 || actions[0].Src != "/dst/changed.txt" || actions[2].Src != "/dst/extra.txt" || actions[2].Dst != "/src/extra.txt" {
+		t.Errorf("unexpected bidirectional actions: %+v", actions)
+	}
+}
+
+func TestPlanMissingSrc(t *testing.T) {
+	mountLocal(t, "/src_gone", map[string]string{"a.txt": "a"})
+	mountLocal(t, "/dst_kept", map[string]string{"a.txt": "a"})
+	ctx := context.WithValue(context.Background(), conf.UserKey, &model.User{Role: model.ADMIN, BasePath: "/"})
+
+	if _, err := Plan(ctx, &model.SyncJob{SrcPath: "/src_gone/missing", DstPath: "/dst_kept", Mode: model.SyncMirror}); err == nil {
+		t.Fatal("expected planning with a missing src to fail instead of removing the dst")
+	}
+	actions, err := Plan(ctx, &model.SyncJob{SrcPath: "/src_gone", DstPath: "/dst_kept/missing", Mode: model.SyncMirror})
+	if err != nil || len(actions) != 1 || actions[0].Action != model.SyncActionCopy {
+		t.Fatalf("expected a missing dst to be empty, got %+v, %+v", actions, err)
+	}
+}
+
+func TestValidateCron(t *testing.T) {
+	if err := validate(&model.SyncJob{SrcPath: "/a", DstPath: "/b", Cron: "0 0 30 2 *"}); err == nil {
+		t.Fatal("expected a cron that never matches to be refused")
+	}
+	if err := validate(&model.SyncJob{SrcPath: "/a", DstPath: "/b", Cron: "0 0 29 2 *"}); err != nil {
+		t.Fatalf("expected Feb 29 to be valid: %v", err)
+	}
+}
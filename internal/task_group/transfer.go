@@ -67,6 +67,8 @@ func HookAndRemove(ctx context.Context, dstPath string, payloads ...any) {
 			err = verifyAndRemove(ctx, srcStorage, dstStorage, srcActualPath, dstActualPath)
 			if err != nil {
 				log.Error(err)
+			} else {
+				op.MoveDeadProps(string(p), path.Join(dstPath, path.Base(string(p))))
 			}
 		}
 	}
@@ -0,0 +1,179 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/sign"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	log "github.com/sirupsen/logrus"
+)
+
+const (
+	EventUpload          = "upload"
+	EventRemove          = "remove"
+	EventRename          = "rename"
+	EventCopy            = "copy"
+	EventMove            = "move"
+	EventOfflineDownload = "offline_download"
+	EventShareAccess     = "share_access"
+	EventLoginFailed     = "login_failed"
+)
+
+var Events = []string{
+	EventUpload, EventRemove, EventRename, EventCopy, EventMove,
+	EventOfflineDownload, EventShareAccess, EventLoginFailed,
+}
+
+type Payload struct {
+	Event string    `json:"event"`
+	Time  time.Time `json:"time"`
+	User  string    `json:"user,omitempty"`
+	Data  any       `json:"data"`
+}
+
+type FileData struct {
+	Path string `json:"path"`
+	Size int64  `json:"size,omitempty"`
+}
+
+type RenameData struct {
+	Path    string `json:"path"`
+	NewName string `json:"new_name"`
+}
+
+type TaskData struct {
+	ID      string `json:"id"`
+	Name    string `json:"name"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+type ShareData struct {
+	ID    string   `json:"id"`
+	Files []string `json:"files"`
+	IP    string   `json:"ip"`
+}
+
+type LoginData struct {
+	Username string `json:"username"`
+	IP       string `json:"ip"`
+}
+
+const (
+	EventHeader     = "X-OpenList-Event"
+	DeliveryHeader  = "X-OpenList-Delivery"
+	SignatureHeader = "X-OpenList-Signature"
+)
+
+var (
+	client = &http.Client{Timeout: 30 * time.Second}
+	// MaxAttempts is the number of attempts of a delivery, the interval
+	// between attempts starts at RetryInterval and doubles every time
+	MaxAttempts   = 5
+	RetryInterval = 10 * time.Second
+)
+
+// Emit delivers the event to the webhooks subscribing to it in the background
+func Emit(ctx context.Context, event string, data any) {
+	all, err := getWebhooks()
+	if err != nil {
+		log.Errorf("failed get webhooks: %+v", err)
+		return
+	}
+	var targets []model.Webhook
+	for i := range all {
+		if all[i].Subscribes(event) {
+			targets = append(targets, all[i])
+		}
+	}
+	if len(targets) == 0 {
+		return
+	}
+	payload := Payload{Event: event, Time: time.Now(), Data: data}
+	if user, ok := ctx.Value(conf.UserKey).(*model.User); ok {
+		payload.User = user.Username
+	}
+	body, err := utils.Json.Marshal(payload)
+	if err != nil {
+		log.Errorf("failed marshal payload of event [%s]: %+v", event, err)
+		return
+	}
+	for _, w := range targets {
+		go deliver(w, event, body)
+	}
+}
+
+func deliver(w model.Webhook, event string, body []byte) {
+	d := &model.WebhookDelivery{WebhookID: w.ID, Event: event, Payload: string(body)}
+	if err := db.CreateWebhookDelivery(d); err != nil {
+		log.Errorf("failed record delivery to webhook [%s]: %+v", w.Name, err)
+	}
+	interval := RetryInterval
+	for {
+		d.Attempts++
+		code, err := post(&w, d, body)
+		d.StatusCode, d.Success, d.Error = code, err == nil, ""
+		if err != nil {
+			d.Error = err.Error()
+			log.Warnf("failed deliver event [%s] to webhook [%s], attempt %d: %s", event, w.Name, d.Attempts, err)
+		}
+		if d.ID != 0 {
+			if err := db.UpdateWebhookDelivery(d); err != nil {
+				log.Errorf("failed record delivery to webhook [%s]: %+v", w.Name, err)
+			}
+		}
+		if d.Success || d.Attempts >= MaxAttempts {
+			return
+		}
+		time.Sleep(interval)
+		interval *= 2
+	}
+}
+
+func post(w *model.Webhook, d *model.WebhookDelivery, body []byte) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, w.URL, bytes.NewReader(body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "OpenList-Webhook/"+conf.Version)
+	req.Header.Set(EventHeader, d.Event)
+	req.Header.Set(DeliveryHeader, strconv.FormatUint(uint64(d.ID), 10))
+	// receivers verify the body with sign.NewHMACSign(secret).Verify
+	req.Header.Set(SignatureHeader, sign.NewHMACSign([]byte(w.Secret)).Sign(string(body), 0))
+	res, err := client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer res.Body.Close()
+	_, _ = io.Copy(io.Discard, io.LimitReader(res.Body, 64*1024))
+	if res.StatusCode < 200 || res.StatusCode >= 300 {
+		return res.StatusCode, fmt.Errorf("unexpected status: %s", res.Status)
+	}
+	return res.StatusCode, nil
+}
+
+type task interface {
+	Ctx() context.Context
+	GetID() string
+	GetName() string
+	GetErr() error
+}
+
+// EmitTask emits the event of a finished task, it's called in OnSucceeded or OnFailed
+func EmitTask(event string, t task) {
+	data := TaskData{ID: t.GetID(), Name: t.GetName(), Success: t.GetErr() == nil}
+	if err := t.GetErr(); err != nil {
+		data.Error = err.Error()
+	}
+	Emit(t.Ctx(), event, data)
+}
@@ -0,0 +1,83 @@
+package webhook
+
+import (
+	"sync"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils/random"
+	"github.com/pkg/errors"
+)
+
+// the webhooks are loaded on the first event and reloaded after they are modified
+var (
+	webhooksMu sync.RWMutex
+	webhooks   []model.Webhook
+	loaded     bool
+)
+
+func getWebhooks() ([]model.Webhook, error) {
+	webhooksMu.RLock()
+	if loaded {
+		defer webhooksMu.RUnlock()
+		return webhooks, nil
+	}
+	webhooksMu.RUnlock()
+	webhooksMu.Lock()
+	defer webhooksMu.Unlock()
+	if !loaded {
+		all, err := db.GetAllWebhooks()
+		if err != nil {
+			return nil, err
+		}
+		webhooks, loaded = all, true
+	}
+	return webhooks, nil
+}
+
+func reload() {
+	webhooksMu.Lock()
+	defer webhooksMu.Unlock()
+	webhooks, loaded = nil, false
+}
+
+func GetWebhooks(pageIndex, pageSize int) ([]model.Webhook, int64, error) {
+	return db.GetWebhooks(pageIndex, pageSize)
+}
+
+func GetWebhookById(id uint) (*model.Webhook, error) {
+	return db.GetWebhookById(id)
+}
+
+func CreateWebhook(w *model.Webhook) error {
+	w.ID = 0
+	if w.Secret == "" {
+		w.Secret = random.String(32)
+	}
+	defer reload()
+	return db.CreateWebhook(w)
+}
+
+func UpdateWebhook(w *model.Webhook) error {
+	old, err := db.GetWebhookById(w.ID)
+	if err != nil {
+		return err
+	}
+	if w.Secret == "" {
+		w.Secret = old.Secret
+	}
+	defer reload()
+	return db.UpdateWebhook(w)
+}
+
+func DeleteWebhookById(id uint) error {
+	if _, err := db.GetWebhookById(id); err != nil {
+		return errors.WithMessage(err, "failed get webhook")
+	}
+	defer reload()
+	return db.DeleteWebhookById(id)
+}
+
+func GetDeliveries(webhookID uint, pageIndex, pageSize int) ([]model.WebhookDelivery, int64, error) {
+	return db.GetWebhookDeliveries(webhookID, pageIndex, pageSize)
+}
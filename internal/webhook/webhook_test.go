@@ -0,0 +1,77 @@
+package webhook
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/sign"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	conf.Conf = conf.DefaultConfig("data")
+	dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database: " + err.Error())
+	}
+	db.Init(dB)
+}
+
+func TestDeliver(t *testing.T) {
+	RetryInterval = 10 * time.Millisecond
+	var attempts atomic.Int32
+	received := make(chan error, 1)
+	secret := "secret"
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// fail the first attempt to get the delivery retried
+		if attempts.Add(1) == 1 {
+			w.WriteHeader(http.StatusInternalServerError)
+			return
+		}
+		body, _ := io.ReadAll(r.Body)
+		received <- sign.NewHMACSign([]byte(secret)).Verify(string(body), r.Header.Get(SignatureHeader))
+	}))
+	defer srv.Close()
+
+	err := CreateWebhook(&model.Webhook{Name: "test", URL: srv.URL, Secret: secret, Events: EventRemove + "," + EventRename})
+	if err != nil {
+		t.Fatal(err)
+	}
+	Emit(context.Background(), EventUpload, FileData{Path: "/a.txt"})
+	Emit(context.Background(), EventRemove, FileData{Path: "/a.txt"})
+	select {
+	case err = <-received:
+		if err != nil {
+			t.Fatalf("invalid signature: %v", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("the event is not delivered")
+	}
+
+	var deliveries []model.WebhookDelivery
+	for range 50 {
+		deliveries, _, err = GetDeliveries(0, 1, 10)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(deliveries) == 1 && deliveries[0].Success {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	if len(deliveries) != 1 {
+		t.Fatalf("expected 1 delivery, got %d", len(deliveries))
+	}
+	if d := deliveries[0]; !d.Success || d.Attempts != 2 || d.Event != EventRemove || d.StatusCode != http.StatusOK {
+		t.Fatalf("unexpected delivery: %+v", d)
+	}
+}
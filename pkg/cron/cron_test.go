@@ -14,3 +14,34 @@ func TestCron(t *testing.T) {
 	c.Stop()
 	c.Stop()
 }
+
+func TestSchedule(t *testing.T) {
+	base := time.Date(2024, 1, 31, 10, 30, 15, 0, time.UTC)
+	tests := []struct {
+		spec string
+		next time.Time
+	}{
+		{"* * * * *", time.Date(2024, 1, 31, 10, 31, 0, 0, time.UTC)},
+		{"0 2 * * *", time.Date(2024, 2, 1, 2, 0, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2024, 1, 31, 10, 45, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2024, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"30 9 * * 1-5", time.Date(2024, 2, 1, 9, 30, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2024, 2, 4, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2024, 2, 1, 0, 0, 0, 0, time.UTC)},
+		{"@every 2h", time.Date(2024, 1, 31, 12, 30, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		s, err := ParseSchedule(tt.spec)
+		if err != nil {
+			t.Fatalf("failed to parse %s: %v", tt.spec, err)
+		}
+		if next := s.Next(base); !next.Equal(tt.next) {
+			t.Errorf("next of %s: expected %s, got %s", tt.spec, tt.next, next)
+		}
+	}
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "*/0 * * * *", "@every 1s"} {
+		if _, err := ParseSchedule(spec); err == nil {
+			t.Errorf("expected %q invalid", spec)
+		}
+	}
+}
@@ -0,0 +1,177 @@
+package cron
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a parsed cron expression with the fields
+// minute, hour, day of month, month and day of week
+type Schedule struct {
+	minute, hour, dom, month, dow uint64
+	// every is set for the @every descriptor
+	every time.Duration
+}
+
+type bounds struct {
+	min, max int
+}
+
+var (
+	minuteBounds = bounds{0, 59}
+	hourBounds   = bounds{0, 23}
+	domBounds    = bounds{1, 31}
+	monthBounds  = bounds{1, 12}
+	dowBounds    = bounds{0, 6}
+)
+
+var descriptors = map[string]string{
+	"@yearly":   "0 0 1 1 *",
+	"@annually": "0 0 1 1 *",
+	"@monthly":  "0 0 1 * *",
+	"@weekly":   "0 0 * * 0",
+	"@daily":    "0 0 * * *",
+	"@midnight": "0 0 * * *",
+	"@hourly":   "0 * * * *",
+}
+
+// ParseSchedule parses a standard 5 fields cron expression,
+// the descriptors like @daily and "@every <duration>" are supported as well
+func ParseSchedule(spec string) (*Schedule, error) {
+	spec = strings.TrimSpace(spec)
+	if d, ok := strings.CutPrefix(spec, "@every "); ok {
+		every, err := time.ParseDuration(strings.TrimSpace(d))
+		if err != nil {
+			return nil, fmt.Errorf("invalid duration of %s: %w", spec, err)
+		}
+		if every < time.Minute {
+			return nil, fmt.Errorf("invalid duration of %s: less than a minute", spec)
+		}
+		return &Schedule{every: every}, nil
+	}
+	if expr, ok := descriptors[spec]; ok {
+		spec = expr
+	}
+	fields := strings.Fields(spec)
+	if len(fields) != 5 {
+		return nil, fmt.Errorf("expected 5 fields in %s, found %d", spec, len(fields))
+	}
+	var s Schedule
+	var err error
+	for i, f := range []struct {
+		bits *uint64
+		b    bounds
+	}{
+		{&s.minute, minuteBounds},
+		{&s.hour, hourBounds},
+		{&s.dom, domBounds},
+		{&s.month, monthBounds},
+		{&s.dow, dowBounds},
+	} {
+		if *f.bits, err = parseField(fields[i], f.b); err != nil {
+			return nil, err
+		}
+	}
+	return &s, nil
+}
+
+func parseField(field string, b bounds) (uint64, error) {
+	var bits uint64
+	for part := range strings.SplitSeq(field, ",") {
+		rng, stepStr, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			if step, err = strconv.Atoi(stepStr); err != nil || step <= 0 {
+				return 0, fmt.Errorf("invalid step of %s", part)
+			}
+		}
+		lo, hi := b.min, b.max
+		if rng != "*" {
+			loStr, hiStr, isRange := strings.Cut(rng, "-")
+			var err error
+			if lo, err = strconv.Atoi(loStr); err != nil {
+				return 0, fmt.Errorf("invalid value of %s", part)
+			}
+			hi = lo
+			if isRange {
+				if hi, err = strconv.Atoi(hiStr); err != nil {
+					return 0, fmt.Errorf("invalid value of %s", part)
+				}
+			} else if hasStep {
+				hi = b.max
+			}
+		}
+		// 7 is sunday as well
+		if b == dowBounds && hi == 7 {
+			bits |= 1
+			if lo == 7 {
+				continue
+			}
+			hi = 6
+		}
+		if lo < b.min || hi > b.max || lo > hi {
+			return 0, fmt.Errorf("value out of range of %s", part)
+		}
+		for i := lo; i <= hi; i += step {
+			bits |= 1 << uint(i)
+		}
+	}
+	return bits, nil
+}
+
+func has(bits uint64, v int) bool {
+	return bits&(1<<uint(v)) != 0
+}
+
+// Next returns the first time matching the schedule after t, the zero time if none matches
+func (s *Schedule) Next(t time.Time) time.Time {
+	if s.every > 0 {
+		return t.Add(s.every).Truncate(time.Minute)
+	}
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	// no time matches if it's not found in 5 years, like Feb 30
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		if !has(s.month, int(t.Month())) {
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !s.matchDay(t) {
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+			continue
+		}
+		if !has(s.hour, t.Hour()) {
+			t = t.Truncate(time.Hour).Add(time.Hour)
+			continue
+		}
+		if !has(s.minute, t.Minute()) {
+			t = t.Add(time.Minute)
+			continue
+		}
+		return t
+	}
+	return time.Time{}
+}
+
+// matchDay follows the cron convention, if both the day of month and the day of week
+// are restricted, either of them matching is enough
+func (s *Schedule) matchDay(t time.Time) bool {
+	allDom := s.dom == fieldBits(domBounds)
+	allDow := s.dow == fieldBits(dowBounds)
+	domMatch, dowMatch := has(s.dom, t.Day()), has(s.dow, int(t.Weekday()))
+	if allDom || allDow {
+		return domMatch && dowMatch
+	}
+	return domMatch || dowMatch
+}
+
+func fieldBits(b bounds) uint64 {
+	var bits uint64
+	for i := b.min; i <= b.max; i++ {
+		bits |= 1 << uint(i)
+	}
+	return bits
+}
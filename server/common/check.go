@@ -2,7 +2,6 @@ package common
 
 import (
 	"path"
-	"slices"
 	"strings"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
@@ -23,7 +22,7 @@ func CanRead(user *model.User, meta *model.Meta, path string) bool {
 	if user == nil {
 		return true
 	}
-	if meta != nil && len(meta.ReadUsers) > 0 && !slices.Contains(meta.ReadUsers, user.ID) && MetaCoversPath(meta.Path, path, meta.ReadUsersSub) {
+	if meta != nil && !meta.AllowRead(user) && MetaCoversPath(meta.Path, path, meta.ReadUsersSub) {
 		return false
 	}
 	return true
@@ -34,7 +33,7 @@ func CanWrite(user *model.User, meta *model.Meta, path string) bool {
 	if user == nil {
 		return true
 	}
-	if meta != nil && len(meta.WriteUsers) > 0 && !slices.Contains(meta.WriteUsers, user.ID) && MetaCoversPath(meta.Path, path, meta.WriteUsersSub) {
+	if meta != nil && !meta.AllowWrite(user) && MetaCoversPath(meta.Path, path, meta.WriteUsersSub) {
 		return false
 	}
 	return true
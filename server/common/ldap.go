@@ -3,6 +3,7 @@ package common
 import (
 	"crypto/tls"
 	"fmt"
+	"slices"
 	"strings"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
@@ -19,6 +20,12 @@ import (
 var ErrFailedLdapAuth = errors.New("failed to auth")
 
 func HandleLdapLogin(username, password string) error {
+	_, err := LdapLogin(username, password)
+	return err
+}
+
+// LdapLogin authenticates the user and returns the names of its LDAP groups
+func LdapLogin(username, password string) ([]string, error) {
 	// Auth start
 	ldapServer := setting.GetStr(conf.LdapServer)
 	skipTlsVerify := setting.GetBool(conf.LdapSkipTlsVerify)
@@ -26,11 +33,12 @@ func HandleLdapLogin(username, password string) error {
 	ldapManagerPassword := setting.GetStr(conf.LdapManagerPassword)
 	ldapUserSearchBase := setting.GetStr(conf.LdapUserSearchBase)
 	ldapUserSearchFilter := setting.GetStr(conf.LdapUserSearchFilter) // (uid=%s)
+	ldapGroupAttribute := setting.GetStr(conf.LdapGroupAttribute)     // memberOf
 
 	// Connect to LdapServer
 	l, err := dial(ldapServer, skipTlsVerify)
 	if err != nil {
-		return errors.WithMessagef(err, "failed to connect to LDAP")
+		return nil, errors.WithMessagef(err, "failed to connect to LDAP")
 	}
 	defer l.Close()
 
@@ -38,50 +46,78 @@ func HandleLdapLogin(username, password string) error {
 	if ldapManagerDN != "" && ldapManagerPassword != "" {
 		err = l.Bind(ldapManagerDN, ldapManagerPassword)
 		if err != nil {
-			return errors.WithMessagef(err, "failed to bind to LDAP")
+			return nil, errors.WithMessagef(err, "failed to bind to LDAP")
 		}
 	}
 
 	// Search for the given username
+	attributes := []string{"dn"}
+	if ldapGroupAttribute != "" {
+		attributes = append(attributes, ldapGroupAttribute)
+	}
 	searchRequest := ldap.NewSearchRequest(
 		ldapUserSearchBase,
 		ldap.ScopeWholeSubtree, ldap.NeverDerefAliases, 0, 0, false,
 		fmt.Sprintf(ldapUserSearchFilter, ldap.EscapeFilter(username)),
-		[]string{"dn"},
+		attributes,
 		nil,
 	)
 	sr, err := l.Search(searchRequest)
 	if err != nil {
-		return errors.WithMessagef(err, "failed login ldap: LDAP search failed")
+		return nil, errors.WithMessagef(err, "failed login ldap: LDAP search failed")
 	}
 	if len(sr.Entries) != 1 {
-		return errors.New("failed login ldap: user does not exist or too many entries returned")
+		return nil, errors.New("failed login ldap: user does not exist or too many entries returned")
 	}
 	userDN := sr.Entries[0].DN
+	groups := ldapGroupNames(sr.Entries[0].GetAttributeValues(ldapGroupAttribute))
 
 	// Bind as the user to verify their password
 	err = l.Bind(userDN, password)
 	if err != nil {
-		return errors.WithMessagef(ErrFailedLdapAuth, "%v", err)
+		return nil, errors.WithMessagef(ErrFailedLdapAuth, "%v", err)
 	}
 	log.Infof("LDAP auth successful for %s", username)
 	// Auth finished
-	return nil
+	return groups, nil
 }
 
-func LdapRegister(username string) (*model.User, error) {
+// ldapGroupNames adds the first RDN value of the group DNs, e.g. admins of cn=admins,ou=groups,dc=example,dc=com
+func ldapGroupNames(values []string) []string {
+	names := slices.Clone(values)
+	for _, v := range values {
+		dn, err := ldap.ParseDN(v)
+		if err != nil || len(dn.RDNs) == 0 || len(dn.RDNs[0].Attributes) == 0 {
+			continue
+		}
+		names = append(names, dn.RDNs[0].Attributes[0].Value)
+	}
+	return names
+}
+
+// LdapRegister creates the user in the groups mapped from its LDAP groups
+func LdapRegister(username string, groups ...string) (*model.User, error) {
 	if username == "" {
 		return nil, errors.New("cannot get username from ldap provider")
 	}
+	groupIds, err := op.MatchExternalGroups(groups)
+	if err != nil {
+		return nil, err
+	}
 	user := &model.User{
-		Username:   username,
-		Password:   "",
-		Authn:      "[]",
-		Permission: int32(setting.GetInt(conf.LdapDefaultPermission, 0)),
-		BasePath:   setting.GetStr(conf.LdapDefaultDir),
-		Role:       0,
-		Disabled:   false,
-		AllowLdap:  true,
+		Username:         username,
+		Password:         "",
+		Authn:            "[]",
+		Permission:       int32(setting.GetInt(conf.LdapDefaultPermission, 0)),
+		BasePath:         setting.GetStr(conf.LdapDefaultDir),
+		Role:             0,
+		Disabled:         false,
+		AllowLdap:        true,
+		GroupIDs:         groupIds,
+		ExternalGroupIDs: map[string][]uint{model.GroupSourceLdap: groupIds},
+	}
+	if p := op.GroupBasePath(groupIds); p != "" {
+		user.BasePath = p
 	}
 	user.SetPassword(random.String(16))
 	if err := op.CreateUser(user); err != nil {
@@ -0,0 +1,52 @@
+package common
+
+import (
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/dlclark/regexp2"
+	"github.com/pkg/errors"
+)
+
+// SearchRestriction builds the restriction of the search results of the user from all the metas.
+// As CanAccess checks the nearest meta of the parent, a meta doesn't apply to the sub folders
+// which have their own metas.
+func SearchRestriction(user *model.User, password string) (*model.SearchRestriction, error) {
+	metas, _, err := op.GetMetas(1, -1)
+	if err != nil {
+		return nil, err
+	}
+	return searchRestriction(user, metas, password)
+}
+
+func searchRestriction(user *model.User, metas []model.Meta, password string) (*model.SearchRestriction, error) {
+	restriction := &model.SearchRestriction{}
+	for _, meta := range metas {
+		var except []string
+		for _, other := range metas {
+			if other.ID != meta.ID && !utils.PathEqual(other.Path, meta.Path) && utils.IsSubPath(meta.Path, other.Path) {
+				except = append(except, other.Path)
+			}
+		}
+		denied := meta.ReadUsersSub && !meta.AllowRead(user)
+		denied = denied || meta.PSub && meta.Password != "" && meta.Password != password && !user.CanAccessWithoutPassword()
+		if denied {
+			restriction.Denied = append(restriction.Denied, model.SearchRule{Path: meta.Path, Sub: true, Except: except})
+		}
+		if meta.Hide == "" || user.CanSeeHides() {
+			continue
+		}
+		rule := model.SearchRule{Path: meta.Path, Sub: meta.HSub, Except: except}
+		for hide := range strings.SplitSeq(meta.Hide, "\n") {
+			re, err := regexp2.Compile(hide, regexp2.None)
+			if err != nil {
+				return nil, errors.Wrapf(err, "invalid hide pattern of meta [%s]", meta.Path)
+			}
+			rule.Patterns = append(rule.Patterns, re)
+		}
+		restriction.Hidden = append(restriction.Hidden, rule)
+	}
+	return restriction, nil
+}
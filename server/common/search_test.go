@@ -0,0 +1,54 @@
+package common
+
+import (
+	"path"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+)
+
+func TestSearchRestrictionMatchesCanAccess(t *testing.T) {
+	metas := []model.Meta{
+		{ID: 1, Path: "/", Hide: `^\.`, HSub: true},
+		{ID: 2, Path: "/private", ReadUsers: []uint{1}, ReadUsersSub: true},
+		{ID: 3, Path: "/private/shared"},
+		{ID: 4, Path: "/locked", Password: "secret", PSub: true, Hide: "tmp", HSub: false},
+		{ID: 5, Path: "/hidden", Hide: "^a", HSub: true},
+	}
+	nearestMeta := func(p string) *model.Meta {
+		var nearest *model.Meta
+		for i := range metas {
+			if utils.IsSubPath(metas[i].Path, p) && (nearest == nil || len(metas[i].Path) > len(nearest.Path)) {
+				nearest = &metas[i]
+			}
+		}
+		return nearest
+	}
+	nodes := []string{
+		"/.git", "/a.txt", "/private/a.txt", "/private/sub/b.txt", "/private/shared/c.txt",
+		"/private/shared/.d", "/locked/tmp", "/locked/sub/tmp", "/hidden/a.txt", "/hidden/sub/a.txt",
+		"/hidden/sub/b.txt",
+	}
+	users := []*model.User{
+		{ID: 1, Role: model.GENERAL},
+		{ID: 2, Role: model.GENERAL},
+		{ID: 3, Role: model.GUEST},
+		{ID: 4, Role: model.GENERAL, Permission: 1},
+	}
+	for _, user := range users {
+		for _, password := range []string{"", "secret"} {
+			restriction, err := searchRestriction(user, metas, password)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, nodePath := range nodes {
+				node := model.SearchNode{Parent: path.Dir(nodePath), Name: path.Base(nodePath)}
+				want := CanAccess(user, nearestMeta(node.Parent), nodePath, password)
+				if got := restriction.Allows(node); got != want {
+					t.Errorf("user %d with password %q: Allows(%s) = %v, want %v", user.ID, password, nodePath, got, want)
+				}
+			}
+		}
+	}
+}
@@ -11,6 +11,7 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/errs"
 	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/metrics"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/internal/stream"
@@ -60,6 +61,7 @@ func OpenDownload(ctx context.Context, reqPath string, offset int64) (*FileDownl
 
 func (f *FileDownloadProxy) Read(p []byte) (n int, err error) {
 	n, err = f.File.Read(p)
+	metrics.AddServedBytes("ftp", int64(n))
 	if err != nil {
 		return n, err
 	}
@@ -69,6 +71,7 @@ func (f *FileDownloadProxy) Read(p []byte) (n int, err error) {
 
 func (f *FileDownloadProxy) ReadAt(p []byte, off int64) (n int, err error) {
 	n, err = f.File.ReadAt(p, off)
+	metrics.AddServedBytes("ftp", int64(n))
 	if err != nil {
 		return n, err
 	}
@@ -0,0 +1,76 @@
+package handles
+
+import (
+	"strconv"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+type AccessTokenResp struct {
+	model.AccessToken
+	Token             string `json:"token"`
+	S3AccessKeyId     string `json:"s3_access_key_id"`
+	S3SecretAccessKey string `json:"s3_secret_access_key"`
+}
+
+func ListMyTokens(c *gin.Context) {
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	tokens, err := op.GetAccessTokens(user.ID)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, tokens)
+}
+
+func CreateMyToken(c *gin.Context) {
+	var req model.AccessToken
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	token, err := op.CreateAccessToken(user, &req)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	common.SuccessResp(c, AccessTokenResp{
+		AccessToken:       req,
+		Token:             token,
+		S3AccessKeyId:     op.AccessTokenS3KeyId(&req),
+		S3SecretAccessKey: op.AccessTokenS3Secret(&req),
+	})
+}
+
+func UpdateMyToken(c *gin.Context) {
+	var req model.AccessToken
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if err := op.UpdateAccessToken(user, &req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+func DeleteMyToken(c *gin.Context) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if err = op.DeleteAccessToken(user.ID, uint(id)); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	common.SuccessResp(c)
+}
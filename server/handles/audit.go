@@ -0,0 +1,100 @@
+package handles
+
+import (
+	"encoding/csv"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+	log "github.com/sirupsen/logrus"
+)
+
+type ListAuditLogsReq struct {
+	model.PageReq
+	model.AuditLogFilter
+}
+
+func ListAuditLogs(c *gin.Context) {
+	var req ListAuditLogsReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	req.Path = fixAuditPath(req.Path)
+	logs, total, err := op.GetAuditLogs(req.AuditLogFilter, req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: logs,
+		Total:   total,
+	})
+}
+
+type ExportAuditLogsReq struct {
+	model.AuditLogFilter
+	Format string `json:"format" form:"format"` // csv or jsonl
+}
+
+var auditCSVHeader = []string{"id", "time", "username", "action", "path", "dst_path", "detail", "protocol",
+	"success", "error", "ip", "asn", "aso", "country", "region", "city", "isp"}
+
+func ExportAuditLogs(c *gin.Context) {
+	var req ExportAuditLogsReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Path = fixAuditPath(req.Path)
+	var write func([]model.AuditLog) error
+	switch req.Format {
+	case "", "csv":
+		req.Format = "csv"
+		c.Header("Content-Type", "text/csv; charset=utf-8")
+		w := csv.NewWriter(c.Writer)
+		_ = w.Write(auditCSVHeader)
+		write = func(logs []model.AuditLog) error {
+			for _, l := range logs {
+				_ = w.Write([]string{strconv.FormatUint(uint64(l.ID), 10), l.Time.Format(time.RFC3339), l.Username,
+					l.Action, l.Path, l.DstPath, l.Detail, l.Protocol, strconv.FormatBool(l.Success), l.Error,
+					l.IP, strconv.FormatUint(uint64(l.Asn), 10), l.Aso, l.Country, l.Region, l.City, l.Isp})
+			}
+			w.Flush()
+			return w.Error()
+		}
+	case "jsonl":
+		c.Header("Content-Type", "application/jsonl; charset=utf-8")
+		encoder := utils.Json.NewEncoder(c.Writer)
+		write = func(logs []model.AuditLog) error {
+			for i := range logs {
+				if err := encoder.Encode(&logs[i]); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	default:
+		common.ErrorStrResp(c, "unsupported format: "+req.Format, 400)
+		return
+	}
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="audit_%s.%s"`, time.Now().Format("20060102150405"), req.Format))
+	c.Status(200)
+	if err := op.ExportAuditLogs(req.AuditLogFilter, write); err != nil {
+		// the response has been started, so the error can only be logged
+		log.Errorf("failed export audit logs: %+v", err)
+	}
+}
+
+func fixAuditPath(path string) string {
+	if path == "" {
+		return ""
+	}
+	return utils.FixAndCleanPath(path)
+}
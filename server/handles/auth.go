@@ -8,9 +8,12 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/webhook"
 	"github.com/OpenListTeam/OpenList/v4/server/common"
 	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
 	"github.com/pquerna/otp/totp"
+	log "github.com/sirupsen/logrus"
 )
 
 type LoginReq struct {
@@ -54,12 +57,14 @@ func loginHash(c *gin.Context, req *LoginReq) {
 	if err != nil {
 		common.ErrorStrResp(c, model.InvalidUsernameOrPassword, 401)
 		model.LoginCache.Set(ip, count+1)
+		auditLogin(c, req.Username, "password", errors.New(model.InvalidUsernameOrPassword))
 		return
 	}
 	// validate password hash
 	if err := user.ValidatePwdStaticHash(req.Password); err != nil {
 		common.ErrorStrResp(c, model.InvalidUsernameOrPassword, 401)
 		model.LoginCache.Set(ip, count+1)
+		auditLogin(c, req.Username, "password", errors.New(model.InvalidUsernameOrPassword))
 		return
 	}
 	// check 2FA
@@ -68,6 +73,7 @@ func loginHash(c *gin.Context, req *LoginReq) {
 			// 402 - need opt
 			common.ErrorStrResp(c, model.Invalid2FACode, 402)
 			model.LoginCache.Set(ip, count+1)
+			auditLogin(c, req.Username, "password", errors.New(model.Invalid2FACode))
 			return
 		}
 	}
@@ -79,23 +85,46 @@ func loginHash(c *gin.Context, req *LoginReq) {
 	}
 	common.SuccessResp(c, gin.H{"token": token})
 	model.LoginCache.Del(ip)
+	auditLogin(c, user.Username, "password", nil)
+}
+
+// auditLogin records the login attempt, the failed ones are sent to the webhooks as well
+func auditLogin(c *gin.Context, username, method string, err error) {
+	op.Audit(c.Request.Context(), &model.AuditLog{Username: username, Action: op.AuditLogin, Detail: method}, err)
+	if err != nil {
+		webhook.Emit(c.Request.Context(), webhook.EventLoginFailed, webhook.LoginData{Username: username, IP: c.ClientIP()})
+	}
 }
 
 type UserResp struct {
 	model.User
-	Otp bool `json:"otp"`
+	Otp                 bool         `json:"otp"`
+	EffectivePermission int32        `json:"effective_permission"`
+	Usage               *model.Usage `json:"usage,omitempty"`
+}
+
+func newUserResp(user *model.User) UserResp {
+	resp := UserResp{
+		User:                *user,
+		Otp:                 user.OtpSecret != "",
+		EffectivePermission: user.EffectivePermission(),
+	}
+	resp.Password = ""
+	return resp
 }
 
 // CurrentUser get current user by token
 // if token is empty, return guest user
 func CurrentUser(c *gin.Context) {
 	user := c.Request.Context().Value(conf.UserKey).(*model.User)
-	userResp := UserResp{
-		User: *user,
-	}
-	userResp.Password = ""
-	if userResp.OtpSecret != "" {
-		userResp.Otp = true
+	userResp := newUserResp(user)
+	if !user.IsGuest() {
+		usage, err := op.GetUserUsage(user)
+		if err != nil {
+			log.Errorf("failed get usage of user [%s]: %+v", user.Username, err)
+		} else {
+			userResp.Usage = usage
+		}
 	}
 	common.SuccessResp(c, userResp)
 }
@@ -170,14 +199,17 @@ func Verify2FA(c *gin.Context) {
 	}
 	if !totp.Validate(req.Code, req.Secret) {
 		common.ErrorStrResp(c, model.Invalid2FACode, 400)
+		op.Audit(c.Request.Context(), &model.AuditLog{Action: op.AuditEnable2FA}, errors.New(model.Invalid2FACode))
 		return
 	}
 	user.OtpSecret = req.Secret
-	if err := op.UpdateUser(user); err != nil {
+	err := op.UpdateUser(user)
+	if err != nil {
 		common.ErrorResp(c, err, 500)
 	} else {
 		common.SuccessResp(c)
 	}
+	op.Audit(c.Request.Context(), &model.AuditLog{Action: op.AuditEnable2FA}, err)
 }
 
 func LogOut(c *gin.Context) {
@@ -0,0 +1,122 @@
+package handles
+
+import (
+	"strconv"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/dedupe"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+type DedupeScanReq struct {
+	Paths []string `json:"paths" binding:"required"`
+}
+
+func DedupeScan(c *gin.Context) {
+	var req DedupeScanReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	report, err := dedupe.Scan(req.Paths)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	common.SuccessResp(c, report)
+}
+
+func DedupeCancel(c *gin.Context) {
+	dedupe.Cancel()
+	common.SuccessResp(c)
+}
+
+func ListDedupeReports(c *gin.Context) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	reports, total, err := dedupe.GetReports(req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: reports,
+		Total:   total,
+	})
+}
+
+func DeleteDedupeReport(c *gin.Context) {
+	idStr := c.Query("id")
+	id, err := strconv.Atoi(idStr)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := dedupe.DeleteReport(uint(id)); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+type ListDuplicateGroupsReq struct {
+	model.PageReq
+	ReportID uint `json:"report_id" form:"report_id"`
+}
+
+func ListDuplicateGroups(c *gin.Context) {
+	var req ListDuplicateGroupsReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	groups, total, err := dedupe.GetGroups(req.ReportID, req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: groups,
+		Total:   total,
+	})
+}
+
+type DedupeFilesReq struct {
+	IDs []uint `json:"ids" binding:"required"`
+}
+
+// DedupeRemove removes the selected duplicate files, a file of each group must be kept
+func DedupeRemove(c *gin.Context) {
+	var req DedupeFilesReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	results, err := dedupe.Remove(c.Request.Context(), req.IDs)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	common.SuccessResp(c, results)
+}
+
+// DedupeLink replaces the selected duplicate files with hard links of a kept file
+func DedupeLink(c *gin.Context) {
+	var req DedupeFilesReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	results, err := dedupe.Link(c.Request.Context(), req.IDs)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	common.SuccessResp(c, results)
+}
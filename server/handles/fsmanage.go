@@ -158,7 +158,7 @@ func FsMove(c *gin.Context) {
 	if len(addedTasks) > 0 {
 		common.SuccessResp(c, gin.H{
 			"message": fmt.Sprintf("Successfully created %d move task(s)", len(addedTasks)),
-			"tasks":   getTaskInfos(addedTasks),
+			"tasks":   GetTaskInfos(addedTasks),
 		})
 	} else {
 		common.SuccessResp(c, gin.H{
@@ -267,7 +267,7 @@ func FsCopy(c *gin.Context) {
 	if len(addedTasks) > 0 {
 		common.SuccessResp(c, gin.H{
 			"message": fmt.Sprintf("Successfully created %d copy task(s)", len(addedTasks)),
-			"tasks":   getTaskInfos(addedTasks),
+			"tasks":   GetTaskInfos(addedTasks),
 		})
 	} else {
 		common.SuccessResp(c, gin.H{
@@ -0,0 +1,191 @@
+package handles
+
+import (
+	"context"
+	"io"
+	stdpath "path"
+	"slices"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/archive/pack"
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/net"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/stream"
+	"github.com/OpenListTeam/OpenList/v4/pkg/http_range"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+type FsArchivePackReq struct {
+	Dir string `json:"dir" form:"dir"`
+	// Names selects the objects in Dir, the whole Dir is packed if empty
+	Names    []string `json:"names" form:"names"`
+	Format   string   `json:"format" form:"format"`
+	Password string   `json:"password" form:"password"`
+}
+
+// FsArchivePack streams a zip or tar of a folder or of the selected objects in it,
+// the layout is computed from the listed sizes so that downloads can be resumed
+func FsArchivePack(c *gin.Context) {
+	var req FsArchivePackReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if req.Format == "" {
+		req.Format = "zip"
+	}
+	if req.Format != "zip" && req.Format != "tar" {
+		common.ErrorStrResp(c, "unsupported format: "+req.Format, 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	reqPath, err := user.JoinPath(req.Dir)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	meta, err := op.GetNearestMeta(reqPath)
+	if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	if !common.CanAccess(user, meta, reqPath, req.Password) {
+		common.ErrorStrResp(c, "password is incorrect or you have no permission", 403)
+		return
+	}
+	// the links of the entries are requested with the headers of the client
+	ctx := context.WithValue(c.Request.Context(), conf.RequestHeaderKey, c.Request.Header)
+	entries, modified, err := packEntries(ctx, user, meta, reqPath, req.Names, req.Password)
+	if err != nil {
+		if errs.IsNotFoundError(err) {
+			common.ErrorResp(c, err, 404)
+		} else {
+			common.ErrorResp(c, err, 500)
+		}
+		return
+	}
+	var archive *pack.Archive
+	if req.Format == "zip" {
+		archive = pack.Zip(entries)
+	} else if archive, err = pack.Tar(entries); err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+
+	name := stdpath.Base(reqPath)
+	if len(req.Names) == 1 {
+		name = req.Names[0]
+	} else if name == "/" {
+		name = "archive"
+	}
+	name += "." + req.Format
+	c.Header("Content-Disposition", utils.GenerateContentDisposition(name))
+	c.Header("ETag", archive.ETag())
+	err = net.ServeHTTP(c.Writer, c.Request.WithContext(ctx), name, modified, archive.Size(), &model.RangeReadCloser{
+		RangeReader: archive,
+	})
+	if err != nil {
+		log.Errorf("failed to pack [%s]: %+v", reqPath, err)
+	}
+}
+
+// packEntries lists the objects to pack recursively, the objects hidden by the metas
+// or not readable by the user are skipped as they are in the listing
+func packEntries(ctx context.Context, user *model.User, meta *model.Meta, dir string, names []string, password string) ([]pack.Entry, time.Time, error) {
+	objs, err := fs.List(context.WithValue(ctx, conf.MetaKey, meta), dir, &fs.ListArgs{})
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	if len(names) > 0 {
+		selected := make([]model.Obj, 0, len(names))
+		for _, name := range names {
+			i := slices.IndexFunc(objs, func(obj model.Obj) bool { return obj.GetName() == name })
+			if i < 0 {
+				return nil, time.Time{}, errors.WithMessagef(errs.ObjectNotFound, "[%s] in [%s]", name, dir)
+			}
+			selected = append(selected, objs[i])
+		}
+		objs = selected
+	}
+
+	var entries []pack.Entry
+	var modified time.Time
+	var walk func(dir, prefix string, objs []model.Obj) error
+	walk = func(dir, prefix string, objs []model.Obj) error {
+		for _, obj := range objs {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			objPath := stdpath.Join(dir, obj.GetName())
+			entry := pack.Entry{
+				Name:     stdpath.Join(prefix, obj.GetName()),
+				Modified: obj.ModTime(),
+				IsDir:    obj.IsDir(),
+			}
+			if entry.Modified.After(modified) {
+				modified = entry.Modified
+			}
+			if !obj.IsDir() {
+				entry.Size = obj.GetSize()
+				entry.Key = objPath
+				entry.Open = func(ctx context.Context, offset, length int64) (io.ReadCloser, error) {
+					return openPackEntry(ctx, objPath, entry.Size, offset, length)
+				}
+				entries = append(entries, entry)
+				continue
+			}
+			subMeta, err := op.GetNearestMeta(objPath)
+			if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+				return err
+			}
+			if !common.CanAccess(user, subMeta, objPath, password) {
+				continue
+			}
+			entries = append(entries, entry)
+			subObjs, err := fs.List(context.WithValue(ctx, conf.MetaKey, subMeta), objPath, &fs.ListArgs{})
+			if err != nil {
+				return err
+			}
+			if err = walk(objPath, entry.Name, subObjs); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+	if err = walk(dir, "", objs); err != nil {
+		return nil, time.Time{}, err
+	}
+	return entries, modified, nil
+}
+
+func openPackEntry(ctx context.Context, path string, size, offset, length int64) (io.ReadCloser, error) {
+	link, _, err := fs.Link(ctx, path, model.LinkArgs{})
+	if err != nil {
+		return nil, err
+	}
+	if link.ContentLength > 0 {
+		size = link.ContentLength
+	}
+	rr, err := stream.GetRangeReaderFromLink(size, link)
+	if err != nil {
+		_ = link.Close()
+		return nil, err
+	}
+	rc, err := rr.RangeRead(ctx, http_range.Range{Start: offset, Length: length})
+	if err != nil {
+		_ = link.Close()
+		return nil, err
+	}
+	return utils.NewReadCloser(rc, func() error {
+		_ = rc.Close()
+		return link.Close()
+	}), nil
+}
@@ -123,7 +123,7 @@ func FsStream(c *gin.Context) {
 		return
 	}
 	common.SuccessResp(c, gin.H{
-		"task": getTaskInfo(t),
+		"task": GetTaskInfo(t),
 	})
 }
 
@@ -223,6 +223,6 @@ func FsForm(c *gin.Context) {
 		return
 	}
 	common.SuccessResp(c, gin.H{
-		"task": getTaskInfo(t),
+		"task": GetTaskInfo(t),
 	})
 }
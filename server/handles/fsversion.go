@@ -0,0 +1,112 @@
+package handles
+
+import (
+	"fmt"
+	stdpath "path"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/sign"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+type FsVersionsReq struct {
+	Path     string `json:"path" form:"path"`
+	Password string `json:"password" form:"password"`
+}
+
+type FsVersionResp struct {
+	model.FileVersion
+	RawURL string `json:"raw_url"`
+}
+
+func FsListVersions(c *gin.Context) {
+	var req FsVersionsReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	reqPath, err := user.JoinPath(req.Path)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	meta, err := op.GetNearestMeta(reqPath)
+	if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	if !common.CanAccess(user, meta, reqPath, req.Password) {
+		common.ErrorStrResp(c, "password is incorrect or you have no permission", 403)
+		return
+	}
+	versions, err := fs.GetVersions(c.Request.Context(), reqPath)
+	if err != nil {
+		if errs.IsNotFoundError(err) {
+			common.ErrorResp(c, err, 404)
+		} else {
+			common.ErrorResp(c, err, 500)
+		}
+		return
+	}
+	resp := make([]FsVersionResp, 0, len(versions))
+	for _, v := range versions {
+		resp = append(resp, FsVersionResp{
+			FileVersion: v,
+			RawURL: fmt.Sprintf("%s/p%s?sign=%s",
+				common.GetApiUrl(c),
+				utils.EncodePath(v.Path, true),
+				sign.Sign(v.Path)),
+		})
+	}
+	common.SuccessResp(c, resp)
+}
+
+type FsRestoreVersionReq struct {
+	Path    string `json:"path" binding:"required"`
+	Version string `json:"version" binding:"required"`
+}
+
+func FsRestoreVersion(c *gin.Context) {
+	var req FsRestoreVersionReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	reqPath, err := user.JoinPath(req.Path)
+	if err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
+	parentPath := stdpath.Dir(reqPath)
+	meta, err := op.GetNearestMeta(parentPath)
+	if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	if !user.CanWriteContent() && !common.CanWriteContentBypassUserPerms(meta, parentPath) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	if !common.CanWrite(user, meta, parentPath) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	if err := fs.RestoreVersion(c.Request.Context(), reqPath, req.Version); err != nil {
+		if errs.IsNotFoundError(err) {
+			common.ErrorResp(c, err, 404)
+		} else {
+			common.ErrorResp(c, err, 500)
+		}
+		return
+	}
+	common.SuccessResp(c)
+}
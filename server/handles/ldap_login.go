@@ -36,8 +36,9 @@ func LoginLdap(c *gin.Context) {
 		return
 	}
 
-	err = common.HandleLdapLogin(req.Username, req.Password)
+	groups, err := common.LdapLogin(req.Username, req.Password)
 	if err != nil {
+		auditLogin(c, req.Username, "ldap", err)
 		if errors.Is(err, common.ErrFailedLdapAuth) {
 			model.LoginCache.Set(ip, count+1)
 			common.ErrorResp(c, err, 400)
@@ -48,12 +49,16 @@ func LoginLdap(c *gin.Context) {
 	}
 
 	if user == nil {
-		user, err = common.LdapRegister(req.Username)
+		user, err = common.LdapRegister(req.Username, groups...)
 		if err != nil {
+			auditLogin(c, req.Username, "ldap", err)
 			common.ErrorResp(c, err, 400)
 			model.LoginCache.Set(ip, count+1)
 			return
 		}
+	} else if err = op.SyncUserGroups(user, model.GroupSourceLdap, groups); err != nil {
+		common.ErrorResp(c, err, 500)
+		return
 	}
 
 	// generate token
@@ -64,4 +69,5 @@ func LoginLdap(c *gin.Context) {
 	}
 	common.SuccessResp(c, gin.H{"token": token})
 	model.LoginCache.Del(ip)
+	auditLogin(c, user.Username, "ldap", nil)
 }
@@ -0,0 +1,88 @@
+package handles
+
+import (
+	"crypto/subtle"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/metrics"
+	"github.com/OpenListTeam/OpenList/v4/internal/multipart"
+	"github.com/OpenListTeam/OpenList/v4/internal/offline_download/tool"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/OpenListTeam/OpenList/v4/internal/stream"
+	"github.com/OpenListTeam/OpenList/v4/internal/task"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/OpenListTeam/tache"
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+var (
+	tasksDesc = prometheus.NewDesc("openlist_tasks",
+		"Number of tasks waiting or running in the task managers.", []string{"manager", "state"}, nil)
+	multipartSessionsDesc = prometheus.NewDesc("openlist_multipart_sessions",
+		"Number of active multipart upload sessions.", nil, nil)
+	rateLimitDesc = prometheus.NewDesc("openlist_rate_limit_bytes",
+		"Current limit of the stream limiters in bytes per second, +Inf if unlimited.", []string{"limiter"}, nil)
+)
+
+// stateCollector reads the state of the running instance on every scrape
+type stateCollector struct{}
+
+func (stateCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- tasksDesc
+	ch <- multipartSessionsDesc
+	ch <- rateLimitDesc
+}
+
+func (stateCollector) Collect(ch chan<- prometheus.Metric) {
+	collectTasks(ch, "upload", fs.UploadTaskManager)
+	collectTasks(ch, "copy", fs.CopyTaskManager)
+	collectTasks(ch, "move", fs.MoveTaskManager)
+	collectTasks(ch, "offline_download", tool.DownloadTaskManager)
+	collectTasks(ch, "offline_download_transfer", tool.TransferTaskManager)
+	collectTasks(ch, "decompress", fs.ArchiveDownloadTaskManager)
+	collectTasks(ch, "decompress_upload", fs.ArchiveContentUploadTaskManager)
+	ch <- prometheus.MustNewConstMetric(multipartSessionsDesc, prometheus.GaugeValue, float64(multipart.DefaultManager.Count()))
+	for name, limiter := range map[string]stream.Limiter{
+		"client_download": stream.ClientDownloadLimit,
+		"client_upload":   stream.ClientUploadLimit,
+		"server_download": stream.ServerDownloadLimit,
+		"server_upload":   stream.ServerUploadLimit,
+	} {
+		if limiter != nil {
+			ch <- prometheus.MustNewConstMetric(rateLimitDesc, prometheus.GaugeValue, float64(limiter.Limit()), name)
+		}
+	}
+}
+
+func collectTasks[T task.TaskExtensionInfo](ch chan<- prometheus.Metric, name string, manager task.Manager[T]) {
+	pending := len(manager.GetByState(tache.StatePending, tache.StateWaitingRetry))
+	running := len(manager.GetByState(tache.StateRunning, tache.StateBeforeRetry))
+	ch <- prometheus.MustNewConstMetric(tasksDesc, prometheus.GaugeValue, float64(pending), name, "pending")
+	ch <- prometheus.MustNewConstMetric(tasksDesc, prometheus.GaugeValue, float64(running), name, "running")
+}
+
+var metricsHandler = promhttp.HandlerFor(metrics.Registry, promhttp.HandlerOpts{})
+
+func init() {
+	metrics.Registry.MustRegister(stateCollector{})
+}
+
+// Metrics serves the metrics in the Prometheus exposition format,
+// it's only accessible with the token in the settings
+func Metrics(c *gin.Context) {
+	token := setting.GetStr(conf.MetricsToken)
+	if token == "" {
+		common.ErrorStrResp(c, "metrics is disabled", 403)
+		return
+	}
+	reqToken := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if subtle.ConstantTimeCompare([]byte(reqToken), []byte(token)) != 1 {
+		common.ErrorStrResp(c, "invalid token", 401)
+		return
+	}
+	metricsHandler.ServeHTTP(c.Writer, c.Request)
+}
@@ -92,7 +92,7 @@ func MultipartInit(c *gin.Context) {
 		return
 	}
 	// fail fast on unusable destinations instead of letting the pipeline discover it
-	storage, _, err := op.GetStorageAndActualPath(dir)
+	storage, actualDir, err := op.GetStorageAndActualPath(dir)
 	if err != nil {
 		common.ErrorResp(c, err, 500)
 		return
@@ -101,6 +101,14 @@ func MultipartInit(c *gin.Context) {
 		common.ErrorResp(c, errs.UploadNotSupported, 405)
 		return
 	}
+	if op.IsSystemPath(storage, actualDir) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	if err = op.CheckPutQuota(c.Request.Context(), storage, actualDir, name, size); err != nil {
+		common.ErrorResp(c, err, 403)
+		return
+	}
 	h := make(map[*utils.HashType]string)
 	if md5 := c.GetHeader("X-File-Md5"); md5 != "" {
 		h[utils.MD5] = md5
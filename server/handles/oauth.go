@@ -0,0 +1,264 @@
+package handles
+
+import (
+	"bytes"
+	"errors"
+	"html/template"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/oauth"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+// oauthError writes the error in the format of RFC 6749
+func oauthError(c *gin.Context, err error) {
+	var e *oauth.Error
+	if !errors.As(err, &e) {
+		e = oauth.NewError(oauth.ErrServerError, "%s", err.Error())
+	}
+	code := 400
+	switch e.Code {
+	case oauth.ErrInvalidClient:
+		code = 401
+	case oauth.ErrServerError:
+		code = 500
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(code, e)
+}
+
+func OIDCDiscovery(c *gin.Context) {
+	issuer := common.GetApiUrl(c)
+	c.JSON(200, gin.H{
+		"issuer":                                issuer,
+		"authorization_endpoint":                issuer + "/api/oauth/authorize",
+		"token_endpoint":                        issuer + "/api/oauth/token",
+		"userinfo_endpoint":                     issuer + "/api/oauth/userinfo",
+		"revocation_endpoint":                   issuer + "/api/oauth/revoke",
+		"jwks_uri":                              issuer + "/api/oauth/jwks",
+		"scopes_supported":                      oauth.Scopes,
+		"response_types_supported":              []string{"code"},
+		"grant_types_supported":                 []string{"authorization_code", "refresh_token"},
+		"subject_types_supported":               []string{"public"},
+		"id_token_signing_alg_values_supported": []string{"RS256"},
+		"code_challenge_methods_supported":      []string{"S256"},
+		"token_endpoint_auth_methods_supported": []string{"client_secret_basic", "client_secret_post", "none"},
+		"claims_supported":                      []string{"sub", "iss", "aud", "exp", "iat", "auth_time", "nonce", "preferred_username", "base_path"},
+	})
+}
+
+func OAuthJWKS(c *gin.Context) {
+	jwks, err := oauth.JWKS()
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	c.JSON(200, jwks)
+}
+
+// OAuthAuthorize validates the authorization request and renders the consent page,
+// errors before the redirect uri is validated are not sent to the client
+func OAuthAuthorize(c *gin.Context) {
+	var req oauth.AuthRequest
+	if err := c.ShouldBindQuery(&req); err != nil {
+		common.ErrorPage(c, err, 400)
+		return
+	}
+	client, err := req.Validate()
+	if err != nil {
+		var e *oauth.Error
+		if client, _ := oauth.GetClient(req.ClientID); client == nil || !client.AllowRedirectURI(req.RedirectURI) || !errors.As(err, &e) {
+			common.ErrorPage(c, err, 400)
+			return
+		}
+		c.Redirect(302, redirectWithQuery(req.RedirectURI, url.Values{
+			"error":             {e.Code},
+			"error_description": {e.Description},
+			"state":             {req.State},
+		}))
+		return
+	}
+	var page bytes.Buffer
+	err = consentPage.Execute(&page, gin.H{
+		"Client":  client.Name,
+		"Scopes":  strings.Fields(req.Scope),
+		"Request": req,
+		"Api":     common.GetApiUrl(c),
+		// the redirect uri is registered by the admin, custom schemes of native apps are allowed
+		"Deny": template.URL(redirectWithQuery(req.RedirectURI, url.Values{
+			"error": {oauth.ErrAccessDenied},
+			"state": {req.State},
+		})),
+	})
+	if err != nil {
+		common.ErrorPage(c, err, 500, true)
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.Header("X-Frame-Options", "DENY")
+	c.Data(200, "text/html; charset=utf-8", page.Bytes())
+}
+
+// consentPage approves the request with the token of the user logged in to the web ui
+var consentPage = template.Must(template.New("consent").Parse(`<!DOCTYPE html>
+<html lang="en">
+	<head>
+		<meta charset="utf-8" />
+		<meta name="viewport" content="width=device-width, initial-scale=1" />
+		<title>Authorize {{.Client}}</title>
+		<style>
+			body { font-family: sans-serif; max-width: 480px; margin: 10vh auto; padding: 0 16px; }
+			button { padding: 8px 16px; margin-right: 8px; }
+			#error { color: #c00; }
+		</style>
+	</head>
+	<body>
+		<h2>{{.Client}} wants to access your account</h2>
+		<p>Requested scopes:</p>
+		<ul>{{range .Scopes}}<li>{{.}}</li>{{end}}</ul>
+		<p id="error"></p>
+		<button id="approve">Approve</button>
+		<a href="{{.Deny}}"><button type="button">Deny</button></a>
+		<script>
+			const req = {{.Request}};
+			const approve = document.getElementById("approve");
+			const error = document.getElementById("error");
+			if (!localStorage.getItem("token")) {
+				error.textContent = "Please log in to OpenList in this browser, then reload this page.";
+				approve.disabled = true;
+			}
+			approve.onclick = async () => {
+				approve.disabled = true;
+				try {
+					const resp = await fetch({{.Api}} + "/api/oauth/authorize", {
+						method: "POST",
+						headers: { "Content-Type": "application/json", Authorization: localStorage.getItem("token") },
+						body: JSON.stringify(req),
+					});
+					const res = await resp.json();
+					if (res.code !== 200) {
+						throw new Error(res.message);
+					}
+					location.href = res.data.redirect;
+				} catch (e) {
+					error.textContent = e.message;
+					approve.disabled = false;
+				}
+			};
+		</script>
+	</body>
+</html>
+`))
+
+// OAuthApprove issues an authorization code after the logged-in user approves the request
+func OAuthApprove(c *gin.Context) {
+	var req oauth.AuthRequest
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	client, err := req.Validate()
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	code := oauth.NewCode(&req, user)
+	common.SuccessResp(c, gin.H{
+		"client":   client.Name,
+		"scope":    req.Scope,
+		"redirect": redirectWithQuery(req.RedirectURI, url.Values{"code": {code}, "state": {req.State}}),
+	})
+}
+
+func redirectWithQuery(uri string, values url.Values) string {
+	u, err := url.Parse(uri)
+	if err != nil {
+		return uri
+	}
+	q := u.Query()
+	for k, v := range values {
+		if len(v) > 0 && v[0] != "" {
+			q[k] = v
+		}
+	}
+	u.RawQuery = q.Encode()
+	return u.String()
+}
+
+// oauthClient authenticates the client by basic auth or the client_id and client_secret in the form
+func oauthClient(c *gin.Context) (*model.OAuthClient, error) {
+	clientID, secret, ok := c.Request.BasicAuth()
+	if !ok {
+		clientID, secret = c.PostForm("client_id"), c.PostForm("client_secret")
+	}
+	if clientID == "" {
+		return nil, oauth.NewError(oauth.ErrInvalidClient, "client_id is required")
+	}
+	return oauth.AuthenticateClient(clientID, secret)
+}
+
+func OAuthToken(c *gin.Context) {
+	client, err := oauthClient(c)
+	if err != nil {
+		oauthError(c, err)
+		return
+	}
+	var resp *oauth.TokenResp
+	switch c.PostForm("grant_type") {
+	case "authorization_code":
+		resp, err = oauth.ExchangeCode(common.GetApiUrl(c), client, c.PostForm("code"), c.PostForm("redirect_uri"), c.PostForm("code_verifier"))
+	case "refresh_token":
+		resp, err = oauth.Refresh(common.GetApiUrl(c), client, c.PostForm("refresh_token"), c.PostForm("scope"))
+	default:
+		err = oauth.NewError(oauth.ErrUnsupportedGrantType, "unsupported grant_type")
+	}
+	if err != nil {
+		oauthError(c, err)
+		return
+	}
+	c.Header("Cache-Control", "no-store")
+	c.JSON(200, resp)
+}
+
+func OAuthRevoke(c *gin.Context) {
+	client, err := oauthClient(c)
+	if err != nil {
+		oauthError(c, err)
+		return
+	}
+	if err = oauth.Revoke(client, c.PostForm("token")); err != nil {
+		oauthError(c, err)
+		return
+	}
+	c.Status(200)
+}
+
+func OAuthUserInfo(c *gin.Context) {
+	token, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+	if !ok {
+		c.Header("WWW-Authenticate", "Bearer")
+		c.Status(401)
+		return
+	}
+	claims, err := oauth.ParseAccessToken(token)
+	if err != nil {
+		c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+		c.Status(401)
+		return
+	}
+	id, _ := strconv.ParseUint(claims.Subject, 10, 64)
+	user, err := op.GetUserById(uint(id))
+	if err != nil || user.Disabled || user.PwdTS != claims.PwdTS {
+		c.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+		c.Status(401)
+		return
+	}
+	c.JSON(200, oauth.UserInfo(user, claims.Scope))
+}
@@ -0,0 +1,91 @@
+package handles
+
+import (
+	"strconv"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/oauth"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+type OAuthClientResp struct {
+	model.OAuthClient
+	Secret string `json:"secret,omitempty"`
+}
+
+func ListOAuthClients(c *gin.Context) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	clients, total, err := oauth.GetClients(req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: clients,
+		Total:   total,
+	})
+}
+
+func ListOAuthScopes(c *gin.Context) {
+	common.SuccessResp(c, oauth.Scopes)
+}
+
+func CreateOAuthClient(c *gin.Context) {
+	var req model.OAuthClient
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	secret, err := oauth.CreateClient(&req)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, OAuthClientResp{OAuthClient: req, Secret: secret})
+}
+
+func UpdateOAuthClient(c *gin.Context) {
+	var req model.OAuthClient
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := oauth.UpdateClient(&req); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
+
+func ResetOAuthClientSecret(c *gin.Context) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	secret, err := oauth.ResetClientSecret(uint(id))
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, gin.H{"secret": secret})
+}
+
+func DeleteOAuthClient(c *gin.Context) {
+	id, err := strconv.Atoi(c.Query("id"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	if err := oauth.DeleteClientById(uint(id)); err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c)
+}
@@ -533,6 +533,6 @@ func AddOfflineDownload(c *gin.Context) {
 		}
 	}
 	common.SuccessResp(c, gin.H{
-		"tasks": getTaskInfos(tasks),
+		"tasks": GetTaskInfos(tasks),
 	})
 }
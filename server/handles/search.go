@@ -1,17 +1,12 @@
 package handles
 
 import (
-	"path"
-
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
-	"github.com/OpenListTeam/OpenList/v4/internal/errs"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
-	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/internal/search"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
 	"github.com/OpenListTeam/OpenList/v4/server/common"
 	"github.com/gin-gonic/gin"
-	"github.com/pkg/errors"
 )
 
 type SearchReq struct {
@@ -19,6 +14,11 @@ type SearchReq struct {
 	Password string `json:"password"`
 }
 
+type SearchPageResp struct {
+	common.PageResp
+	Facets *model.SearchFacets `json:"facets"`
+}
+
 type SearchResp struct {
 	model.SearchNode
 	Type int `json:"type"`
@@ -43,23 +43,24 @@ func Search(c *gin.Context) {
 		common.ErrorResp(c, err, 400)
 		return
 	}
-	nodes, total, err := search.SearchFiltered(c, req.SearchReq, func(node model.SearchNode) bool {
-		if !utils.IsSubPath(user.BasePath, node.Parent) {
-			return false
-		}
-		meta, err := op.GetNearestMeta(node.Parent)
-		if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
-			return false
-		}
-		return common.CanAccess(user, meta, path.Join(node.Parent, node.Name), req.Password)
-	})
+	// the parent is under the base path of the user, and the nodes the user can't access
+	// are excluded by the searchers so that the pages and the total are right
+	req.Restriction, err = common.SearchRestriction(user, req.Password)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	nodes, total, facets, err := search.SearchWithFacets(c, req.SearchReq, nil)
 	if err != nil {
 		common.ErrorResp(c, err, 500)
 		return
 	}
-	common.SuccessResp(c, common.PageResp{
-		Content: utils.MustSliceConvert(nodes, nodeToSearchResp),
-		Total:   total,
+	common.SuccessResp(c, SearchPageResp{
+		PageResp: common.PageResp{
+			Content: utils.MustSliceConvert(nodes, nodeToSearchResp),
+			Total:   total,
+		},
+		Facets: facets,
 	})
 }
 
@@ -1,6 +1,7 @@
 package handles
 
 import (
+	"context"
 	"fmt"
 	stdpath "path"
 	"regexp"
@@ -14,11 +15,13 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/internal/setting"
 	"github.com/OpenListTeam/OpenList/v4/internal/sharing"
+	"github.com/OpenListTeam/OpenList/v4/internal/webhook"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
 	"github.com/OpenListTeam/OpenList/v4/server/common"
 	"github.com/OpenListTeam/go-cache"
 	"github.com/gin-gonic/gin"
 	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
 )
 
 func SharingGet(c *gin.Context, req *FsGetReq) {
@@ -34,7 +37,7 @@ func SharingGet(c *gin.Context, req *FsGetReq) {
 	if dealError(c, err) {
 		return
 	}
-	_ = countAccess(c.ClientIP(), s)
+	_ = countAccess(c, s, path, 0)
 	url := ""
 	if !obj.IsDir() {
 		fakePath := fmt.Sprintf("/%s/%s", sid, path)
@@ -78,7 +81,7 @@ func SharingList(c *gin.Context, req *ListReq) {
 	if dealError(c, err) {
 		return
 	}
-	_ = countAccess(c.ClientIP(), s)
+	_ = countAccess(c, s, path, 0)
 	total, objs := pagination(objs, &req.PageReq)
 	common.SuccessResp(c, FsListResp{
 		Content: utils.MustSliceConvert(objs, func(obj model.Obj) ObjResp {
@@ -99,7 +102,7 @@ func SharingList(c *gin.Context, req *ListReq) {
 		Total:    int64(total),
 		Readme:   s.Readme,
 		Header:   s.Header,
-		Write:    false,
+		Write:    s.CanUpload(),
 		Provider: "unknown",
 	})
 }
@@ -131,7 +134,7 @@ func SharingArchiveMeta(c *gin.Context, req *ArchiveMetaReq) {
 	if dealError(c, err) {
 		return
 	}
-	_ = countAccess(c.ClientIP(), s)
+	_ = countAccess(c, s, path, 0)
 	fakePath := fmt.Sprintf("/%s/%s", sid, path)
 	url := fmt.Sprintf("%s/sad%s", common.GetApiUrl(c), utils.EncodePath(fakePath, true))
 	if s.Pwd != "" {
@@ -177,7 +180,7 @@ func SharingArchiveList(c *gin.Context, req *ArchiveListReq) {
 	if dealError(c, err) {
 		return
 	}
-	_ = countAccess(c.ClientIP(), s)
+	_ = countAccess(c, s, path, 0)
 	total, objs := pagination(objs, &req.PageReq)
 	ret, _ := utils.SliceConvert(objs, func(src model.Obj) (ObjResp, error) {
 		return toObjsRespWithoutSignAndThumb(src), nil
@@ -199,6 +202,10 @@ func SharingDown(c *gin.Context) {
 			err = errs.InvalidSharing
 		} else if !s.Verify(pwd) {
 			err = errs.WrongShareCode
+		} else if !s.CanRead() {
+			err = errs.SharingNoRead
+		} else if e := op.CheckSharingAccess(c.Request.Context(), s); e != nil {
+			err = e
 		} else if len(s.Files) != 1 && path == "/" {
 			err = errors.New("cannot get sharing root link")
 		}
@@ -219,7 +226,11 @@ func SharingDown(c *gin.Context) {
 		if _, ok := c.GetQuery("d"); !ok {
 			if url := common.GenerateDownProxyURL(storage.GetStorage(), unwrapPath); url != "" {
 				c.Redirect(302, url)
-				_ = countAccess(c.ClientIP(), s)
+				var size int64
+				if obj, err := op.Get(c.Request.Context(), storage, actualPath); err == nil {
+					size = obj.GetSize()
+				}
+				_ = countAccess(c, s, path, size)
 				return
 			}
 		}
@@ -231,10 +242,10 @@ func SharingDown(c *gin.Context) {
 			common.ErrorPage(c, errors.WithMessage(err, "failed get sharing link"), 500)
 			return
 		}
-		_ = countAccess(c.ClientIP(), s)
 		proxy(c, link, obj, storage.GetStorage().ProxyRange)
+		_ = countAccess(c, s, path, int64(max(c.Writer.Size(), 0)))
 	} else {
-		link, _, err := op.Link(c.Request.Context(), storage, actualPath, model.LinkArgs{
+		link, obj, err := op.Link(c.Request.Context(), storage, actualPath, model.LinkArgs{
 			IP:       c.ClientIP(),
 			Header:   c.Request.Header,
 			Type:     c.Query("type"),
@@ -244,7 +255,7 @@ func SharingDown(c *gin.Context) {
 			common.ErrorPage(c, errors.WithMessage(err, "failed get sharing link"), 500)
 			return
 		}
-		_ = countAccess(c.ClientIP(), s)
+		_ = countAccess(c, s, path, obj.GetSize())
 		redirect(c, link)
 	}
 }
@@ -266,6 +277,10 @@ func SharingArchiveExtract(c *gin.Context) {
 			err = errs.InvalidSharing
 		} else if !s.Verify(pwd) {
 			err = errs.WrongShareCode
+		} else if !s.CanRead() {
+			err = errs.SharingNoRead
+		} else if e := op.CheckSharingAccess(c.Request.Context(), s); e != nil {
+			err = e
 		} else if len(s.Files) != 1 && path == "/" {
 			err = errors.New("cannot extract sharing root")
 		}
@@ -324,7 +339,7 @@ func dealError(c *gin.Context, err error) bool {
 		common.ErrorStrResp(c, "the share does not exist", 500)
 	} else if errors.Is(err, errs.InvalidSharing) {
 		common.ErrorStrResp(c, "the share has expired or is no longer valid", 500)
-	} else if errors.Is(err, errs.WrongShareCode) {
+	} else if errors.Is(err, errs.WrongShareCode) || errors.Is(err, errs.SharingNoRead) || errors.Is(err, errs.SharingDenied) {
 		common.ErrorResp(c, err, 403)
 	} else if errors.Is(err, errs.WrongArchivePassword) {
 		common.ErrorResp(c, err, 202)
@@ -341,7 +356,7 @@ func dealErrorPage(c *gin.Context, err error) bool {
 		common.ErrorPage(c, errors.New("the share does not exist"), 500)
 	} else if errors.Is(err, errs.InvalidSharing) {
 		common.ErrorPage(c, errors.New("the share has expired or is no longer valid"), 500)
-	} else if errors.Is(err, errs.WrongShareCode) {
+	} else if errors.Is(err, errs.WrongShareCode) || errors.Is(err, errs.SharingNoRead) || errors.Is(err, errs.SharingDenied) {
 		common.ErrorPage(c, err, 403)
 	} else if errors.Is(err, errs.WrongArchivePassword) {
 		common.ErrorPage(c, err, 202)
@@ -413,6 +428,14 @@ type UpdateSharingReq struct {
 	Remark      string     `json:"remark"`
 	Readme      string     `json:"readme"`
 	Header      string     `json:"header"`
+	Mode        int        `json:"mode"`
+	MaxFileSize int64      `json:"max_file_size"`
+	AllowedExts string     `json:"allowed_exts"`
+	UploadQuota int64      `json:"upload_quota"`
+	MaxVisitors int        `json:"max_visitors"`
+	MaxServed   int64      `json:"max_served"`
+	// AllowedCountries is a comma separated list of country codes
+	AllowedCountries string `json:"allowed_countries"`
 	model.Sort
 	CreatorName string `json:"creator"`
 	Accessed    int    `json:"accessed"`
@@ -466,6 +489,10 @@ func UpdateSharing(c *gin.Context) {
 			return
 		}
 	}
+	if req.Mode < model.SharingModeRead || req.Mode > model.SharingModeReadWrite {
+		common.ErrorStrResp(c, "invalid sharing mode", 400)
+		return
+	}
 	s, err := op.GetSharingById(req.ID)
 	if err != nil || (!reqUser.IsAdmin() && s.CreatorId != user.ID) {
 		common.ErrorStrResp(c, "sharing not found", 404)
@@ -484,6 +511,13 @@ func UpdateSharing(c *gin.Context) {
 	s.Header = req.Header
 	s.Readme = req.Readme
 	s.Remark = req.Remark
+	s.Mode = req.Mode
+	s.MaxFileSize = req.MaxFileSize
+	s.AllowedExts = req.AllowedExts
+	s.UploadQuota = req.UploadQuota
+	s.MaxVisitors = req.MaxVisitors
+	s.MaxServed = req.MaxServed
+	s.AllowedCountries = req.AllowedCountries
 	s.Creator = user
 	if req.NewID != "" && req.NewID != req.ID {
 		if !reqUser.CanCustomizeShareID() {
@@ -550,18 +584,29 @@ func CreateSharing(c *gin.Context) {
 			return
 		}
 	}
+	if req.Mode < model.SharingModeRead || req.Mode > model.SharingModeReadWrite {
+		common.ErrorStrResp(c, "invalid sharing mode", 400)
+		return
+	}
 	s := &model.Sharing{
 		SharingDB: &model.SharingDB{
-			ID:          req.ID,
-			Expires:     req.Expires,
-			Pwd:         req.Pwd,
-			Accessed:    req.Accessed,
-			MaxAccessed: req.MaxAccessed,
-			Disabled:    req.Disabled,
-			Sort:        req.Sort,
-			Remark:      req.Remark,
-			Readme:      req.Readme,
-			Header:      req.Header,
+			ID:               req.ID,
+			Expires:          req.Expires,
+			Pwd:              req.Pwd,
+			Accessed:         req.Accessed,
+			MaxAccessed:      req.MaxAccessed,
+			Disabled:         req.Disabled,
+			Sort:             req.Sort,
+			Remark:           req.Remark,
+			Readme:           req.Readme,
+			Header:           req.Header,
+			Mode:             req.Mode,
+			MaxFileSize:      req.MaxFileSize,
+			AllowedExts:      req.AllowedExts,
+			UploadQuota:      req.UploadQuota,
+			MaxVisitors:      req.MaxVisitors,
+			MaxServed:        req.MaxServed,
+			AllowedCountries: req.AllowedCountries,
 		},
 		Files:   req.Files,
 		Creator: user,
@@ -579,6 +624,50 @@ func CreateSharing(c *gin.Context) {
 	}
 }
 
+type SharingStatsReq struct {
+	ID       string `form:"id" binding:"required"`
+	Start    int64  `form:"start"`    // unix seconds, defaults to 30 days before end
+	End      int64  `form:"end"`      // unix seconds, defaults to now
+	Interval string `form:"interval"` // hour or day, defaults to day
+}
+
+func SharingStats(c *gin.Context) {
+	var req SharingStatsReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	s, err := op.GetSharingById(req.ID)
+	if err != nil || (!user.IsAdmin() && s.CreatorId != user.ID) {
+		common.ErrorStrResp(c, "sharing not found", 404)
+		return
+	}
+	interval := 24 * time.Hour
+	switch req.Interval {
+	case "", "day":
+	case "hour":
+		interval = time.Hour
+	default:
+		common.ErrorStrResp(c, "interval must be hour or day", 400)
+		return
+	}
+	end := time.Now()
+	if req.End > 0 {
+		end = time.Unix(req.End, 0)
+	}
+	start := end.Add(-30 * 24 * time.Hour)
+	if req.Start > 0 {
+		start = time.Unix(req.Start, 0)
+	}
+	stats, err := op.GetSharingStats(s.ID, start.Truncate(interval), end, interval)
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	common.SuccessResp(c, stats)
+}
+
 func DeleteSharing(c *gin.Context) {
 	sid := c.Query("id")
 	user := c.Request.Context().Value(conf.UserKey).(*model.User)
@@ -617,13 +706,24 @@ var (
 	AccessCountDelay = 30 * time.Minute
 )
 
-func countAccess(ip string, s *model.Sharing) error {
+// countAccess logs the access of path in the sharing, the access counter
+// is increased once per ip in AccessCountDelay
+func countAccess(c *gin.Context, s *model.Sharing, path string, bytes int64) error {
+	ip := c.ClientIP()
+	if err := op.RecordSharingAccess(s, &model.SharingAccess{
+		IP:        ip,
+		UserAgent: c.Request.UserAgent(),
+		Path:      utils.FixAndCleanPath(path),
+		Bytes:     bytes,
+	}); err != nil {
+		log.Warnf("failed record access of sharing [%s]: %+v", s.ID, err)
+	}
 	key := fmt.Sprintf("%s:%s", s.ID, ip)
 	_, ok := AccessCache.Get(key)
 	if !ok {
 		AccessCache.Set(key, struct{}{}, cache.WithEx[interface{}](AccessCountDelay))
-		s.Accessed += 1
-		return op.UpdateSharing(s, true)
+		webhook.Emit(context.Background(), webhook.EventShareAccess, webhook.ShareData{ID: s.ID, Files: s.Files, IP: ip})
+		return op.AddSharingAccessed(s.ID)
 	}
 	return nil
 }
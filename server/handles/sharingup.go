@@ -0,0 +1,195 @@
+package handles
+
+import (
+	"context"
+	"io"
+	"net/url"
+	stdpath "path"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/stream"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+	log "github.com/sirupsen/logrus"
+)
+
+// SharingPut uploads a file into an upload or read-write sharing,
+// File-Path is /sid/path and the file is written as the sharing creator in an upload task.
+// Existing files are never overwritten, the upload record holds the name until the task is over.
+func SharingPut(c *gin.Context) {
+	defer func() {
+		if n, _ := io.ReadFull(c.Request.Body, []byte{0}); n == 1 {
+			_, _ = utils.CopyWithBuffer(io.Discard, c.Request.Body)
+		}
+		_ = c.Request.Body.Close()
+	}()
+	path, err := url.PathUnescape(c.GetHeader("File-Path"))
+	if err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	sid, path, _ := strings.Cut(strings.TrimPrefix(path, "/"), "/")
+	path = utils.FixAndCleanPath(path)
+	if sid == "" || path == "/" {
+		common.ErrorStrResp(c, "invalid file path", 400)
+		return
+	}
+	s, err := op.GetSharingById(sid)
+	if err != nil {
+		err = errs.SharingNotFound
+	} else if !s.Valid() {
+		err = errs.InvalidSharing
+	} else if !s.Verify(c.GetHeader("Password")) {
+		err = errs.WrongShareCode
+	} else {
+		err = op.CheckSharingAccess(c.Request.Context(), s)
+	}
+	if dealError(c, err) {
+		return
+	}
+	if !s.CanUpload() {
+		common.ErrorStrResp(c, "the share does not accept uploads", 403)
+		return
+	}
+	dir, name := stdpath.Split(path)
+	if shouldIgnoreSystemFile(name) {
+		common.ErrorStrResp(c, errs.IgnoredSystemFile.Error(), 403)
+		return
+	}
+	if !s.AllowExt(name) {
+		common.ErrorStrResp(c, "file type is not allowed", 403)
+		return
+	}
+	size := c.Request.ContentLength
+	if size < 0 {
+		common.ErrorStrResp(c, "file size is required", 411)
+		return
+	}
+	if !s.AllowSize(size) {
+		common.ErrorStrResp(c, "file is too large or the share upload quota is exceeded", 413)
+		return
+	}
+	dstDir, err := op.GetSharingUnwrapPath(s, dir)
+	if err != nil {
+		common.ErrorResp(c, errors.WithMessage(err, "failed get sharing unwrap path"), 400)
+		return
+	}
+	if !canUploadToSharing(c.Request.Context(), s, dstDir) {
+		common.ErrorResp(c, errs.PermissionDenied, 403)
+		return
+	}
+	mimetype := c.GetHeader("Content-Type")
+	if len(mimetype) == 0 {
+		mimetype = utils.GetMimeType(name)
+	}
+	fileStream := &stream.FileStream{
+		Obj: &model.Object{
+			Name:     name,
+			Size:     size,
+			Modified: getLastModified(c),
+		},
+		Reader:       c.Request.Body,
+		Mimetype:     mimetype,
+		WebPutAsTask: true,
+	}
+	reserved, err := op.ReserveSharingUpload(s, size)
+	if err != nil {
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	if !reserved {
+		common.ErrorStrResp(c, "the share upload quota is exceeded", 413)
+		return
+	}
+	dstPath := stdpath.Join(dstDir, name)
+	upload, started, err := op.StartSharingUpload(s, stdpath.Join(dir, name), dstPath, size, c.ClientIP())
+	if err != nil || !started {
+		if e := op.ReleaseSharingUpload(s, size); e != nil {
+			log.Warnf("failed release upload of sharing [%s]: %+v", s.ID, e)
+		}
+		if err != nil {
+			common.ErrorResp(c, err, 500)
+		} else {
+			common.ErrorStrResp(c, "file is being uploaded", 409)
+		}
+		return
+	}
+	done := func(succeeded bool) {
+		var err error
+		if succeeded {
+			err = op.FinishSharingUpload(upload)
+		} else {
+			err = op.CancelSharingUpload(upload)
+		}
+		if err != nil {
+			log.Warnf("failed finish upload of sharing [%s]: %+v", s.ID, err)
+		}
+	}
+	// the name is held by the upload record, so no other upload can create it after the check
+	if _, err = fs.Get(c.Request.Context(), dstPath, &fs.GetArgs{NoLog: true}); err == nil {
+		done(false)
+		common.ErrorStrResp(c, "file exists", 409)
+		return
+	} else if !errs.IsObjectNotFound(err) {
+		done(false)
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	ctx := context.WithValue(c.Request.Context(), conf.UserKey, s.Creator)
+	t, err := fs.PutAsTask(ctx, dstDir, fileStream, done)
+	if err != nil {
+		done(false)
+		common.ErrorResp(c, err, 500)
+		return
+	}
+	common.SuccessResp(c, gin.H{
+		"task": GetTaskInfo(t),
+	})
+}
+
+// canUploadToSharing checks that dir is a folder the sharing creator may write to
+func canUploadToSharing(ctx context.Context, s *model.Sharing, dir string) bool {
+	obj, err := fs.Get(ctx, dir, &fs.GetArgs{NoLog: true})
+	if err != nil || !obj.IsDir() {
+		return false
+	}
+	meta, err := op.GetNearestMeta(dir)
+	if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+		return false
+	}
+	if !s.Creator.CanWriteContent() && !common.CanWriteContentBypassUserPerms(meta, dir) {
+		return false
+	}
+	return common.CanWrite(s.Creator, meta, dir)
+}
+
+func ListSharingUploads(c *gin.Context) {
+	var req model.PageReq
+	if err := c.ShouldBind(&req); err != nil {
+		common.ErrorResp(c, err, 400)
+		return
+	}
+	req.Validate()
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	s, err := op.GetSharingById(c.Query("id"))
+	if err != nil || (!user.IsAdmin() && s.CreatorId != user.ID) {
+		common.ErrorStrResp(c, "sharing not found", 404)
+		return
+	}
+	uploads, total, err := op.GetSharingUploads(s.ID, req.Page, req.PerPage)
+	if err != nil {
+		common.ErrorResp(c, err, 500, true)
+		return
+	}
+	common.SuccessResp(c, common.PageResp{
+		Content: uploads,
+		Total:   total,
+	})
+}
@@ -15,6 +15,7 @@ import (
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/db"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/internal/setting"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils/random"
@@ -22,6 +23,7 @@ import (
 	"github.com/coreos/go-oidc"
 	"github.com/gin-gonic/gin"
 	"github.com/go-resty/resty/v2"
+	jsoniter "github.com/json-iterator/go"
 	"golang.org/x/oauth2"
 	"gorm.io/gorm"
 )
@@ -141,22 +143,31 @@ func GetOIDCClient(c *gin.Context, useCompatibility bool, redirectUri, method st
 	}, nil
 }
 
-func autoRegister(username, userID string, err error) (*model.User, error) {
+func autoRegister(username, userID string, groups []string, err error) (*model.User, error) {
 	if !errors.Is(err, gorm.ErrRecordNotFound) || !setting.GetBool(conf.SSOAutoRegister) {
 		return nil, err
 	}
 	if username == "" {
 		return nil, errors.New("cannot get username from SSO provider")
 	}
+	groupIds, err := op.MatchExternalGroups(groups)
+	if err != nil {
+		return nil, err
+	}
 	user := &model.User{
-		ID:         0,
-		Username:   username,
-		Password:   random.String(16),
-		Permission: int32(setting.GetInt(conf.SSODefaultPermission, 0)),
-		BasePath:   setting.GetStr(conf.SSODefaultDir),
-		Role:       0,
-		Disabled:   false,
-		SsoID:      userID,
+		ID:               0,
+		Username:         username,
+		Password:         random.String(16),
+		Permission:       int32(setting.GetInt(conf.SSODefaultPermission, 0)),
+		BasePath:         setting.GetStr(conf.SSODefaultDir),
+		Role:             0,
+		Disabled:         false,
+		SsoID:            userID,
+		GroupIDs:         groupIds,
+		ExternalGroupIDs: map[string][]uint{model.GroupSourceSSO: groupIds},
+	}
+	if p := op.GroupBasePath(groupIds); p != "" {
+		user.BasePath = p
 	}
 	if err = db.CreateUser(user); err != nil {
 		if strings.HasPrefix(err.Error(), "UNIQUE constraint failed") && strings.HasSuffix(err.Error(), "username") {
@@ -171,6 +182,39 @@ func autoRegister(username, userID string, err error) (*model.User, error) {
 	return user, nil
 }
 
+// ssoGroups reads the group names of the user from the claim set by sso_groups_key,
+// the claim is either an array or a comma separated string
+func ssoGroups(data []byte) []string {
+	key := setting.GetStr(conf.SSOGroupsKey)
+	if key == "" {
+		return nil
+	}
+	claim := utils.Json.Get(data, key)
+	switch claim.ValueType() {
+	case jsoniter.ArrayValue:
+		var groups []string
+		for i := range claim.Size() {
+			groups = append(groups, claim.Get(i).ToString())
+		}
+		return groups
+	case jsoniter.StringValue:
+		return strings.FieldsFunc(claim.ToString(), func(r rune) bool { return r == ',' || r == ' ' })
+	}
+	return nil
+}
+
+// ssoLoginUser finds the user bound to the SSO id and syncs its groups, the user is registered if allowed
+func ssoLoginUser(username, userID string, groups []string) (*model.User, error) {
+	user, err := db.GetUserBySSOID(userID)
+	if err != nil {
+		return autoRegister(username, userID, groups, err)
+	}
+	if err = op.SyncUserGroups(user, model.GroupSourceSSO, groups); err != nil {
+		return nil, err
+	}
+	return user, nil
+}
+
 func parseJWT(p string) ([]byte, error) {
 	parts := strings.Split(p, ".")
 	if len(parts) < 2 {
@@ -251,19 +295,18 @@ func OIDCLoginCallback(c *gin.Context) {
 		return
 	}
 	if method == "sso_get_token" {
-		user, err := db.GetUserBySSOID(userID)
+		user, err := ssoLoginUser(userID, userID, ssoGroups(payload))
 		if err != nil {
-			user, err = autoRegister(userID, userID, err)
-			if err != nil {
-				common.ErrorResp(c, err, 400)
-				return
-			}
+			auditLogin(c, userID, "sso", err)
+			common.ErrorResp(c, err, 400)
+			return
 		}
 		token, err := common.GenerateToken(user)
 		if err != nil {
 			common.ErrorResp(c, err, 400)
 			return
 		}
+		auditLogin(c, user.Username, "sso", nil)
 		if useCompatibility {
 			c.Redirect(302, common.GetApiUrl(c)+"/@login?token="+token)
 			return
@@ -418,19 +461,18 @@ func SSOLoginCallback(c *gin.Context) {
 		return
 	}
 	username := utils.Json.Get(resp.Body(), usernameField).ToString()
-	user, err := db.GetUserBySSOID(userID)
+	user, err := ssoLoginUser(username, userID, ssoGroups(resp.Body()))
 	if err != nil {
-		user, err = autoRegister(username, userID, err)
-		if err != nil {
-			common.ErrorResp(c, err, 400)
-			return
-		}
+		auditLogin(c, username, "sso", err)
+		common.ErrorResp(c, err, 400)
+		return
 	}
 	token, err := common.GenerateToken(user)
 	if err != nil {
 		common.ErrorResp(c, err, 400)
 		return
 	}
+	auditLogin(c, user.Username, "sso", nil)
 	if usecompatibility {
 		c.Redirect(302, common.GetApiUrl(c)+"/@login?token="+token)
 		return
@@ -30,7 +30,7 @@ type TaskInfo struct {
 	Error       string      `json:"error"`
 }
 
-func getTaskInfo[T task.TaskExtensionInfo](task T) TaskInfo {
+func GetTaskInfo[T task.TaskExtensionInfo](task T) TaskInfo {
 	errMsg := ""
 	if task.GetErr() != nil {
 		errMsg = task.GetErr().Error()
@@ -61,8 +61,8 @@ func getTaskInfo[T task.TaskExtensionInfo](task T) TaskInfo {
 	}
 }
 
-func getTaskInfos[T task.TaskExtensionInfo](tasks []T) []TaskInfo {
-	return utils.MustSliceConvert(tasks, getTaskInfo[T])
+func GetTaskInfos[T task.TaskExtensionInfo](tasks []T) []TaskInfo {
+	return utils.MustSliceConvert(tasks, GetTaskInfo[T])
 }
 
 func argsContains[T comparable](v T, slice ...T) bool {
@@ -132,7 +132,7 @@ func taskRoute[T task.TaskExtensionInfo](g *gin.RouterGroup, manager task.Manage
 			common.ErrorStrResp(c, "user invalid", 401)
 			return
 		}
-		common.SuccessResp(c, getTaskInfos(manager.GetByCondition(func(task T) bool {
+		common.SuccessResp(c, GetTaskInfos(manager.GetByCondition(func(task T) bool {
 			// avoid directly passing the user object into the function to reduce closure size
 			return (isAdmin || uid == task.GetCreator().ID) &&
 				argsContains(task.GetState(), tache.StatePending, tache.StateRunning, tache.StateCanceling,
@@ -146,13 +146,13 @@ func taskRoute[T task.TaskExtensionInfo](g *gin.RouterGroup, manager task.Manage
 			common.ErrorStrResp(c, "user invalid", 401)
 			return
 		}
-		common.SuccessResp(c, getTaskInfos(manager.GetByCondition(func(task T) bool {
+		common.SuccessResp(c, GetTaskInfos(manager.GetByCondition(func(task T) bool {
 			return (isAdmin || uid == task.GetCreator().ID) &&
 				argsContains(task.GetState(), tache.StateCanceled, tache.StateFailed, tache.StateSucceeded)
 		})))
 	})
 	g.POST("/info", getTargetedHandler(manager, func(c *gin.Context, task T) {
-		common.SuccessResp(c, getTaskInfo(task))
+		common.SuccessResp(c, GetTaskInfo(task))
 	}))
 	g.POST("/cancel", getTargetedHandler(manager, func(c *gin.Context, task T) {
 		manager.Cancel(task.GetID())
@@ -23,8 +23,12 @@ func ListUsers(c *gin.Context) {
 		common.ErrorResp(c, err, 500, true)
 		return
 	}
+	resp := make([]UserResp, 0, len(users))
+	for i := range users {
+		resp = append(resp, newUserResp(&users[i]))
+	}
 	common.SuccessResp(c, common.PageResp{
-		Content: users,
+		Content: resp,
 		Total:   total,
 	})
 }
@@ -74,6 +78,10 @@ func UpdateUser(c *gin.Context) {
 	if req.OtpSecret == "" {
 		req.OtpSecret = user.OtpSecret
 	}
+	if req.GroupIDs == nil {
+		req.GroupIDs = user.GroupIDs
+	}
+	req.ExternalGroupIDs = user.ExternalGroupIDs
 	if req.Disabled && req.IsAdmin() {
 		common.ErrorStrResp(c, "admin user can not be disabled", 400)
 		return
@@ -111,7 +119,7 @@ func GetUser(c *gin.Context) {
 		common.ErrorResp(c, err, 500, true)
 		return
 	}
-	common.SuccessResp(c, user)
+	common.SuccessResp(c, newUserResp(user))
 }
 
 func Cancel2FAById(c *gin.Context) {
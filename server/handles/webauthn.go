@@ -107,6 +107,11 @@ func FinishAuthnLogin(c *gin.Context) {
 		}, sessionData, c.Request)
 	}
 	if err != nil {
+		username := c.Query("username")
+		if user != nil {
+			username = user.Username
+		}
+		auditLogin(c, username, "webauthn", err)
 		common.ErrorResp(c, err, 400)
 		return
 	}
@@ -117,6 +122,7 @@ func FinishAuthnLogin(c *gin.Context) {
 		return
 	}
 	common.SuccessResp(c, gin.H{"token": token})
+	auditLogin(c, user.Username, "webauthn", nil)
 }
 
 func BeginAuthnRegistration(c *gin.Context) {
@@ -183,12 +189,10 @@ func FinishAuthnRegistration(c *gin.Context) {
 	}
 
 	credential, err := authnInstance.FinishRegistration(user, sessionData, c.Request)
-
-	if err != nil {
-		common.ErrorResp(c, err, 400)
-		return
+	if err == nil {
+		err = db.RegisterAuthn(user, credential)
 	}
-	err = db.RegisterAuthn(user, credential)
+	op.Audit(c.Request.Context(), &model.AuditLog{Action: op.AuditAddWebAuthn}, err)
 	if err != nil {
 		common.ErrorResp(c, err, 400)
 		return
@@ -213,6 +217,7 @@ func DeleteAuthnLogin(c *gin.Context) {
 		return
 	}
 	err = db.RemoveAuthn(user, req.ID)
+	op.Audit(c.Request.Context(), &model.AuditLog{Action: op.AuditDeleteWebAuthn, Detail: req.ID}, err)
 	if err != nil {
 		common.ErrorResp(c, err, 400)
 		return
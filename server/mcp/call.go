@@ -45,6 +45,24 @@ func (s *Server) handleToolsCall(c *gin.Context, req request) (int, response) {
 		result, err = s.callFSGet(c, params.Arguments)
 	case "openlist.fs.link":
 		result, err = s.callFSLink(c, params.Arguments)
+	case "openlist.fs.read":
+		result, err = s.callFSRead(c, params.Arguments)
+	case "openlist.fs.search":
+		result, err = s.callFSSearch(c, params.Arguments)
+	case "openlist.task.get":
+		result, err = s.callTaskGet(c, params.Arguments)
+	case "openlist.fs.mkdir":
+		result, err = s.callFSMkdir(c, params.Arguments)
+	case "openlist.fs.rename":
+		result, err = s.callFSRename(c, params.Arguments)
+	case "openlist.fs.move":
+		result, err = s.callFSTransfer(c, params.Arguments, false)
+	case "openlist.fs.copy":
+		result, err = s.callFSTransfer(c, params.Arguments, true)
+	case "openlist.fs.remove":
+		result, err = s.callFSRemove(c, params.Arguments)
+	case "openlist.fs.write":
+		result, err = s.callFSWrite(c, params.Arguments)
 	default:
 		return http.StatusOK, response{
 			JSONRPC: "2.0",
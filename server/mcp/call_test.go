@@ -85,7 +85,7 @@ func TestToolsListSuccess(t *testing.T) {
 		t.Fatalf("unexpected result type: %T", resp.Result)
 	}
 	tools, ok := result["tools"].([]any)
-	if !ok || len(tools) != 3 {
+	if !ok || len(tools) != len(openListTools) {
 		t.Fatalf("unexpected tools payload: %#v", result["tools"])
 	}
 	names := map[string]bool{}
@@ -96,6 +96,11 @@ func TestToolsListSuccess(t *testing.T) {
 		}
 		name, _ := currentTool["name"].(string)
 		names[name] = true
+		annotations, _ := currentTool["annotations"].(map[string]any)
+		if name == "openlist.fs.remove" && annotations["destructiveHint"] != true ||
+			name == "openlist.fs.list" && annotations["readOnlyHint"] != true {
+			t.Fatalf("unexpected annotations of %s: %#v", name, annotations)
+		}
 	}
 	if !names["openlist.fs.list"] || !names["openlist.fs.get"] || !names["openlist.fs.link"] {
 		t.Fatalf("unexpected tool names: %#v", names)
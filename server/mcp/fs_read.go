@@ -0,0 +1,129 @@
+package mcp
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"unicode/utf8"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/stream"
+	"github.com/OpenListTeam/OpenList/v4/pkg/http_range"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	defaultReadBytes = 64 << 10
+	maxReadBytes     = 1 << 20
+)
+
+type fsReadArgs struct {
+	Path     string `json:"path"`
+	Password string `json:"password"`
+	Offset   int64  `json:"offset"`
+	Length   int64  `json:"length"`
+}
+
+type fsReadResp struct {
+	Path    string `json:"path"`
+	Size    int64  `json:"size"`
+	Offset  int64  `json:"offset"`
+	Length  int64  `json:"length"`
+	EOF     bool   `json:"eof"`
+	Content string `json:"content"`
+}
+
+func (s *Server) callFSRead(c *gin.Context, raw json.RawMessage) (any, *rpcError) {
+	args := &fsReadArgs{Length: defaultReadBytes}
+	if mcpErr := parseToolArgs(raw, "openlist.fs.read", args); mcpErr != nil {
+		return nil, mcpErr
+	}
+	if args.Path == "" {
+		return nil, &rpcError{Code: -32602, Message: "path is required"}
+	}
+	if args.Offset < 0 || args.Length < 1 || args.Length > maxReadBytes {
+		return nil, &rpcError{Code: -32602, Message: fmt.Sprintf("offset must be >= 0 and length must be in [1, %d]", maxReadBytes)}
+	}
+	user, mcpErr := toolUser(c)
+	if mcpErr != nil {
+		return nil, mcpErr
+	}
+	reqPath, err := user.JoinPath(args.Path)
+	if err != nil {
+		return nil, &rpcError{Code: -32003, Message: err.Error()}
+	}
+	meta, mcpErr := nearestMeta(reqPath)
+	if mcpErr != nil {
+		return nil, mcpErr
+	}
+	if !common.CanAccess(user, meta, reqPath, args.Password) {
+		return nil, &rpcError{Code: -32003, Message: "password is incorrect or you have no permission"}
+	}
+
+	ctx := context.WithValue(c.Request.Context(), conf.MetaKey, meta)
+	obj, err := fs.Get(ctx, reqPath, &fs.GetArgs{})
+	if err != nil {
+		return nil, &rpcError{Code: -32603, Message: err.Error()}
+	}
+	if obj.IsDir() {
+		return nil, &rpcError{Code: -32003, Message: "path is a directory"}
+	}
+	resp := fsReadResp{Path: reqPath, Size: obj.GetSize(), Offset: args.Offset}
+	if args.Offset >= obj.GetSize() {
+		resp.EOF = true
+		return resp, nil
+	}
+	length := min(args.Length, obj.GetSize()-args.Offset)
+	data, err := readRange(ctx, c, reqPath, obj, args.Offset, length)
+	if err != nil {
+		return nil, &rpcError{Code: -32603, Message: err.Error()}
+	}
+	data, ok := trimText(data)
+	if !ok {
+		return nil, &rpcError{Code: -32003, Message: "file is not a text file"}
+	}
+	resp.Length = int64(len(data))
+	resp.EOF = args.Offset+resp.Length >= obj.GetSize()
+	resp.Content = string(data)
+	return resp, nil
+}
+
+func readRange(ctx context.Context, c *gin.Context, reqPath string, obj model.Obj, offset, length int64) ([]byte, error) {
+	link, _, err := fs.Link(ctx, reqPath, model.LinkArgs{
+		IP:     c.ClientIP(),
+		Header: c.Request.Header,
+	})
+	if err != nil {
+		return nil, err
+	}
+	defer link.Close()
+	rr, err := stream.GetRangeReaderFromLink(obj.GetSize(), link)
+	if err != nil {
+		return nil, err
+	}
+	rc, err := rr.RangeRead(ctx, http_range.Range{Start: offset, Length: length})
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(io.LimitReader(rc, length))
+}
+
+// trimText drops the rune cut at the end of the range so that the next read starts at its first byte,
+// false if the data is not UTF-8 text
+func trimText(data []byte) ([]byte, bool) {
+	if bytes.IndexByte(data, 0) >= 0 {
+		return nil, false
+	}
+	for i := 0; i < utf8.UTFMax && len(data) > i; i++ {
+		if text := data[:len(data)-i]; utf8.Valid(text) && len(text) > 0 {
+			return text, true
+		}
+	}
+	return nil, len(data) == 0
+}
@@ -0,0 +1,380 @@
+package mcp
+
+import (
+	"encoding/json"
+	"fmt"
+	stdpath "path"
+	"strings"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/OpenListTeam/OpenList/v4/internal/stream"
+	"github.com/OpenListTeam/OpenList/v4/internal/task"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/OpenListTeam/OpenList/v4/server/handles"
+	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
+)
+
+// maxWriteBytes limits the content of openlist.fs.write, the request body is limited to 1 MiB anyway
+const maxWriteBytes = 1 << 20
+
+var errNoPermission = &rpcError{Code: -32003, Message: "you have no permission"}
+
+type fsPathResp struct {
+	Path string `json:"path"`
+}
+
+type fsMkdirArgs struct {
+	Path string `json:"path"`
+}
+
+type fsRenameArgs struct {
+	Path      string `json:"path"`
+	Name      string `json:"name"`
+	Overwrite bool   `json:"overwrite"`
+}
+
+type fsTransferArgs struct {
+	SrcDir       string   `json:"src_dir"`
+	DstDir       string   `json:"dst_dir"`
+	Names        []string `json:"names"`
+	Overwrite    bool     `json:"overwrite"`
+	SkipExisting bool     `json:"skip_existing"`
+}
+
+type fsTransferResp struct {
+	Tasks   []handles.TaskInfo `json:"tasks"`
+	Skipped []string           `json:"skipped,omitempty"`
+}
+
+type fsRemoveArgs struct {
+	Dir   string   `json:"dir"`
+	Names []string `json:"names"`
+}
+
+type fsRemoveResp struct {
+	Removed []string `json:"removed"`
+}
+
+type fsWriteArgs struct {
+	Path      string `json:"path"`
+	Content   string `json:"content"`
+	Overwrite bool   `json:"overwrite"`
+}
+
+type fsWriteResp struct {
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+func (s *Server) callFSMkdir(c *gin.Context, raw json.RawMessage) (any, *rpcError) {
+	args := &fsMkdirArgs{}
+	if mcpErr := parseToolArgs(raw, "openlist.fs.mkdir", args); mcpErr != nil {
+		return nil, mcpErr
+	}
+	if args.Path == "" {
+		return nil, &rpcError{Code: -32602, Message: "path is required"}
+	}
+	user, mcpErr := toolUser(c)
+	if mcpErr != nil {
+		return nil, mcpErr
+	}
+	reqPath, err := user.JoinPath(args.Path)
+	if err != nil {
+		return nil, &rpcError{Code: -32003, Message: err.Error()}
+	}
+	if mcpErr = checkWriteContent(user, stdpath.Dir(reqPath)); mcpErr != nil {
+		return nil, mcpErr
+	}
+	if err = fs.MakeDir(c.Request.Context(), reqPath); err != nil {
+		return nil, &rpcError{Code: -32603, Message: err.Error()}
+	}
+	return fsPathResp{Path: reqPath}, nil
+}
+
+func (s *Server) callFSRename(c *gin.Context, raw json.RawMessage) (any, *rpcError) {
+	args := &fsRenameArgs{}
+	if mcpErr := parseToolArgs(raw, "openlist.fs.rename", args); mcpErr != nil {
+		return nil, mcpErr
+	}
+	if args.Path == "" {
+		return nil, &rpcError{Code: -32602, Message: "path is required"}
+	}
+	if !validName(args.Name) {
+		return nil, &rpcError{Code: -32602, Message: errs.RelativePath.Error()}
+	}
+	user, mcpErr := toolUser(c)
+	if mcpErr != nil {
+		return nil, mcpErr
+	}
+	if !user.CanRename() {
+		return nil, errNoPermission
+	}
+	reqPath, err := user.JoinPath(args.Path)
+	if err != nil {
+		return nil, &rpcError{Code: -32003, Message: err.Error()}
+	}
+	if mcpErr = checkWrite(user, stdpath.Dir(reqPath)); mcpErr != nil {
+		return nil, mcpErr
+	}
+	dstPath := stdpath.Join(stdpath.Dir(reqPath), args.Name)
+	if !args.Overwrite && dstPath != reqPath && exists(c, dstPath) {
+		return nil, &rpcError{Code: -32003, Message: fmt.Sprintf("file [%s] exists", args.Name)}
+	}
+	if err = fs.Rename(c.Request.Context(), reqPath, args.Name); err != nil {
+		return nil, &rpcError{Code: -32603, Message: err.Error()}
+	}
+	return fsPathResp{Path: dstPath}, nil
+}
+
+// callFSTransfer moves or copies the items like the fs/move and fs/copy handlers
+func (s *Server) callFSTransfer(c *gin.Context, raw json.RawMessage, isCopy bool) (any, *rpcError) {
+	name := "openlist.fs.move"
+	if isCopy {
+		name = "openlist.fs.copy"
+	}
+	args := &fsTransferArgs{}
+	if mcpErr := parseToolArgs(raw, name, args); mcpErr != nil {
+		return nil, mcpErr
+	}
+	if args.SrcDir == "" || args.DstDir == "" || len(args.Names) == 0 {
+		return nil, &rpcError{Code: -32602, Message: "src_dir, dst_dir and names are required"}
+	}
+	user, mcpErr := toolUser(c)
+	if mcpErr != nil {
+		return nil, mcpErr
+	}
+	if isCopy && !user.CanCopy() || !isCopy && !user.CanMove() {
+		return nil, errNoPermission
+	}
+	srcDir, err := user.JoinPath(args.SrcDir)
+	if err != nil {
+		return nil, &rpcError{Code: -32003, Message: err.Error()}
+	}
+	if isCopy {
+		mcpErr = checkRead(user, srcDir)
+	} else {
+		mcpErr = checkWrite(user, srcDir)
+	}
+	if mcpErr != nil {
+		return nil, mcpErr
+	}
+	dstDir, err := user.JoinPath(args.DstDir)
+	if err != nil {
+		return nil, &rpcError{Code: -32003, Message: err.Error()}
+	}
+	if mcpErr = checkWrite(user, dstDir); mcpErr != nil {
+		return nil, mcpErr
+	}
+	srcPaths, mcpErr := joinNames(srcDir, args.Names)
+	if mcpErr != nil {
+		return nil, mcpErr
+	}
+
+	resp := fsTransferResp{Tasks: []handles.TaskInfo{}}
+	var todo []string
+	for _, srcPath := range srcPaths {
+		base := stdpath.Base(srcPath)
+		if !args.Overwrite && exists(c, stdpath.Join(dstDir, base)) {
+			if !args.SkipExisting {
+				return nil, &rpcError{Code: -32003, Message: fmt.Sprintf("file [%s] exists", base)}
+			}
+			resp.Skipped = append(resp.Skipped, base)
+			continue
+		}
+		todo = append(todo, srcPath)
+	}
+	for i, srcPath := range todo {
+		var t task.TaskExtensionInfo
+		if isCopy {
+			t, err = fs.Copy(c.Request.Context(), srcPath, dstDir, len(todo) > i+1)
+		} else {
+			t, err = fs.Move(c.Request.Context(), srcPath, dstDir, len(todo) > i+1)
+		}
+		if err != nil {
+			return nil, &rpcError{Code: -32603, Message: err.Error()}
+		}
+		if t != nil {
+			resp.Tasks = append(resp.Tasks, handles.GetTaskInfo(t))
+		}
+	}
+	return resp, nil
+}
+
+func (s *Server) callFSRemove(c *gin.Context, raw json.RawMessage) (any, *rpcError) {
+	args := &fsRemoveArgs{}
+	if mcpErr := parseToolArgs(raw, "openlist.fs.remove", args); mcpErr != nil {
+		return nil, mcpErr
+	}
+	if args.Dir == "" || len(args.Names) == 0 {
+		return nil, &rpcError{Code: -32602, Message: "dir and names are required"}
+	}
+	user, mcpErr := toolUser(c)
+	if mcpErr != nil {
+		return nil, mcpErr
+	}
+	if !user.CanRemove() {
+		return nil, errNoPermission
+	}
+	reqDir, err := user.JoinPath(args.Dir)
+	if err != nil {
+		return nil, &rpcError{Code: -32003, Message: err.Error()}
+	}
+	if mcpErr = checkWrite(user, reqDir); mcpErr != nil {
+		return nil, mcpErr
+	}
+	paths, mcpErr := joinNames(reqDir, args.Names)
+	if mcpErr != nil {
+		return nil, mcpErr
+	}
+	resp := fsRemoveResp{Removed: []string{}}
+	for _, p := range paths {
+		if err = fs.Remove(c.Request.Context(), p); err != nil {
+			return nil, &rpcError{Code: -32603, Message: err.Error()}
+		}
+		resp.Removed = append(resp.Removed, p)
+	}
+	return resp, nil
+}
+
+func (s *Server) callFSWrite(c *gin.Context, raw json.RawMessage) (any, *rpcError) {
+	args := &fsWriteArgs{}
+	if mcpErr := parseToolArgs(raw, "openlist.fs.write", args); mcpErr != nil {
+		return nil, mcpErr
+	}
+	if args.Path == "" {
+		return nil, &rpcError{Code: -32602, Message: "path is required"}
+	}
+	if len(args.Content) > maxWriteBytes {
+		return nil, &rpcError{Code: -32602, Message: fmt.Sprintf("content is larger than %d bytes", maxWriteBytes)}
+	}
+	user, mcpErr := toolUser(c)
+	if mcpErr != nil {
+		return nil, mcpErr
+	}
+	reqPath, err := user.JoinPath(args.Path)
+	if err != nil {
+		return nil, &rpcError{Code: -32003, Message: err.Error()}
+	}
+	dir, name := stdpath.Split(reqPath)
+	if name == "" {
+		return nil, &rpcError{Code: -32602, Message: "path must be a file"}
+	}
+	if setting.GetBool(conf.IgnoreSystemFiles) && utils.IsSystemFile(name) {
+		return nil, &rpcError{Code: -32003, Message: errs.IgnoredSystemFile.Error()}
+	}
+	if mcpErr = checkWriteContent(user, stdpath.Dir(reqPath)); mcpErr != nil {
+		return nil, mcpErr
+	}
+	if !args.Overwrite && exists(c, reqPath) {
+		return nil, &rpcError{Code: -32003, Message: "file exists"}
+	}
+	size := int64(len(args.Content))
+	fileStream := &stream.FileStream{
+		Obj: &model.Object{
+			Name:     name,
+			Size:     size,
+			Modified: time.Now(),
+		},
+		Reader:   strings.NewReader(args.Content),
+		Mimetype: utils.GetMimeType(name),
+	}
+	if err = fs.PutDirectly(c.Request.Context(), dir, fileStream); err != nil {
+		return nil, &rpcError{Code: -32603, Message: err.Error()}
+	}
+	return fsWriteResp{Path: reqPath, Size: size}, nil
+}
+
+func parseToolArgs(raw json.RawMessage, name string, args any) *rpcError {
+	if len(raw) == 0 || string(raw) == "null" {
+		return &rpcError{Code: -32602, Message: fmt.Sprintf("invalid %s arguments", name)}
+	}
+	if err := json.Unmarshal(raw, args); err != nil {
+		return &rpcError{Code: -32602, Message: fmt.Sprintf("invalid %s arguments", name)}
+	}
+	return nil
+}
+
+func toolUser(c *gin.Context) (*model.User, *rpcError) {
+	user, ok := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if !ok || user == nil {
+		return nil, &rpcError{Code: -32603, Message: "missing user context"}
+	}
+	if user.IsGuest() && user.Disabled {
+		return nil, &rpcError{Code: -32001, Message: "guest user is disabled"}
+	}
+	return user, nil
+}
+
+func nearestMeta(path string) (*model.Meta, *rpcError) {
+	meta, err := op.GetNearestMeta(path)
+	if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+		return nil, &rpcError{Code: -32603, Message: err.Error()}
+	}
+	return meta, nil
+}
+
+func checkRead(user *model.User, dir string) *rpcError {
+	meta, mcpErr := nearestMeta(dir)
+	if mcpErr != nil {
+		return mcpErr
+	}
+	if !common.CanRead(user, meta, dir) {
+		return errNoPermission
+	}
+	return nil
+}
+
+func checkWrite(user *model.User, dir string) *rpcError {
+	meta, mcpErr := nearestMeta(dir)
+	if mcpErr != nil {
+		return mcpErr
+	}
+	if !common.CanWrite(user, meta, dir) {
+		return errNoPermission
+	}
+	return nil
+}
+
+// checkWriteContent checks the permission to create files in the dir like the FsUp middleware
+func checkWriteContent(user *model.User, dir string) *rpcError {
+	meta, mcpErr := nearestMeta(dir)
+	if mcpErr != nil {
+		return mcpErr
+	}
+	if !user.CanWriteContent() && !common.CanWriteContentBypassUserPerms(meta, dir) {
+		return errNoPermission
+	}
+	if !common.CanWrite(user, meta, dir) {
+		return errNoPermission
+	}
+	return nil
+}
+
+// joinNames joins the names to the dir, the names must not escape the dir
+func joinNames(dir string, names []string) ([]string, *rpcError) {
+	paths := make([]string, 0, len(names))
+	for _, name := range names {
+		if !validName(name) {
+			return nil, &rpcError{Code: -32602, Message: fmt.Sprintf("invalid file name [%s]", name)}
+		}
+		paths = append(paths, stdpath.Join(dir, name))
+	}
+	return paths, nil
+}
+
+func validName(name string) bool {
+	return !strings.ContainsAny(name, "/\\") && name != "" && name != "." && name != ".."
+}
+
+func exists(c *gin.Context, path string) bool {
+	obj, _ := fs.Get(c.Request.Context(), path, &fs.GetArgs{NoLog: true})
+	return obj != nil
+}
@@ -25,6 +25,7 @@ const (
 	ProtocolVersionHeader = "MCP-Protocol-Version"
 	SessionHeader         = "MCP-Session-Id"
 	sessionTTL            = 30 * time.Minute
+	streamPingInterval    = 30 * time.Second
 	maxSessions           = 128
 	maxUserSessions       = 16
 )
@@ -36,6 +37,8 @@ type session struct {
 	initialized     bool
 	createdAt       time.Time
 	lastUsedAt      time.Time
+	subscriptions   map[string]*subscription
+	stream          chan notification
 }
 
 type Server struct {
@@ -78,7 +81,7 @@ var supportedProtocolVersions = map[string]struct{}{
 }
 
 func Register(g *gin.RouterGroup) {
-	mcpGroup := g.Group("/mcp", middlewares.Auth(false), middlewares.AuthAdmin)
+	mcpGroup := g.Group("/mcp", middlewares.Protocol("mcp"), middlewares.Auth(false), middlewares.AuthAdmin)
 	mcpGroup.GET("", defaultServer.handleGet)
 	mcpGroup.POST("", defaultServer.handlePost)
 	mcpGroup.DELETE("", defaultServer.handleDelete)
@@ -89,8 +92,63 @@ func (s *Server) handleGet(c *gin.Context) {
 		c.Status(http.StatusForbidden)
 		return
 	}
-	c.Header("Allow", "POST, DELETE")
-	c.Status(http.StatusMethodNotAllowed)
+	if !acceptsEventStream(c.GetHeader("Accept")) {
+		c.Status(http.StatusNotAcceptable)
+		return
+	}
+	sessionID := c.GetHeader(SessionHeader)
+	if sessionID == "" {
+		c.Status(http.StatusBadRequest)
+		return
+	}
+	currentSession, ok := s.getSession(sessionID)
+	user := c.Request.Context().Value(conf.UserKey).(*model.User)
+	if !ok || currentSession.userID != user.ID {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	stream, ok := s.openStream(sessionID)
+	if !ok {
+		c.Status(http.StatusNotFound)
+		return
+	}
+	defer s.closeStream(sessionID, stream)
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	ticker := time.NewTicker(streamPingInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case n, ok := <-stream:
+			if !ok {
+				return
+			}
+			data, err := json.Marshal(n)
+			if err != nil {
+				continue
+			}
+			if _, err = fmt.Fprintf(c.Writer, "event: message\ndata: %s\n\n", data); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		case <-ticker.C:
+			if _, ok := s.getSession(sessionID); !ok {
+				return
+			}
+			if _, err := io.WriteString(c.Writer, ": ping\n\n"); err != nil {
+				return
+			}
+			c.Writer.Flush()
+		}
+	}
 }
 
 func (s *Server) handlePost(c *gin.Context) {
@@ -205,6 +263,16 @@ func (s *Server) handlePost(c *gin.Context) {
 		}
 		status, resp := s.handleToolsCall(c, req)
 		c.JSON(status, resp)
+	case "resources/list", "resources/templates/list", "resources/read", "resources/subscribe", "resources/unsubscribe":
+		if !s.sessionInitialized(sessionID) {
+			c.JSON(http.StatusBadRequest, response{
+				JSONRPC: "2.0",
+				ID:      req.ID,
+				Error:   &rpcError{Code: -32002, Message: "MCP session not initialized"},
+			})
+			return
+		}
+		c.JSON(s.handleResources(c, sessionID, req))
 	default:
 		c.JSON(http.StatusOK, response{
 			JSONRPC: "2.0",
@@ -243,12 +311,16 @@ func (s *Server) handleInitialize(c *gin.Context, req request) {
 				"tools": map[string]any{
 					"listChanged": false,
 				},
+				"resources": map[string]any{
+					"subscribe":   true,
+					"listChanged": false,
+				},
 			},
 			"serverInfo": map[string]any{
 				"name":    "OpenList MCP",
 				"version": conf.Version,
 			},
-			"instructions": "Complete initialization with notifications/initialized, then use tools/list and tools/call. Available tools include openlist.fs.list, openlist.fs.get, and openlist.fs.link.",
+			"instructions": "Complete initialization with notifications/initialized, then use tools/list and tools/call. Available tools include openlist.fs.list, openlist.fs.get, openlist.fs.link, openlist.fs.read, openlist.fs.search and openlist.task.get, and the write tools openlist.fs.mkdir, openlist.fs.rename, openlist.fs.move, openlist.fs.copy, openlist.fs.remove and openlist.fs.write. Files and directories are also resources with openlist://<path> uris, open the GET stream to receive notifications for resources/subscribe.",
 		},
 	})
 }
@@ -503,6 +575,26 @@ func acceptsStreamableHTTP(accept string) bool {
 	return hasJSON || hasSSE
 }
 
+func acceptsEventStream(accept string) bool {
+	for part := range strings.SplitSeq(accept, ",") {
+		mediaType, params, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err != nil {
+			continue
+		}
+		if q, ok := params["q"]; ok {
+			quality, err := strconv.ParseFloat(q, 64)
+			if err == nil && quality == 0 {
+				continue
+			}
+		}
+		switch mediaType {
+		case "*/*", "text/*", "text/event-stream":
+			return true
+		}
+	}
+	return false
+}
+
 func validateOrigin(r *http.Request) bool {
 	origin := r.Header.Get("Origin")
 	if origin == "" {
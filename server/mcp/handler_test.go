@@ -519,7 +519,7 @@ func TestDeleteRemovesSession(t *testing.T) {
 	}
 }
 
-func TestGetReturnsMethodNotAllowed(t *testing.T) {
+func TestGetRequiresEventStream(t *testing.T) {
 	gin.SetMode(gin.TestMode)
 
 	r := gin.New()
@@ -530,15 +530,37 @@ func TestGetReturnsMethodNotAllowed(t *testing.T) {
 
 	req := httptest.NewRequest(http.MethodGet, "http://example.com/mcp", nil)
 	req.Header.Set("Origin", "http://example.com")
+	req.Header.Set("Accept", "application/json")
 
 	w := httptest.NewRecorder()
 	r.ServeHTTP(w, req)
 
-	if w.Code != http.StatusMethodNotAllowed {
-		t.Fatalf("unexpected status: got %d want %d", w.Code, http.StatusMethodNotAllowed)
+	if w.Code != http.StatusNotAcceptable {
+		t.Fatalf("unexpected status: got %d want %d", w.Code, http.StatusNotAcceptable)
 	}
-	if allow := w.Header().Get("Allow"); allow != "POST, DELETE" {
-		t.Fatalf("unexpected Allow header: got %q want %q", allow, "POST, DELETE")
+}
+
+func TestGetRejectsUnknownSession(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	srv := newTestServer(map[string]*session{
+		"session-1": {id: "session-1", userID: 2, initialized: true},
+	})
+	r := gin.New()
+	r.GET("/mcp", func(c *gin.Context) {
+		common.GinAppendValues(c, conf.UserKey, &model.User{ID: 1, Role: model.ADMIN})
+		srv.handleGet(c)
+	})
+
+	req := httptest.NewRequest(http.MethodGet, "http://example.com/mcp", nil)
+	req.Header.Set("Accept", "text/event-stream")
+	req.Header.Set(SessionHeader, "session-1")
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	if w.Code != http.StatusNotFound {
+		t.Fatalf("unexpected status: got %d want %d", w.Code, http.StatusNotFound)
 	}
 }
 
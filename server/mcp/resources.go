@@ -0,0 +1,413 @@
+package mcp
+
+import (
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	stdpath "path"
+	"strconv"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	resourceScheme = "openlist://"
+	// dirMimeType is the MIME type of the directory resources, their contents are the JSON listings
+	dirMimeType      = "inode/directory"
+	resourcesPerPage = 100
+	maxResourceBytes = maxReadBytes
+	streamBufferSize = 16
+)
+
+type subscription struct {
+	uri string
+	dir bool
+	// sum is the fingerprint of the object or of the directory listing last seen
+	sum string
+}
+
+type notification struct {
+	JSONRPC string `json:"jsonrpc"`
+	Method  string `json:"method"`
+	Params  any    `json:"params,omitempty"`
+}
+
+type resource struct {
+	URI      string `json:"uri"`
+	Name     string `json:"name"`
+	MimeType string `json:"mimeType,omitempty"`
+	Size     int64  `json:"size,omitempty"`
+}
+
+type resourceTemplate struct {
+	URITemplate string `json:"uriTemplate"`
+	Name        string `json:"name"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+type resourceContents struct {
+	URI      string `json:"uri"`
+	MimeType string `json:"mimeType,omitempty"`
+	Text     string `json:"text,omitempty"`
+	Blob     string `json:"blob,omitempty"`
+}
+
+type resourcesListParams struct {
+	Cursor string `json:"cursor,omitempty"`
+}
+
+type resourceParams struct {
+	URI string `json:"uri"`
+}
+
+var openListResourceTemplates = []resourceTemplate{
+	{
+		URITemplate: resourceScheme + "{+path}",
+		Name:        "openlist-path",
+		Title:       "OpenList file or directory",
+		Description: "A file or directory by its mount path, for example openlist:///movies/demo.mp4.",
+	},
+}
+
+func (s *Server) handleResources(c *gin.Context, sessionID string, req request) (int, response) {
+	var (
+		result any
+		err    *rpcError
+	)
+	switch req.Method {
+	case "resources/list":
+		result, err = s.listResources(c, req.Params)
+	case "resources/templates/list":
+		result = map[string]any{"resourceTemplates": openListResourceTemplates}
+	case "resources/read":
+		result, err = s.readResource(c, req.Params)
+	case "resources/subscribe":
+		result, err = s.subscribeResource(c, sessionID, req.Params)
+	case "resources/unsubscribe":
+		result, err = s.unsubscribeResource(sessionID, req.Params)
+	}
+	if err != nil {
+		return http.StatusOK, response{JSONRPC: "2.0", ID: req.ID, Error: err}
+	}
+	return http.StatusOK, response{JSONRPC: "2.0", ID: req.ID, Result: result}
+}
+
+// listResources lists the root of the user page by page, the cursor is the offset
+func (s *Server) listResources(c *gin.Context, raw json.RawMessage) (any, *rpcError) {
+	var params resourcesListParams
+	if len(raw) > 0 {
+		if err := json.Unmarshal(raw, &params); err != nil {
+			return nil, &rpcError{Code: -32602, Message: "invalid resources/list params"}
+		}
+	}
+	offset := 0
+	if params.Cursor != "" {
+		var err error
+		if offset, err = strconv.Atoi(params.Cursor); err != nil || offset < 0 {
+			return nil, &rpcError{Code: -32602, Message: "invalid cursor"}
+		}
+	}
+	user, mcpErr := toolUser(c)
+	if mcpErr != nil {
+		return nil, mcpErr
+	}
+	ctx, reqPath, mcpErr := accessResource(c, user, "/")
+	if mcpErr != nil {
+		return nil, mcpErr
+	}
+	objs, err := fs.List(ctx, reqPath, &fs.ListArgs{})
+	if err != nil {
+		return nil, &rpcError{Code: -32603, Message: err.Error()}
+	}
+	resources := make([]resource, 0, resourcesPerPage)
+	for _, obj := range objs[min(offset, len(objs)):min(offset+resourcesPerPage, len(objs))] {
+		resources = append(resources, toResource("/"+obj.GetName(), obj))
+	}
+	result := map[string]any{"resources": resources}
+	if offset+resourcesPerPage < len(objs) {
+		result["nextCursor"] = strconv.Itoa(offset + resourcesPerPage)
+	}
+	return result, nil
+}
+
+func (s *Server) readResource(c *gin.Context, raw json.RawMessage) (any, *rpcError) {
+	path, mcpErr := parseResourceParams(raw)
+	if mcpErr != nil {
+		return nil, mcpErr
+	}
+	user, mcpErr := toolUser(c)
+	if mcpErr != nil {
+		return nil, mcpErr
+	}
+	ctx, reqPath, mcpErr := accessResource(c, user, path)
+	if mcpErr != nil {
+		return nil, mcpErr
+	}
+	obj, err := fs.Get(ctx, reqPath, &fs.GetArgs{})
+	if err != nil {
+		return nil, resourceNotFound(path)
+	}
+	contents := resourceContents{URI: resourceURI(path)}
+	if obj.IsDir() {
+		objs, err := fs.List(ctx, reqPath, &fs.ListArgs{})
+		if err != nil {
+			return nil, &rpcError{Code: -32603, Message: err.Error()}
+		}
+		meta, _ := ctx.Value(conf.MetaKey).(*model.Meta)
+		listing, err := json.Marshal(toObjResp(objs, reqPath, isEncrypt(meta, reqPath)))
+		if err != nil {
+			return nil, &rpcError{Code: -32603, Message: err.Error()}
+		}
+		contents.MimeType = "application/json"
+		contents.Text = string(listing)
+		return map[string]any{"contents": []resourceContents{contents}}, nil
+	}
+	if obj.GetSize() > maxResourceBytes {
+		return nil, &rpcError{Code: -32603, Message: fmt.Sprintf("resource is larger than %d bytes, use openlist.fs.read or openlist.fs.link instead", maxResourceBytes)}
+	}
+	data := []byte{}
+	if obj.GetSize() > 0 {
+		if data, err = readRange(ctx, c, reqPath, obj, 0, obj.GetSize()); err != nil {
+			return nil, &rpcError{Code: -32603, Message: err.Error()}
+		}
+	}
+	contents.MimeType = utils.GetMimeType(obj.GetName())
+	if text, ok := trimText(data); ok && len(text) == len(data) {
+		contents.Text = string(text)
+	} else {
+		contents.Blob = base64.StdEncoding.EncodeToString(data)
+	}
+	return map[string]any{"contents": []resourceContents{contents}}, nil
+}
+
+func (s *Server) subscribeResource(c *gin.Context, sessionID string, raw json.RawMessage) (any, *rpcError) {
+	path, mcpErr := parseResourceParams(raw)
+	if mcpErr != nil {
+		return nil, mcpErr
+	}
+	user, mcpErr := toolUser(c)
+	if mcpErr != nil {
+		return nil, mcpErr
+	}
+	ctx, reqPath, mcpErr := accessResource(c, user, path)
+	if mcpErr != nil {
+		return nil, mcpErr
+	}
+	obj, err := fs.Get(ctx, reqPath, &fs.GetArgs{NoLog: true})
+	if err != nil {
+		return nil, resourceNotFound(path)
+	}
+	sub := &subscription{uri: resourceURI(path), dir: obj.IsDir()}
+	if obj.IsDir() {
+		if objs, err := fs.List(ctx, reqPath, &fs.ListArgs{}); err == nil {
+			sub.sum = objsSum(objs)
+		}
+	} else {
+		sub.sum = objSum(obj)
+	}
+	if !s.subscribe(sessionID, reqPath, sub) {
+		return nil, &rpcError{Code: -32001, Message: "session not found"}
+	}
+	return map[string]any{}, nil
+}
+
+func (s *Server) unsubscribeResource(sessionID string, raw json.RawMessage) (any, *rpcError) {
+	path, mcpErr := parseResourceParams(raw)
+	if mcpErr != nil {
+		return nil, mcpErr
+	}
+	s.unsubscribe(sessionID, resourceURI(path))
+	return map[string]any{}, nil
+}
+
+// accessResource checks the access of the user to the path relative to its base path,
+// the returned context carries the nearest meta
+func accessResource(c *gin.Context, user *model.User, path string) (context.Context, string, *rpcError) {
+	reqPath, err := user.JoinPath(path)
+	if err != nil {
+		return nil, "", &rpcError{Code: -32003, Message: err.Error()}
+	}
+	meta, mcpErr := nearestMeta(reqPath)
+	if mcpErr != nil {
+		return nil, "", mcpErr
+	}
+	if !common.CanAccess(user, meta, reqPath, "") {
+		return nil, "", &rpcError{Code: -32003, Message: "password is incorrect or you have no permission"}
+	}
+	return context.WithValue(c.Request.Context(), conf.MetaKey, meta), reqPath, nil
+}
+
+func parseResourceParams(raw json.RawMessage) (string, *rpcError) {
+	var params resourceParams
+	if len(raw) == 0 || json.Unmarshal(raw, &params) != nil || params.URI == "" {
+		return "", &rpcError{Code: -32602, Message: "uri is required"}
+	}
+	path, ok := parseResourceURI(params.URI)
+	if !ok {
+		return "", &rpcError{Code: -32602, Message: fmt.Sprintf("invalid resource uri [%s]", params.URI)}
+	}
+	return path, nil
+}
+
+// parseResourceURI returns the path of the openlist://<path> uri relative to the base path of the user
+func parseResourceURI(uri string) (string, bool) {
+	encoded, ok := strings.CutPrefix(uri, resourceScheme)
+	if !ok || !strings.HasPrefix(encoded, "/") {
+		return "", false
+	}
+	path, err := url.PathUnescape(encoded)
+	if err != nil {
+		return "", false
+	}
+	return utils.FixAndCleanPath(path), true
+}
+
+func resourceURI(path string) string {
+	return resourceScheme + utils.EncodePath(utils.FixAndCleanPath(path), true)
+}
+
+func resourceNotFound(path string) *rpcError {
+	return &rpcError{Code: -32002, Message: fmt.Sprintf("resource not found: %s", resourceURI(path))}
+}
+
+func toResource(path string, obj model.Obj) resource {
+	r := resource{URI: resourceURI(path), Name: obj.GetName(), MimeType: dirMimeType}
+	if !obj.IsDir() {
+		r.MimeType = utils.GetMimeType(obj.GetName())
+		r.Size = obj.GetSize()
+	}
+	return r
+}
+
+func objSum(obj model.Obj) string {
+	return fmt.Sprintf("%s:%d:%d", obj.GetName(), obj.GetSize(), obj.ModTime().UnixNano())
+}
+
+func objsSum(objs []model.Obj) string {
+	var b strings.Builder
+	for _, obj := range objs {
+		b.WriteString(objSum(obj))
+		b.WriteByte('\n')
+	}
+	return utils.HashData(utils.MD5, []byte(b.String()))
+}
+
+func init() {
+	op.RegisterObjsUpdateHook(defaultServer.objsUpdated)
+}
+
+// objsUpdated notifies the sessions subscribed to the updated directory or to the files in it
+func (s *Server) objsUpdated(_ context.Context, parent string, objs []model.Obj) {
+	parent = utils.FixAndCleanPath(parent)
+	dirSum := ""
+	fileSums := map[string]string{}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, currentSession := range s.sessions {
+		if currentSession == nil || currentSession.stream == nil {
+			continue
+		}
+		for path, sub := range currentSession.subscriptions {
+			var sum string
+			switch {
+			case sub.dir && path == parent:
+				if dirSum == "" {
+					dirSum = objsSum(objs)
+				}
+				sum = dirSum
+			case !sub.dir && stdpath.Dir(path) == parent:
+				if len(fileSums) == 0 {
+					for _, obj := range objs {
+						fileSums[obj.GetName()] = objSum(obj)
+					}
+				}
+				sum = fileSums[stdpath.Base(path)]
+			default:
+				continue
+			}
+			if sum == sub.sum {
+				continue
+			}
+			sub.sum = sum
+			currentSession.notifyLocked(notification{
+				JSONRPC: "2.0",
+				Method:  "notifications/resources/updated",
+				Params:  map[string]any{"uri": sub.uri},
+			})
+		}
+	}
+}
+
+// subscribe adds the subscription to the session, keyed by the actual path
+func (s *Server) subscribe(id, path string, sub *subscription) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	currentSession, ok := s.sessions[id]
+	if !ok || currentSession == nil {
+		return false
+	}
+	if currentSession.subscriptions == nil {
+		currentSession.subscriptions = map[string]*subscription{}
+	}
+	currentSession.subscriptions[path] = sub
+	return true
+}
+
+func (s *Server) unsubscribe(id, uri string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	currentSession, ok := s.sessions[id]
+	if !ok || currentSession == nil {
+		return
+	}
+	for path, sub := range currentSession.subscriptions {
+		if sub.uri == uri {
+			delete(currentSession.subscriptions, path)
+		}
+	}
+}
+
+// openStream replaces the notification stream of the session, the previous one is closed
+func (s *Server) openStream(id string) (chan notification, bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	currentSession, ok := s.sessions[id]
+	if !ok || currentSession == nil {
+		return nil, false
+	}
+	if currentSession.stream != nil {
+		close(currentSession.stream)
+	}
+	currentSession.stream = make(chan notification, streamBufferSize)
+	return currentSession.stream, true
+}
+
+func (s *Server) closeStream(id string, stream chan notification) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	currentSession, ok := s.sessions[id]
+	if !ok || currentSession == nil || currentSession.stream != stream {
+		return
+	}
+	close(stream)
+	currentSession.stream = nil
+}
+
+// notifyLocked drops the notification if the client does not keep up with the stream
+func (currentSession *session) notifyLocked(n notification) {
+	select {
+	case currentSession.stream <- n:
+	default:
+	}
+}
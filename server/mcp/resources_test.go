@@ -0,0 +1,61 @@
+package mcp
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+)
+
+func TestResourceURI(t *testing.T) {
+	uri := resourceURI("/movies/a b#1.mp4")
+	if uri != "openlist:///movies/a%20b%231.mp4" {
+		t.Fatalf("unexpected uri: %s", uri)
+	}
+	path, ok := parseResourceURI(uri)
+	if !ok || path != "/movies/a b#1.mp4" {
+		t.Fatalf("unexpected path: %q %v", path, ok)
+	}
+	for _, uri := range []string{"file:///movies", "openlist://movies", "openlist:///%zz"} {
+		if _, ok := parseResourceURI(uri); ok {
+			t.Fatalf("expected %s to be rejected", uri)
+		}
+	}
+}
+
+func TestObjsUpdatedNotifiesSubscriptions(t *testing.T) {
+	now := time.Now()
+	file := &model.Object{Name: "a.txt", Size: 1, Modified: now}
+	stream := make(chan notification, streamBufferSize)
+	srv := newTestServer(map[string]*session{
+		"session-1": {
+			id:     "session-1",
+			userID: 1,
+			stream: stream,
+			subscriptions: map[string]*subscription{
+				"/local":       {uri: "openlist:///local", dir: true, sum: objsSum([]model.Obj{file})},
+				"/local/a.txt": {uri: "openlist:///local/a.txt", sum: objSum(file)},
+			},
+		},
+	})
+
+	srv.objsUpdated(context.Background(), "/local", []model.Obj{file})
+	if len(stream) != 0 {
+		t.Fatalf("expected no notification for unchanged objects, got %d", len(stream))
+	}
+
+	changed := &model.Object{Name: "b.txt", Size: 2, Modified: now}
+	srv.objsUpdated(context.Background(), "/local", []model.Obj{file, changed})
+	if len(stream) != 1 {
+		t.Fatalf("expected one notification, got %d", len(stream))
+	}
+	if n := <-stream; n.Method != "notifications/resources/updated" || n.Params.(map[string]any)["uri"] != "openlist:///local" {
+		t.Fatalf("unexpected notification: %+v", n)
+	}
+
+	srv.objsUpdated(context.Background(), "/local", []model.Obj{changed})
+	if len(stream) != 2 {
+		t.Fatalf("expected directory and removed file notifications, got %d", len(stream))
+	}
+}
@@ -0,0 +1,67 @@
+package mcp
+
+import (
+	"encoding/json"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/search"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+type fsSearchArgs struct {
+	Keywords string `json:"keywords"`
+	Parent   string `json:"parent"`
+	Scope    int    `json:"scope"`
+	Password string `json:"password"`
+	Page     int    `json:"page"`
+	PerPage  int    `json:"per_page"`
+}
+
+type fsSearchResp struct {
+	Content []model.SearchNode `json:"content"`
+	Total   int64              `json:"total"`
+}
+
+func (s *Server) callFSSearch(c *gin.Context, raw json.RawMessage) (any, *rpcError) {
+	args := &fsSearchArgs{Parent: "/", Page: 1, PerPage: 100}
+	if mcpErr := parseToolArgs(raw, "openlist.fs.search", args); mcpErr != nil {
+		return nil, mcpErr
+	}
+	if args.Keywords == "" {
+		return nil, &rpcError{Code: -32602, Message: "keywords is required"}
+	}
+	if setting.GetStr(conf.SearchIndex) == "none" {
+		return nil, &rpcError{Code: -32603, Message: errs.SearchNotAvailable.Error()}
+	}
+	user, mcpErr := toolUser(c)
+	if mcpErr != nil {
+		return nil, mcpErr
+	}
+	parent, err := user.JoinPath(args.Parent)
+	if err != nil {
+		return nil, &rpcError{Code: -32003, Message: err.Error()}
+	}
+	req := model.SearchReq{
+		Parent:   parent,
+		Keywords: args.Keywords,
+		Scope:    args.Scope,
+		PageReq:  model.PageReq{Page: args.Page, PerPage: args.PerPage},
+	}
+	if err = req.Validate(); err != nil {
+		return nil, &rpcError{Code: -32602, Message: err.Error()}
+	}
+	// the nodes the user can't access are excluded before the pagination like the search handler
+	req.Restriction, err = common.SearchRestriction(user, args.Password)
+	if err != nil {
+		return nil, &rpcError{Code: -32603, Message: err.Error()}
+	}
+	nodes, total, err := search.SearchFiltered(c.Request.Context(), req, nil)
+	if err != nil {
+		return nil, &rpcError{Code: -32603, Message: err.Error()}
+	}
+	return fsSearchResp{Content: nodes, Total: total}, nil
+}
@@ -0,0 +1,57 @@
+package mcp
+
+import (
+	"context"
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/search"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func TestFSSearchExcludesDeniedAndHiddenPaths(t *testing.T) {
+	conf.Conf = conf.DefaultConfig("data")
+	dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		t.Fatalf("failed to connect database: %+v", err)
+	}
+	db.Init(dB)
+	if err = search.Init("database"); err != nil {
+		t.Fatalf("failed to init searcher: %+v", err)
+	}
+	for _, parent := range []string{"/public", "/secret", "/hidden"} {
+		if err = search.Index(context.Background(), parent, &model.Object{Name: "report.txt"}); err != nil {
+			t.Fatalf("failed to index: %+v", err)
+		}
+	}
+	// the hide rules can't be pushed down to the searchers
+	for _, meta := range []*model.Meta{
+		{Path: "/secret", ReadUsers: []uint{1}, ReadUsersSub: true},
+		{Path: "/hidden", Hide: "^report", HSub: true},
+	} {
+		if err = op.CreateMeta(meta); err != nil {
+			t.Fatalf("failed to create meta: %+v", err)
+		}
+	}
+
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("POST", "http://example.com/mcp", nil)
+	common.GinAppendValues(c, conf.UserKey, &model.User{ID: 2, Role: model.GENERAL, BasePath: "/"})
+	res, rpcErr := newTestServer(nil).callFSSearch(c, json.RawMessage(`{"keywords":"report"}`))
+	if rpcErr != nil {
+		t.Fatalf("failed to search: %+v", rpcErr)
+	}
+	resp := res.(fsSearchResp)
+	if resp.Total != 1 || len(resp.Content) != 1 || resp.Content[0].Parent != "/public" {
+		t.Fatalf("expected only the public result, got %+v", resp)
+	}
+}
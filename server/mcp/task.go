@@ -0,0 +1,71 @@
+package mcp
+
+import (
+	"encoding/json"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	offline "github.com/OpenListTeam/OpenList/v4/internal/offline_download/tool"
+	"github.com/OpenListTeam/OpenList/v4/internal/task"
+	"github.com/OpenListTeam/OpenList/v4/server/handles"
+	"github.com/gin-gonic/gin"
+)
+
+// taskTypes are the task types of openlist.task.get, the same as the groups of the task routes
+var taskTypes = []string{"upload", "copy", "move", "offline_download", "offline_download_transfer", "decompress", "decompress_upload"}
+
+type taskGetArgs struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+}
+
+type taskGetter[T task.TaskExtensionInfo] interface {
+	GetByID(id string) (T, bool)
+}
+
+func getTask[T task.TaskExtensionInfo](manager taskGetter[T], id string) (task.TaskExtensionInfo, bool) {
+	t, ok := manager.GetByID(id)
+	if !ok {
+		return nil, false
+	}
+	return t, true
+}
+
+func findTask(typ, id string) (task.TaskExtensionInfo, bool) {
+	switch typ {
+	case "upload":
+		return getTask(fs.UploadTaskManager, id)
+	case "copy":
+		return getTask(fs.CopyTaskManager, id)
+	case "move":
+		return getTask(fs.MoveTaskManager, id)
+	case "offline_download":
+		return getTask(offline.DownloadTaskManager, id)
+	case "offline_download_transfer":
+		return getTask(offline.TransferTaskManager, id)
+	case "decompress":
+		return getTask(fs.ArchiveDownloadTaskManager, id)
+	case "decompress_upload":
+		return getTask(fs.ArchiveContentUploadTaskManager, id)
+	}
+	return nil, false
+}
+
+func (s *Server) callTaskGet(c *gin.Context, raw json.RawMessage) (any, *rpcError) {
+	args := &taskGetArgs{}
+	if mcpErr := parseToolArgs(raw, "openlist.task.get", args); mcpErr != nil {
+		return nil, mcpErr
+	}
+	if args.Type == "" || args.ID == "" {
+		return nil, &rpcError{Code: -32602, Message: "type and id are required"}
+	}
+	user, mcpErr := toolUser(c)
+	if mcpErr != nil {
+		return nil, mcpErr
+	}
+	t, ok := findTask(args.Type, args.ID)
+	// the tasks of the other users are not found like the task routes
+	if !ok || !user.IsAdmin() && (t.GetCreator() == nil || t.GetCreator().ID != user.ID) {
+		return nil, &rpcError{Code: -32003, Message: "task not found"}
+	}
+	return handles.GetTaskInfo(t), nil
+}
@@ -7,8 +7,22 @@ type tool struct {
 	Title       string          `json:"title,omitempty"`
 	Description string          `json:"description,omitempty"`
 	InputSchema toolInputSchema `json:"inputSchema"`
+	Annotations toolAnnotations `json:"annotations"`
 }
 
+// toolAnnotations are the behavior hints of the tool for the clients
+type toolAnnotations struct {
+	ReadOnlyHint    bool `json:"readOnlyHint"`
+	DestructiveHint bool `json:"destructiveHint"`
+	IdempotentHint  bool `json:"idempotentHint"`
+	OpenWorldHint   bool `json:"openWorldHint"`
+}
+
+var (
+	readOnlyTool    = toolAnnotations{ReadOnlyHint: true, IdempotentHint: true}
+	destructiveTool = toolAnnotations{DestructiveHint: true}
+)
+
 type toolInputSchema struct {
 	Type       string                    `json:"type"`
 	Properties map[string]schemaProperty `json:"properties,omitempty"`
@@ -16,8 +30,10 @@ type toolInputSchema struct {
 }
 
 type schemaProperty struct {
-	Type        string `json:"type,omitempty"`
-	Description string `json:"description,omitempty"`
+	Type        string          `json:"type,omitempty"`
+	Description string          `json:"description,omitempty"`
+	Items       *schemaProperty `json:"items,omitempty"`
+	Enum        []string        `json:"enum,omitempty"`
 }
 
 type toolsListParams struct {
@@ -55,6 +71,7 @@ var openListTools = []tool{
 			},
 			Required: []string{"path"},
 		},
+		Annotations: readOnlyTool,
 	},
 	{
 		Name:        "openlist.fs.get",
@@ -74,6 +91,7 @@ var openListTools = []tool{
 			},
 			Required: []string{"path"},
 		},
+		Annotations: readOnlyTool,
 	},
 	{
 		Name:        "openlist.fs.link",
@@ -97,7 +115,220 @@ var openListTools = []tool{
 			},
 			Required: []string{"path"},
 		},
+		Annotations: readOnlyTool,
+	},
+	{
+		Name:        "openlist.fs.read",
+		Title:       "OpenList FS Read",
+		Description: "Read a byte range of a text file that the current user can access.",
+		InputSchema: toolInputSchema{
+			Type: "object",
+			Properties: map[string]schemaProperty{
+				"path": {
+					Type:        "string",
+					Description: "File mount path, for example \"/docs/readme.md\".",
+				},
+				"password": {
+					Type:        "string",
+					Description: "Optional password for protected paths.",
+				},
+				"offset": {
+					Type:        "integer",
+					Description: "Byte offset to start reading from, 0 by default.",
+				},
+				"length": {
+					Type:        "integer",
+					Description: "Maximum number of bytes to read, 65536 by default and at most 1048576.",
+				},
+			},
+			Required: []string{"path"},
+		},
+		Annotations: readOnlyTool,
+	},
+	{
+		Name:        "openlist.fs.search",
+		Title:       "OpenList FS Search",
+		Description: "Search the index for files and directories that the current user can access.",
+		InputSchema: toolInputSchema{
+			Type: "object",
+			Properties: map[string]schemaProperty{
+				"keywords": {
+					Type:        "string",
+					Description: "Keywords to search for.",
+				},
+				"parent": {
+					Type:        "string",
+					Description: "Mount path to search under, \"/\" by default.",
+				},
+				"scope": {
+					Type:        "integer",
+					Description: "0 for all, 1 for directories only, 2 for files only.",
+				},
+				"password": {
+					Type:        "string",
+					Description: "Optional password for protected paths.",
+				},
+				"page": {
+					Type:        "integer",
+					Description: "1-based page number.",
+				},
+				"per_page": {
+					Type:        "integer",
+					Description: "Page size.",
+				},
+			},
+			Required: []string{"keywords"},
+		},
+		Annotations: readOnlyTool,
+	},
+	{
+		Name:        "openlist.task.get",
+		Title:       "OpenList Task Get",
+		Description: "Get the status of a task created by the current user, such as a move or copy task.",
+		InputSchema: toolInputSchema{
+			Type: "object",
+			Properties: map[string]schemaProperty{
+				"type": {
+					Type:        "string",
+					Description: "Task type.",
+					Enum:        taskTypes,
+				},
+				"id": {
+					Type:        "string",
+					Description: "Task ID returned by the tool that created the task.",
+				},
+			},
+			Required: []string{"type", "id"},
+		},
+		Annotations: readOnlyTool,
+	},
+	{
+		Name:        "openlist.fs.mkdir",
+		Title:       "OpenList FS Mkdir",
+		Description: "Create a directory and its missing parents.",
+		InputSchema: toolInputSchema{
+			Type: "object",
+			Properties: map[string]schemaProperty{
+				"path": {
+					Type:        "string",
+					Description: "Mount path of the directory to create, for example \"/docs/new\".",
+				},
+			},
+			Required: []string{"path"},
+		},
+		Annotations: toolAnnotations{IdempotentHint: true},
+	},
+	{
+		Name:        "openlist.fs.rename",
+		Title:       "OpenList FS Rename",
+		Description: "Rename a file or directory in place.",
+		InputSchema: toolInputSchema{
+			Type: "object",
+			Properties: map[string]schemaProperty{
+				"path": {
+					Type:        "string",
+					Description: "Mount path of the file or directory to rename.",
+				},
+				"name": {
+					Type:        "string",
+					Description: "New name without any slash.",
+				},
+				"overwrite": {
+					Type:        "boolean",
+					Description: "Replace an existing file with the new name.",
+				},
+			},
+			Required: []string{"path", "name"},
+		},
+		Annotations: destructiveTool,
+	},
+	{
+		Name:        "openlist.fs.move",
+		Title:       "OpenList FS Move",
+		Description: "Move files and directories to another directory, returns the IDs of the created move tasks.",
+		InputSchema: transferSchema,
+		Annotations: destructiveTool,
+	},
+	{
+		Name:        "openlist.fs.copy",
+		Title:       "OpenList FS Copy",
+		Description: "Copy files and directories to another directory, returns the IDs of the created copy tasks.",
+		InputSchema: transferSchema,
+		Annotations: destructiveTool,
+	},
+	{
+		Name:        "openlist.fs.remove",
+		Title:       "OpenList FS Remove",
+		Description: "Remove files and directories from a directory.",
+		InputSchema: toolInputSchema{
+			Type: "object",
+			Properties: map[string]schemaProperty{
+				"dir": {
+					Type:        "string",
+					Description: "Mount path of the directory containing the items.",
+				},
+				"names": {
+					Type:        "array",
+					Description: "Names of the files and directories to remove.",
+					Items:       &schemaProperty{Type: "string"},
+				},
+			},
+			Required: []string{"dir", "names"},
+		},
+		Annotations: destructiveTool,
+	},
+	{
+		Name:        "openlist.fs.write",
+		Title:       "OpenList FS Write",
+		Description: "Write a small text file, at most 1048576 bytes.",
+		InputSchema: toolInputSchema{
+			Type: "object",
+			Properties: map[string]schemaProperty{
+				"path": {
+					Type:        "string",
+					Description: "File mount path, for example \"/docs/notes.txt\".",
+				},
+				"content": {
+					Type:        "string",
+					Description: "UTF-8 text content of the file.",
+				},
+				"overwrite": {
+					Type:        "boolean",
+					Description: "Replace the file if it exists.",
+				},
+			},
+			Required: []string{"path", "content"},
+		},
+		Annotations: destructiveTool,
+	},
+}
+
+var transferSchema = toolInputSchema{
+	Type: "object",
+	Properties: map[string]schemaProperty{
+		"src_dir": {
+			Type:        "string",
+			Description: "Mount path of the directory containing the items.",
+		},
+		"dst_dir": {
+			Type:        "string",
+			Description: "Mount path of the destination directory.",
+		},
+		"names": {
+			Type:        "array",
+			Description: "Names of the files and directories in src_dir.",
+			Items:       &schemaProperty{Type: "string"},
+		},
+		"overwrite": {
+			Type:        "boolean",
+			Description: "Replace the existing items in dst_dir.",
+		},
+		"skip_existing": {
+			Type:        "boolean",
+			Description: "Skip the items which already exist in dst_dir instead of failing.",
+		},
 	},
+	Required: []string{"src_dir", "dst_dir", "names"},
 }
 
 func (s *Server) handleToolsList(req request) response {
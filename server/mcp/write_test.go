@@ -0,0 +1,64 @@
+package mcp
+
+import (
+	"encoding/json"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+func TestWriteToolsRequirePermissions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	c, _ := gin.CreateTestContext(httptest.NewRecorder())
+	c.Request = httptest.NewRequest("POST", "http://example.com/mcp", nil)
+	common.GinAppendValues(c, conf.UserKey, &model.User{ID: 2, Role: model.GENERAL, BasePath: "/"})
+
+	srv := newTestServer(nil)
+	calls := map[string]func() (any, *rpcError){
+		"rename": func() (any, *rpcError) {
+			return srv.callFSRename(c, json.RawMessage(`{"path":"/a.txt","name":"b.txt"}`))
+		},
+		"move": func() (any, *rpcError) {
+			return srv.callFSTransfer(c, json.RawMessage(`{"src_dir":"/a","dst_dir":"/b","names":["c"]}`), false)
+		},
+		"copy": func() (any, *rpcError) {
+			return srv.callFSTransfer(c, json.RawMessage(`{"src_dir":"/a","dst_dir":"/b","names":["c"]}`), true)
+		},
+		"remove": func() (any, *rpcError) {
+			return srv.callFSRemove(c, json.RawMessage(`{"dir":"/a","names":["c"]}`))
+		},
+	}
+	for name, call := range calls {
+		if _, err := call(); err != errNoPermission {
+			t.Errorf("%s: expected no permission, got %+v", name, err)
+		}
+	}
+}
+
+func TestWriteToolsRejectInvalidNames(t *testing.T) {
+	for _, name := range []string{"", ".", "..", "a/b", `a\b`} {
+		if validName(name) {
+			t.Errorf("expected %q to be rejected", name)
+		}
+	}
+	if _, err := joinNames("/dir", []string{"ok", "../escape"}); err == nil || err.Code != -32602 {
+		t.Fatalf("unexpected error: %+v", err)
+	}
+}
+
+func TestTrimText(t *testing.T) {
+	text, ok := trimText([]byte("hi \xe4\xbd"))
+	if !ok || string(text) != "hi " {
+		t.Fatalf("expected the cut rune to be dropped, got %q %v", text, ok)
+	}
+	if _, ok = trimText([]byte("a\x00b")); ok {
+		t.Fatal("expected binary data to be rejected")
+	}
+	if _, ok = trimText([]byte{0xff, 0xfe, 0xfd, 0xfc, 'a'}); ok {
+		t.Fatal("expected invalid UTF-8 to be rejected")
+	}
+}
@@ -2,16 +2,23 @@ package middlewares
 
 import (
 	"crypto/subtle"
+	"strconv"
+	"strings"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/oauth"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/internal/setting"
 	"github.com/OpenListTeam/OpenList/v4/server/common"
 	"github.com/gin-gonic/gin"
+	"github.com/pkg/errors"
 	log "github.com/sirupsen/logrus"
 )
 
+// AccessTokenScope is the scope in the context of the requests authorized by a personal access token
+const AccessTokenScope = "access_token"
+
 // Auth is a middleware that checks if the user is logged in.
 // if token is empty, set user to guest
 func Auth(allowDisabledGuest bool) func(c *gin.Context) {
@@ -46,6 +53,30 @@ func Auth(allowDisabledGuest bool) func(c *gin.Context) {
 			c.Next()
 			return
 		}
+		if accessToken := strings.TrimPrefix(token, "Bearer "); op.IsAccessToken(accessToken) {
+			user, err := op.AuthenticateAccessToken(accessToken)
+			if err != nil {
+				common.ErrorResp(c, err, 401)
+				c.Abort()
+				return
+			}
+			common.GinAppendValues(c, conf.UserKey, user, conf.TokenScopeKey, AccessTokenScope)
+			log.Debugf("use personal access token: %+v", user)
+			c.Next()
+			return
+		}
+		if accessToken, ok := strings.CutPrefix(token, "Bearer "); ok {
+			user, scope, err := oauthUser(accessToken)
+			if err != nil {
+				common.ErrorResp(c, err, 401)
+				c.Abort()
+				return
+			}
+			common.GinAppendValues(c, conf.UserKey, user, conf.TokenScopeKey, scope)
+			log.Debugf("use oauth access token: %+v", user)
+			c.Next()
+			return
+		}
 		userClaims, err := common.ParseToken(token)
 		if err != nil {
 			common.ErrorResp(c, err, 401)
@@ -75,6 +106,33 @@ func Auth(allowDisabledGuest bool) func(c *gin.Context) {
 	}
 }
 
+// oauthUser returns the user of an access token issued to an OAuth client,
+// limited to the permissions of the scope of the token
+func oauthUser(token string) (*model.User, string, error) {
+	claims, err := oauth.ParseAccessToken(token)
+	if err != nil {
+		return nil, "", err
+	}
+	if !oauth.HasScope(claims.Scope, oauth.ScopeRead) {
+		return nil, "", errors.New("the token has no read scope")
+	}
+	id, err := strconv.ParseUint(claims.Subject, 10, 64)
+	if err != nil {
+		return nil, "", errors.WithStack(err)
+	}
+	user, err := op.GetUserById(uint(id))
+	if err != nil {
+		return nil, "", err
+	}
+	if claims.PwdTS != user.PwdTS {
+		return nil, "", errors.New("password has been changed, authorize again please")
+	}
+	if user.Disabled {
+		return nil, "", errors.New("current user is disabled")
+	}
+	return oauth.TokenUser(user, claims.Scope), claims.Scope, nil
+}
+
 func Authn(c *gin.Context) {
 	token := c.GetHeader("Authorization")
 	if subtle.ConstantTimeCompare([]byte(token), []byte(setting.GetStr(conf.Token))) == 1 {
@@ -139,6 +197,16 @@ func AuthNotGuest(c *gin.Context) {
 	}
 }
 
+// AuthNotScoped rejects the requests authorized by an OAuth or a personal access token
+func AuthNotScoped(c *gin.Context) {
+	if _, ok := c.Request.Context().Value(conf.TokenScopeKey).(string); ok {
+		common.ErrorStrResp(c, "not allowed with an access token", 403)
+		c.Abort()
+	} else {
+		c.Next()
+	}
+}
+
 func AuthAdmin(c *gin.Context) {
 	user := c.Request.Context().Value(conf.UserKey).(*model.User)
 	if !user.IsAdmin() {
@@ -30,8 +30,9 @@ func Down(verifyFunc func(string, string) error) func(c *gin.Context) {
 			return
 		}
 		common.GinAppendValues(c, conf.MetaKey, meta)
-		// verify sign
-		if needSign(meta, rawPath) {
+		// verify sign, the old versions are only reachable through the signed links of the versions api
+		isVersion := isVersionPath(rawPath)
+		if isVersion || needSign(meta, rawPath) {
 			s := c.Query("sign")
 			err = verifyFunc(rawPath, strings.TrimSuffix(s, "/"))
 			if err != nil {
@@ -40,6 +41,9 @@ func Down(verifyFunc func(string, string) error) func(c *gin.Context) {
 				return
 			}
 		}
+		if isVersion {
+			common.GinAppendValues(c, conf.SystemPathKey, rawPath)
+		}
 		c.Next()
 	}
 }
@@ -50,6 +54,11 @@ func parsePath(path string) string {
 	return utils.FixAndCleanPath(path)
 }
 
+func isVersionPath(rawPath string) bool {
+	storage, actualPath, err := op.GetStorageAndActualPath(rawPath)
+	return err == nil && op.IsVersionPath(actualPath) && op.IsSystemPath(storage, actualPath)
+}
+
 func needSign(meta *model.Meta, path string) bool {
 	if setting.GetBool(conf.SignAll) {
 		return true
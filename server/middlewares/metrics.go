@@ -0,0 +1,14 @@
+package middlewares
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/metrics"
+	"github.com/gin-gonic/gin"
+)
+
+// ServedBytes counts the size of the response bodies as bytes served by server
+func ServedBytes(server string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Next()
+		metrics.AddServedBytes(server, int64(c.Writer.Size()))
+	}
+}
@@ -0,0 +1,15 @@
+package middlewares
+
+import (
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/gin-gonic/gin"
+)
+
+// Protocol records the protocol and the ip of the client in the request context
+func Protocol(protocol string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		common.GinAppendValues(c, conf.ProtocolKey, protocol, conf.ClientIPKey, c.ClientIP())
+		c.Next()
+	}
+}
@@ -31,12 +31,13 @@ func Init(e *gin.Engine) {
 	g.Any("/ping", func(c *gin.Context) {
 		c.String(200, "pong")
 	})
+	g.GET("/metrics", handles.Metrics)
 	g.GET("/favicon.ico", handles.Favicon)
 	g.GET("/robots.txt", handles.Robots)
 	g.GET("/manifest.json", static.ManifestJSON)
 	g.GET("/i/:link_name", handles.Plist)
 	common.SecretKey = []byte(conf.Conf.JwtSecret)
-	g.Use(middlewares.StoragesLoaded)
+	g.Use(middlewares.Protocol("web"), middlewares.StoragesLoaded)
 	if conf.Conf.MaxConnections > 0 {
 		g.Use(middlewares.MaxAllowed(conf.Conf.MaxConnections))
 	}
@@ -46,8 +47,8 @@ func Init(e *gin.Engine) {
 
 	downloadLimiter := middlewares.DownloadRateLimiter(stream.ClientDownloadLimit)
 	signCheck := middlewares.Down(sign.Verify)
-	g.GET("/d/*path", middlewares.PathParse, signCheck, downloadLimiter, handles.Down)
-	g.GET("/p/*path", middlewares.PathParse, signCheck, downloadLimiter, handles.Proxy)
+	g.GET("/d/*path", middlewares.ServedBytes("down"), middlewares.PathParse, signCheck, downloadLimiter, handles.Down)
+	g.GET("/p/*path", middlewares.ServedBytes("proxy"), middlewares.PathParse, signCheck, downloadLimiter, handles.Proxy)
 	g.HEAD("/d/*path", middlewares.PathParse, signCheck, handles.Down)
 	g.HEAD("/p/*path", middlewares.PathParse, signCheck, handles.Proxy)
 	archiveSignCheck := middlewares.Down(sign.VerifyArchive)
@@ -75,12 +76,17 @@ func Init(e *gin.Engine) {
 	api.POST("/auth/login/hash", handles.LoginHash)
 	api.POST("/auth/login/ldap", handles.LoginLdap)
 	auth.GET("/me", handles.CurrentUser)
-	auth.POST("/me/update", handles.UpdateCurrent)
+	auth.POST("/me/update", middlewares.AuthNotScoped, handles.UpdateCurrent)
 	auth.GET("/me/sshkey/list", handles.ListMyPublicKey)
-	auth.POST("/me/sshkey/add", handles.AddMyPublicKey)
-	auth.POST("/me/sshkey/delete", handles.DeleteMyPublicKey)
-	auth.POST("/auth/2fa/generate", handles.Generate2FA)
-	auth.POST("/auth/2fa/verify", handles.Verify2FA)
+	auth.POST("/me/sshkey/add", middlewares.AuthNotScoped, handles.AddMyPublicKey)
+	auth.POST("/me/sshkey/delete", middlewares.AuthNotScoped, handles.DeleteMyPublicKey)
+	tokens := auth.Group("/me/tokens", middlewares.AuthNotGuest, middlewares.AuthNotScoped)
+	tokens.GET("/list", handles.ListMyTokens)
+	tokens.POST("/create", handles.CreateMyToken)
+	tokens.POST("/update", handles.UpdateMyToken)
+	tokens.POST("/delete", handles.DeleteMyToken)
+	auth.POST("/auth/2fa/generate", middlewares.AuthNotScoped, handles.Generate2FA)
+	auth.POST("/auth/2fa/verify", middlewares.AuthNotScoped, handles.Verify2FA)
 	auth.GET("/auth/logout", handles.LogOut)
 
 	// auth
@@ -89,6 +95,15 @@ func Init(e *gin.Engine) {
 	api.GET("/auth/get_sso_id", handles.SSOLoginCallback)
 	api.GET("/auth/sso_get_token", handles.SSOLoginCallback)
 
+	// oauth provider
+	g.GET("/.well-known/openid-configuration", handles.OIDCDiscovery)
+	api.GET("/oauth/jwks", handles.OAuthJWKS)
+	api.GET("/oauth/authorize", handles.OAuthAuthorize)
+	auth.POST("/oauth/authorize", middlewares.AuthNotGuest, middlewares.AuthNotScoped, handles.OAuthApprove)
+	api.POST("/oauth/token", handles.OAuthToken)
+	api.POST("/oauth/revoke", handles.OAuthRevoke)
+	api.Any("/oauth/userinfo", handles.OAuthUserInfo)
+
 	// webauthn
 	api.GET("/authn/webauthn_begin_login", handles.BeginAuthnLogin)
 	api.POST("/authn/webauthn_finish_login", handles.FinishAuthnLogin)
@@ -138,6 +153,13 @@ func admin(g *gin.RouterGroup) {
 	user.GET("/sshkey/list", handles.ListPublicKeys)
 	user.POST("/sshkey/delete", handles.DeletePublicKey)
 
+	group := g.Group("/group")
+	group.GET("/list", handles.ListGroups)
+	group.GET("/get", handles.GetGroup)
+	group.POST("/create", handles.CreateGroup)
+	group.POST("/update", handles.UpdateGroup)
+	group.POST("/delete", handles.DeleteGroup)
+
 	storage := g.Group("/storage")
 	storage.GET("/list", handles.ListStorages)
 	storage.GET("/get", handles.GetStorage)
@@ -148,6 +170,47 @@ func admin(g *gin.RouterGroup) {
 	storage.POST("/disable", handles.DisableStorage)
 	storage.POST("/load_all", handles.LoadAllStorages)
 
+	trash := g.Group("/trash")
+	trash.GET("/list", handles.ListTrash)
+	trash.POST("/restore", handles.RestoreTrash)
+	trash.POST("/purge", handles.PurgeTrash)
+
+	webhook := g.Group("/webhook")
+	webhook.GET("/list", handles.ListWebhooks)
+	webhook.GET("/events", handles.ListWebhookEvents)
+	webhook.POST("/create", handles.CreateWebhook)
+	webhook.POST("/update", handles.UpdateWebhook)
+	webhook.POST("/delete", handles.DeleteWebhook)
+	webhook.GET("/deliveries", handles.ListWebhookDeliveries)
+
+	syncJob := g.Group("/sync")
+	syncJob.GET("/list", handles.ListSyncJobs)
+	syncJob.POST("/create", handles.CreateSyncJob)
+	syncJob.POST("/update", handles.UpdateSyncJob)
+	syncJob.POST("/delete", handles.DeleteSyncJob)
+	syncJob.POST("/run", handles.RunSyncJob)
+
+	dedupe := g.Group("/dedupe")
+	dedupe.POST("/scan", handles.DedupeScan)
+	dedupe.POST("/cancel", handles.DedupeCancel)
+	dedupe.GET("/reports", handles.ListDedupeReports)
+	dedupe.POST("/delete", handles.DeleteDedupeReport)
+	dedupe.GET("/groups", handles.ListDuplicateGroups)
+	dedupe.POST("/remove", handles.DedupeRemove)
+	dedupe.POST("/link", handles.DedupeLink)
+
+	oauthClient := g.Group("/oauth_clients")
+	oauthClient.GET("/list", handles.ListOAuthClients)
+	oauthClient.GET("/scopes", handles.ListOAuthScopes)
+	oauthClient.POST("/create", handles.CreateOAuthClient)
+	oauthClient.POST("/update", handles.UpdateOAuthClient)
+	oauthClient.POST("/reset_secret", handles.ResetOAuthClientSecret)
+	oauthClient.POST("/delete", handles.DeleteOAuthClient)
+
+	audit := g.Group("/audit")
+	audit.GET("/list", handles.ListAuditLogs)
+	audit.GET("/export", handles.ExportAuditLogs)
+
 	driver := g.Group("/driver")
 	driver.GET("/list", handles.ListDriverInfo)
 	driver.GET("/names", handles.ListDriverNames)
@@ -198,6 +261,7 @@ func fsAndShare(g *gin.RouterGroup) {
 	a := g.Group("/archive")
 	a.Any("/meta", handles.FsArchiveMetaSplit)
 	a.Any("/list", handles.FsArchiveListSplit)
+	g.PUT("/share/put", middlewares.UploadRateLimiter(stream.ClientUploadLimit), handles.SharingPut)
 }
 
 func _fs(g *gin.RouterGroup) {
@@ -213,6 +277,8 @@ func _fs(g *gin.RouterGroup) {
 	g.POST("/copy", handles.FsCopy)
 	g.POST("/remove", handles.FsRemove)
 	g.POST("/remove_empty_directory", handles.FsRemoveEmptyDirectory)
+	g.Any("/versions/list", handles.FsListVersions)
+	g.POST("/versions/restore", handles.FsRestoreVersion)
 	uploadLimiter := middlewares.UploadRateLimiter(stream.ClientUploadLimit)
 	g.PUT("/put", middlewares.FsUp, uploadLimiter, handles.FsStream)
 	g.PUT("/form", middlewares.FsUp, uploadLimiter, handles.FsForm)
@@ -229,6 +295,7 @@ func _fs(g *gin.RouterGroup) {
 	// g.POST("/add_transmission", handles.SetTransmission)
 	g.POST("/add_offline_download", handles.AddOfflineDownload)
 	g.POST("/archive/decompress", handles.FsArchiveDecompress)
+	g.Any("/archive/pack", middlewares.ServedBytes("pack"), middlewares.DownloadRateLimiter(stream.ClientDownloadLimit), handles.FsArchivePack)
 	// Torrent 相关接口
 	g.POST("/torrent/parse", handles.ParseTorrent)
 	g.POST("/torrent/upload_parse", handles.UploadTorrentAndParse)
@@ -250,6 +317,8 @@ func _sharing(g *gin.RouterGroup) {
 	g.POST("/delete", handles.DeleteSharing)
 	g.POST("/enable", handles.SetEnableSharing(false))
 	g.POST("/disable", handles.SetEnableSharing(true))
+	g.Any("/uploads", handles.ListSharingUploads)
+	g.Any("/stats", handles.SharingStats)
 }
 
 func Cors(r *gin.Engine) {
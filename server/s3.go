@@ -7,6 +7,7 @@ import (
 
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/server/common"
+	"github.com/OpenListTeam/OpenList/v4/server/middlewares"
 	"github.com/OpenListTeam/OpenList/v4/server/s3"
 	"github.com/gin-gonic/gin"
 )
@@ -26,7 +27,7 @@ func S3(g *gin.RouterGroup) {
 	}
 	h, _ := s3.NewServer(context.Background())
 
-	g.Any("/*path", func(c *gin.Context) {
+	g.Any("/*path", middlewares.ServedBytes("s3"), middlewares.Protocol("s3"), func(c *gin.Context) {
 		adjustedPath := strings.TrimPrefix(c.Request.URL.Path, path.Join(conf.URL.Path, "/s3"))
 		c.Request.URL.Path = adjustedPath
 		gin.WrapH(h)(c)
@@ -35,5 +36,5 @@ func S3(g *gin.RouterGroup) {
 
 func S3Server(g *gin.RouterGroup) {
 	h, _ := s3.NewServer(context.Background())
-	g.Any("/*path", gin.WrapH(h))
+	g.Any("/*path", middlewares.ServedBytes("s3"), middlewares.Protocol("s3"), gin.WrapH(h))
 }
@@ -14,8 +14,9 @@ import (
 func NewServer(ctx context.Context) (h http.Handler, err error) {
 	var newLogger logger
 	authPairs := authlistResolver()
+	backend := newBackend()
 	faker := gofakes3.New(
-		newBackend(),
+		backend,
 		// gofakes3.WithHostBucket(!opt.pathBucketMode),
 		gofakes3.WithLogger(newLogger),
 		gofakes3.WithRequestID(rand.Uint64()),
@@ -24,5 +25,15 @@ func NewServer(ctx context.Context) (h http.Handler, err error) {
 		gofakes3.WithIntegrityCheck(true), // Check Content-MD5 if supplied
 	)
 
-	return redirectHandler(faker.Server(), authPairs), nil
+	// the requests of the personal access tokens are verified by accessTokenHandler,
+	// their keys are not added to the keys of gofakes3 so they can be revoked
+	tokenFaker := gofakes3.New(
+		backend,
+		gofakes3.WithLogger(newLogger),
+		gofakes3.WithRequestID(rand.Uint64()),
+		gofakes3.WithoutVersioning(),
+		gofakes3.WithIntegrityCheck(true),
+	)
+
+	return accessTokenHandler(redirectHandler(faker.Server(), authPairs), redirectHandler(tokenFaker.Server(), nil)), nil
 }
@@ -0,0 +1,54 @@
+package s3
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/aws/aws-sdk-go/aws/credentials"
+	v4 "github.com/aws/aws-sdk-go/aws/signer/v4"
+)
+
+func TestVerifyV4(t *testing.T) {
+	signer := v4.NewSigner(credentials.NewStaticCredentials("ol_key", "secret", ""))
+	signer.DisableURIPathEscaping = true
+
+	req := httptest.NewRequest("PUT", "http://localhost:5246/bucket/dir/a%20b.txt?x-id=PutObject", strings.NewReader("data"))
+	if _, err := signer.Sign(req, strings.NewReader("data"), "s3", "us-east-1", time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if !verifyV4(req, "ol_key", "secret") {
+		t.Fatal("expected the signature to be valid")
+	}
+	if verifyV4(req, "ol_key", "other") || verifyV4(req, "ol_other", "secret") {
+		t.Fatal("expected the signature to be invalid for another key")
+	}
+	req.Header.Set("X-Amz-Copy-Source", "/bucket/other")
+	req.URL.Path = "/bucket/other.txt"
+	if verifyV4(req, "ol_key", "secret") {
+		t.Fatal("expected the signature to be invalid for a changed request")
+	}
+
+	req = httptest.NewRequest("GET", "http://localhost:5246/bucket/file.txt", nil)
+	if _, err := signer.Presign(req, nil, "s3", "us-east-1", time.Minute, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if !verifyV4(req, "ol_key", "secret") {
+		t.Fatal("expected the presigned url to be valid")
+	}
+	req = httptest.NewRequest("GET", "http://localhost:5246/bucket/file.txt", nil)
+	if _, err := signer.Presign(req, nil, "s3", "us-east-1", time.Minute, time.Now().Add(-2*time.Minute)); err != nil {
+		t.Fatal(err)
+	}
+	if verifyV4(req, "ol_key", "secret") {
+		t.Fatal("expected the presigned url to be expired")
+	}
+	req = httptest.NewRequest("GET", "http://localhost:5246/bucket/file.txt", nil)
+	if _, err := signer.Presign(req, nil, "s3", "us-east-1", 8*24*time.Hour, time.Now()); err != nil {
+		t.Fatal(err)
+	}
+	if verifyV4(req, "ol_key", "secret") {
+		t.Fatal("expected the presigned url valid for more than 7 days to be refused")
+	}
+}
@@ -0,0 +1,107 @@
+package s3
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"path"
+	"strings"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+)
+
+// requestAccessKey returns the access key id the request is signed with
+func requestAccessKey(r *http.Request) string {
+	auth := r.Header.Get("Authorization")
+	if cred := r.URL.Query().Get("X-Amz-Credential"); auth == "" && cred != "" {
+		key, _, _ := strings.Cut(cred, "/")
+		return key
+	}
+	if v2, ok := strings.CutPrefix(auth, "AWS "); ok {
+		key, _, _ := strings.Cut(v2, ":")
+		return key
+	}
+	if _, cred, ok := strings.Cut(auth, "Credential="); ok {
+		key, _, _ := strings.Cut(cred, "/")
+		return key
+	}
+	return ""
+}
+
+// accessTokenHandler authenticates the requests signed with a personal access token,
+// the user of the token is put into the context and its path and permissions are checked.
+// The other requests go to next, the token requests to tokenNext, which doesn't verify
+// the signatures again.
+func accessTokenHandler(next, tokenNext http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		key := requestAccessKey(r)
+		keyID, ok := strings.CutPrefix(key, model.AccessTokenS3KeyPrefix)
+		if !ok || key == setting.GetStr(conf.S3AccessKeyId) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		t, err := op.GetAccessTokenByKeyId(keyID)
+		if err != nil {
+			http.Error(w, "invalid access key", http.StatusForbidden)
+			return
+		}
+		user, err := op.GetAccessTokenUser(t)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusForbidden)
+			return
+		}
+		if !verifyV4(r, key, op.AccessTokenS3Secret(t)) {
+			http.Error(w, "invalid signature", http.StatusForbidden)
+			return
+		}
+		if !accessTokenAllowed(r, user) {
+			http.Error(w, "access denied", http.StatusForbidden)
+			return
+		}
+		tokenNext.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), conf.UserKey, user)))
+	})
+}
+
+func accessTokenAllowed(r *http.Request, user *model.User) bool {
+	bucketName, objectName, _ := strings.Cut(strings.TrimPrefix(r.URL.Path, "/"), "/")
+	if bucketName != "" && !bucketPathAllowed(user, bucketName, objectName) {
+		return false
+	}
+	// CopyObject and UploadPartCopy read the source, e.g. /bucket/key?versionId=1
+	if src := r.Header.Get("X-Amz-Copy-Source"); src != "" {
+		src, _, _ = strings.Cut(src, "?")
+		src, err := url.PathUnescape(src)
+		if err != nil {
+			return false
+		}
+		bucketName, objectName, _ = strings.Cut(strings.TrimPrefix(src, "/"), "/")
+		if bucketName == "" || !bucketPathAllowed(user, bucketName, objectName) {
+			return false
+		}
+	}
+	switch r.Method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return true
+	case http.MethodDelete:
+		return user.CanRemove()
+	case http.MethodPost:
+		if r.URL.Query().Has("delete") {
+			return user.CanRemove()
+		}
+		return user.CanWriteContent()
+	default:
+		return user.CanWriteContent()
+	}
+}
+
+func bucketPathAllowed(user *model.User, bucketName, objectName string) bool {
+	bucket, err := getBucketByName(bucketName)
+	if err != nil {
+		return false
+	}
+	return utils.IsSubPath(user.BasePath, path.Join(bucket.Path, objectName))
+}
@@ -6,9 +6,9 @@ import (
 )
 
 func tryLdapLoginAndRegister(user, pass string) (*model.User, error) {
-	err := common.HandleLdapLogin(user, pass)
+	groups, err := common.LdapLogin(user, pass)
 	if err != nil {
 		return nil, err
 	}
-	return common.LdapRegister(user)
+	return common.LdapRegister(user, groups...)
 }
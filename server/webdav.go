@@ -5,12 +5,14 @@ import (
 	"net/http"
 	"path"
 	"strings"
+	"time"
 
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
 	"github.com/OpenListTeam/OpenList/v4/internal/op"
 	"github.com/OpenListTeam/OpenList/v4/internal/setting"
 	"github.com/OpenListTeam/OpenList/v4/internal/stream"
+	"github.com/OpenListTeam/OpenList/v4/pkg/cron"
 	"github.com/OpenListTeam/OpenList/v4/server/common"
 	"github.com/OpenListTeam/OpenList/v4/server/middlewares"
 	"github.com/OpenListTeam/OpenList/v4/server/webdav"
@@ -23,12 +25,12 @@ var handler *webdav.Handler
 func WebDav(dav *gin.RouterGroup) {
 	handler = &webdav.Handler{
 		Prefix:     path.Join(conf.URL.Path, "/dav"),
-		LockSystem: webdav.NewMemLS(),
+		LockSystem: newLockSystem(),
 		Logger: func(request *http.Request, err error) {
 			log.Errorf("%s %s %+v", request.Method, request.URL.Path, err)
 		},
 	}
-	dav.Use(WebDAVAuth)
+	dav.Use(middlewares.ServedBytes("webdav"), middlewares.Protocol("webdav"), WebDAVAuth)
 	uploadLimiter := middlewares.UploadRateLimiter(stream.ClientUploadLimit)
 	downloadLimiter := middlewares.DownloadRateLimiter(stream.ClientDownloadLimit)
 	dav.Any("/*path", uploadLimiter, downloadLimiter, ServeWebDAV)
@@ -41,6 +43,24 @@ func WebDav(dav *gin.RouterGroup) {
 	dav.Handle("PROPPATCH", "/*path", ServeWebDAV)
 	dav.Handle("COPY", "/*path", ServeWebDAV)
 	dav.Handle("MOVE", "/*path", ServeWebDAV)
+	dav.Handle("REPORT", "/*path", ServeWebDAV)
+}
+
+func newLockSystem() webdav.LockSystem {
+	switch conf.Conf.WebDAV.LockSystem {
+	case "database":
+		ls := webdav.NewDBLS()
+		cron.NewCron(10 * time.Minute).Do(func() {
+			if err := ls.(webdav.Sweeper).Sweep(time.Now()); err != nil {
+				log.Errorf("failed sweep webdav locks: %+v", err)
+			}
+		})
+		return ls
+	case "", "memory":
+	default:
+		log.Warnf("unknown webdav lock system %q, using memory", conf.Conf.WebDAV.LockSystem)
+	}
+	return webdav.NewMemLS()
 }
 
 func ServeWebDAV(c *gin.Context) {
@@ -63,6 +83,7 @@ func WebDAVAuth(c *gin.Context) {
 		model.LoginCache.Expire(ip, model.DefaultLockDuration)
 		return
 	}
+	var user *model.User
 	username, password, ok := c.Request.BasicAuth()
 	if !ok {
 		bt := c.GetHeader("Authorization")
@@ -82,18 +103,25 @@ func WebDAVAuth(c *gin.Context) {
 				c.Next()
 				return
 			}
+			if op.IsAccessToken(bt) {
+				user, _ = op.AuthenticateAccessToken(bt)
+			}
 		}
-		if c.Request.Method == "OPTIONS" {
-			common.GinAppendValues(c, conf.UserKey, guest)
-			c.Next()
+		if user == nil {
+			if c.Request.Method == "OPTIONS" {
+				common.GinAppendValues(c, conf.UserKey, guest)
+				c.Next()
+				return
+			}
+			c.Writer.Header()["WWW-Authenticate"] = []string{`Basic realm="openlist"`}
+			c.Status(http.StatusUnauthorized)
+			c.Abort()
 			return
 		}
-		c.Writer.Header()["WWW-Authenticate"] = []string{`Basic realm="openlist"`}
-		c.Status(http.StatusUnauthorized)
-		c.Abort()
-		return
+		ok = true
+	} else {
+		user, ok = tryLogin(username, password)
 	}
-	user, ok := tryLogin(username, password)
 	if !ok {
 		if c.Request.Method == "OPTIONS" {
 			common.GinAppendValues(c, conf.UserKey, guest)
@@ -152,6 +180,11 @@ func WebDAVAuth(c *gin.Context) {
 }
 
 func tryLogin(username, password string) (*model.User, bool) {
+	// a personal access token can be used as the password of its user
+	if op.IsAccessToken(password) {
+		user, err := op.AuthenticateAccessToken(password)
+		return user, err == nil && user.Username == username
+	}
 	user, err := op.GetUserByName(username)
 	if err == nil {
 		err = user.ValidateRawPassword(password)
@@ -0,0 +1,243 @@
+package webdav
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/google/uuid"
+)
+
+// holdTimeout bounds how long a lock stays held by a Confirm that is never released,
+// e.g. when the instance serving the request crashed
+const holdTimeout = time.Hour
+
+// Sweeper is implemented by the LockSystems that should collect the expired locks periodically.
+type Sweeper interface {
+	Sweep(now time.Time) error
+}
+
+// NewDBLS returns a new LockSystem stored in the database, so the locks survive
+// restarts and are shared by the instances using the same database.
+func NewDBLS() LockSystem {
+	return &dbLS{}
+}
+
+type dbLS struct {
+	// mu serializes the check and the insert of Create in this instance,
+	// Create checks again after the insert against the other instances
+	mu sync.Mutex
+}
+
+// Sweep deletes the expired locks, they are also collected by every operation.
+func (m *dbLS) Sweep(now time.Time) error {
+	return db.DeleteExpiredWebDAVLocks(now.UnixNano())
+}
+
+func (m *dbLS) Confirm(now time.Time, name0, name1 string, conditions ...Condition) (func(), error) {
+	if err := m.Sweep(now); err != nil {
+		return nil, err
+	}
+	var l0, l1 *model.WebDAVLock
+	var err error
+	if name0 != "" {
+		if l0, err = m.lookup(now, slashClean(name0), conditions...); err != nil || l0 == nil {
+			return nil, confirmErr(err)
+		}
+	}
+	if name1 != "" {
+		if l1, err = m.lookup(now, slashClean(name1), conditions...); err != nil || l1 == nil {
+			return nil, confirmErr(err)
+		}
+	}
+
+	// Don't hold the same lock twice.
+	if l0 != nil && l1 != nil && l0.Token == l1.Token {
+		l1 = nil
+	}
+
+	var held []string
+	release := func() {
+		for _, token := range held {
+			_ = db.ReleaseWebDAVLock(token)
+		}
+	}
+	for _, l := range []*model.WebDAVLock{l0, l1} {
+		if l == nil {
+			continue
+		}
+		ok, err := db.HoldWebDAVLock(l.Token, now.UnixNano(), now.Add(holdTimeout).UnixNano())
+		if err != nil || !ok {
+			// held by a concurrent request in the meantime
+			release()
+			return nil, confirmErr(err)
+		}
+		held = append(held, l.Token)
+	}
+	return release, nil
+}
+
+func confirmErr(err error) error {
+	if err != nil {
+		return err
+	}
+	return ErrConfirmationFailed
+}
+
+// lookup returns the lock that locks the named resource, provided that it
+// matches at least one of the given conditions and that it isn't held.
+// Otherwise, it returns nil.
+func (m *dbLS) lookup(now time.Time, name string, conditions ...Condition) (*model.WebDAVLock, error) {
+	for _, c := range conditions {
+		if c.Token == "" {
+			continue
+		}
+		l, err := db.GetWebDAVLock(c.Token)
+		if err != nil {
+			return nil, err
+		}
+		if l == nil || l.Held(now.UnixNano()) {
+			continue
+		}
+		if name == l.Root {
+			return l, nil
+		}
+		if l.ZeroDepth {
+			continue
+		}
+		if l.Root == "/" || strings.HasPrefix(name, l.Root+"/") {
+			return l, nil
+		}
+	}
+	return nil, nil
+}
+
+func (m *dbLS) Create(now time.Time, details LockDetails) (string, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if err := m.Sweep(now); err != nil {
+		return "", err
+	}
+	details.Root = slashClean(details.Root)
+
+	ok, err := m.canCreate(details.Root, details.ZeroDepth, "")
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", ErrLocked
+	}
+	l := &model.WebDAVLock{
+		Token:     "opaquelocktoken:" + uuid.NewString(),
+		Root:      details.Root,
+		ZeroDepth: details.ZeroDepth,
+		OwnerXML:  details.OwnerXML,
+	}
+	setLockDuration(l, now, details.Duration)
+	if err := db.CreateWebDAVLock(l); err != nil {
+		// another instance locked the same root in the meantime
+		if locks, _ := db.GetWebDAVLocksByRoots([]string{l.Root}); len(locks) > 0 {
+			return "", ErrLocked
+		}
+		return "", err
+	}
+	// another instance may have created a conflicting lock between the check and the insert,
+	// each of them sees the other one then and backs off
+	if ok, err = m.canCreate(l.Root, l.ZeroDepth, l.Token); err != nil || !ok {
+		_ = db.DeleteWebDAVLock(l.Token)
+		if err != nil {
+			return "", err
+		}
+		return "", ErrLocked
+	}
+	return l.Token, nil
+}
+
+func (m *dbLS) Refresh(now time.Time, token string, duration time.Duration) (LockDetails, error) {
+	if err := m.Sweep(now); err != nil {
+		return LockDetails{}, err
+	}
+	l, err := db.GetWebDAVLock(token)
+	if err != nil {
+		return LockDetails{}, err
+	}
+	if l == nil {
+		return LockDetails{}, ErrNoSuchLock
+	}
+	if l.Held(now.UnixNano()) {
+		return LockDetails{}, ErrLocked
+	}
+	setLockDuration(l, now, duration)
+	if err := db.RefreshWebDAVLock(l.Token, l.Duration, l.Expiry); err != nil {
+		return LockDetails{}, err
+	}
+	return lockDetails(l), nil
+}
+
+func (m *dbLS) Unlock(now time.Time, token string) error {
+	if err := m.Sweep(now); err != nil {
+		return err
+	}
+	l, err := db.GetWebDAVLock(token)
+	if err != nil {
+		return err
+	}
+	if l == nil {
+		return ErrNoSuchLock
+	}
+	if l.Held(now.UnixNano()) {
+		return ErrLocked
+	}
+	return db.DeleteWebDAVLock(token)
+}
+
+// canCreate checks the locks other than token
+func (m *dbLS) canCreate(name string, zeroDepth bool, token string) (bool, error) {
+	var roots []string
+	walkToRoot(name, func(name0 string, first bool) bool {
+		roots = append(roots, name0)
+		return true
+	})
+	locks, err := db.GetWebDAVLocksByRoots(roots)
+	if err != nil {
+		return false, err
+	}
+	for _, l := range locks {
+		if l.Token == token {
+			continue
+		}
+		if l.Root == name {
+			// The target node is already locked.
+			return false, nil
+		}
+		if !l.ZeroDepth {
+			// An ancestor of the target node is locked with infinite depth.
+			return false, nil
+		}
+	}
+	if zeroDepth {
+		return true, nil
+	}
+	// The requested lock depth is infinite, a locked descendant conflicts.
+	locked, err := db.HasWebDAVLockUnder(name, token)
+	return !locked, err
+}
+
+func setLockDuration(l *model.WebDAVLock, now time.Time, duration time.Duration) {
+	l.Duration = int64(duration)
+	l.Expiry = 0
+	if duration >= 0 {
+		l.Expiry = now.Add(duration).UnixNano()
+	}
+}
+
+func lockDetails(l *model.WebDAVLock) LockDetails {
+	return LockDetails{
+		Root:      l.Root,
+		Duration:  time.Duration(l.Duration),
+		OwnerXML:  l.OwnerXML,
+		ZeroDepth: l.ZeroDepth,
+	}
+}
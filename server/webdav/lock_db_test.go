@@ -0,0 +1,370 @@
+package webdav
+
+import (
+	"math/rand"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/db"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/glebarez/sqlite"
+	"gorm.io/gorm"
+)
+
+func init() {
+	dB, err := gorm.Open(sqlite.Open("file::memory:?cache=shared"), &gorm.Config{})
+	if err != nil {
+		panic("failed to connect database")
+	}
+	conf.Conf = conf.DefaultConfig("data")
+	db.Init(dB)
+}
+
+// lockSystems runs the tests of the lock_test.go semantics, which only use
+// the LockSystem interface, against every implementation.
+var lockSystems = []struct {
+	name string
+	new  func(t *testing.T) LockSystem
+}{
+	{"mem", func(t *testing.T) LockSystem { return NewMemLS() }},
+	{"db", func(t *testing.T) LockSystem {
+		if err := db.GetDb().Where("1 = 1").Delete(&model.WebDAVLock{}).Error; err != nil {
+			t.Fatalf("failed clear webdav locks: %v", err)
+		}
+		return NewDBLS()
+	}},
+}
+
+func TestLockSystemCanCreate(t *testing.T) {
+	for _, ls := range lockSystems {
+		t.Run(ls.name, func(t *testing.T) {
+			now := time.Unix(0, 0)
+			m := ls.new(t)
+			for _, name := range lockTestNames {
+				if _, err := m.Create(now, LockDetails{
+					Root:      name,
+					Duration:  infiniteTimeout,
+					ZeroDepth: lockTestZeroDepth(name),
+				}); err != nil {
+					t.Fatalf("creating lock for %q: %v", name, err)
+				}
+			}
+
+			wantCanCreate := func(name string, zeroDepth bool) bool {
+				for _, n := range lockTestNames {
+					switch {
+					case n == name:
+						return false
+					case strings.HasPrefix(n, name):
+						if !zeroDepth {
+							return false
+						}
+					case strings.HasPrefix(name, n):
+						if n[len(n)-1] == 'i' {
+							return false
+						}
+					}
+				}
+				return true
+			}
+
+			var check func(int, string)
+			check = func(recursion int, name string) {
+				for _, zeroDepth := range []bool{false, true} {
+					token, err := m.Create(now, LockDetails{Root: name, Duration: infiniteTimeout, ZeroDepth: zeroDepth})
+					got := err == nil
+					if err != nil && err != ErrLocked {
+						t.Fatalf("Create name=%q zeroDepth=%t: %v", name, zeroDepth, err)
+					}
+					if want := wantCanCreate(name, zeroDepth); got != want {
+						t.Errorf("Create name=%q zeroDepth=%t: got %t, want %t", name, zeroDepth, got, want)
+					}
+					if got {
+						if err := m.Unlock(now, token); err != nil {
+							t.Fatalf("Unlock name=%q: %v", name, err)
+						}
+					}
+				}
+				if recursion == 4 {
+					return
+				}
+				if name != "/" {
+					name += "/"
+				}
+				for _, c := range "_iz" {
+					check(recursion+1, name+string(c))
+				}
+			}
+			check(0, "/")
+		})
+	}
+}
+
+func TestLockSystemConfirm(t *testing.T) {
+	for _, ls := range lockSystems {
+		t.Run(ls.name, func(t *testing.T) {
+			now := time.Unix(0, 0)
+			m := ls.new(t)
+			alice, err := m.Create(now, LockDetails{Root: "/alice", Duration: infiniteTimeout})
+			if err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+			tweedle, err := m.Create(now, LockDetails{Root: "/tweedle", Duration: infiniteTimeout})
+			if err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+
+			if _, err = m.Confirm(now, "/tweedle/dee", "", Condition{Token: alice}); err != ErrConfirmationFailed {
+				t.Fatalf("Confirm (mismatch): got %v, want ErrConfirmationFailed", err)
+			}
+
+			release, err := m.Confirm(now, "/tweedle/dee", "/tweedle/dum", Condition{Token: tweedle})
+			if err != nil {
+				t.Fatalf("Confirm (twins): %v", err)
+			}
+			release()
+
+			releaseDee, err := m.Confirm(now, "/tweedle/dee", "", Condition{Token: tweedle})
+			if err != nil {
+				t.Fatalf("Confirm (sequence #0): %v", err)
+			}
+			if _, err = m.Confirm(now, "/tweedle/dum", "", Condition{Token: tweedle}); err != ErrConfirmationFailed {
+				t.Fatalf("Confirm (sequence #1): got %v, want ErrConfirmationFailed", err)
+			}
+			releaseDee()
+
+			releaseDum, err := m.Confirm(now, "/tweedle/dum", "", Condition{Token: tweedle})
+			if err != nil {
+				t.Fatalf("Confirm (sequence #3): %v", err)
+			}
+			if err = m.Unlock(now, tweedle); err != ErrLocked {
+				t.Fatalf("Unlock (sequence #4): got %v, want ErrLocked", err)
+			}
+			releaseDum()
+			if err = m.Unlock(now, tweedle); err != nil {
+				t.Fatalf("Unlock (sequence #6): %v", err)
+			}
+			if err = m.Unlock(now, tweedle); err != ErrNoSuchLock {
+				t.Fatalf("Unlock (sequence #7): got %v, want ErrNoSuchLock", err)
+			}
+		})
+	}
+}
+
+func TestLockSystemNonCanonicalRoot(t *testing.T) {
+	for _, ls := range lockSystems {
+		t.Run(ls.name, func(t *testing.T) {
+			now := time.Unix(0, 0)
+			m := ls.new(t)
+			token, err := m.Create(now, LockDetails{Root: "/foo/./bar//", Duration: 1 * time.Second})
+			if err != nil {
+				t.Fatalf("Create: %v", err)
+			}
+			if _, err := m.Create(now, LockDetails{Root: "/foo/bar", Duration: 1 * time.Second}); err != ErrLocked {
+				t.Fatalf("Create (canonical): got %v, want ErrLocked", err)
+			}
+			if err := m.Unlock(now, token); err != nil {
+				t.Fatalf("Unlock: %v", err)
+			}
+		})
+	}
+}
+
+func TestLockSystemExpiry(t *testing.T) {
+	testCases := []string{
+		"setNow 0",
+		"create /a.5",
+		"want /a",
+		"create /c.6",
+		"want /a /c",
+		"create /a/b.7",
+		"want /a /a/b /c",
+		"setNow 4",
+		"want /a /a/b /c",
+		"setNow 5",
+		"want /a/b /c",
+		"setNow 6",
+		"want /a/b",
+		"setNow 7",
+		"want ",
+		"create /a.12",
+		"create /b.13",
+		"create /c.15",
+		"create /a/d.16",
+		"want /a /a/d /b /c",
+		"refresh /a.14",
+		"setNow 13",
+		"want /a /a/d /c",
+		"setNow 14",
+		"want /a/d /c",
+		"refresh /a/d.20",
+		"refresh /c.20",
+		"setNow 19",
+		"want /a/d /c",
+		"setNow 20",
+		"want ",
+	}
+	for _, ls := range lockSystems {
+		t.Run(ls.name, func(t *testing.T) {
+			m := ls.new(t)
+			tokens := map[string]string{}
+			now := time.Unix(0, 0)
+			for i, tc := range testCases {
+				op, arg, _ := strings.Cut(tc, " ")
+				switch op {
+				case "create", "refresh":
+					root, d, _ := strings.Cut(arg, ".")
+					seconds, err := strconv.Atoi(d)
+					if err != nil {
+						t.Fatalf("test case #%d %q: invalid duration", i, tc)
+					}
+					dur := time.Unix(0, 0).Add(time.Duration(seconds) * time.Second).Sub(now)
+					if op == "create" {
+						token, err := m.Create(now, LockDetails{Root: root, Duration: dur, ZeroDepth: true})
+						if err != nil {
+							t.Fatalf("test case #%d %q: Create: %v", i, tc, err)
+						}
+						tokens[root] = token
+						continue
+					}
+					got, err := m.Refresh(now, tokens[root], dur)
+					if err != nil {
+						t.Fatalf("test case #%d %q: Refresh: %v", i, tc, err)
+					}
+					if want := (LockDetails{Root: root, Duration: dur, ZeroDepth: true}); got != want {
+						t.Fatalf("test case #%d %q:\ngot  %v\nwant %v", i, tc, got, want)
+					}
+				case "setNow":
+					seconds, err := strconv.Atoi(arg)
+					if err != nil {
+						t.Fatalf("test case #%d %q: invalid duration", i, tc)
+					}
+					now = time.Unix(0, 0).Add(time.Duration(seconds) * time.Second)
+				case "want":
+					got := []string{}
+					for root, token := range tokens {
+						release, err := m.Confirm(now, root, "", Condition{Token: token})
+						if err == nil {
+							release()
+							got = append(got, root)
+						}
+					}
+					sort.Strings(got)
+					want := []string{}
+					if arg != "" {
+						want = strings.Split(arg, " ")
+					}
+					if !reflect.DeepEqual(got, want) {
+						t.Fatalf("test case #%d %q:\ngot  %q\nwant %q", i, tc, got, want)
+					}
+				default:
+					t.Fatalf("test case #%d %q: invalid operation %q", i, tc, op)
+				}
+			}
+		})
+	}
+}
+
+func TestLockSystemRandom(t *testing.T) {
+	for _, ls := range lockSystems {
+		t.Run(ls.name, func(t *testing.T) {
+			now := time.Unix(0, 0)
+			m := ls.new(t)
+			rng := rand.New(rand.NewSource(0))
+			tokens := map[string]string{}
+			const N = 1000
+
+			for i := 0; i < N; i++ {
+				name := lockTestNames[rng.Intn(len(lockTestNames))]
+				duration := lockTestDurations[rng.Intn(len(lockTestDurations))]
+				confirmed, unlocked := false, false
+
+				token := tokens[name]
+				if token != "" {
+					switch rng.Intn(3) {
+					case 0:
+						confirmed = true
+						release, err := m.Confirm(now, name, "", Condition{Token: token})
+						if err != nil {
+							t.Fatalf("iteration #%d: Confirm %q: %v", i, name, err)
+						}
+						release()
+					case 1:
+						if _, err := m.Refresh(now, token, duration); err != nil {
+							t.Fatalf("iteration #%d: Refresh %q: %v", i, name, err)
+						}
+					case 2:
+						unlocked = true
+						if err := m.Unlock(now, token); err != nil {
+							t.Fatalf("iteration #%d: Unlock %q: %v", i, name, err)
+						}
+					}
+				} else {
+					var err error
+					token, err = m.Create(now, LockDetails{
+						Root:      name,
+						Duration:  duration,
+						ZeroDepth: lockTestZeroDepth(name),
+					})
+					if err != nil {
+						t.Fatalf("iteration #%d: Create %q: %v", i, name, err)
+					}
+				}
+
+				if !confirmed {
+					if duration == 0 || unlocked {
+						tokens[name] = ""
+					} else {
+						tokens[name] = token
+					}
+				}
+			}
+		})
+	}
+}
+
+func TestDBLSSharedAcrossInstances(t *testing.T) {
+	now := time.Unix(0, 0)
+	m0 := lockSystems[1].new(t)
+	m1 := NewDBLS()
+	token, err := m0.Create(now, LockDetails{Root: "/shared", Duration: infiniteTimeout})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := m1.Create(now, LockDetails{Root: "/shared/file", Duration: infiniteTimeout}); err != ErrLocked {
+		t.Fatalf("Create on the other instance: got %v, want ErrLocked", err)
+	}
+	release, err := m1.Confirm(now, "/shared/file", "", Condition{Token: token})
+	if err != nil {
+		t.Fatalf("Confirm on the other instance: %v", err)
+	}
+	if err := m0.Unlock(now, token); err != ErrLocked {
+		t.Fatalf("Unlock while held: got %v, want ErrLocked", err)
+	}
+	release()
+	if err := m0.Unlock(now, token); err != nil {
+		t.Fatalf("Unlock: %v", err)
+	}
+}
+
+func TestDBLSLongRoot(t *testing.T) {
+	now := time.Unix(0, 0)
+	m := lockSystems[1].new(t)
+	root := "/" + strings.Repeat("a", 300)
+	token, err := m.Create(now, LockDetails{Root: root, Duration: infiniteTimeout})
+	if err != nil {
+		t.Fatalf("Create: %v", err)
+	}
+	if _, err := m.Create(now, LockDetails{Root: root, Duration: infiniteTimeout}); err != ErrLocked {
+		t.Fatalf("Create again: got %v, want ErrLocked", err)
+	}
+	// the check after the insert ignores the lock being created
+	if ok, err := m.(*dbLS).canCreate(root, false, token); err != nil || !ok {
+		t.Fatalf("canCreate excluding the lock: got %v, %v, want true", ok, err)
+	}
+}
@@ -15,10 +15,16 @@ import (
 	"strings"
 	"time"
 
+	"github.com/OpenListTeam/OpenList/v4/internal/cache"
 	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
 	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/setting"
 	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
 	"github.com/OpenListTeam/OpenList/v4/server/common"
+	log "github.com/sirupsen/logrus"
 )
 
 // Proppatch describes a property update instruction as defined in RFC 4918.
@@ -109,6 +115,9 @@ var liveProps = map[xml.Name]struct {
 	findFn func(context.Context, LockSystem, string, model.Obj) (string, error)
 	// dir is true if the property applies to directories.
 	dir bool
+	// explicit is true if the property is only returned when it's named,
+	// it's left out of allprop and propname.
+	explicit bool
 }{
 	{Space: "DAV:", Local: "resourcetype"}: {
 		findFn: findResourceType,
@@ -165,6 +174,18 @@ var liveProps = map[xml.Name]struct {
 		findFn: findChecksums,
 		dir:    false,
 	},
+	// http://www.webdav.org/specs/rfc4331.html#quota-available-bytes
+	// says that the quota properties should not be returned by allprop.
+	{Space: "DAV:", Local: "quota-available-bytes"}: {
+		findFn:   findQuotaAvailableBytes,
+		dir:      true,
+		explicit: true,
+	},
+	{Space: "DAV:", Local: "quota-used-bytes"}: {
+		findFn:   findQuotaUsedBytes,
+		dir:      true,
+		explicit: true,
+	},
 }
 
 // TODO(nigeltao) merge props and allprop?
@@ -173,27 +194,9 @@ var liveProps = map[xml.Name]struct {
 //
 // Each Propstat has a unique status and each property name will only be part
 // of one Propstat element.
-func props(ctx context.Context, ls LockSystem, fi model.Obj, pnames []xml.Name) ([]Propstat, error) {
-	//f, err := fs.OpenFile(ctx, name, os.O_RDONLY, 0)
-	//if err != nil {
-	//	return nil, err
-	//}
-	//defer f.Close()
-	//fi, err := f.Stat()
-	//if err != nil {
-	//	return nil, err
-	//}
+func props(ctx context.Context, ls LockSystem, name string, fi model.Obj, deadProps map[xml.Name]Property, pnames []xml.Name) ([]Propstat, error) {
 	isDir := fi.IsDir()
 
-	var deadProps map[xml.Name]Property
-	// ??? what is this for?
-	//if dph, ok := f.(DeadPropsHolder); ok {
-	//	deadProps, err = dph.DeadProps()
-	//	if err != nil {
-	//		return nil, err
-	//	}
-	//}
-
 	pstatOK := Propstat{Status: http.StatusOK}
 	pstatNotFound := Propstat{Status: http.StatusNotFound}
 	for _, pn := range pnames {
@@ -204,7 +207,11 @@ func props(ctx context.Context, ls LockSystem, fi model.Obj, pnames []xml.Name)
 		}
 		// Otherwise, it must either be a live property or we don't know it.
 		if prop := liveProps[pn]; prop.findFn != nil && (prop.dir || !isDir) {
-			innerXML, err := prop.findFn(ctx, ls, fi.GetName(), fi)
+			innerXML, err := prop.findFn(ctx, ls, name, fi)
+			if errors.Is(err, errPropNotFound) {
+				pstatNotFound.Props = append(pstatNotFound.Props, Property{XMLName: pn})
+				continue
+			}
 			if err != nil {
 				return nil, err
 			}
@@ -222,30 +229,12 @@ func props(ctx context.Context, ls LockSystem, fi model.Obj, pnames []xml.Name)
 }
 
 // Propnames returns the property names defined for resource name.
-func propnames(ctx context.Context, ls LockSystem, fi model.Obj) ([]xml.Name, error) {
-	//f, err := fs.OpenFile(ctx, name, os.O_RDONLY, 0)
-	//if err != nil {
-	//	return nil, err
-	//}
-	//defer f.Close()
-	//fi, err := f.Stat()
-	//if err != nil {
-	//	return nil, err
-	//}
+func propnames(ctx context.Context, ls LockSystem, fi model.Obj, deadProps map[xml.Name]Property) ([]xml.Name, error) {
 	isDir := fi.IsDir()
 
-	var deadProps map[xml.Name]Property
-	// ??? what is this for?
-	//if dph, ok := f.(DeadPropsHolder); ok {
-	//	deadProps, err = dph.DeadProps()
-	//	if err != nil {
-	//		return nil, err
-	//	}
-	//}
-
 	pnames := make([]xml.Name, 0, len(liveProps)+len(deadProps))
 	for pn, prop := range liveProps {
-		if prop.findFn != nil && (prop.dir || !isDir) {
+		if prop.findFn != nil && !prop.explicit && (prop.dir || !isDir) {
 			pnames = append(pnames, pn)
 		}
 	}
@@ -263,8 +252,8 @@ func propnames(ctx context.Context, ls LockSystem, fi model.Obj) ([]xml.Name, er
 // returned if they are named in 'include'.
 //
 // See http://www.webdav.org/specs/rfc4918.html#METHOD_PROPFIND
-func allprop(ctx context.Context, ls LockSystem, fi model.Obj, include []xml.Name) ([]Propstat, error) {
-	pnames, err := propnames(ctx, ls, fi)
+func allprop(ctx context.Context, ls LockSystem, name string, fi model.Obj, deadProps map[xml.Name]Property, include []xml.Name) ([]Propstat, error) {
+	pnames, err := propnames(ctx, ls, fi, deadProps)
 	if err != nil {
 		return nil, err
 	}
@@ -278,20 +267,57 @@ func allprop(ctx context.Context, ls LockSystem, fi model.Obj, include []xml.Nam
 			pnames = append(pnames, pn)
 		}
 	}
-	return props(ctx, ls, fi, pnames)
+	return props(ctx, ls, name, fi, deadProps, pnames)
 }
 
+// deadPropsUnder returns the dead properties of name and its descendants by their paths.
+func deadPropsUnder(name string) (map[string]map[xml.Name]Property, error) {
+	stored, err := op.GetDeadPropsUnder(name)
+	if err != nil {
+		return nil, err
+	}
+	deadProps := make(map[string]map[xml.Name]Property)
+	for _, p := range stored {
+		if deadProps[p.Path] == nil {
+			deadProps[p.Path] = make(map[xml.Name]Property)
+		}
+		pn := xml.Name{Space: p.Space, Local: p.Local}
+		deadProps[p.Path][pn] = Property{XMLName: pn, Lang: p.Lang, InnerXML: []byte(p.InnerXML)}
+	}
+	return deadProps, nil
+}
+
+// lastModifiedProp is the only live property that can be patched, when the
+// WebdavSetModTime setting is on and the storage can set the modification time.
+var lastModifiedProp = xml.Name{Space: "DAV:", Local: "getlastmodified"}
+
 // Patch patches the properties of resource name. The return values are
 // constrained in the same manner as DeadPropsHolder.Patch.
 func patch(ctx context.Context, ls LockSystem, name string, patches []Proppatch) ([]Propstat, error) {
+	var modTime *time.Time
 	conflict := false
 loop:
 	for _, patch := range patches {
 		for _, p := range patch.Props {
-			if _, ok := liveProps[p.XMLName]; ok {
-				conflict = true
-				break loop
+			if _, ok := liveProps[p.XMLName]; !ok {
+				continue
+			}
+			if p.XMLName == lastModifiedProp && !patch.Remove && setting.GetBool(conf.WebdavSetModTime) {
+				if t, err := http.ParseTime(strings.TrimSpace(string(p.InnerXML))); err == nil {
+					modTime = &t
+					continue
+				}
 			}
+			conflict = true
+			break loop
+		}
+	}
+	if !conflict && modTime != nil {
+		err := fs.SetModTime(ctx, name, *modTime)
+		if errors.Is(err, errs.NotImplement) {
+			conflict = true
+		} else if err != nil {
+			return nil, err
 		}
 	}
 	if conflict {
@@ -314,37 +340,44 @@ loop:
 		return makePropstats(pstatForbidden, pstatFailedDep), nil
 	}
 
-	// ------------------------------------------------------------
-	//f, err := fs.OpenFile(ctx, name, os.O_RDWR, 0)
-	//if err != nil {
-	//	return nil, err
-	//}
-	//defer f.Close()
-	//if dph, ok := f.(DeadPropsHolder); ok {
-	//	ret, err := dph.Patch(patches)
-	//	if err != nil {
-	//		return nil, err
-	//	}
-	//	// http://www.webdav.org/specs/rfc4918.html#ELEMENT_propstat says that
-	//	// "The contents of the prop XML element must only list the names of
-	//	// properties to which the result in the status element applies."
-	//	for _, pstat := range ret {
-	//		for i, p := range pstat.Props {
-	//			pstat.Props[i] = Property{XMLName: p.XMLName}
-	//		}
-	//	}
-	//	return ret, nil
-	//}
-	// ------------------------------------------------------------
-
-	// The file doesn't implement the optional DeadPropsHolder interface, so
-	// all patches are forbidden.
-	pstat := Propstat{Status: http.StatusForbidden}
+	// Later patches of the same property win, as the patches are applied in order.
+	patched := make(map[xml.Name]*model.DeadProp)
+	var order []xml.Name
+	pstat := Propstat{Status: http.StatusOK}
 	for _, patch := range patches {
 		for _, p := range patch.Props {
+			// http://www.webdav.org/specs/rfc4918.html#ELEMENT_propstat says that
+			// "The contents of the prop XML element must only list the names of
+			// properties to which the result in the status element applies."
 			pstat.Props = append(pstat.Props, Property{XMLName: p.XMLName})
+			if p.XMLName == lastModifiedProp {
+				continue
+			}
+			if _, ok := patched[p.XMLName]; !ok {
+				order = append(order, p.XMLName)
+			}
+			patched[p.XMLName] = nil
+			if !patch.Remove {
+				patched[p.XMLName] = &model.DeadProp{
+					Space:    p.XMLName.Space,
+					Local:    p.XMLName.Local,
+					Lang:     p.Lang,
+					InnerXML: string(p.InnerXML),
+				}
+			}
 		}
 	}
+	var set, remove []model.DeadProp
+	for _, pn := range order {
+		if p := patched[pn]; p != nil {
+			set = append(set, *p)
+		} else {
+			remove = append(remove, model.DeadProp{Space: pn.Space, Local: pn.Local})
+		}
+	}
+	if err := op.PatchDeadProps(name, set, remove); err != nil {
+		return nil, err
+	}
 	return []Propstat{pstat}, nil
 }
 
@@ -398,6 +431,10 @@ func findCreationDate(ctx context.Context, ls LockSystem, name string, fi model.
 	return fi.CreateTime().UTC().Format(time.RFC3339), nil
 }
 
+// errPropNotFound is returned by the find functions of the live properties
+// that are not defined for the resource.
+var errPropNotFound = errors.New("webdav: property not found")
+
 // ErrNotImplemented should be returned by optional interfaces if they
 // want the original implementation to be used.
 var ErrNotImplemented = errors.New("not implemented")
@@ -493,3 +530,55 @@ func findChecksums(ctx context.Context, ls LockSystem, name string, fi model.Obj
 	}
 	return checksums, nil
 }
+
+// quotaCache keeps the details of the storages by mount path for a while, nil if they are
+// unavailable, so that the collections of a PROPFIND don't query a failing storage again.
+var quotaCache = cache.NewKeyedCache[*model.StorageDetails](time.Minute)
+
+func findQuotaAvailableBytes(ctx context.Context, ls LockSystem, name string, fi model.Obj) (string, error) {
+	details, err := findQuota(ctx, name, fi)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(max(details.FreeSpace(), 0), 10), nil
+}
+
+func findQuotaUsedBytes(ctx context.Context, ls LockSystem, name string, fi model.Obj) (string, error) {
+	details, err := findQuota(ctx, name, fi)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatInt(details.UsedSpace, 10), nil
+}
+
+// findQuota returns the details of the storage of the collection, which
+// is shared by all the collections of the storage.
+func findQuota(ctx context.Context, name string, fi model.Obj) (*model.StorageDetails, error) {
+	if !fi.IsDir() || setting.GetBool(conf.HideStorageDetails) {
+		return nil, errPropNotFound
+	}
+	if user, ok := ctx.Value(conf.UserKey).(*model.User); !ok || user.IsGuest() {
+		return nil, errPropNotFound
+	}
+	if details, ok := model.GetStorageDetails(fi); ok && details != nil {
+		return details, nil
+	}
+	storage, _, err := op.GetStorageAndActualPath(name)
+	if err != nil {
+		// a virtual folder above the storages
+		return nil, errPropNotFound
+	}
+	mountPath := storage.GetStorage().MountPath
+	details, ok := quotaCache.Get(mountPath)
+	if !ok {
+		details, err = op.GetStorageDetails(ctx, storage)
+		if err != nil && !errors.Is(err, errs.NotImplement) {
+			log.Debugf("failed get details of storage [%s]: %+v", mountPath, err)
+		}
+		quotaCache.Set(mountPath, details)
+	}
+	if details == nil || details.TotalSpace <= 0 {
+		return nil, errPropNotFound
+	}
+	return details, nil
+}
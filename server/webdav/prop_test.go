@@ -0,0 +1,93 @@
+package webdav
+
+import (
+	"context"
+	"encoding/xml"
+	"net/http"
+	"testing"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+)
+
+func TestPatchDeadProps(t *testing.T) {
+	ctx := context.Background()
+	custom := xml.Name{Space: "http://example.com/ns", Local: "color"}
+	other := xml.Name{Space: "http://example.com/ns", Local: "tag"}
+	pstats, err := patch(ctx, nil, "/dav_test/a_b", []Proppatch{
+		{Props: []Property{{XMLName: custom, InnerXML: []byte("red")}, {XMLName: other, InnerXML: []byte("x")}}},
+		{Remove: true, Props: []Property{{XMLName: other}}},
+	})
+	if err != nil {
+		t.Fatalf("patch: %v", err)
+	}
+	if len(pstats) != 1 || pstats[0].Status != http.StatusOK || len(pstats[0].Props) != 3 {
+		t.Fatalf("unexpected propstats: %+v", pstats)
+	}
+
+	deadProps, err := deadPropsUnder("/dav_test")
+	if err != nil {
+		t.Fatalf("deadPropsUnder: %v", err)
+	}
+	if len(deadProps["/dav_test/a_b"]) != 1 || string(deadProps["/dav_test/a_b"][custom].InnerXML) != "red" {
+		t.Fatalf("unexpected dead props: %+v", deadProps)
+	}
+
+	// the names contain the LIKE wildcards, axb must not match a_b
+	op.MoveDeadProps("/dav_test/axb", "/dav_test/moved")
+	op.MoveDeadProps("/dav_test", "/dav_renamed")
+	if deadProps, _ = deadPropsUnder("/dav_renamed/a_b"); len(deadProps["/dav_renamed/a_b"]) != 1 {
+		t.Fatalf("expected the props to follow the move, got %+v", deadProps)
+	}
+	op.DeleteDeadProps("/dav_renamed")
+	if deadProps, _ = deadPropsUnder("/dav_renamed"); len(deadProps) != 0 {
+		t.Fatalf("expected the props to be removed, got %+v", deadProps)
+	}
+}
+
+func TestPatchLiveProps(t *testing.T) {
+	pstats, err := patch(context.Background(), nil, "/dav_test/live", []Proppatch{
+		{Props: []Property{
+			{XMLName: lastModifiedProp, InnerXML: []byte("Mon, 02 Jan 2006 15:04:05 GMT")},
+			{XMLName: xml.Name{Space: "http://example.com/ns", Local: "color"}},
+		}},
+	})
+	if err != nil {
+		t.Fatalf("patch: %v", err)
+	}
+	if len(pstats) != 2 || pstats[0].Status != http.StatusForbidden || pstats[1].Status != StatusFailedDependency {
+		t.Fatalf("unexpected propstats: %+v", pstats)
+	}
+	if deadProps, _ := deadPropsUnder("/dav_test/live"); len(deadProps) != 0 {
+		t.Fatalf("expected nothing to be patched, got %+v", deadProps)
+	}
+}
+
+func TestQuotaProps(t *testing.T) {
+	ctx := context.Background()
+	quota := []xml.Name{
+		{Space: "DAV:", Local: "quota-available-bytes"},
+		{Space: "DAV:", Local: "quota-used-bytes"},
+	}
+	dir := &model.Object{Name: "dir", IsFolder: true}
+	pnames, err := propnames(ctx, nil, dir, nil)
+	if err != nil {
+		t.Fatalf("propnames: %v", err)
+	}
+	for _, pn := range pnames {
+		if pn == quota[0] || pn == quota[1] {
+			t.Fatalf("propnames should not include %v", pn)
+		}
+	}
+
+	// files, and collections without a user or a storage, don't have a quota
+	for _, fi := range []model.Obj{dir, &model.Object{Name: "file"}} {
+		pstats, err := props(ctx, nil, "/"+fi.GetName(), fi, nil, quota)
+		if err != nil {
+			t.Fatalf("props: %v", err)
+		}
+		if len(pstats) != 1 || pstats[0].Status != http.StatusNotFound || len(pstats[0].Props) != 2 {
+			t.Fatalf("unexpected propstats for %s: %+v", fi.GetName(), pstats)
+		}
+	}
+}
@@ -0,0 +1,144 @@
+package webdav
+
+import (
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"path"
+
+	"github.com/OpenListTeam/OpenList/v4/internal/conf"
+	"github.com/OpenListTeam/OpenList/v4/internal/errs"
+	"github.com/OpenListTeam/OpenList/v4/internal/fs"
+	"github.com/OpenListTeam/OpenList/v4/internal/model"
+	"github.com/OpenListTeam/OpenList/v4/internal/op"
+	"github.com/OpenListTeam/OpenList/v4/internal/sign"
+	"github.com/OpenListTeam/OpenList/v4/pkg/utils"
+	"github.com/OpenListTeam/OpenList/v4/server/common"
+	ixml "github.com/OpenListTeam/OpenList/v4/server/webdav/internal/xml"
+	"github.com/pkg/errors"
+)
+
+var errUnsupportedReport = errors.New("webdav: unsupported report")
+
+// http://www.webdav.org/specs/rfc3253.html#REPORT_version-tree
+type versionTree struct {
+	XMLName ixml.Name     `xml:"DAV: version-tree"`
+	Prop    propfindProps `xml:"DAV: prop"`
+}
+
+var versionNameProp = xml.Name{Space: "DAV:", Local: "version-name"}
+
+var defaultVersionProps = []xml.Name{
+	versionNameProp,
+	{Space: "DAV:", Local: "displayname"},
+	{Space: "DAV:", Local: "getcontentlength"},
+	{Space: "DAV:", Local: "getlastmodified"},
+}
+
+func readVersionTree(r io.Reader) (vt versionTree, status int, err error) {
+	if err = ixml.NewDecoder(r).Decode(&vt); err != nil {
+		if _, ok := err.(ixml.UnmarshalError); ok {
+			// a report other than the version tree
+			return versionTree{}, http.StatusForbidden, errUnsupportedReport
+		}
+		return versionTree{}, http.StatusBadRequest, err
+	}
+	return vt, 0, nil
+}
+
+// handleReport answers the DeltaV version tree report with the old versions of a file
+func (h *Handler) handleReport(w http.ResponseWriter, r *http.Request) (status int, err error) {
+	reqPath, status, err := h.stripPrefix(r.URL.Path)
+	if err != nil {
+		return status, err
+	}
+	ctx := r.Context()
+	user := ctx.Value(conf.UserKey).(*model.User)
+	password, _ := ctx.Value(conf.MetaPassKey).(string)
+	reqPath, err = user.JoinPath(reqPath)
+	if err != nil {
+		return http.StatusForbidden, err
+	}
+	meta, err := op.GetNearestMeta(reqPath)
+	if err != nil && !errors.Is(errors.Cause(err), errs.MetaNotFound) {
+		return http.StatusInternalServerError, err
+	}
+	if !common.CanAccess(user, meta, reqPath, password) {
+		return http.StatusForbidden, errs.PermissionDenied
+	}
+	fi, err := fs.Get(ctx, reqPath, &fs.GetArgs{})
+	if err != nil {
+		if errs.IsNotFoundError(err) {
+			return http.StatusNotFound, err
+		}
+		return http.StatusInternalServerError, err
+	}
+	if fi.IsDir() {
+		return http.StatusForbidden, errUnsupportedReport
+	}
+	vt, status, err := readVersionTree(r.Body)
+	if err != nil {
+		return status, err
+	}
+	pnames := []xml.Name(vt.Prop)
+	if len(pnames) == 0 {
+		pnames = defaultVersionProps
+	}
+	versions, err := fs.GetVersions(ctx, reqPath)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
+	mw := multistatusWriter{w: w}
+	for _, v := range versions {
+		obj := &model.Object{Name: path.Base(v.Path), Size: v.Size, Modified: v.Modified}
+		pstats, err := versionProps(ctx, h.LockSystem, obj, v.Version, pnames)
+		if err != nil {
+			return http.StatusInternalServerError, err
+		}
+		resp := makePropstatResponse("", pstats)
+		resp.Href = []string{versionHref(r, v.Path)}
+		if err = mw.write(resp); err != nil {
+			return http.StatusInternalServerError, err
+		}
+	}
+	// an empty multistatus for a file without old versions
+	if err = mw.writeHeader(); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	if err = mw.close(); err != nil {
+		return http.StatusInternalServerError, err
+	}
+	return 0, nil
+}
+
+// versionHref returns the signed link of the version, the versions folder
+// can't be reached under the prefix of the handler
+func versionHref(r *http.Request, p string) string {
+	return fmt.Sprintf("%s/p%s?sign=%s", common.GetApiUrlFromRequest(r), utils.EncodePath(p, true), sign.Sign(p))
+}
+
+func versionProps(ctx context.Context, ls LockSystem, obj model.Obj, version string, pnames []xml.Name) ([]Propstat, error) {
+	others := make([]xml.Name, 0, len(pnames))
+	var name *Property
+	for _, pn := range pnames {
+		if pn == versionNameProp {
+			name = &Property{XMLName: pn, InnerXML: []byte(escapeXML(version))}
+		} else {
+			others = append(others, pn)
+		}
+	}
+	pstats, err := props(ctx, ls, obj.GetName(), obj, nil, others)
+	if err != nil || name == nil {
+		return pstats, err
+	}
+	for i := range pstats {
+		if pstats[i].Status == http.StatusOK {
+			pstats[i].Props = append([]Property{*name}, pstats[i].Props...)
+			return pstats, nil
+		}
+	}
+	return append([]Propstat{{Status: http.StatusOK, Props: []Property{*name}}}, pstats...), nil
+}
@@ -84,6 +84,8 @@ func (h *Handler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			status, err = h.handlePropfind(brw, r)
 		case "PROPPATCH":
 			status, err = h.handleProppatch(brw, r)
+		case "REPORT":
+			status, err = h.handleReport(brw, r)
 		}
 	}
 
@@ -204,7 +206,7 @@ func (h *Handler) handleOptions(w http.ResponseWriter, r *http.Request) (status
 		if fi.IsDir() {
 			allow = "OPTIONS, LOCK, DELETE, PROPPATCH, COPY, MOVE, UNLOCK, PROPFIND"
 		} else {
-			allow = "OPTIONS, LOCK, GET, HEAD, POST, DELETE, PROPPATCH, COPY, MOVE, UNLOCK, PROPFIND, PUT"
+			allow = "OPTIONS, LOCK, GET, HEAD, POST, DELETE, PROPPATCH, COPY, MOVE, UNLOCK, PROPFIND, PUT, REPORT"
 		}
 	}
 	w.Header().Set("Allow", allow)
@@ -749,6 +751,11 @@ func (h *Handler) handlePropfind(w http.ResponseWriter, r *http.Request) (status
 		return status, err
 	}
 
+	deadProps, err := deadPropsUnder(reqPath)
+	if err != nil {
+		return http.StatusInternalServerError, err
+	}
+
 	mw := multistatusWriter{w: w}
 
 	walkFn := func(reqPath string, info model.Obj, err error) error {
@@ -757,7 +764,7 @@ func (h *Handler) handlePropfind(w http.ResponseWriter, r *http.Request) (status
 		}
 		var pstats []Propstat
 		if pf.Propname != nil {
-			pnames, err := propnames(ctx, h.LockSystem, info)
+			pnames, err := propnames(ctx, h.LockSystem, info, deadProps[reqPath])
 			if err != nil {
 				return err
 			}
@@ -767,9 +774,9 @@ func (h *Handler) handlePropfind(w http.ResponseWriter, r *http.Request) (status
 			}
 			pstats = append(pstats, pstat)
 		} else if pf.Allprop != nil {
-			pstats, err = allprop(ctx, h.LockSystem, info, pf.Prop)
+			pstats, err = allprop(ctx, h.LockSystem, reqPath, info, deadProps[reqPath], pf.Prop)
 		} else {
-			pstats, err = props(ctx, h.LockSystem, info, pf.Prop)
+			pstats, err = props(ctx, h.LockSystem, reqPath, info, deadProps[reqPath], pf.Prop)
 		}
 		if err != nil {
 			return err